@@ -0,0 +1,147 @@
+// Package activity streams live gateway events (inbound messages, tool calls,
+// outbound replies) to local subscribers over a unix socket.
+package activity
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	KindInbound  = "inbound"
+	KindToolCall = "tool_call"
+	KindOutbound = "outbound"
+)
+
+// subscriberBuffer is the number of events buffered per subscriber. Events
+// are dropped for subscribers that fall further behind.
+const subscriberBuffer = 256
+
+type Event struct {
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"`
+	Channel    string    `json:"channel,omitempty"`
+	ChatID     string    `json:"chatId,omitempty"`
+	SessionKey string    `json:"sessionKey,omitempty"`
+	Tool       string    `json:"tool,omitempty"`
+	Text       string    `json:"text,omitempty"`
+}
+
+// Hub fans events out to subscribers. A nil *Hub is valid and discards events.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[chan Event]struct{}{}}
+}
+
+// Publish sends ev to every subscriber without blocking.
+func (h *Hub) Publish(ev Event) {
+	if h == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of events and a function that unsubscribes.
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Serve listens on the unix socket at path and streams events to every
+// connection as JSON lines until ctx is done. The socket is owner-only.
+func (h *Hub) Serve(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// Remove a stale socket left by a previous run.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+	defer os.Remove(path)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go h.stream(ctx, conn)
+	}
+}
+
+func (h *Hub) stream(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	events, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	// Detect the client hanging up.
+	closed := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	w := bufio.NewWriter(conn)
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case ev := <-events:
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
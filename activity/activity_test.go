@@ -0,0 +1,84 @@
+package activity
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHub_ServeStreamsEvents(t *testing.T) {
+	// Keep the socket path short; unix socket paths are length limited.
+	dir, err := os.MkdirTemp("", "act")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.sock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h := NewHub()
+	done := make(chan error, 1)
+	go func() { done <- h.Serve(ctx, path) }()
+
+	var conn net.Conn
+	for range 100 {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode=%v", fi.Mode().Perm())
+	}
+
+	// Wait for the connection to subscribe before publishing.
+	for range 100 {
+		h.mu.Lock()
+		n := len(h.subs)
+		h.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.Publish(Event{Kind: KindToolCall, Channel: "slack", ChatID: "C1", Tool: "exec"})
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var ev Event
+	if err := json.Unmarshal(line, &ev); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if ev.Kind != KindToolCall || ev.Tool != "exec" || ev.Time.IsZero() {
+		t.Fatalf("event=%+v", ev)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket should be removed on shutdown: %v", err)
+	}
+}
+
+func TestHub_NilIsNoop(t *testing.T) {
+	var h *Hub
+	h.Publish(Event{Kind: KindInbound})
+}
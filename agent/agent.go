@@ -11,8 +11,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/mosaxiv/clawlet/bus"
 	"github.com/mosaxiv/clawlet/config"
 	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/media"
 	"github.com/mosaxiv/clawlet/memory"
 	"github.com/mosaxiv/clawlet/paths"
 	"github.com/mosaxiv/clawlet/session"
@@ -26,6 +28,12 @@ type Options struct {
 	SessionKey   string
 	MaxIters     int
 	Verbose      bool
+	// Ephemeral starts from an empty session and never persists it or
+	// consolidates it into memory (used by session replay).
+	Ephemeral bool
+	// Replay runs the agent read-only: tools.safeMode is forced on and memory
+	// writes are off, so replayed turns cannot repeat their side effects.
+	Replay bool
 }
 
 type Agent struct {
@@ -33,7 +41,12 @@ type Agent struct {
 	workspace    string
 	maxIters     int
 	memoryWindow int
-	verbose      bool
+	// historyWindow bounds the messages sent per turn; memoryWindow bounds
+	// what the session keeps before consolidation.
+	historyWindow int
+	verbose       bool
+	retryEmpty    bool
+	ephemeral     bool
 
 	llm   *llm.Client
 	tools *tools.Registry
@@ -41,6 +54,8 @@ type Agent struct {
 	sessionDir string
 	sess       *session.Session
 
+	persona personaFile
+
 	consolidationMu      sync.Mutex
 	consolidationRunning bool
 }
@@ -64,38 +79,68 @@ func New(opts Options) (*Agent, error) {
 	}
 	sdir := paths.SessionsDir()
 
-	sess, err := session.Load(sdir, opts.SessionKey)
-	if err != nil {
-		return nil, err
+	var sess *session.Session
+	if !opts.Ephemeral {
+		sess, err = session.Load(sdir, opts.SessionKey)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if sess == nil {
 		sess = session.New(opts.SessionKey)
 	}
 
 	c := &llm.Client{
-		Provider:    opts.Config.LLM.Provider,
-		BaseURL:     opts.Config.LLM.BaseURL,
-		APIKey:      opts.Config.LLM.APIKey,
-		Model:       opts.Config.LLM.Model,
-		MaxTokens:   opts.Config.Agents.Defaults.MaxTokensValue(),
-		Temperature: opts.Config.Agents.Defaults.Temperature,
-		Headers:     opts.Config.LLM.Headers,
+		Provider:         opts.Config.LLM.Provider,
+		BaseURL:          opts.Config.LLM.BaseURL,
+		APIKey:           opts.Config.LLM.APIKey,
+		Model:            opts.Config.LLM.Model,
+		MaxTokens:        opts.Config.Agents.Defaults.MaxTokensValue(),
+		Temperature:      opts.Config.Agents.Defaults.Temperature,
+		Headers:          opts.Config.LLM.HeadersFor(opts.Config.LLM.Provider),
+		MaxContinuations: opts.Config.LLM.MaxContinuations,
+		ReasoningEffort:  string(opts.Config.LLM.ReasoningEffort),
+		Limiter:          llm.NewLimiter(opts.Config.LLM.RateLimit.RequestsPerSecond, opts.Config.LLM.RateLimit.MaxConcurrent),
+		LocalTranscriber: buildLocalTranscriber(opts.Config),
+		Timeout:          opts.Config.LLM.TimeoutFor(opts.Config.LLM.Provider),
+		APIVersion:       opts.Config.LLM.AzureAPIVersion,
 	}
+	// Built up front: the c is shared by concurrent sessions and copied
+	// for routed models, so it must not be filled in lazily.
+	c.HTTP = llm.NewHTTPClient(c.Timeout)
 
 	treg := &tools.Registry{
-		WorkspaceDir:           wsAbs,
-		RestrictToWorkspace:    opts.Config.Tools.RestrictToWorkspaceValue(),
-		ExecTimeout:            time.Duration(opts.Config.Tools.Exec.TimeoutSec) * time.Second,
-		BraveAPIKey:            opts.Config.Tools.Web.BraveAPIKey,
-		WebFetchAllowedDomains: append([]string(nil), opts.Config.Tools.Web.AllowedDomains...),
-		WebFetchBlockedDomains: append([]string(nil), opts.Config.Tools.Web.BlockedDomains...),
-		WebFetchMaxResponse:    opts.Config.Tools.Web.MaxResponseBytes,
-		WebFetchTimeout:        time.Duration(opts.Config.Tools.Web.FetchTimeoutSec) * time.Second,
+		WorkspaceDir:            wsAbs,
+		RestrictToWorkspace:     opts.Config.Tools.RestrictToWorkspaceValue(),
+		ExecTimeout:             time.Duration(opts.Config.Tools.Exec.TimeoutSec) * time.Second,
+		ToolTimeouts:            opts.Config.Tools.TimeoutDurations(),
+		WriteDenyGlobs:          append([]string(nil), opts.Config.Tools.WriteDenyGlobs...),
+		RequireReadBeforeWrite:  opts.Config.Tools.RequireReadBeforeWrite,
+		TruncateMode:            opts.Config.Tools.TruncateMode,
+		SpillThreshold:          opts.Config.Tools.SpillResultKB << 10,
+		ExecCleanOutput:         opts.Config.Tools.Exec.CleanOutputValue(),
+		ExecExtraEnv:            append([]string(nil), opts.Config.Tools.Exec.ExtraEnv...),
+		ExecAllowCommands:       opts.Config.Tools.Exec.AllowedCommands(),
+		ScriptInterpreters:      append([]string(nil), opts.Config.Tools.Exec.ScriptInterpreters...),
+		HelpCommands:            append([]string(nil), opts.Config.Tools.Exec.HelpCommands...),
+		SafeMode:                opts.Config.Tools.SafeMode || opts.Replay,
+		Profiles:                opts.Config.Tools.Profiles,
+		EgressAllowHosts:        append([]string(nil), opts.Config.Tools.EgressAllowHosts...),
+		BraveAPIKey:             opts.Config.Tools.Web.BraveAPIKey,
+		WebFetchAllowedDomains:  append([]string(nil), opts.Config.Tools.Web.AllowedDomains...),
+		WebFetchBlockedDomains:  append([]string(nil), opts.Config.Tools.Web.BlockedDomains...),
+		WebSearchBlockedDomains: append([]string(nil), opts.Config.Tools.Web.SearchBlockedDomains...),
+		WebFetchMaxResponse:     opts.Config.Tools.Web.MaxResponseBytes,
+		WebFetchTimeout:         time.Duration(opts.Config.Tools.Web.FetchTimeoutSec) * time.Second,
+		WebFetchRetries:         opts.Config.Tools.Web.FetchRetriesValue(),
 		ReadSkill: func(name string) (string, bool) {
 			// CLI agent doesn't have a skills loader; use the embedded loader via workspace.
 			l := skills.New(wsAbs)
 			return l.Load(name)
 		},
+		SessionState: func(string) (tools.MetaStore, error) {
+			return sess, nil
+		},
 	}
 	treg.SkillRegistry, treg.SkillSearchDefaultLimit = buildSkillRegistry(opts.Config)
 	memMgr, err := memory.NewIndexManager(opts.Config, wsAbs)
@@ -103,38 +148,105 @@ func New(opts Options) (*Agent, error) {
 		return nil, err
 	}
 	treg.MemorySearch = memMgr
+	if opts.Config.Tools.Memory.ReadValue() {
+		treg.MemoryStore = memory.New(wsAbs)
+		treg.MemoryWrite = opts.Config.Tools.Memory.Write && !opts.Replay
+	}
+	if opts.Config.Tools.Summarize.EnabledValue() {
+		treg.WebFetchSummarizeOver = opts.Config.Tools.Web.SummarizeOverChars
+		treg.Summarize = newSummarizeFunc(c, opts.Config.Tools.Summarize.Model)
+	}
+	if w := opts.Config.Tools.Weather; w.Enabled {
+		treg.WeatherProvider, treg.WeatherAPIKey, treg.WeatherUnits = w.ProviderValue(), w.APIKey, w.Units
+	}
+	if s := opts.Config.Tools.SystemInfo; s.Enabled {
+		treg.SystemInfo, treg.SystemInfoProcesses, treg.SystemInfoMaxProcesses = true, s.Processes, s.MaxProcessesValue()
+	}
+	if gh := opts.Config.Tools.GitHub; gh.Enabled {
+		treg.GitHub, treg.GitHubToken, treg.GitHubAPIURL = true, gh.Token, gh.APIURLValue()
+	}
+	if opts.Config.Tools.AuditLog {
+		treg.Audit = tools.NewAuditLog(paths.AuditLogPath())
+	}
 
 	return &Agent{
-		cfg:          opts.Config,
-		workspace:    wsAbs,
-		maxIters:     opts.MaxIters,
-		memoryWindow: opts.Config.Agents.Defaults.MemoryWindowValue(),
-		verbose:      opts.Verbose,
-		llm:          c,
-		tools:        treg,
-		sessionDir:   sdir,
-		sess:         sess,
+		cfg:           opts.Config,
+		workspace:     wsAbs,
+		maxIters:      opts.MaxIters,
+		memoryWindow:  opts.Config.Agents.Defaults.MemoryWindowValue(),
+		historyWindow: opts.Config.Agents.Defaults.HistoryWindowValue(),
+		verbose:       opts.Verbose,
+		retryEmpty:    opts.Config.LLM.RetryEmptyResponses,
+		ephemeral:     opts.Ephemeral,
+		llm:           c,
+		tools:         treg,
+		sessionDir:    sdir,
+		sess:          sess,
 	}, nil
 }
 
+// RunTool executes a single tool outside the LLM loop, as if the model had
+// called it from the CLI session. The same gating applies (safe mode,
+// allowTools, workspace restriction).
+func (a *Agent) RunTool(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	return a.tools.Execute(ctx, tools.Context{
+		Channel:    "cli",
+		ChatID:     "direct",
+		SessionKey: a.sess.Key,
+	}, name, args)
+}
+
 func (a *Agent) Process(ctx context.Context, input string) (string, error) {
+	return a.process(ctx, llm.Message{Role: "user", Content: input}, input)
+}
+
+// ProcessWithAttachments is Process with files attached, prepared the same
+// way as channel attachments (text inlined, images as vision input when the
+// model supports it).
+func (a *Agent) ProcessWithAttachments(ctx context.Context, input string, attachments []bus.Attachment) (string, error) {
+	if len(attachments) == 0 {
+		return a.Process(ctx, input)
+	}
+	prepared, err := media.PrepareInbound(ctx, a.llm, a.cfg.Tools.Media, bus.InboundMessage{
+		Channel:     "cli",
+		ChatID:      "direct",
+		Content:     input,
+		Attachments: attachments,
+	})
+	if err != nil {
+		return "", err
+	}
+	return a.process(ctx, prepared.UserMessage, prepared.SessionText)
+}
+
+func (a *Agent) process(ctx context.Context, userMessage llm.Message, sessionText string) (string, error) {
 	a.scheduleConsolidation()
 
 	sys := a.systemPrompt()
-	history := a.sess.History(a.memoryWindow)
+	if a.cfg.Agents.Defaults.RunningSummary.Enabled {
+		sys = withRunningSummary(sys, a.sess.MetaString(runningSummaryMetaKey))
+	}
+	if a.cfg.Agents.Defaults.DetectReplyLanguage {
+		sys = withReplyLanguage(sys, noteReplyLanguage(a.sess, sessionText))
+	}
+	history := a.sess.History(a.historyWindow)
 	messages := make([]llm.Message, 0, 1+len(history)+1)
 	messages = append(messages, llm.Message{Role: "system", Content: sys})
 	for _, m := range history {
 		messages = append(messages, llm.Message{Role: m.Role, Content: m.Content})
 	}
-	messages = append(messages, llm.Message{Role: "user", Content: input})
+	messages = append(messages, userMessage)
 
-	toolsDefs := a.tools.Definitions()
+	profile := a.cfg.Tools.ProfileFor("cli")
+	toolsDefs := a.tools.DefinitionsFor(profile)
 
 	var final string
 	toolsUsed := make([]string, 0, 8)
+	results := newToolResultLog(a.cfg.Agents.Defaults.ConsolidationToolResultChars)
+	dedup := newToolCallDeduper(a.cfg)
+	reads := tools.NewReadSet()
 	for iter := 0; iter < a.maxIters; iter++ {
-		res, err := a.llm.Chat(ctx, messages, toolsDefs)
+		res, err := chatWithEmptyRetry(ctx, a.llm, messages, toolsDefs, a.retryEmpty)
 		if err != nil {
 			return "", err
 		}
@@ -143,38 +255,55 @@ func (a *Agent) Process(ctx context.Context, input string) (string, error) {
 			for _, tc := range res.ToolCalls {
 				toolsUsed = append(toolsUsed, tc.Name)
 			}
-			messages = appendToolRound(messages, res.Content, res.ToolCalls, func(tc llm.ToolCall) string {
+			var outs []string
+			messages, outs = appendToolRound(messages, res, a.cfg.Agents.Defaults.MaxParallelToolsValue(), func(tc llm.ToolCall) string {
 				if a.verbose {
 					fmt.Fprintf(os.Stderr, "tool: %s %s\n", tc.Name, previewJSON(tc.Arguments, 200))
 				}
-				out, err := a.tools.Execute(ctx, tools.Context{
-					Channel:    "cli",
-					ChatID:     "direct",
-					SessionKey: a.sess.Key,
-				}, tc.Name, tc.Arguments)
-				if err != nil {
-					return "error: " + err.Error()
-				}
+				out := dedup.run(tc, func() string {
+					out, err := a.tools.Execute(ctx, tools.Context{
+						Channel:    "cli",
+						ChatID:     "direct",
+						SessionKey: a.sess.Key,
+						Profile:    profile,
+						Intent:     sessionText,
+						Reads:      reads,
+					}, tc.Name, tc.Arguments)
+					if err != nil {
+						return "error: " + err.Error()
+					}
+					return out
+				})
 				return out
 			})
+			// Recorded after the round, in call order, so parallel calls
+			// finishing in a different order do not reorder the session.
+			for i, tc := range res.ToolCalls {
+				results.record(tc.Name, outs[i])
+			}
 			continue
 		}
 
 		final = res.Content
+		if res.Refusal != "" {
+			final = refusalReply(res, a.llm.Model, a.cfg.Agents.Defaults.RefusalReplyValue())
+		}
 		break
 	}
 	if strings.TrimSpace(final) == "" {
 		final = "(no response)"
 	}
 
-	a.sess.Add("user", input)
-	a.sess.AddWithTools("assistant", final, toolsUsed)
-	_ = session.Save(a.sessionDir, a.sess)
+	a.sess.Add("user", sessionText)
+	a.sess.AddWithToolResults("assistant", final, toolsUsed, results.list())
+	if !a.ephemeral {
+		_ = session.Save(a.sessionDir, a.sess)
+	}
 	return final, nil
 }
 
 func (a *Agent) scheduleConsolidation() {
-	if a == nil || a.sess == nil {
+	if a == nil || a.sess == nil || a.ephemeral {
 		return
 	}
 	if !a.sess.NeedsConsolidation(a.memoryWindow) {
@@ -199,9 +328,18 @@ func (a *Agent) scheduleConsolidation() {
 		cctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
 
-		done, err := maybeConsolidateSession(cctx, a.workspace, a.sess, a.memoryWindow, func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
-			return summarizeConsolidationWithLLM(ctx, a.llm, currentMemory, conversation)
-		})
+		summarize := func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
+			return summarizeConsolidationWithLLM(ctx, a.llm, currentMemory, conversation, a.cfg.Agents.Defaults.ConsolidationRepairValue())
+		}
+		var done bool
+		var err error
+		if rs := a.cfg.Agents.Defaults.RunningSummary; rs.Enabled {
+			done, err = consolidateWithRunningSummary(cctx, a.workspace, a.sess, rs.KeepMessagesValue(), summarize, func(ctx context.Context, previous, conversation string) (string, error) {
+				return rollRunningSummary(ctx, llmChatFunc(a.llm), previous, conversation, rs.MaxCharsValue())
+			})
+		} else {
+			done, err = maybeConsolidateSession(cctx, a.workspace, a.sess, a.memoryWindow, summarize)
+		}
 		if err != nil {
 			if a.verbose {
 				fmt.Fprintf(os.Stderr, "consolidation error: %v\n", err)
@@ -214,21 +352,25 @@ func (a *Agent) scheduleConsolidation() {
 		if err := session.Save(a.sessionDir, a.sess); err != nil && a.verbose {
 			fmt.Fprintf(os.Stderr, "consolidation save error: %v\n", err)
 		}
+		if err := rotateHistory(cctx, a.workspace, a.cfg.Agents.Defaults.HistoryRotation, llmChatFunc(a.llm)); err != nil && a.verbose {
+			fmt.Fprintf(os.Stderr, "history rotation error: %v\n", err)
+		}
 	}()
 }
 
 func (a *Agent) systemPrompt() string {
-	now := time.Now().Format("2006-01-02 15:04 (Mon)")
 	ws := a.workspace
 	rt := fmt.Sprintf("%s/%s Go %s", runtime.GOOS, runtime.GOARCH, runtime.Version())
 
-	var b strings.Builder
+	var b promptBuilder
 	b.WriteString("# clawlet\n\n")
-	b.WriteString("You are clawlet, a helpful AI assistant.\n")
+	b.WriteString(a.persona.load(personaPath(ws, a.cfg.Agents.Defaults.PersonaFile)) + "\n")
 	b.WriteString("You can use tools to read/write/edit files, list directories, execute shell commands, and fetch/search the web.\n\n")
 	b.WriteString("IMPORTANT: Reply with plain text. Do not call the message tool.\n\n")
-	b.WriteString("## Current Time\n")
-	b.WriteString(now + "\n\n")
+	if d := a.cfg.Agents.Defaults; d.PromptTimeValue() {
+		b.WriteString("## Current Time\n")
+		b.WriteString(tools.FormatCurrentTime(time.Now().In(d.Location())) + "\n\n")
+	}
 	b.WriteString("## Runtime\n")
 	b.WriteString(rt + "\n\n")
 	b.WriteString("## Workspace\n")
@@ -236,28 +378,23 @@ func (a *Agent) systemPrompt() string {
 	if a.cfg.Tools.RestrictToWorkspaceValue() {
 		b.WriteString("## Safety\nTools are restricted to the workspace directory.\n\n")
 	}
+	if a.cfg.Tools.SafeMode {
+		b.WriteString("## Safe Mode\nOnly read-only tools are available. You cannot write or edit files, run commands, schedule jobs, or spawn subagents.\n\n")
+	}
 
 	// Bootstrap files from workspace (optional).
 	for _, fn := range []string{"AGENTS.md", "SOUL.md", "USER.md", "TOOLS.md", "IDENTITY.md"} {
 		p := filepath.Join(ws, fn)
 		if bb, err := os.ReadFile(p); err == nil && len(bb) > 0 {
-			b.WriteString("## " + fn + "\n\n")
-			b.Write(bb)
-			if bb[len(bb)-1] != '\n' {
-				b.WriteString("\n")
-			}
-			b.WriteString("\n")
+			b.add(fn, bootstrapSection(fn, bb), promptTrimBootstrap)
 		}
 	}
 
 	// Memory (long-term + today's notes)
-	mem := memory.New(ws).GetContext()
-	if strings.TrimSpace(mem) != "" {
-		b.WriteString("# Memory\n\n")
-		b.WriteString(mem)
-		b.WriteString("\n\n")
+	if a.cfg.Tools.Memory.InPromptValue() {
+		b.addMemory(memory.New(ws).ContextSections())
 	}
-	return b.String()
+	return b.build(a.cfg.Agents.Defaults.SystemPromptMaxChars)
 }
 
 func previewJSON(b json.RawMessage, max int) string {
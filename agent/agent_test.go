@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestAgent_RunTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Tools.SafeMode = true
+	a, err := New(Options{Config: cfg, WorkspaceDir: ws, SessionKey: "cli:tool", Ephemeral: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := a.RunTool(context.Background(), "read_file", json.RawMessage(`{"path":"a.txt"}`))
+	if err != nil || out != "hello" {
+		t.Fatalf("out=%q err=%v", out, err)
+	}
+	if _, err := a.RunTool(context.Background(), "write_file", json.RawMessage(`{"path":"b.txt","content":"x"}`)); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("safe mode should block write_file: %v", err)
+	}
+}
+
+func TestAgent_ReplayIsReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := t.TempDir()
+	cfg := config.Default()
+	a, err := New(Options{Config: cfg, WorkspaceDir: ws, SessionKey: "replay:x", Ephemeral: true, Replay: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, args := range map[string]string{
+		"write_file":   `{"path":"b.txt","content":"x"}`,
+		"exec":         `{"command":"touch c.txt"}`,
+		"write_memory": `{"content":"x"}`,
+	} {
+		if _, err := a.RunTool(context.Background(), name, json.RawMessage(args)); err == nil || !strings.Contains(err.Error(), "disabled") {
+			t.Fatalf("replay should refuse %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(ws, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("replay wrote a file: %v", err)
+	}
+}
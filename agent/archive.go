@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/session"
+)
+
+// archiveDirName is the workspace directory /new saves transcripts to.
+const archiveDirName = "archive"
+
+// archiveTranscript writes msgs as Markdown to
+// {workspace}/archive/<time>-<session>.md and returns the path relative to
+// the workspace.
+func archiveTranscript(workspace, sessionKey string, msgs []session.Message, now time.Time) (string, error) {
+	dir := filepath.Join(workspace, archiveDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := now.Format("2006-01-02-150405") + "-" + session.SafeFilename(sessionKey) + ".md"
+	rel := filepath.Join(archiveDirName, name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversation %s\n\nArchived %s.\n", sessionKey, now.Format("2006-01-02 15:04 MST"))
+	for _, m := range msgs {
+		fmt.Fprintf(&b, "\n## %s", m.Role)
+		if m.Timestamp != "" {
+			fmt.Fprintf(&b, " (%s)", m.Timestamp)
+		}
+		b.WriteString("\n\n" + strings.TrimSpace(m.Content) + "\n")
+		if len(m.ToolsUsed) > 0 {
+			fmt.Fprintf(&b, "\n_Tools: %s_\n", strings.Join(m.ToolsUsed, ", "))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(workspace, rel), []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
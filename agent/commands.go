@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/session"
+	"github.com/mosaxiv/clawlet/tools"
+)
+
+// toolProfileMetaKey stores a session's /tools choice. "*" selects every
+// tool regardless of the channel default.
+const toolProfileMetaKey = "toolProfile"
+
+// parseSlashCommand splits "/name arg" into its parts. A Telegram-style
+// "@botname" suffix on the command is dropped.
+func parseSlashCommand(text string) (name, arg string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	name, arg, _ = strings.Cut(text[1:], " ")
+	name, _, _ = strings.Cut(name, "@")
+	return strings.ToLower(name), strings.TrimSpace(arg), name != ""
+}
+
+// runCommand handles chat commands without calling the model. It reports
+// false for anything that is not a known command, which is then processed
+// as a normal message. channels.enabledCommands and channels.adminCommands
+// are enforced here.
+func (l *Loop) runCommand(sessionKey, channel, senderID, text string) (string, bool) {
+	name, arg, ok := parseSlashCommand(text)
+	if !ok || !l.commandAvailable(name) {
+		return "", false
+	}
+	if !l.cfg.Channels.CommandEnabled(name) {
+		return fmt.Sprintf("Unknown command: /%s", name), true
+	}
+	if !l.cfg.Channels.CommandAllowed(name, channel, senderID) {
+		return fmt.Sprintf("/%s is limited to admins.", name), true
+	}
+	sess, err := l.sessions.GetOrCreate(sessionKey)
+	if err != nil {
+		return "error: " + err.Error(), true
+	}
+	switch name {
+	case "tools":
+		return l.toolsCommand(sess, channel, arg), true
+	case "new":
+		return l.newCommand(sess), true
+	}
+	return "", false
+}
+
+// commandAvailable reports whether name is a chat command in this
+// configuration.
+func (l *Loop) commandAvailable(name string) bool {
+	switch name {
+	case "tools":
+		return len(l.cfg.Tools.Profiles) > 0
+	case "new":
+		return true
+	}
+	return false
+}
+
+// newCommand starts a fresh conversation in sess. With
+// channels.archiveOnNew the old transcript is saved to the workspace first;
+// if that fails the session is left as it was.
+func (l *Loop) newCommand(sess *session.Session) string {
+	saved := ""
+	if l.cfg.Channels.ArchiveOnNew {
+		if msgs := sess.History(0); len(msgs) > 0 {
+			rel, err := archiveTranscript(l.workspace, sess.Key, msgs, time.Now())
+			if err != nil {
+				return "Could not save the conversation, so it was kept: " + err.Error()
+			}
+			saved = rel
+		}
+	}
+	sess.Clear()
+	sess.SetMeta(tools.StateMetaKey, "")
+	sess.SetMeta(runningSummaryMetaKey, "")
+	sess.SetMeta(replyLanguageMetaKey, "")
+	_ = l.sessions.Save(sess)
+	if saved != "" {
+		return fmt.Sprintf("Saved the previous conversation to %s and started a new one.", saved)
+	}
+	return "Started a new conversation."
+}
+
+func (l *Loop) toolsCommand(sess *session.Session, channel, arg string) string {
+	names := make([]string, 0, len(l.cfg.Tools.Profiles))
+	for n := range l.cfg.Tools.Profiles {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	usage := fmt.Sprintf("Profiles: %s. Use /tools <profile>, /tools all, or /tools default.", strings.Join(names, ", "))
+
+	switch arg = strings.TrimSpace(arg); arg {
+	case "":
+		return fmt.Sprintf("Tool profile: %s. %s", l.describeToolProfile(sess, channel), usage)
+	case "all":
+		sess.SetMeta(toolProfileMetaKey, "*")
+	case "default":
+		sess.SetMeta(toolProfileMetaKey, "")
+	default:
+		if _, ok := l.cfg.Tools.Profiles[arg]; !ok {
+			return fmt.Sprintf("Unknown tool profile %q. %s", arg, usage)
+		}
+		sess.SetMeta(toolProfileMetaKey, arg)
+	}
+	_ = l.sessions.Save(sess)
+	return fmt.Sprintf("Tool profile set to %s.", l.describeToolProfile(sess, channel))
+}
+
+func (l *Loop) describeToolProfile(sess *session.Session, channel string) string {
+	p := l.toolProfile(sess, channel)
+	n := len(l.tools.DefinitionsFor(p))
+	if p == "" {
+		p = "all"
+	}
+	return fmt.Sprintf("%s (%d tools)", p, n)
+}
+
+// toolProfile resolves the active tool profile: the session's /tools
+// choice, else tools.channelProfiles, else tools.profile. "" means all tools.
+func (l *Loop) toolProfile(sess *session.Session, channel string) string {
+	if sess != nil {
+		switch p := sess.MetaString(toolProfileMetaKey); {
+		case p == "*":
+			return ""
+		case p != "":
+			if _, ok := l.cfg.Tools.Profiles[p]; ok {
+				return p
+			}
+		}
+	}
+	return l.cfg.Tools.ProfileFor(channel)
+}
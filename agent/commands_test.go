@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/session"
+	"github.com/mosaxiv/clawlet/tools"
+)
+
+func TestParseSlashCommand(t *testing.T) {
+	cases := []struct {
+		in, name, arg string
+		ok            bool
+	}{
+		{"/tools coding", "tools", "coding", true},
+		{" /Tools@clawlet_bot  chat ", "tools", "chat", true},
+		{"/tools", "tools", "", true},
+		{"hello /tools", "", "", false},
+		{"/", "", "", false},
+	}
+	for _, tc := range cases {
+		name, arg, ok := parseSlashCommand(tc.in)
+		if name != tc.name || arg != tc.arg || ok != tc.ok {
+			t.Fatalf("%q: got (%q, %q, %v)", tc.in, name, arg, ok)
+		}
+	}
+}
+
+func TestToolsCommandSelectsProfile(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.Profiles = map[string][]string{
+		"chat":   {"web_fetch", "context_info"},
+		"coding": {"read_file", "write_file", "exec"},
+	}
+	cfg.Tools.ChannelProfiles = map[string]string{"telegram": "chat"}
+	l := &Loop{
+		cfg:      cfg,
+		sessions: session.NewManager(t.TempDir()),
+		tools:    &tools.Registry{WorkspaceDir: t.TempDir(), Profiles: cfg.Tools.Profiles},
+	}
+	const key = "telegram:1"
+	sess, _ := l.sessions.GetOrCreate(key)
+	if got := l.toolProfile(sess, "telegram"); got != "chat" {
+		t.Fatalf("channel default=%q", got)
+	}
+	if got := l.toolProfile(sess, "discord"); got != "" {
+		t.Fatalf("discord profile=%q", got)
+	}
+
+	reply, ok := l.runCommand(key, "telegram", "", "/tools coding")
+	if !ok || !strings.Contains(reply, "coding (4 tools)") {
+		t.Fatalf("reply=%q ok=%v", reply, ok)
+	}
+	if got := l.toolProfile(sess, "telegram"); got != "coding" {
+		t.Fatalf("after /tools coding: %q", got)
+	}
+	if reply, _ := l.runCommand(key, "telegram", "", "/tools nope"); !strings.Contains(reply, "Unknown tool profile") {
+		t.Fatalf("reply=%q", reply)
+	}
+	l.runCommand(key, "telegram", "", "/tools all")
+	if got := l.toolProfile(sess, "telegram"); got != "" {
+		t.Fatalf("after /tools all: %q", got)
+	}
+	l.runCommand(key, "telegram", "", "/tools default")
+	if got := l.toolProfile(sess, "telegram"); got != "chat" {
+		t.Fatalf("after /tools default: %q", got)
+	}
+	if _, ok := l.runCommand(key, "telegram", "", "/unknown"); ok {
+		t.Fatal("unknown commands should reach the model")
+	}
+}
+
+func TestRunCommand_EnabledAndAdminCommands(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.Profiles = map[string][]string{"chat": {"web_fetch"}}
+	l := &Loop{
+		cfg:      cfg,
+		sessions: session.NewManager(t.TempDir()),
+		tools:    &tools.Registry{WorkspaceDir: t.TempDir(), Profiles: cfg.Tools.Profiles},
+	}
+	const key = "telegram:1"
+
+	cfg.Channels.EnabledCommands = []string{"help"}
+	if reply, ok := l.runCommand(key, "telegram", "42", "/tools chat"); !ok || reply != "Unknown command: /tools" {
+		t.Fatalf("disabled: reply=%q ok=%v", reply, ok)
+	}
+
+	cfg.Channels.EnabledCommands = []string{"/tools"}
+	cfg.Channels.AdminCommands = []string{"tools"}
+	cfg.Channels.Admins = []string{"telegram:42"}
+	if reply, ok := l.runCommand(key, "telegram", "7", "/tools chat"); !ok || !strings.Contains(reply, "limited to admins") {
+		t.Fatalf("non-admin: reply=%q ok=%v", reply, ok)
+	}
+	if reply, _ := l.runCommand(key, "telegram.support", "42", "/tools chat"); !strings.Contains(reply, "Tool profile set to chat") {
+		t.Fatalf("admin on instance: reply=%q", reply)
+	}
+	if reply, _ := l.runCommand(key, "discord", "42", "/tools"); !strings.Contains(reply, "limited to admins") {
+		t.Fatalf("admin entry is per channel: reply=%q", reply)
+	}
+}
+
+func TestNewCommand_ArchivesWhenEnabled(t *testing.T) {
+	ws := t.TempDir()
+	cfg := config.Default()
+	l := &Loop{cfg: cfg, workspace: ws, sessions: session.NewManager(t.TempDir())}
+	const key = "telegram:1"
+	sess, _ := l.sessions.GetOrCreate(key)
+	sess.Add("user", "plan my trip")
+	sess.AddWithTools("assistant", "Here is the plan.", []string{"web_search"})
+	sess.SetMeta(tools.StateMetaKey, `{"plan":"x"}`)
+
+	reply, ok := l.runCommand(key, "telegram", "", "/new")
+	if !ok || reply != "Started a new conversation." {
+		t.Fatalf("reply=%q ok=%v", reply, ok)
+	}
+	if len(sess.History(0)) != 0 || sess.MetaString(tools.StateMetaKey) != "" {
+		t.Fatal("/new should clear messages and session state")
+	}
+	if _, err := os.Stat(filepath.Join(ws, archiveDirName)); !os.IsNotExist(err) {
+		t.Fatalf("archive written without archiveOnNew: %v", err)
+	}
+
+	cfg.Channels.ArchiveOnNew = true
+	sess.Add("user", "plan my trip")
+	sess.AddWithTools("assistant", "Here is the plan.", []string{"web_search"})
+	reply, _ = l.runCommand(key, "telegram", "", "/new")
+	if !strings.HasPrefix(reply, "Saved the previous conversation to archive/") {
+		t.Fatalf("reply=%q", reply)
+	}
+	rel := strings.TrimSuffix(strings.TrimPrefix(reply, "Saved the previous conversation to "), " and started a new one.")
+	b, err := os.ReadFile(filepath.Join(ws, rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Conversation telegram:1", "## user", "plan my trip", "## assistant", "_Tools: web_search_"} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("archive missing %q:\n%s", want, b)
+		}
+	}
+	if !strings.HasSuffix(rel, "-telegram_1.md") || len(sess.History(0)) != 0 {
+		t.Fatalf("rel=%q history=%d", rel, len(sess.History(0)))
+	}
+
+	if reply, _ := l.runCommand(key, "telegram", "", "/new"); reply != "Started a new conversation." {
+		t.Fatalf("empty session reply=%q", reply)
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/mosaxiv/clawlet/config"
 	"github.com/mosaxiv/clawlet/llm"
 	"github.com/mosaxiv/clawlet/memory"
 	"github.com/mosaxiv/clawlet/session"
@@ -29,7 +33,56 @@ func maybeConsolidateSession(
 	if memoryWindow <= 0 {
 		memoryWindow = 50
 	}
-	oldMessages, keep, version, ok := sess.SnapshotForConsolidation(memoryWindow)
+	return consolidateSnapshot(ctx, workspace, sess, summarize, nil, func() ([]session.Message, int, uint64, bool) {
+		return sess.SnapshotForConsolidation(memoryWindow)
+	})
+}
+
+// maybeConsolidateIdleSession consolidates everything except the newest keep
+// messages, regardless of the memory window. It is used for idle sessions.
+func maybeConsolidateIdleSession(
+	ctx context.Context,
+	workspace string,
+	sess *session.Session,
+	keep int,
+	summarize summarizeConsolidationFunc,
+) (bool, error) {
+	if sess == nil || summarize == nil {
+		return false, nil
+	}
+	return consolidateSnapshot(ctx, workspace, sess, summarize, nil, func() ([]session.Message, int, uint64, bool) {
+		return sess.SnapshotForIdleConsolidation(keep)
+	})
+}
+
+// consolidateWithRunningSummary is maybeConsolidateIdleSession for
+// agents.defaults.runningSummary: the dropped messages are also folded into
+// the session's running summary by roll.
+func consolidateWithRunningSummary(
+	ctx context.Context,
+	workspace string,
+	sess *session.Session,
+	keep int,
+	summarize summarizeConsolidationFunc,
+	roll runningSummaryFunc,
+) (bool, error) {
+	if sess == nil || summarize == nil || roll == nil {
+		return false, nil
+	}
+	return consolidateSnapshot(ctx, workspace, sess, summarize, roll, func() ([]session.Message, int, uint64, bool) {
+		return sess.SnapshotForIdleConsolidation(keep)
+	})
+}
+
+func consolidateSnapshot(
+	ctx context.Context,
+	workspace string,
+	sess *session.Session,
+	summarize summarizeConsolidationFunc,
+	roll runningSummaryFunc,
+	snapshot func() ([]session.Message, int, uint64, bool),
+) (bool, error) {
+	oldMessages, keep, version, ok := snapshot()
 	if !ok {
 		return false, nil
 	}
@@ -41,9 +94,20 @@ func maybeConsolidateSession(
 	if err != nil {
 		return false, err
 	}
+	var summary string
+	if roll != nil {
+		// Read before ApplyConsolidation: a summary changed meanwhile bumps
+		// the version and the snapshot is dropped.
+		if summary, err = roll(ctx, sess.MetaString(runningSummaryMetaKey), conversation); err != nil {
+			return false, err
+		}
+	}
 	if !sess.ApplyConsolidation(version, keep) {
 		return false, nil
 	}
+	if roll != nil {
+		sess.SetMeta(runningSummaryMetaKey, summary)
+	}
 
 	if strings.TrimSpace(historyEntry) != "" {
 		if err := store.AppendHistory(historyEntry); err != nil {
@@ -59,20 +123,122 @@ func maybeConsolidateSession(
 	return true, nil
 }
 
-func summarizeConsolidationWithLLM(ctx context.Context, c *llm.Client, currentMemory, conversation string) (string, string, error) {
+// maxRotatedHistoryBytes caps how much of an archived HISTORY.md is sent to
+// the model when summarizing it into MEMORY.md; the newest part is kept.
+const maxRotatedHistoryBytes = 64 << 10
+
+// rotateHistory archives HISTORY.md once it exceeds the configured size and,
+// when enabled, folds durable facts from the archive into MEMORY.md. A
+// failed summary leaves the archive and MEMORY.md as they were.
+func rotateHistory(ctx context.Context, workspace string, cfg config.HistoryRotationConfig, chat consolidationChatFunc) error {
+	store := memory.New(workspace)
+	archive, err := store.RotateHistory(cfg.MaxBytes(), time.Now())
+	if err != nil || archive == "" {
+		return err
+	}
+	log.Printf("agent: rotated %s to %s", store.History, archive)
+	if !cfg.Summarize || chat == nil {
+		return nil
+	}
+	b, err := os.ReadFile(archive)
+	if err != nil {
+		return err
+	}
+	history := string(b)
+	if len(history) > maxRotatedHistoryBytes {
+		history = history[len(history)-maxRotatedHistoryBytes:]
+	}
+	currentMemory := store.ReadLongTerm()
+	reply, err := chat(ctx, []llm.Message{
+		{Role: "system", Content: "You are a memory consolidation agent. Reply with the full updated MEMORY.md as markdown and nothing else."},
+		{Role: "user", Content: buildHistoryRotationPrompt(currentMemory, history)},
+	})
+	if err != nil {
+		return fmt.Errorf("summarize rotated history: %w", err)
+	}
+	updated := strings.TrimSpace(reply)
+	if updated == "" || updated == strings.TrimSpace(currentMemory) {
+		return nil
+	}
+	return store.WriteLongTerm(updated + "\n")
+}
+
+func buildHistoryRotationPrompt(currentMemory, history string) string {
+	return fmt.Sprintf(`The session history log below is being archived. Update the long-term memory with any durable facts from it (user preferences, decisions, ongoing projects, important context) that are not already there. Keep everything in the current memory unless the history shows it is outdated. Do not copy the log itself.
+
+## Current Long-term Memory
+%s
+
+## Archived History
+%s`, strings.TrimSpace(currentMemory), strings.TrimSpace(history))
+}
+
+// maxFallbackHistoryRunes caps the conversation excerpt archived when the
+// model never returns valid consolidation JSON.
+const maxFallbackHistoryRunes = 2000
+
+type consolidationChatFunc func(ctx context.Context, messages []llm.Message) (string, error)
+
+func summarizeConsolidationWithLLM(ctx context.Context, c *llm.Client, currentMemory, conversation string, repair bool) (string, string, error) {
 	if c == nil {
 		return "", "", fmt.Errorf("llm client is nil")
 	}
-	prompt := buildConsolidationPrompt(currentMemory, conversation)
-	res, err := c.Chat(ctx, []llm.Message{
+	return summarizeConsolidation(ctx, llmChatFunc(c), currentMemory, conversation, repair)
+}
+
+func llmChatFunc(c *llm.Client) consolidationChatFunc {
+	if c == nil {
+		return nil
+	}
+	return func(ctx context.Context, messages []llm.Message) (string, error) {
+		res, err := c.Chat(ctx, messages, nil)
+		if err != nil {
+			return "", err
+		}
+		return res.Content, nil
+	}
+}
+
+// summarizeConsolidation asks for the consolidation JSON. With repair, an
+// invalid reply is retried once with the parse error, and a second failure
+// falls back to archiving a conversation excerpt with no memory update.
+// Chat errors are always returned so the session is left untouched.
+func summarizeConsolidation(ctx context.Context, chat consolidationChatFunc, currentMemory, conversation string, repair bool) (string, string, error) {
+	messages := []llm.Message{
 		{Role: "system", Content: "You are a memory consolidation agent. Respond only with valid JSON."},
-		{Role: "user", Content: prompt},
-	}, nil)
+		{Role: "user", Content: buildConsolidationPrompt(currentMemory, conversation)},
+	}
+	reply, err := chat(ctx, messages)
 	if err != nil {
 		return "", "", err
 	}
+	historyEntry, memoryUpdate, err := parseConsolidationResponse(reply)
+	if err == nil || !repair {
+		return historyEntry, memoryUpdate, err
+	}
 
-	text := strings.TrimSpace(res.Content)
+	messages = append(messages,
+		llm.Message{Role: "assistant", Content: reply},
+		llm.Message{Role: "user", Content: fmt.Sprintf(`That reply could not be used (%v). Reply again with ONLY a JSON object with the string keys "history_entry" and "memory_update". No markdown fences, no other text.`, err)},
+	)
+	reply, err = chat(ctx, messages)
+	if err != nil {
+		return "", "", err
+	}
+	historyEntry, memoryUpdate, err = parseConsolidationResponse(reply)
+	if err == nil {
+		return historyEntry, memoryUpdate, nil
+	}
+	log.Printf("agent: consolidation: %v; archiving conversation excerpt without memory update", err)
+	return fallbackHistoryEntry(conversation, time.Now()), "", nil
+}
+
+// parseConsolidationResponse strictly validates the consolidation reply: a
+// JSON object, optionally fenced or surrounded by prose, whose
+// history_entry is a non-empty string and whose memory_update, if present,
+// is a string.
+func parseConsolidationResponse(reply string) (string, string, error) {
+	text := strings.TrimSpace(reply)
 	if text == "" {
 		return "", "", fmt.Errorf("empty consolidation response")
 	}
@@ -83,15 +249,39 @@ func summarizeConsolidationWithLLM(ctx context.Context, c *llm.Client, currentMe
 		text = strings.TrimSuffix(text, "```")
 		text = strings.TrimSpace(text)
 	}
-
-	var parsed struct {
-		HistoryEntry string `json:"history_entry"`
-		MemoryUpdate string `json:"memory_update"`
+	if !strings.HasPrefix(text, "{") {
+		start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+		if start >= 0 && end > start {
+			text = text[start : end+1]
+		}
 	}
-	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
 		return "", "", fmt.Errorf("parse consolidation json: %w", err)
 	}
-	return strings.TrimSpace(parsed.HistoryEntry), strings.TrimSpace(parsed.MemoryUpdate), nil
+	var historyEntry, memoryUpdate string
+	raw, ok := fields["history_entry"]
+	if !ok {
+		return "", "", fmt.Errorf("parse consolidation json: missing history_entry")
+	}
+	if err := json.Unmarshal(raw, &historyEntry); err != nil || strings.TrimSpace(historyEntry) == "" {
+		return "", "", fmt.Errorf("parse consolidation json: history_entry must be a non-empty string")
+	}
+	if raw, ok := fields["memory_update"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &memoryUpdate); err != nil {
+			return "", "", fmt.Errorf("parse consolidation json: memory_update must be a string")
+		}
+	}
+	return strings.TrimSpace(historyEntry), strings.TrimSpace(memoryUpdate), nil
+}
+
+func fallbackHistoryEntry(conversation string, now time.Time) string {
+	excerpt := strings.TrimSpace(conversation)
+	if r := []rune(excerpt); len(r) > maxFallbackHistoryRunes {
+		excerpt = string(r[:maxFallbackHistoryRunes]) + "…"
+	}
+	return fmt.Sprintf("[%s] Conversation archived without a summary (the model returned invalid JSON):\n%s", now.Format("2006-01-02 15:04"), excerpt)
 }
 
 func formatConsolidationConversation(msgs []session.Message) string {
@@ -112,6 +302,10 @@ func formatConsolidationConversation(msgs []session.Message) string {
 		if role == "" {
 			role = "UNKNOWN"
 		}
+		// Tool results ran before the reply they led to.
+		for _, r := range m.ToolResults {
+			lines = append(lines, fmt.Sprintf("TOOL RESULT %s: %s", r.Name, r.Output))
+		}
 		toolsLabel := formatToolsLabel(m.ToolsUsed)
 		if ts == "" {
 			lines = append(lines, fmt.Sprintf("%s%s: %s", role, toolsLabel, content))
@@ -122,6 +316,38 @@ func formatConsolidationConversation(msgs []session.Message) string {
 	return strings.Join(lines, "\n")
 }
 
+// toolResultLog collects a turn's tool results, cut to max runes each, for
+// agents.defaults.consolidationToolResultChars. A nil log records nothing.
+type toolResultLog struct {
+	max     int
+	results []session.ToolResult
+}
+
+func newToolResultLog(maxChars int) *toolResultLog {
+	if maxChars <= 0 {
+		return nil
+	}
+	return &toolResultLog{max: maxChars}
+}
+
+func (t *toolResultLog) record(name, output string) {
+	if t == nil {
+		return
+	}
+	output = strings.Join(strings.Fields(output), " ")
+	if r := []rune(output); len(r) > t.max {
+		output = string(r[:t.max]) + "…"
+	}
+	t.results = append(t.results, session.ToolResult{Name: name, Output: output})
+}
+
+func (t *toolResultLog) list() []session.ToolResult {
+	if t == nil {
+		return nil
+	}
+	return t.results
+}
+
 func formatToolsLabel(names []string) string {
 	if len(names) == 0 {
 		return ""
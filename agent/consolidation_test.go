@@ -2,11 +2,16 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/memory"
 	"github.com/mosaxiv/clawlet/session"
 )
 
@@ -105,3 +110,209 @@ func TestMaybeConsolidateSession_SummarizeError_NoTrim(t *testing.T) {
 		t.Fatalf("messages=%d", len(sess.Messages))
 	}
 }
+
+func TestMaybeConsolidateIdleSession_BelowWindow(t *testing.T) {
+	ws := t.TempDir()
+	sess := session.New("cli:test")
+	for range 6 {
+		sess.Add("user", "question")
+		sess.Add("assistant", "answer")
+	}
+
+	summarize := func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
+		return "[2026-02-13 23:20] idle summary", "", nil
+	}
+	// 12 messages is well under a window of 50, but idle consolidation still
+	// archives everything except the newest keep messages.
+	done, err := maybeConsolidateIdleSession(context.Background(), ws, sess, 4, summarize)
+	if err != nil {
+		t.Fatalf("maybeConsolidateIdleSession error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected consolidation")
+	}
+	if len(sess.Messages) != 4 {
+		t.Fatalf("messages=%d", len(sess.Messages))
+	}
+
+	// A second pass has nothing left to archive.
+	done, err = maybeConsolidateIdleSession(context.Background(), ws, sess, 4, summarize)
+	if err != nil || done {
+		t.Fatalf("second pass done=%v err=%v", done, err)
+	}
+}
+
+func TestConsolidateWithRunningSummary(t *testing.T) {
+	ws := t.TempDir()
+	sess := session.New("cli:test")
+	sess.SetMeta(runningSummaryMetaKey, "User is planning a trip to Kyoto.")
+	for i := range 6 {
+		sess.Add("user", fmt.Sprintf("question %d", i))
+		sess.Add("assistant", "answer")
+	}
+
+	summarize := func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
+		return "[2026-02-13 23:20] summary", "", nil
+	}
+	var gotPrev, gotConv string
+	roll := func(ctx context.Context, previous, conversation string) (string, error) {
+		gotPrev, gotConv = previous, conversation
+		return "User is planning a trip to Kyoto in April.", nil
+	}
+	done, err := consolidateWithRunningSummary(context.Background(), ws, sess, 4, summarize, roll)
+	if err != nil || !done {
+		t.Fatalf("done=%v err=%v", done, err)
+	}
+	if gotPrev != "User is planning a trip to Kyoto." || !strings.Contains(gotConv, "USER: question 0") || strings.Contains(gotConv, "question 5") {
+		t.Fatalf("roll got previous=%q conversation=%q", gotPrev, gotConv)
+	}
+	if len(sess.Messages) != 4 {
+		t.Fatalf("messages=%d", len(sess.Messages))
+	}
+	if got := sess.MetaString(runningSummaryMetaKey); got != "User is planning a trip to Kyoto in April." {
+		t.Fatalf("summary=%q", got)
+	}
+
+	// A failed summary keeps the messages so the next pass can retry.
+	for range 4 {
+		sess.Add("user", "more")
+	}
+	_, err = consolidateWithRunningSummary(context.Background(), ws, sess, 4, summarize, func(context.Context, string, string) (string, error) {
+		return "", errors.New("boom")
+	})
+	if err == nil || len(sess.Messages) != 8 {
+		t.Fatalf("err=%v messages=%d", err, len(sess.Messages))
+	}
+
+	sys := withRunningSummary("# clawlet\n", sess.MetaString(runningSummaryMetaKey))
+	if !strings.HasSuffix(sys, "Kyoto in April.\n") || !strings.Contains(sys, "## Conversation Summary") {
+		t.Fatalf("system prompt:\n%s", sys)
+	}
+	if withRunningSummary("# clawlet\n", "") != "# clawlet\n" {
+		t.Fatal("empty summary changed the prompt")
+	}
+}
+
+func TestRollRunningSummary_CapsLength(t *testing.T) {
+	chat := func(ctx context.Context, messages []llm.Message) (string, error) {
+		if !strings.Contains(messages[1].Content, "(none yet)") {
+			t.Fatalf("prompt: %s", messages[1].Content)
+		}
+		return "  " + strings.Repeat("x", 50) + "\n", nil
+	}
+	got, err := rollRunningSummary(context.Background(), chat, "", "USER: hi", 10)
+	if err != nil || got != strings.Repeat("x", 10) {
+		t.Fatalf("got %q err=%v", got, err)
+	}
+}
+
+func TestFormatConsolidationConversation_ToolResults(t *testing.T) {
+	log := newToolResultLog(12)
+	log.record("exec", "ok  pkg/a\nFAIL pkg/b\n3 failed")
+	log.record("read_file", "short")
+	sess := session.New("telegram:1")
+	sess.Add("user", "run the tests")
+	sess.AddWithToolResults("assistant", "Three tests fail in pkg/b.", []string{"exec", "read_file"}, log.list())
+
+	got := formatConsolidationConversation(sess.History(0))
+	lines := strings.Split(got, "\n")
+	if len(lines) != 4 || lines[1] != "TOOL RESULT exec: ok pkg/a FAI…" || lines[2] != "TOOL RESULT read_file: short" || !strings.Contains(lines[3], "ASSISTANT [tools: exec, read_file]: Three tests fail") {
+		t.Fatalf("conversation:\n%s", got)
+	}
+
+	off := newToolResultLog(0)
+	off.record("exec", "x")
+	if off.list() != nil {
+		t.Fatal("disabled log should record nothing")
+	}
+}
+
+func TestParseConsolidationResponse(t *testing.T) {
+	entry, update, err := parseConsolidationResponse("Here you go:\n{\"history_entry\":\"[2026-03-02 09:00] Talked.\",\"memory_update\":\"likes tea\"}\nThanks")
+	if err != nil || entry != "[2026-03-02 09:00] Talked." || update != "likes tea" {
+		t.Fatalf("entry=%q update=%q err=%v", entry, update, err)
+	}
+	if _, update, err := parseConsolidationResponse("```json\n{\"history_entry\":\"x\",\"memory_update\":null}\n```"); err != nil || update != "" {
+		t.Fatalf("fenced null update: %q %v", update, err)
+	}
+	for _, bad := range []string{
+		"",
+		"not json",
+		`{"memory_update":"m"}`,
+		`{"history_entry":""}`,
+		`{"history_entry":["a"]}`,
+		`{"history_entry":"x","memory_update":{"k":"v"}}`,
+	} {
+		if _, _, err := parseConsolidationResponse(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestSummarizeConsolidation_RepairRetry(t *testing.T) {
+	calls := 0
+	chat := func(ctx context.Context, messages []llm.Message) (string, error) {
+		calls++
+		if calls == 1 {
+			return `{"history_entry": "unterminated`, nil
+		}
+		if last := messages[len(messages)-1].Content; !strings.Contains(last, "ONLY a JSON object") {
+			t.Fatalf("retry prompt=%q", last)
+		}
+		return `{"history_entry":"[2026-03-02 09:00] ok","memory_update":"m"}`, nil
+	}
+	entry, update, err := summarizeConsolidation(context.Background(), chat, "", "USER: hi", true)
+	if err != nil || calls != 2 || entry != "[2026-03-02 09:00] ok" || update != "m" {
+		t.Fatalf("calls=%d entry=%q update=%q err=%v", calls, entry, update, err)
+	}
+}
+
+func TestSummarizeConsolidation_FallbackArchivesConversation(t *testing.T) {
+	chat := func(ctx context.Context, messages []llm.Message) (string, error) { return "sorry, no", nil }
+	entry, update, err := summarizeConsolidation(context.Background(), chat, "mem", "USER: plan the trip", true)
+	if err != nil || update != "" || !strings.Contains(entry, "USER: plan the trip") {
+		t.Fatalf("entry=%q update=%q err=%v", entry, update, err)
+	}
+
+	if _, _, err := summarizeConsolidation(context.Background(), chat, "mem", "USER: x", false); err == nil {
+		t.Fatal("expected error without repair")
+	}
+
+	chatErr := func(ctx context.Context, messages []llm.Message) (string, error) { return "", errors.New("network") }
+	if _, _, err := summarizeConsolidation(context.Background(), chatErr, "mem", "USER: x", true); err == nil {
+		t.Fatal("chat errors must not fall back")
+	}
+}
+
+func TestRotateHistory_SummarizesIntoMemory(t *testing.T) {
+	ws := t.TempDir()
+	store := memory.New(ws)
+	if err := store.AppendHistory("[2026-03-01] User moved to Osaka. " + strings.Repeat("z", 2048)); err != nil {
+		t.Fatal(err)
+	}
+	var prompt string
+	chat := func(ctx context.Context, messages []llm.Message) (string, error) {
+		prompt = messages[len(messages)-1].Content
+		return "# Long-term Memory\n\n- Lives in Osaka", nil
+	}
+	cfg := config.HistoryRotationConfig{MaxKB: 1, Summarize: true}
+	if err := rotateHistory(context.Background(), ws, cfg, chat); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "User moved to Osaka") {
+		t.Fatalf("prompt missing archived history: %q", prompt)
+	}
+	if got := store.ReadLongTerm(); !strings.Contains(got, "Lives in Osaka") {
+		t.Fatalf("memory=%q", got)
+	}
+	if _, err := os.Stat(store.History); !os.IsNotExist(err) {
+		t.Fatalf("HISTORY.md not rotated: %v", err)
+	}
+
+	// Under the cap nothing is rotated or summarized.
+	prompt = ""
+	_ = store.AppendHistory("short")
+	if err := rotateHistory(context.Background(), ws, cfg, chat); err != nil || prompt != "" {
+		t.Fatalf("err=%v prompt=%q", err, prompt)
+	}
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/mosaxiv/clawlet/bus"
+)
+
+// sessionDispatcher runs inbound messages of different sessions in parallel
+// while keeping messages of the same session in arrival order.
+type sessionDispatcher struct {
+	handle func(context.Context, bus.InboundMessage)
+	// slots bounds how many sessions are being handled at once.
+	slots chan struct{}
+	// pending bounds queued plus running messages so a flood of chats applies
+	// backpressure to the bus instead of growing memory.
+	pending chan struct{}
+
+	mu     sync.Mutex
+	queues map[string][]bus.InboundMessage // a key is present while its worker runs
+	wg     sync.WaitGroup
+}
+
+func newSessionDispatcher(concurrency int, handle func(context.Context, bus.InboundMessage)) *sessionDispatcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &sessionDispatcher{
+		handle:  handle,
+		slots:   make(chan struct{}, concurrency),
+		pending: make(chan struct{}, concurrency*16),
+		queues:  map[string][]bus.InboundMessage{},
+	}
+}
+
+// Dispatch queues msg behind earlier messages with the same key. It blocks
+// only when too many messages are already waiting.
+func (d *sessionDispatcher) Dispatch(ctx context.Context, key string, msg bus.InboundMessage) error {
+	select {
+	case d.pending <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	d.mu.Lock()
+	if q, ok := d.queues[key]; ok {
+		d.queues[key] = append(q, msg)
+		d.mu.Unlock()
+		return nil
+	}
+	d.queues[key] = nil
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go d.run(ctx, key, msg)
+	return nil
+}
+
+func (d *sessionDispatcher) run(ctx context.Context, key string, msg bus.InboundMessage) {
+	defer d.wg.Done()
+	for {
+		select {
+		case d.slots <- struct{}{}:
+			d.handle(ctx, msg)
+			<-d.slots
+		case <-ctx.Done():
+		}
+		<-d.pending
+
+		d.mu.Lock()
+		q := d.queues[key]
+		if len(q) == 0 {
+			delete(d.queues, key)
+			d.mu.Unlock()
+			return
+		}
+		msg, d.queues[key] = q[0], q[1:]
+		d.mu.Unlock()
+	}
+}
+
+// Wait blocks until every dispatched message has been handled or dropped.
+func (d *sessionDispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// inboundSessionKey is the session a message will be written to. Subagent
+// announcements on the "system" channel belong to their origin session.
+func inboundSessionKey(msg bus.InboundMessage) string {
+	if msg.Channel == "system" {
+		originCh, originChat := parseOrigin(msg.ChatID)
+		if originCh == "" || originChat == "" {
+			return "cli:" + msg.ChatID
+		}
+		return originCh + ":" + originChat
+	}
+	if strings.TrimSpace(msg.SessionKey) != "" {
+		return msg.SessionKey
+	}
+	return msg.Channel + ":" + msg.ChatID
+}
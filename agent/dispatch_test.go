@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+)
+
+func TestSessionDispatcher_OrderPerKeyParallelAcrossKeys(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	started := make(chan string, 8)
+
+	var mu sync.Mutex
+	seen := map[string][]string{}
+	d := newSessionDispatcher(2, func(ctx context.Context, msg bus.InboundMessage) {
+		started <- msg.Content
+		if msg.Content == "a1" {
+			<-release
+		}
+		mu.Lock()
+		seen[msg.SessionKey] = append(seen[msg.SessionKey], msg.Content)
+		mu.Unlock()
+	})
+
+	for _, m := range []bus.InboundMessage{
+		{SessionKey: "a", Content: "a1"},
+		{SessionKey: "a", Content: "a2"},
+		{SessionKey: "b", Content: "b1"},
+		{SessionKey: "a", Content: "a3"},
+	} {
+		if err := d.Dispatch(ctx, m.SessionKey, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// b1 must run while a1 is still blocked; a2 must not.
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case c := <-started:
+			got[c] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("started=%v", got)
+		}
+	}
+	if !got["a1"] || !got["b1"] {
+		t.Fatalf("started=%v", got)
+	}
+	close(release)
+	d.Wait()
+
+	if a := seen["a"]; len(a) != 3 || a[0] != "a1" || a[1] != "a2" || a[2] != "a3" {
+		t.Fatalf("session a order=%v", a)
+	}
+	if b := seen["b"]; len(b) != 1 {
+		t.Fatalf("session b=%v", b)
+	}
+}
+
+func TestInboundSessionKey(t *testing.T) {
+	cases := []struct {
+		msg  bus.InboundMessage
+		want string
+	}{
+		{bus.InboundMessage{Channel: "slack", ChatID: "C1", SessionKey: "slack:C1:t1"}, "slack:C1:t1"},
+		{bus.InboundMessage{Channel: "discord", ChatID: "42"}, "discord:42"},
+		{bus.InboundMessage{Channel: "system", ChatID: "telegram:99"}, "telegram:99"},
+	}
+	for _, tc := range cases {
+		if got := inboundSessionKey(tc.msg); got != tc.want {
+			t.Fatalf("%+v: got %q want %q", tc.msg, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+// Error classes used to pick the reply sent when a message cannot be processed.
+const (
+	errorClassAuth      = "auth"
+	errorClassRateLimit = "rateLimit"
+	errorClassTransient = "transient"
+	errorClassOther     = "other"
+)
+
+// providerHTTPStatusRe matches the status in provider errors that reached
+// us only as text, such as "llm http 429: ..." or "codex http 401: ...".
+var providerHTTPStatusRe = regexp.MustCompile(`\bhttp (\d{3})\b`)
+
+func classifyError(err error) string {
+	if err == nil {
+		return errorClassOther
+	}
+	if apiErr, ok := llm.AsAPIError(err); ok {
+		return classifyStatus(apiErr.StatusCode)
+	}
+	if m := providerHTTPStatusRe.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return classifyStatus(code)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorClassTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return errorClassTransient
+	}
+	return errorClassOther
+}
+
+func classifyStatus(code int) string {
+	switch {
+	case code == 401 || code == 403:
+		return errorClassAuth
+	case code == 429:
+		return errorClassRateLimit
+	case code == 408 || code >= 500:
+		return errorClassTransient
+	}
+	return errorClassOther
+}
+
+// errorReplyText returns the user-facing reply for err.
+func errorReplyText(cfg config.ErrorReplyConfig, err error) string {
+	text := cfg.Text(classifyError(err))
+	if cfg.Detail && err != nil {
+		text += "\n\n(error: " + err.Error() + ")"
+	}
+	return text
+}
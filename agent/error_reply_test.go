@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("llm http 401: invalid api key"), errorClassAuth},
+		{errors.New("codex http 403: forbidden"), errorClassAuth},
+		{fmt.Errorf("chat: %w", errors.New("llm http 429: slow down")), errorClassRateLimit},
+		{errors.New("llm http 503: overloaded"), errorClassTransient},
+		{errors.New("llm http 400: bad request"), errorClassOther},
+		{context.DeadlineExceeded, errorClassTransient},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, errorClassTransient},
+		{errors.New("session store broken"), errorClassOther},
+		{fmt.Errorf("chat: %w", &llm.APIError{StatusCode: 401, Message: "bad key"}), errorClassAuth},
+		{&llm.APIError{StatusCode: 529, Message: "overloaded"}, errorClassTransient},
+	}
+	for _, tc := range cases {
+		if got := classifyError(tc.err); got != tc.want {
+			t.Fatalf("classifyError(%v)=%q want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestErrorReplyText(t *testing.T) {
+	err := errors.New("llm http 429: slow down")
+	if got := errorReplyText(config.ErrorReplyConfig{}, err); got != config.DefaultErrorReplyRateLimit {
+		t.Fatalf("default=%q", got)
+	}
+	cfg := config.ErrorReplyConfig{RateLimit: "busy", Detail: true}
+	if got := errorReplyText(cfg, err); !strings.HasPrefix(got, "busy") || !strings.Contains(got, "llm http 429") {
+		t.Fatalf("custom=%q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/session"
+	"github.com/mosaxiv/clawlet/tools"
+)
+
+const (
+	// wrapUpTargetMetaKey holds where the session's last message came from,
+	// so an idle wrap-up can be sent back there.
+	wrapUpTargetMetaKey = "wrap_up_target"
+	// wrapUpDoneMetaKey marks a session wrapped up since its last message.
+	wrapUpDoneMetaKey = "wrap_up_done"
+)
+
+const wrapUpSummaryPrompt = "The conversation below has gone quiet. Write a short closing message for the user: summarize what was covered in a few bullet points, note anything left open, and ask if there is anything else. Reply with the message only, in the user's language."
+
+type wrapUpTarget struct {
+	Channel  string       `json:"channel"`
+	ChatID   string       `json:"chatId"`
+	Delivery bus.Delivery `json:"delivery"`
+}
+
+// noteWrapUpTarget records msg's chat on its session and re-arms the idle
+// wrap-up.
+func (l *Loop) noteWrapUpTarget(sessionKey string, msg bus.InboundMessage) {
+	if !l.cfg.Agents.Defaults.IdleWrapUp.Enabled() || msg.Channel == "cli" {
+		return
+	}
+	sess, err := l.sessions.GetOrCreate(sessionKey)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(wrapUpTarget{
+		Channel: msg.Channel,
+		ChatID:  msg.ChatID,
+		// Keep the thread, not the message being replied to.
+		Delivery: bus.Delivery{ThreadID: msg.Delivery.ThreadID, IsDirect: msg.Delivery.IsDirect},
+	})
+	if err != nil {
+		return
+	}
+	sess.SetMeta(wrapUpTargetMetaKey, string(b))
+	sess.SetMeta(wrapUpDoneMetaKey, "")
+}
+
+// idleWrapUpLoop periodically wraps up cached sessions that have had no
+// activity for cfg.AfterSec.
+func (l *Loop) idleWrapUpLoop(ctx context.Context, cfg config.IdleWrapUpConfig) {
+	t := time.NewTicker(time.Duration(cfg.CheckIntervalSecValue()) * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			l.wrapUpIdleSessions(ctx, cfg, time.Now())
+		}
+	}
+}
+
+func (l *Loop) wrapUpIdleSessions(ctx context.Context, cfg config.IdleWrapUpConfig, now time.Time) {
+	idleAfter := time.Duration(cfg.AfterSec) * time.Second
+	for _, sess := range l.sessions.Cached() {
+		if now.Sub(sess.LastUpdated()) < idleAfter || sess.MetaString(wrapUpDoneMetaKey) != "" {
+			continue
+		}
+		var target wrapUpTarget
+		if err := json.Unmarshal([]byte(sess.MetaString(wrapUpTargetMetaKey)), &target); err != nil || target.Channel == "" || target.ChatID == "" {
+			continue
+		}
+		if len(sess.History(1)) == 0 {
+			continue
+		}
+		// Share the consolidation guard so a wrap-up never races a
+		// consolidation of the same session.
+		if _, loaded := l.consolidationInFlight.LoadOrStore(sess.Key, struct{}{}); loaded {
+			continue
+		}
+		err := l.wrapUpSession(ctx, cfg, sess, target, now)
+		l.consolidationInFlight.Delete(sess.Key)
+		if err != nil {
+			log.Printf("agent: idle wrap-up %s: %v", sess.Key, err)
+		}
+	}
+}
+
+func (l *Loop) wrapUpSession(ctx context.Context, cfg config.IdleWrapUpConfig, sess *session.Session, target wrapUpTarget, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if cfg.ActionValue() == config.IdleWrapUpArchive {
+		return l.archiveIdleSession(ctx, sess, now)
+	}
+	text := cfg.MessageValue()
+	if cfg.ActionValue() == config.IdleWrapUpSummary {
+		res, err := l.llm.Chat(ctx, []llm.Message{
+			{Role: "system", Content: wrapUpSummaryPrompt},
+			{Role: "user", Content: formatConsolidationConversation(sess.History(l.historyWindow))},
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if s := strings.TrimSpace(res.Content); s != "" {
+			text = s
+		}
+	}
+	if err := l.bus.PublishOutbound(ctx, bus.OutboundMessage{
+		Channel:  target.Channel,
+		ChatID:   target.ChatID,
+		Content:  text,
+		Delivery: target.Delivery,
+	}); err != nil {
+		return err
+	}
+	sess.Add("assistant", text)
+	sess.SetMeta(wrapUpDoneMetaKey, now.UTC().Format(time.RFC3339))
+	return l.sessions.Save(sess)
+}
+
+// archiveIdleSession consolidates the whole session into memory, saves its
+// transcript to the archive, and starts it afresh, like /new.
+func (l *Loop) archiveIdleSession(ctx context.Context, sess *session.Session, now time.Time) error {
+	msgs := sess.History(0)
+	if _, err := archiveTranscript(l.workspace, sess.Key, msgs, now); err != nil {
+		return err
+	}
+	if _, err := maybeConsolidateIdleSession(ctx, l.workspace, sess, 0, func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
+		return summarizeConsolidationWithLLM(ctx, l.llm, currentMemory, conversation, l.cfg.Agents.Defaults.ConsolidationRepairValue())
+	}); err != nil {
+		// The transcript is archived; keep going so the session still closes.
+		log.Printf("agent: idle wrap-up %s: consolidation failed: %v", sess.Key, err)
+	}
+	sess.Clear()
+	sess.SetMeta(tools.StateMetaKey, "")
+	sess.SetMeta(runningSummaryMetaKey, "")
+	sess.SetMeta(replyLanguageMetaKey, "")
+	sess.SetMeta(wrapUpDoneMetaKey, now.UTC().Format(time.RFC3339))
+	return l.sessions.Save(sess)
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/session"
+)
+
+func TestWrapUpIdleSessions_Message(t *testing.T) {
+	cfg := config.Default()
+	cfg.Agents.Defaults.IdleWrapUp = config.IdleWrapUpConfig{AfterSec: 60}
+	l := &Loop{cfg: cfg, bus: bus.New(4), workspace: t.TempDir(), sessions: session.NewManager(t.TempDir())}
+	const key = "slack:C1:t1"
+	l.noteWrapUpTarget(key, bus.InboundMessage{Channel: "slack", ChatID: "C1", Delivery: bus.Delivery{MessageID: "m1", ThreadID: "t1"}})
+	sess, _ := l.sessions.GetOrCreate(key)
+	sess.Add("user", "thanks")
+	ctx := context.Background()
+
+	l.wrapUpIdleSessions(ctx, cfg.Agents.Defaults.IdleWrapUp, time.Now())
+	if n := len(sess.History(0)); n != 1 {
+		t.Fatalf("active session was wrapped up: %d messages", n)
+	}
+
+	later := time.Now().Add(2 * time.Minute)
+	l.wrapUpIdleSessions(ctx, cfg.Agents.Defaults.IdleWrapUp, later)
+	out, err := l.bus.ConsumeOutbound(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Channel != "slack" || out.ChatID != "C1" || out.Delivery.ThreadID != "t1" || out.Delivery.MessageID != "" || out.Content != config.DefaultIdleWrapUpMessage {
+		t.Fatalf("outbound=%+v", out)
+	}
+	if h := sess.History(0); len(h) != 2 || h[1].Content != config.DefaultIdleWrapUpMessage {
+		t.Fatalf("history=%+v", h)
+	}
+
+	l.wrapUpIdleSessions(ctx, cfg.Agents.Defaults.IdleWrapUp, later.Add(time.Hour))
+	if n := len(sess.History(0)); n != 2 {
+		t.Fatalf("wrapped up twice: %d messages", n)
+	}
+	l.noteWrapUpTarget(key, bus.InboundMessage{Channel: "slack", ChatID: "C1"})
+	if sess.MetaString(wrapUpDoneMetaKey) != "" {
+		t.Fatal("a new message should re-arm the wrap-up")
+	}
+}
+
+func TestWrapUpIdleSessions_Archive(t *testing.T) {
+	cfg := config.Default()
+	cfg.Agents.Defaults.IdleWrapUp = config.IdleWrapUpConfig{AfterSec: 60, Action: config.IdleWrapUpArchive}
+	ws := t.TempDir()
+	doer := &scriptedDoer{bodies: []string{`{"choices":[{"message":{"content":"{\"history_entry\":\"Talked about trips.\",\"memory_update\":\"\"}"}}]}`}}
+	l := &Loop{
+		cfg:       cfg,
+		bus:       bus.New(4),
+		workspace: ws,
+		sessions:  session.NewManager(t.TempDir()),
+		llm:       &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer},
+	}
+	const key = "telegram:1"
+	l.noteWrapUpTarget(key, bus.InboundMessage{Channel: "telegram", ChatID: "1"})
+	sess, _ := l.sessions.GetOrCreate(key)
+	sess.Add("user", "plan my trip")
+	sess.Add("assistant", "Here is the plan.")
+
+	l.wrapUpIdleSessions(context.Background(), cfg.Agents.Defaults.IdleWrapUp, time.Now().Add(2*time.Minute))
+	if n := len(sess.History(0)); n != 0 {
+		t.Fatalf("session not cleared: %d messages", n)
+	}
+	entries, _ := os.ReadDir(filepath.Join(ws, archiveDirName))
+	if len(entries) != 1 {
+		t.Fatalf("archive entries=%v", entries)
+	}
+	if doer.calls != 1 {
+		t.Fatalf("consolidation calls=%d", doer.calls)
+	}
+}
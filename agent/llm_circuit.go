@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+// llmCircuit pauses message handling after repeated LLM failures
+// (gateway.llmCircuit). While open, each session is told once that the
+// assistant is unavailable and its messages are dropped; a background probe
+// closes the circuit once the provider answers again.
+type llmCircuit struct {
+	threshold int
+	interval  time.Duration
+	reply     string
+	probe     func(context.Context) error
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	notified map[string]bool
+}
+
+// newLLMCircuit returns nil when the circuit is disabled.
+func newLLMCircuit(cfg config.LLMCircuitConfig, probe func(context.Context) error) *llmCircuit {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &llmCircuit{
+		threshold: cfg.FailuresValue(),
+		interval:  cfg.ProbeInterval(),
+		reply:     cfg.ReplyValue(),
+		probe:     probe,
+	}
+}
+
+// admit reports whether a message for sessionKey may be processed. When it
+// may not, notice is the reply to send, or "" if the session was already
+// told.
+func (c *llmCircuit) admit(sessionKey string) (ok bool, notice string) {
+	if c == nil {
+		return true, ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.open {
+		return true, ""
+	}
+	if c.notified[sessionKey] {
+		return false, ""
+	}
+	c.notified[sessionKey] = true
+	return false, c.reply
+}
+
+// record notes the outcome of a turn. Auth, server, and network errors count
+// towards opening the circuit; a success resets the count.
+func (c *llmCircuit) record(ctx context.Context, err error) {
+	if c == nil {
+		return
+	}
+	if err != nil {
+		if class := classifyError(err); class != errorClassAuth && class != errorClassTransient {
+			return
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.open || c.failures < c.threshold {
+		return
+	}
+	c.open = true
+	c.notified = map[string]bool{}
+	log.Printf("agent: %d LLM failures in a row (last: %v); pausing message handling until the provider recovers", c.failures, err)
+	go c.probeLoop(ctx)
+}
+
+func (c *llmCircuit) probeLoop(ctx context.Context) {
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := c.probe(ctx); err != nil {
+			log.Printf("agent: LLM health probe failed: %v", err)
+			continue
+		}
+		c.mu.Lock()
+		c.open, c.failures, c.notified = false, 0, nil
+		c.mu.Unlock()
+		log.Printf("agent: LLM health probe succeeded; resuming message handling")
+		return
+	}
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func TestLLMCircuit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var healthy atomic.Bool
+	c := newLLMCircuit(config.LLMCircuitConfig{Enabled: true, Failures: 2, Reply: "down"}, func(context.Context) error {
+		if healthy.Load() {
+			return nil
+		}
+		return errors.New("still down")
+	})
+	c.interval = 10 * time.Millisecond
+	outage := &llm.APIError{StatusCode: 503}
+
+	c.record(ctx, outage)
+	c.record(ctx, nil)
+	c.record(ctx, outage)
+	c.record(ctx, errors.New("tool loop failed"))
+	if ok, _ := c.admit("a"); !ok {
+		t.Fatal("opened before two failures in a row")
+	}
+	c.record(ctx, outage)
+	ok, notice := c.admit("a")
+	if ok || notice != "down" {
+		t.Fatalf("admit after outage: ok=%v notice=%q", ok, notice)
+	}
+	if ok, notice := c.admit("a"); ok || notice != "" {
+		t.Fatalf("second message: ok=%v notice=%q", ok, notice)
+	}
+	if _, notice := c.admit("b"); notice != "down" {
+		t.Fatalf("other session not told: %q", notice)
+	}
+
+	healthy.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if ok, _ := c.admit("a"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("circuit did not close after a successful probe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLLMCircuit_Disabled(t *testing.T) {
+	c := newLLMCircuit(config.LLMCircuitConfig{}, nil)
+	for range 5 {
+		c.record(context.Background(), &llm.APIError{StatusCode: 500})
+	}
+	if ok, _ := c.admit("a"); !ok {
+		t.Fatal("disabled circuit paused")
+	}
+}
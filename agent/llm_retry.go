@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+// chatWithEmptyRetry calls the LLM and, when retryEmpty is set, retries once
+// with a slightly higher temperature and token budget if the response has
+// neither content nor tool calls.
+func chatWithEmptyRetry(ctx context.Context, c *llm.Client, messages []llm.Message, defs []llm.ToolDefinition, retryEmpty bool) (*llm.ChatResult, error) {
+	res, err := c.Chat(ctx, messages, defs)
+	if err != nil || !retryEmpty || !isEmptyChatResult(res) {
+		return res, err
+	}
+	log.Printf("agent: empty llm response (model=%s); retrying once", c.Model)
+	retry := retryTunedClient(c)
+	res2, err := retry.Chat(ctx, messages, defs)
+	if err != nil {
+		log.Printf("agent: empty-response retry failed: %v", err)
+		return res, nil
+	}
+	return res2, nil
+}
+
+// refusalReply logs a provider content-policy refusal and returns what to
+// tell the user: the provider's own refusal text, or fallback when it gave
+// none.
+func refusalReply(res *llm.ChatResult, model, fallback string) string {
+	log.Printf("agent: provider refused to respond (model=%s, reason=%s)", model, res.Refusal)
+	if text := strings.TrimSpace(res.Content); text != "" {
+		return text
+	}
+	return fallback
+}
+
+func isEmptyChatResult(res *llm.ChatResult) bool {
+	return res != nil && !res.HasToolCalls() && strings.TrimSpace(res.Content) == "" && res.Refusal == ""
+}
+
+func retryTunedClient(c *llm.Client) *llm.Client {
+	cp := *c
+	maxTokens := c.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 8192
+	}
+	cp.MaxTokens = maxTokens + maxTokens/2
+	temp := 0.7
+	if c.Temperature != nil {
+		temp = *c.Temperature
+	}
+	temp = min(temp+0.1, 1.0)
+	cp.Temperature = &temp
+	return &cp
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+type scriptedDoer struct {
+	bodies []string
+	calls  int
+}
+
+func (d *scriptedDoer) Do(req *http.Request) (*http.Response, error) {
+	body := d.bodies[min(d.calls, len(d.bodies)-1)]
+	d.calls++
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestChatWithEmptyRetry_RetriesOnceOnEmpty(t *testing.T) {
+	doer := &scriptedDoer{bodies: []string{
+		`{"choices":[{"message":{"content":""}}]}`,
+		`{"choices":[{"message":{"content":"hello"}}]}`,
+	}}
+	c := &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer}
+
+	res, err := chatWithEmptyRetry(context.Background(), c, []llm.Message{{Role: "user", Content: "hi"}}, nil, true)
+	if err != nil {
+		t.Fatalf("chat error: %v", err)
+	}
+	if res.Content != "hello" {
+		t.Fatalf("content=%q", res.Content)
+	}
+	if doer.calls != 2 {
+		t.Fatalf("calls=%d", doer.calls)
+	}
+}
+
+func TestChatWithEmptyRetry_DisabledDoesNotRetry(t *testing.T) {
+	doer := &scriptedDoer{bodies: []string{`{"choices":[{"message":{"content":""}}]}`}}
+	c := &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer}
+
+	if _, err := chatWithEmptyRetry(context.Background(), c, []llm.Message{{Role: "user", Content: "hi"}}, nil, false); err != nil {
+		t.Fatalf("chat error: %v", err)
+	}
+	if doer.calls != 1 {
+		t.Fatalf("calls=%d", doer.calls)
+	}
+}
+
+func TestChatWithEmptyRetry_RefusalIsNotRetried(t *testing.T) {
+	doer := &scriptedDoer{bodies: []string{`{"choices":[{"message":{"content":""},"finish_reason":"content_filter"}]}`}}
+	c := &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer}
+
+	res, err := chatWithEmptyRetry(context.Background(), c, []llm.Message{{Role: "user", Content: "hi"}}, nil, true)
+	if err != nil {
+		t.Fatalf("chat error: %v", err)
+	}
+	if doer.calls != 1 {
+		t.Fatalf("calls=%d", doer.calls)
+	}
+	if got := refusalReply(res, c.Model, "blocked"); got != "blocked" {
+		t.Fatalf("reply=%q", got)
+	}
+	if got := refusalReply(&llm.ChatResult{Refusal: "refusal", Content: "I can't help with that."}, c.Model, "blocked"); got != "I can't help with that." {
+		t.Fatalf("provider text should be kept: %q", got)
+	}
+}
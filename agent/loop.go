@@ -3,18 +3,21 @@ package agent
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/mosaxiv/clawlet/activity"
 	"github.com/mosaxiv/clawlet/bus"
 	"github.com/mosaxiv/clawlet/config"
 	"github.com/mosaxiv/clawlet/cron"
 	"github.com/mosaxiv/clawlet/llm"
 	"github.com/mosaxiv/clawlet/media"
 	"github.com/mosaxiv/clawlet/memory"
+	"github.com/mosaxiv/clawlet/paths"
 	"github.com/mosaxiv/clawlet/session"
 	"github.com/mosaxiv/clawlet/skills"
 	"github.com/mosaxiv/clawlet/tools"
@@ -26,6 +29,9 @@ type Loop struct {
 	model        string
 	maxIters     int
 	memoryWindow int
+	// historyWindow bounds the messages sent per turn; memoryWindow bounds
+	// what the session keeps before consolidation.
+	historyWindow int
 
 	bus      *bus.Bus
 	sessions *session.Manager
@@ -36,7 +42,17 @@ type Loop struct {
 
 	cron *cron.Service
 
-	verbose bool
+	activity *activity.Hub
+
+	postProcess func(ctx context.Context, channel, text string) (string, error)
+	moderator   *moderator
+	router      *modelRouter
+
+	verbose    bool
+	retryEmpty bool
+
+	persona personaFile
+	circuit *llmCircuit
 
 	consolidationInFlight sync.Map
 }
@@ -51,7 +67,12 @@ type LoopOptions struct {
 	Skills       *skills.Loader
 	Cron         *cron.Service
 	Spawn        func(ctx context.Context, task, label, originChannel, originChatID string) (string, error)
-	Verbose      bool
+	// Activity, when set, receives inbound and tool-call events.
+	Activity *activity.Hub
+	// PostProcess, when set, rewrites each final reply before it is published
+	// (e.g. to append a disclaimer). On error the original reply is sent.
+	PostProcess func(ctx context.Context, channel, text string) (string, error)
+	Verbose     bool
 }
 
 func NewLoop(opts LoopOptions) (*Loop, error) {
@@ -87,35 +108,64 @@ func NewLoop(opts LoopOptions) (*Loop, error) {
 	}
 
 	client := &llm.Client{
-		Provider:    opts.Config.LLM.Provider,
-		BaseURL:     opts.Config.LLM.BaseURL,
-		APIKey:      opts.Config.LLM.APIKey,
-		Model:       model,
-		MaxTokens:   opts.Config.Agents.Defaults.MaxTokensValue(),
-		Temperature: opts.Config.Agents.Defaults.Temperature,
-		Headers:     opts.Config.LLM.Headers,
+		Provider:         opts.Config.LLM.Provider,
+		BaseURL:          opts.Config.LLM.BaseURL,
+		APIKey:           opts.Config.LLM.APIKey,
+		Model:            model,
+		MaxTokens:        opts.Config.Agents.Defaults.MaxTokensValue(),
+		Temperature:      opts.Config.Agents.Defaults.Temperature,
+		Headers:          opts.Config.LLM.HeadersFor(opts.Config.LLM.Provider),
+		MaxContinuations: opts.Config.LLM.MaxContinuations,
+		ReasoningEffort:  string(opts.Config.LLM.ReasoningEffort),
+		Limiter:          llm.NewLimiter(opts.Config.LLM.RateLimit.RequestsPerSecond, opts.Config.LLM.RateLimit.MaxConcurrent),
+		LocalTranscriber: buildLocalTranscriber(opts.Config),
+		Timeout:          opts.Config.LLM.TimeoutFor(opts.Config.LLM.Provider),
+		APIVersion:       opts.Config.LLM.AzureAPIVersion,
 	}
+	// Built up front: the client is shared by concurrent sessions and copied
+	// for routed models, so it must not be filled in lazily.
+	client.HTTP = llm.NewHTTPClient(client.Timeout)
 
 	treg := &tools.Registry{
-		WorkspaceDir:           ws,
-		RestrictToWorkspace:    opts.Config.Tools.RestrictToWorkspaceValue(),
-		ExecTimeout:            time.Duration(opts.Config.Tools.Exec.TimeoutSec) * time.Second,
-		BraveAPIKey:            opts.Config.Tools.Web.BraveAPIKey,
-		WebFetchAllowedDomains: append([]string(nil), opts.Config.Tools.Web.AllowedDomains...),
-		WebFetchBlockedDomains: append([]string(nil), opts.Config.Tools.Web.BlockedDomains...),
-		WebFetchMaxResponse:    opts.Config.Tools.Web.MaxResponseBytes,
-		WebFetchTimeout:        time.Duration(opts.Config.Tools.Web.FetchTimeoutSec) * time.Second,
+		WorkspaceDir:            ws,
+		RestrictToWorkspace:     opts.Config.Tools.RestrictToWorkspaceValue(),
+		ExecTimeout:             time.Duration(opts.Config.Tools.Exec.TimeoutSec) * time.Second,
+		ToolTimeouts:            opts.Config.Tools.TimeoutDurations(),
+		WriteDenyGlobs:          append([]string(nil), opts.Config.Tools.WriteDenyGlobs...),
+		RequireReadBeforeWrite:  opts.Config.Tools.RequireReadBeforeWrite,
+		RequireApproval:         append([]string(nil), opts.Config.Tools.RequireApproval...),
+		TruncateMode:            opts.Config.Tools.TruncateMode,
+		SpillThreshold:          opts.Config.Tools.SpillResultKB << 10,
+		ExecCleanOutput:         opts.Config.Tools.Exec.CleanOutputValue(),
+		ExecExtraEnv:            append([]string(nil), opts.Config.Tools.Exec.ExtraEnv...),
+		ExecAllowCommands:       opts.Config.Tools.Exec.AllowedCommands(),
+		ScriptInterpreters:      append([]string(nil), opts.Config.Tools.Exec.ScriptInterpreters...),
+		HelpCommands:            append([]string(nil), opts.Config.Tools.Exec.HelpCommands...),
+		SafeMode:                opts.Config.Tools.SafeMode,
+		Profiles:                opts.Config.Tools.Profiles,
+		EgressAllowHosts:        append([]string(nil), opts.Config.Tools.EgressAllowHosts...),
+		BraveAPIKey:             opts.Config.Tools.Web.BraveAPIKey,
+		WebFetchAllowedDomains:  append([]string(nil), opts.Config.Tools.Web.AllowedDomains...),
+		WebFetchBlockedDomains:  append([]string(nil), opts.Config.Tools.Web.BlockedDomains...),
+		WebSearchBlockedDomains: append([]string(nil), opts.Config.Tools.Web.SearchBlockedDomains...),
+		WebFetchMaxResponse:     opts.Config.Tools.Web.MaxResponseBytes,
+		WebFetchTimeout:         time.Duration(opts.Config.Tools.Web.FetchTimeoutSec) * time.Second,
+		WebFetchRetries:         opts.Config.Tools.Web.FetchRetriesValue(),
 		Outbound: func(ctx context.Context, msg bus.OutboundMessage) error {
 			return opts.Bus.PublishOutbound(ctx, msg)
 		},
-		Spawn: opts.Spawn,
-		Cron:  opts.Cron,
+		MessageAllowedTargets: append([]string(nil), opts.Config.Tools.Message.AllowedTargets...),
+		Spawn:                 opts.Spawn,
+		Cron:                  opts.Cron,
 		ReadSkill: func(name string) (string, bool) {
 			if sloader == nil {
 				return "", false
 			}
 			return sloader.Load(name)
 		},
+		SessionState: func(key string) (tools.MetaStore, error) {
+			return smgr.GetOrCreate(key)
+		},
 	}
 	treg.SkillRegistry, treg.SkillSearchDefaultLimit = buildSkillRegistry(opts.Config)
 	memMgr, err := memory.NewIndexManager(opts.Config, ws)
@@ -123,20 +173,54 @@ func NewLoop(opts LoopOptions) (*Loop, error) {
 		return nil, err
 	}
 	treg.MemorySearch = memMgr
+	if opts.Config.Tools.Memory.ReadValue() {
+		treg.MemoryStore = memory.New(ws)
+		treg.MemoryWrite = opts.Config.Tools.Memory.Write
+	}
+	if opts.Config.Tools.Summarize.EnabledValue() {
+		treg.WebFetchSummarizeOver = opts.Config.Tools.Web.SummarizeOverChars
+		treg.Summarize = newSummarizeFunc(client, opts.Config.Tools.Summarize.Model)
+	}
+	if w := opts.Config.Tools.Weather; w.Enabled {
+		treg.WeatherProvider, treg.WeatherAPIKey, treg.WeatherUnits = w.ProviderValue(), w.APIKey, w.Units
+	}
+	if s := opts.Config.Tools.SystemInfo; s.Enabled {
+		treg.SystemInfo, treg.SystemInfoProcesses, treg.SystemInfoMaxProcesses = true, s.Processes, s.MaxProcessesValue()
+	}
+	if gh := opts.Config.Tools.GitHub; gh.Enabled {
+		treg.GitHub, treg.GitHubToken, treg.GitHubAPIURL = true, gh.Token, gh.APIURLValue()
+	}
+	if opts.Config.Tools.AuditLog {
+		treg.Audit = tools.NewAuditLog(paths.AuditLogPath())
+	}
+	mod, err := newModerator(opts.Config)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Loop{
-		cfg:          opts.Config,
-		workspace:    ws,
-		model:        model,
-		maxIters:     opts.MaxIters,
-		memoryWindow: memoryWindow,
-		bus:          opts.Bus,
-		sessions:     smgr,
-		skills:       sloader,
-		llm:          client,
-		tools:        treg,
-		cron:         opts.Cron,
-		verbose:      opts.Verbose,
+		cfg:           opts.Config,
+		workspace:     ws,
+		model:         model,
+		maxIters:      opts.MaxIters,
+		memoryWindow:  memoryWindow,
+		historyWindow: opts.Config.Agents.Defaults.HistoryWindowValue(),
+		bus:           opts.Bus,
+		sessions:      smgr,
+		skills:        sloader,
+		llm:           client,
+		tools:         treg,
+		cron:          opts.Cron,
+		activity:      opts.Activity,
+		postProcess:   opts.PostProcess,
+		moderator:     mod,
+		router:        newModelRouter(opts.Config),
+		verbose:       opts.Verbose,
+		retryEmpty:    opts.Config.LLM.RetryEmptyResponses,
+		circuit: newLLMCircuit(opts.Config.Gateway.LLMCircuit, func(ctx context.Context) error {
+			_, err := client.Chat(ctx, []llm.Message{{Role: "user", Content: "Reply with OK."}}, nil)
+			return err
+		}),
 	}, nil
 }
 
@@ -147,26 +231,138 @@ func (l *Loop) SetSpawn(fn func(ctx context.Context, task, label, originChannel,
 	l.tools.Spawn = fn
 }
 
+// SetApprover lets RequireApproval tools ask the chat a call came from
+// before they run (see channels.Manager.RequestApproval).
+func (l *Loop) SetApprover(fn func(ctx context.Context, channel, chatID, prompt string) (bool, error)) {
+	if l == nil || l.tools == nil || fn == nil {
+		return
+	}
+	l.tools.Approve = func(ctx context.Context, tctx tools.Context, prompt string) (bool, error) {
+		return fn(ctx, tctx.Channel, tctx.ChatID, prompt)
+	}
+}
+
 func (l *Loop) Run(ctx context.Context) error {
+	if idle := l.cfg.Agents.Defaults.IdleConsolidation; idle.Enabled() {
+		go l.idleConsolidationLoop(ctx, time.Duration(idle.AfterSec)*time.Second, time.Duration(idle.CheckIntervalSecValue())*time.Second)
+	}
+	if wrap := l.cfg.Agents.Defaults.IdleWrapUp; wrap.Enabled() {
+		go l.idleWrapUpLoop(ctx, wrap)
+	}
+	// Sessions run in parallel up to gateway.inboundConcurrency; each
+	// session's messages stay in order on its own queue.
+	var disp *sessionDispatcher
+	if n := l.cfg.Gateway.InboundConcurrencyValue(); n > 1 {
+		disp = newSessionDispatcher(n, l.handleInbound)
+		defer disp.Wait()
+	}
 	for {
 		msg, err := l.bus.ConsumeInbound(ctx)
 		if err != nil {
 			return err
 		}
-		out, omsg, err := l.processInbound(ctx, msg)
-		_ = out
-		if err != nil {
-			// Best-effort error reply
-			if omsg.Channel != "" && omsg.ChatID != "" {
-				omsg.Content = "error: " + err.Error()
-				_ = l.bus.PublishOutbound(ctx, omsg)
-			}
+		if age, stale := l.staleInbound(msg, time.Now()); stale {
+			log.Printf("agent: dropping stale %s message in %s (sent %s ago)", msg.Channel, msg.ChatID, age.Round(time.Second))
 			continue
 		}
-		if omsg.Channel != "" && omsg.ChatID != "" && strings.TrimSpace(omsg.Content) != "" {
+		l.activity.Publish(activity.Event{
+			Kind:       activity.KindInbound,
+			Channel:    msg.Channel,
+			ChatID:     msg.ChatID,
+			SessionKey: msg.SessionKey,
+			Text:       msg.Content,
+		})
+		if disp == nil {
+			l.handleInbound(ctx, msg)
+			continue
+		}
+		if err := disp.Dispatch(ctx, inboundSessionKey(msg), msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handleInbound processes one inbound message and publishes its reply.
+func (l *Loop) handleInbound(ctx context.Context, msg bus.InboundMessage) {
+	_, omsg, err := l.processInbound(ctx, msg)
+	if err != nil {
+		log.Printf("agent: %s:%s: processing failed: %v", msg.Channel, msg.ChatID, err)
+		// Best-effort error reply
+		if omsg.Channel == "" && msg.Channel != "system" {
+			omsg = bus.OutboundMessage{Channel: msg.Channel, ChatID: msg.ChatID, Delivery: msg.Delivery}
+		}
+		if omsg.Channel != "" && omsg.ChatID != "" {
+			omsg.Content = errorReplyText(l.cfg.Gateway.ErrorReply, err)
 			_ = l.bus.PublishOutbound(ctx, omsg)
 		}
+		// The error reply does not count as delivering the answer.
+		l.bus.ReportDelivery(msg.TrackingID, err)
+		return
+	}
+	if omsg.Channel != "" && omsg.ChatID != "" && strings.TrimSpace(omsg.Content) != "" {
+		omsg.Content = l.applyPostProcess(ctx, omsg.Channel, omsg.Content)
+		if l.moderator != nil && l.moderator.outbound && l.blockedByModeration(ctx, "reply", omsg.Channel, omsg.ChatID, omsg.Content) {
+			omsg.Content = l.moderator.reply
+		}
+		omsg.TrackingID = msg.TrackingID
+		_ = l.bus.PublishOutbound(ctx, omsg)
+	} else {
+		l.bus.ReportDelivery(msg.TrackingID, bus.ErrNoReply)
+	}
+}
+
+// blockedByModeration reports whether text fails gateway.moderation. The
+// endpoint failing open is logged, as is every block.
+func (l *Loop) blockedByModeration(ctx context.Context, kind, channel, chatID, text string) bool {
+	if l.moderator == nil {
+		return false
+	}
+	reason, err := l.moderator.check(ctx, text)
+	if err != nil {
+		log.Printf("agent: moderation: %s:%s: check failed, allowing %s: %v", channel, chatID, kind, err)
+		return false
+	}
+	if reason == "" {
+		return false
+	}
+	log.Printf("agent: moderation: %s:%s: blocked %s (%s)", channel, chatID, kind, reason)
+	return true
+}
+
+// clientFor returns the LLM client for channel, switched to the channel's
+// model override when it has one.
+func (l *Loop) clientFor(channel string) *llm.Client {
+	model := l.cfg.Channels.Model(channel)
+	if model == "" || l.llm == nil || model == l.llm.Model {
+		return l.llm
+	}
+	c := *l.llm
+	c.Model = model
+	return &c
+}
+
+// staleInbound reports whether msg is older than channels.maxMessageAgeSec.
+func (l *Loop) staleInbound(msg bus.InboundMessage, now time.Time) (time.Duration, bool) {
+	maxAge := l.cfg.Channels.MaxMessageAgeSec
+	if maxAge <= 0 || msg.SentAt.IsZero() {
+		return 0, false
+	}
+	age := now.Sub(msg.SentAt)
+	return age, age > time.Duration(maxAge)*time.Second
+}
+
+func (l *Loop) applyPostProcess(ctx context.Context, channel, text string) string {
+	if l.postProcess == nil {
+		return text
+	}
+	out, err := l.postProcess(ctx, channel, text)
+	if err != nil {
+		if l.verbose {
+			fmt.Fprintf(os.Stderr, "post-process failed: %v\n", err)
+		}
+		return text
 	}
+	return out
 }
 
 func (l *Loop) ProcessDirect(ctx context.Context, content, sessionKey, channel, chatID string) (string, error) {
@@ -184,7 +380,12 @@ func (l *Loop) processInbound(ctx context.Context, msg bus.InboundMessage) (stri
 		}
 		// Route response back to origin session.
 		sk := originCh + ":" + originChat
+		if ok, _ := l.circuit.admit(sk); !ok {
+			log.Printf("agent: %s: LLM unavailable; system message dropped", sk)
+			return "", bus.OutboundMessage{}, nil
+		}
 		res, err := l.processDirect(ctx, llm.Message{Role: "user", Content: msg.Content}, msg.Content, sk, originCh, originChat)
+		l.circuit.record(ctx, err)
 		return res, bus.OutboundMessage{Channel: originCh, ChatID: originChat, Content: res}, err
 	}
 
@@ -192,7 +393,25 @@ func (l *Loop) processInbound(ctx context.Context, msg bus.InboundMessage) (stri
 	if strings.TrimSpace(sessionKey) == "" {
 		sessionKey = msg.Channel + ":" + msg.ChatID
 	}
-	userInput, err := media.PrepareInbound(ctx, l.llm, l.cfg.Tools.Media, msg)
+	if reply, ok := l.runCommand(sessionKey, msg.Channel, msg.SenderID, msg.Content); ok {
+		return reply, bus.OutboundMessage{
+			Channel:  msg.Channel,
+			ChatID:   msg.ChatID,
+			Content:  reply,
+			Delivery: msg.Delivery,
+		}, nil
+	}
+	if ok, notice := l.circuit.admit(sessionKey); !ok {
+		log.Printf("agent: %s:%s: LLM unavailable; message not processed", msg.Channel, msg.ChatID)
+		return notice, bus.OutboundMessage{
+			Channel:  msg.Channel,
+			ChatID:   msg.ChatID,
+			Content:  notice,
+			Delivery: msg.Delivery,
+		}, nil
+	}
+	mediaCfg := l.cfg.Channels.Attachments(msg.Channel).ApplyTo(l.cfg.Tools.Media)
+	userInput, err := media.PrepareInbound(ctx, l.llm, mediaCfg, msg)
 	if err != nil {
 		return "", bus.OutboundMessage{}, err
 	}
@@ -200,7 +419,18 @@ func (l *Loop) processInbound(ctx context.Context, msg bus.InboundMessage) (stri
 	if sessionText == "" {
 		sessionText = strings.TrimSpace(msg.Content)
 	}
+	if l.blockedByModeration(ctx, "inbound", msg.Channel, msg.ChatID, sessionText) {
+		// Blocked messages never reach the model or the session.
+		return l.moderator.reply, bus.OutboundMessage{
+			Channel:  msg.Channel,
+			ChatID:   msg.ChatID,
+			Content:  l.moderator.reply,
+			Delivery: msg.Delivery,
+		}, nil
+	}
+	l.noteWrapUpTarget(sessionKey, msg)
 	res, err := l.processDirect(ctx, userInput.UserMessage, sessionText, sessionKey, msg.Channel, msg.ChatID)
+	l.circuit.record(ctx, err)
 	return res, bus.OutboundMessage{
 		Channel:  msg.Channel,
 		ChatID:   msg.ChatID,
@@ -216,21 +446,32 @@ func (l *Loop) processDirect(ctx context.Context, userMessage llm.Message, sessi
 	}
 	l.scheduleConsolidation(sessionKey, sess)
 
-	history := sess.History(l.memoryWindow)
+	history := sess.History(l.historyWindow)
 	messages := make([]llm.Message, 0, 1+len(history)+1)
 	system := l.buildSystemPrompt(channel, chatID)
+	if l.cfg.Agents.Defaults.RunningSummary.Enabled {
+		system = withRunningSummary(system, sess.MetaString(runningSummaryMetaKey))
+	}
+	if l.cfg.Agents.Defaults.DetectReplyLanguage && strings.TrimSpace(l.cfg.Channels.Prompt(channel).Language) == "" {
+		system = withReplyLanguage(system, noteReplyLanguage(sess, sessionUserText))
+	}
 	messages = append(messages, llm.Message{Role: "system", Content: system})
 	for _, m := range history {
 		messages = append(messages, llm.Message{Role: m.Role, Content: m.Content})
 	}
 	messages = append(messages, userMessage)
 
-	toolsDefs := l.tools.Definitions()
+	profile := l.toolProfile(sess, channel)
+	toolsDefs := l.tools.DefinitionsFor(profile)
+	client := l.router.route(ctx, l.clientFor(channel), userMessage, sessionUserText, history)
 
 	var final string
 	toolsUsed := make([]string, 0, 8)
+	results := newToolResultLog(l.cfg.Agents.Defaults.ConsolidationToolResultChars)
+	dedup := newToolCallDeduper(l.cfg)
+	reads := tools.NewReadSet()
 	for iter := 0; iter < l.maxIters; iter++ {
-		res, err := l.llm.Chat(ctx, messages, toolsDefs)
+		res, err := chatWithEmptyRetry(ctx, client, messages, toolsDefs, l.retryEmpty)
 		if err != nil {
 			return "", err
 		}
@@ -238,20 +479,43 @@ func (l *Loop) processDirect(ctx context.Context, userMessage llm.Message, sessi
 			for _, tc := range res.ToolCalls {
 				toolsUsed = append(toolsUsed, tc.Name)
 			}
-			messages = appendToolRound(messages, res.Content, res.ToolCalls, func(tc llm.ToolCall) string {
-				out, err := l.tools.Execute(ctx, tools.Context{
+			var outs []string
+			messages, outs = appendToolRound(messages, res, l.cfg.Agents.Defaults.MaxParallelToolsValue(), func(tc llm.ToolCall) string {
+				l.activity.Publish(activity.Event{
+					Kind:       activity.KindToolCall,
 					Channel:    channel,
 					ChatID:     chatID,
 					SessionKey: sessionKey,
-				}, tc.Name, tc.Arguments)
-				if err != nil {
-					return "error: " + err.Error()
-				}
+					Tool:       tc.Name,
+					Text:       string(tools.RedactAuditArgs(tc.Arguments)),
+				})
+				out := dedup.run(tc, func() string {
+					out, err := l.tools.Execute(ctx, tools.Context{
+						Channel:    channel,
+						ChatID:     chatID,
+						SessionKey: sessionKey,
+						Profile:    profile,
+						Intent:     sessionUserText,
+						Reads:      reads,
+					}, tc.Name, tc.Arguments)
+					if err != nil {
+						return "error: " + err.Error()
+					}
+					return out
+				})
 				return out
 			})
+			// Recorded after the round, in call order, so parallel calls
+			// finishing in a different order do not reorder the session.
+			for i, tc := range res.ToolCalls {
+				results.record(tc.Name, outs[i])
+			}
 			continue
 		}
 		final = res.Content
+		if res.Refusal != "" {
+			final = refusalReply(res, client.Model, l.cfg.Agents.Defaults.RefusalReplyValue())
+		}
 		break
 	}
 	if strings.TrimSpace(final) == "" {
@@ -259,7 +523,7 @@ func (l *Loop) processDirect(ctx context.Context, userMessage llm.Message, sessi
 	}
 
 	sess.Add("user", sessionUserText)
-	sess.AddWithTools("assistant", final, toolsUsed)
+	sess.AddWithToolResults("assistant", final, toolsUsed, results.list())
 	_ = l.sessions.Save(sess)
 	return final, nil
 }
@@ -271,6 +535,57 @@ func (l *Loop) scheduleConsolidation(sessionKey string, sess *session.Session) {
 	if !sess.NeedsConsolidation(l.memoryWindow) {
 		return
 	}
+	if rs := l.cfg.Agents.Defaults.RunningSummary; rs.Enabled {
+		l.startConsolidation(sessionKey, sess, func(ctx context.Context, summarize summarizeConsolidationFunc) (bool, error) {
+			return consolidateWithRunningSummary(ctx, l.workspace, sess, rs.KeepMessagesValue(), summarize, l.rollRunningSummary)
+		})
+		return
+	}
+	l.startConsolidation(sessionKey, sess, func(ctx context.Context, summarize summarizeConsolidationFunc) (bool, error) {
+		return maybeConsolidateSession(ctx, l.workspace, sess, l.memoryWindow, summarize)
+	})
+}
+
+func (l *Loop) rollRunningSummary(ctx context.Context, previous, conversation string) (string, error) {
+	return rollRunningSummary(ctx, llmChatFunc(l.llm), previous, conversation, l.cfg.Agents.Defaults.RunningSummary.MaxCharsValue())
+}
+
+// idleConsolidationLoop periodically consolidates cached sessions that have
+// had no activity for idleAfter. It shares the in-flight guard with the
+// count-based trigger, so a session is never consolidated twice at once.
+func (l *Loop) idleConsolidationLoop(ctx context.Context, idleAfter, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			l.consolidateIdleSessions(time.Now(), idleAfter)
+		}
+	}
+}
+
+func (l *Loop) consolidateIdleSessions(now time.Time, idleAfter time.Duration) {
+	keep := session.ConsolidationKeep(l.memoryWindow)
+	rs := l.cfg.Agents.Defaults.RunningSummary
+	for _, sess := range l.sessions.Cached() {
+		if now.Sub(sess.LastUpdated()) < idleAfter {
+			continue
+		}
+		if rs.Enabled {
+			l.startConsolidation(sess.Key, sess, func(ctx context.Context, summarize summarizeConsolidationFunc) (bool, error) {
+				return consolidateWithRunningSummary(ctx, l.workspace, sess, rs.KeepMessagesValue(), summarize, l.rollRunningSummary)
+			})
+			continue
+		}
+		l.startConsolidation(sess.Key, sess, func(ctx context.Context, summarize summarizeConsolidationFunc) (bool, error) {
+			return maybeConsolidateIdleSession(ctx, l.workspace, sess, keep, summarize)
+		})
+	}
+}
+
+func (l *Loop) startConsolidation(sessionKey string, sess *session.Session, run func(context.Context, summarizeConsolidationFunc) (bool, error)) {
 	if _, loaded := l.consolidationInFlight.LoadOrStore(sessionKey, struct{}{}); loaded {
 		return
 	}
@@ -280,8 +595,8 @@ func (l *Loop) scheduleConsolidation(sessionKey string, sess *session.Session) {
 		cctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
 
-		done, err := maybeConsolidateSession(cctx, l.workspace, sess, l.memoryWindow, func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
-			return summarizeConsolidationWithLLM(ctx, l.llm, currentMemory, conversation)
+		done, err := run(cctx, func(ctx context.Context, currentMemory, conversation string) (string, string, error) {
+			return summarizeConsolidationWithLLM(ctx, l.llm, currentMemory, conversation, l.cfg.Agents.Defaults.ConsolidationRepairValue())
 		})
 		if err != nil {
 			if l.verbose {
@@ -295,24 +610,32 @@ func (l *Loop) scheduleConsolidation(sessionKey string, sess *session.Session) {
 		if err := l.sessions.Save(sess); err != nil && l.verbose {
 			fmt.Fprintf(os.Stderr, "consolidation save error (%s): %v\n", sessionKey, err)
 		}
+		if err := rotateHistory(cctx, l.workspace, l.cfg.Agents.Defaults.HistoryRotation, llmChatFunc(l.llm)); err != nil && l.verbose {
+			fmt.Fprintf(os.Stderr, "history rotation error: %v\n", err)
+		}
 	}()
 }
 
 func (l *Loop) buildSystemPrompt(channel, chatID string) string {
 	// Keep it simple and deterministic. Add progressive skill summary.
-	var b strings.Builder
+	var b promptBuilder
 	b.WriteString("# clawlet\n\n")
-	b.WriteString("You are clawlet, a helpful AI assistant.\n")
+	b.WriteString(l.persona.load(personaPath(l.workspace, l.cfg.Agents.Defaults.PersonaFile)) + "\n")
 	b.WriteString("You can use tools to read/write/edit files, list directories, execute shell commands, fetch/search the web, schedule tasks, and spawn background subagents.\n\n")
 	b.WriteString("IMPORTANT: When replying to the current conversation, respond with plain text. Do not call the message tool.\n")
 	b.WriteString("Only use the message tool when you must send to a different channel/chat_id.\n\n")
-	b.WriteString("## Current Time\n")
-	b.WriteString(time.Now().Format("2006-01-02 15:04 (Mon)") + "\n\n")
+	if d := l.cfg.Agents.Defaults; d.PromptTimeValue() {
+		b.WriteString("## Current Time\n")
+		b.WriteString(tools.FormatCurrentTime(time.Now().In(d.Location())) + "\n\n")
+	}
 	b.WriteString("## Workspace\n")
 	b.WriteString(l.workspace + "\n\n")
 	if l.cfg.Tools.RestrictToWorkspaceValue() {
 		b.WriteString("## Safety\nTools are restricted to the workspace directory.\n\n")
 	}
+	if l.cfg.Tools.SafeMode {
+		b.WriteString("## Safe Mode\nOnly read-only tools are available. You cannot write or edit files, run commands, schedule jobs, or spawn subagents.\n\n")
+	}
 	if channel != "" && chatID != "" {
 		b.WriteString("## Current Session\n")
 		b.WriteString("Channel: " + channel + "\nChat ID: " + chatID + "\n\n")
@@ -322,34 +645,40 @@ func (l *Loop) buildSystemPrompt(channel, chatID string) string {
 	for _, fn := range []string{"AGENTS.md", "SOUL.md", "USER.md", "TOOLS.md", "IDENTITY.md"} {
 		p := filepath.Join(l.workspace, fn)
 		if bb, err := os.ReadFile(p); err == nil && len(bb) > 0 {
-			b.WriteString("## " + fn + "\n\n")
-			b.Write(bb)
-			if bb[len(bb)-1] != '\n' {
-				b.WriteString("\n")
-			}
-			b.WriteString("\n")
+			b.add(fn, bootstrapSection(fn, bb), promptTrimBootstrap)
 		}
 	}
 
 	// Memory (long-term + today's notes)
-	mem := memory.New(l.workspace).GetContext()
-	if strings.TrimSpace(mem) != "" {
-		b.WriteString("# Memory\n\n")
-		b.WriteString(mem)
-		b.WriteString("\n\n")
+	if l.cfg.Tools.Memory.InPromptValue() {
+		b.addMemory(memory.New(l.workspace).ContextSections())
 	}
 
 	// Skills summary (progressive loading).
 	if l.skills != nil {
 		sum := l.skills.SummaryXML()
 		if sum != "" {
-			b.WriteString("# Skills\n\n")
-			b.WriteString("To use a skill:\n- workspace skills: read_file(path)\n- bundled skills: read_skill(name)\n\n")
-			b.WriteString(sum + "\n\n")
+			b.add("skills summary", "# Skills\n\n"+
+				"To use a skill:\n- workspace skills: read_file(path)\n- bundled skills: read_skill(name)\n\n"+
+				sum+"\n\n", promptTrimSkills)
+		}
+	}
+
+	// Per-channel instructions go last so they take precedence.
+	if channel != "" {
+		if pc := l.cfg.Channels.Prompt(channel); pc.Language != "" || pc.SystemPromptAppend != "" {
+			b.WriteString("## Channel Instructions\n")
+			if lang := strings.TrimSpace(pc.Language); lang != "" {
+				b.WriteString("Always reply in " + lang + ", regardless of the language of the user's message.\n")
+			}
+			if extra := strings.TrimSpace(pc.SystemPromptAppend); extra != "" {
+				b.WriteString(extra + "\n")
+			}
+			b.WriteString("\n")
 		}
 	}
 
-	return b.String()
+	return b.build(l.cfg.Agents.Defaults.SystemPromptMaxChars)
 }
 
 func parseOrigin(chatID string) (string, string) {
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func TestApplyPostProcess(t *testing.T) {
+	l := &Loop{}
+	if got := l.applyPostProcess(context.Background(), "slack", "hi"); got != "hi" {
+		t.Fatalf("no hook: %q", got)
+	}
+
+	l.postProcess = func(ctx context.Context, channel, text string) (string, error) {
+		return text + " [" + channel + "]", nil
+	}
+	if got := l.applyPostProcess(context.Background(), "slack", "hi"); got != "hi [slack]" {
+		t.Fatalf("hook: %q", got)
+	}
+
+	l.postProcess = func(ctx context.Context, channel, text string) (string, error) {
+		return "", errors.New("boom")
+	}
+	if got := l.applyPostProcess(context.Background(), "slack", "hi"); got != "hi" {
+		t.Fatalf("error fallback: %q", got)
+	}
+}
+
+func TestStaleInbound(t *testing.T) {
+	cfg := config.Default()
+	l := &Loop{cfg: cfg}
+	now := time.Now()
+	old := bus.InboundMessage{SentAt: now.Add(-2 * time.Hour)}
+
+	if _, stale := l.staleInbound(old, now); stale {
+		t.Fatalf("check should be disabled by default")
+	}
+	cfg.Channels.MaxMessageAgeSec = 600
+	if _, stale := l.staleInbound(old, now); !stale {
+		t.Fatalf("expected 2h-old message to be stale")
+	}
+	if _, stale := l.staleInbound(bus.InboundMessage{SentAt: now.Add(-time.Minute)}, now); stale {
+		t.Fatalf("recent message should not be stale")
+	}
+	if _, stale := l.staleInbound(bus.InboundMessage{}, now); stale {
+		t.Fatalf("message without timestamp should not be stale")
+	}
+}
+
+func TestClientFor_ChannelModel(t *testing.T) {
+	cfg := config.Default()
+	cfg.Channels.Telegram.Instances = []config.TelegramConfig{{ID: "support", Model: "small-model"}}
+	base := &llm.Client{Model: "main-model"}
+	l := &Loop{cfg: cfg, llm: base}
+
+	if c := l.clientFor("telegram"); c != base {
+		t.Fatal("channel without override should use the shared client")
+	}
+	c := l.clientFor("telegram.support")
+	if c == base || c.Model != "small-model" || base.Model != "main-model" {
+		t.Fatalf("override client model=%q base=%q", c.Model, base.Model)
+	}
+}
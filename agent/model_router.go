@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/session"
+)
+
+const simpleClassifierTimeout = 10 * time.Second
+
+// defaultComplexKeywords mark a message as needing the main model. Matching
+// is by substring, so a false hit only costs the cheaper route.
+var defaultComplexKeywords = []string{
+	"code", "debug", "error", "fix", "implement", "refactor", "script",
+	"function", "explain", "analyze", "analyse", "compare", "plan", "design",
+	"research", "summarize", "summarise", "translate", "calculate", "write",
+	"file", "step by step", "why",
+}
+
+const simpleClassifierPrompt = `Classify the user's message for routing. Reply with exactly one word.
+simple: a greeting, thanks, small talk, or a quick question answerable in a sentence or two.
+complex: anything that needs tools, files, code, research, or several steps of reasoning.`
+
+// modelRouter picks agents.defaults.simpleModel for simple inbound messages.
+// A nil router always keeps the main model.
+type modelRouter struct {
+	model           string
+	useLLM          bool
+	classifierModel string
+	maxChars        int
+	keywords        []string
+}
+
+func newModelRouter(cfg *config.Config) *modelRouter {
+	sc := cfg.Agents.Defaults.SimpleModel
+	if !sc.Enabled() {
+		return nil
+	}
+	r := &modelRouter{
+		model:           strings.TrimSpace(sc.Model),
+		useLLM:          sc.Classifier == "llm",
+		classifierModel: strings.TrimSpace(sc.ClassifierModel),
+		maxChars:        sc.MaxCharsValue(),
+	}
+	if r.classifierModel == "" {
+		r.classifierModel = r.model
+	}
+	keywords := sc.ComplexKeywords
+	if len(keywords) == 0 {
+		keywords = defaultComplexKeywords
+	}
+	for _, k := range keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			r.keywords = append(r.keywords, k)
+		}
+	}
+	return r
+}
+
+// route returns client switched to the simple model when the message is
+// simple, otherwise client itself.
+func (r *modelRouter) route(ctx context.Context, client *llm.Client, msg llm.Message, text string, history []session.Message) *llm.Client {
+	if r == nil || client == nil || client.Model == r.model {
+		return client
+	}
+	if !r.looksSimple(msg, text, history) {
+		return client
+	}
+	if r.useLLM && !r.classifySimple(ctx, client, text) {
+		return client
+	}
+	c := *client
+	c.Model = r.model
+	return &c
+}
+
+// looksSimple is the heuristic check. Attachments, code, links, long or
+// multi-line text, complex keywords, and replies to a turn that used tools
+// (e.g. "yes, go ahead") all keep the main model.
+func (r *modelRouter) looksSimple(msg llm.Message, text string, history []session.Message) bool {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > r.maxChars || strings.Count(text, "\n") > 2 {
+		return false
+	}
+	for _, p := range msg.Parts {
+		if p.Type != llm.ContentPartTypeText {
+			return false
+		}
+	}
+	lower := strings.ToLower(text)
+	if strings.Contains(lower, "```") || strings.Contains(lower, "http://") || strings.Contains(lower, "https://") {
+		return false
+	}
+	for _, k := range r.keywords {
+		if strings.Contains(lower, k) {
+			return false
+		}
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "assistant" {
+			return len(history[i].ToolsUsed) == 0
+		}
+	}
+	return true
+}
+
+// classifySimple asks the classifier model about text. Failures keep the
+// main model.
+func (r *modelRouter) classifySimple(ctx context.Context, client *llm.Client, text string) bool {
+	c := *client
+	c.Model = r.classifierModel
+	c.ReasoningEffort = ""
+	c.MaxContinuations = 0
+	cctx, cancel := context.WithTimeout(ctx, simpleClassifierTimeout)
+	defer cancel()
+	res, err := c.Chat(cctx, []llm.Message{
+		{Role: "system", Content: simpleClassifierPrompt},
+		{Role: "user", Content: text},
+	}, nil)
+	if err != nil {
+		log.Printf("agent: simple model classifier: %v", err)
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(res.Content)), "simple")
+}
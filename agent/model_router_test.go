@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/session"
+)
+
+func TestModelRouter_Heuristic(t *testing.T) {
+	cfg := config.Default()
+	if r := newModelRouter(cfg); r != nil {
+		t.Fatal("router should be off without simpleModel.model")
+	}
+	cfg.Agents.Defaults.SimpleModel.Model = "small"
+	r := newModelRouter(cfg)
+	base := &llm.Client{Model: "main"}
+	route := func(text string, history ...session.Message) string {
+		return r.route(context.Background(), base, llm.Message{Role: "user", Content: text}, text, history).Model
+	}
+
+	for _, text := range []string{"hi!", "thanks, that's great", "what's the capital of France?"} {
+		if got := route(text); got != "small" {
+			t.Fatalf("%q routed to %q", text, got)
+		}
+	}
+	for _, text := range []string{
+		"can you fix the failing test?",
+		"look at https://example.com",
+		"```go\nx := 1\n```",
+		strings.Repeat("a", config.DefaultSimpleModelMaxChars+1),
+	} {
+		if got := route(text); got != "main" {
+			t.Fatalf("%q routed to %q", text, got)
+		}
+	}
+	if got := route("yes, go ahead", session.Message{Role: "assistant", Content: "Shall I?", ToolsUsed: []string{"read_file"}}); got != "main" {
+		t.Fatalf("follow-up to a tool turn routed to %q", got)
+	}
+	img := llm.Message{Role: "user", Parts: []llm.ContentPart{{Type: llm.ContentPartTypeImage}}}
+	if got := r.route(context.Background(), base, img, "hi", nil).Model; got != "main" {
+		t.Fatalf("image routed to %q", got)
+	}
+	if base.Model != "main" {
+		t.Fatalf("base client changed: %q", base.Model)
+	}
+}
+
+func TestModelRouter_LLMClassifier(t *testing.T) {
+	cfg := config.Default()
+	cfg.Agents.Defaults.SimpleModel = config.SimpleModelConfig{Model: "small", Classifier: "llm", ClassifierModel: "tiny"}
+	r := newModelRouter(cfg)
+	doer := &scriptedDoer{bodies: []string{
+		`{"choices":[{"message":{"content":"complex"}}]}`,
+		`{"choices":[{"message":{"content":"Simple."}}]}`,
+	}}
+	base := &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "main", HTTP: doer}
+	msg := llm.Message{Role: "user", Content: "what time is it in Tokyo?"}
+
+	if got := r.route(context.Background(), base, msg, msg.Content, nil).Model; got != "main" {
+		t.Fatalf("complex verdict routed to %q", got)
+	}
+	if got := r.route(context.Background(), base, msg, msg.Content, nil).Model; got != "small" {
+		t.Fatalf("simple verdict routed to %q", got)
+	}
+	if doer.calls != 2 {
+		t.Fatalf("classifier calls=%d", doer.calls)
+	}
+	if got := r.route(context.Background(), base, llm.Message{Content: "debug this"}, "debug this", nil).Model; got != "main" || doer.calls != 2 {
+		t.Fatalf("heuristic miss should skip the classifier: model=%q calls=%d", got, doer.calls)
+	}
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+const moderationTimeout = 10 * time.Second
+
+// moderator checks chat text against gateway.moderation. A nil moderator
+// allows everything.
+type moderator struct {
+	deny     []*regexp.Regexp
+	endpoint string
+	apiKey   string
+	model    string
+	outbound bool
+	reply    string
+	http     *http.Client
+}
+
+func newModerator(cfg *config.Config) (*moderator, error) {
+	mc := cfg.Gateway.Moderation
+	if !mc.Enabled() {
+		return nil, nil
+	}
+	m := &moderator{
+		endpoint: strings.TrimSpace(mc.Endpoint),
+		apiKey:   strings.TrimSpace(mc.APIKey),
+		model:    strings.TrimSpace(mc.Model),
+		outbound: mc.Outbound,
+		reply:    mc.ReplyValue(),
+		http:     &http.Client{Timeout: moderationTimeout},
+	}
+	// llm.apiKey is only sent to the LLM provider's own host, never to an
+	// unrelated moderation service.
+	if m.apiKey == "" && sameHost(m.endpoint, cfg.LLM.BaseURL) {
+		m.apiKey = cfg.LLM.APIKey
+	}
+	if m.model == "" {
+		m.model = config.DefaultModerationModel
+	}
+	for i, pat := range mc.Deny {
+		re, err := regexp.Compile(pat)
+		if err != nil {
+			return nil, fmt.Errorf("gateway.moderation.deny[%d]: %w", i, err)
+		}
+		m.deny = append(m.deny, re)
+	}
+	return m, nil
+}
+
+// sameHost reports whether URLs a and b name the same scheme and host.
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(strings.TrimSpace(a))
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(strings.TrimSpace(b))
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// check returns a non-empty reason when text violates policy. Endpoint
+// failures are returned as errors; callers let the message through so an
+// outage does not silence the bot.
+func (m *moderator) check(ctx context.Context, text string) (string, error) {
+	if m == nil || strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+	for _, re := range m.deny {
+		if re.MatchString(text) {
+			return "deny pattern " + re.String(), nil
+		}
+	}
+	if m.endpoint == "" {
+		return "", nil
+	}
+	return m.checkEndpoint(ctx, text)
+}
+
+func (m *moderator) checkEndpoint(ctx context.Context, text string) (string, error) {
+	body, err := json.Marshal(map[string]string{"model": m.model, "input": text})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("moderation endpoint: status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var parsed struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("moderation endpoint: %w", err)
+	}
+	for _, r := range parsed.Results {
+		if !r.Flagged {
+			continue
+		}
+		var cats []string
+		for name, on := range r.Categories {
+			if on {
+				cats = append(cats, name)
+			}
+		}
+		slices.Sort(cats)
+		if len(cats) == 0 {
+			return "flagged", nil
+		}
+		return "flagged: " + strings.Join(cats, ", "), nil
+	}
+	return "", nil
+}
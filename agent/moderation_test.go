@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestModerator_DenyPatterns(t *testing.T) {
+	cfg := config.Default()
+	if m, err := newModerator(cfg); err != nil || m != nil {
+		t.Fatalf("disabled moderation: m=%v err=%v", m, err)
+	}
+
+	cfg.Gateway.Moderation.Deny = []string{`(?i)\bbuy followers\b`}
+	m, err := newModerator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, err := m.check(context.Background(), "Want to BUY FOLLOWERS cheap?"); err != nil || reason == "" {
+		t.Fatalf("reason=%q err=%v", reason, err)
+	}
+	if reason, _ := m.check(context.Background(), "what's the weather"); reason != "" {
+		t.Fatalf("unexpected block: %q", reason)
+	}
+	if m.reply != config.DefaultModerationReply {
+		t.Fatalf("reply=%q", m.reply)
+	}
+}
+
+func TestModerator_Endpoint(t *testing.T) {
+	var gotAuth, gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		var body struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		flagged := strings.Contains(body.Input, "bad")
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": []map[string]any{{
+			"flagged":    flagged,
+			"categories": map[string]bool{"harassment": flagged, "violence": false},
+		}}})
+	}))
+	defer srv.Close()
+
+	cfg := config.Default()
+	cfg.LLM.APIKey = "sk-test"
+	cfg.LLM.BaseURL = srv.URL + "/v1"
+	cfg.Gateway.Moderation.Endpoint = srv.URL
+	m, err := newModerator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reason, err := m.check(context.Background(), "something bad")
+	if err != nil || reason != "flagged: harassment" {
+		t.Fatalf("reason=%q err=%v", reason, err)
+	}
+	if gotAuth != "Bearer sk-test" || gotModel != config.DefaultModerationModel {
+		t.Fatalf("auth=%q model=%q", gotAuth, gotModel)
+	}
+	if reason, err := m.check(context.Background(), "hello"); err != nil || reason != "" {
+		t.Fatalf("clean: reason=%q err=%v", reason, err)
+	}
+
+	srv.Close()
+	l := &Loop{moderator: m}
+	if l.blockedByModeration(context.Background(), "inbound", "telegram", "1", "something bad") {
+		t.Fatal("endpoint failure should let the message through")
+	}
+}
+
+func TestModerator_APIKeyFallback(t *testing.T) {
+	cfg := config.Default()
+	cfg.LLM.APIKey = "sk-main"
+	cfg.LLM.BaseURL = "https://api.anthropic.com/v1"
+	cfg.Gateway.Moderation.Endpoint = "https://moderation.example.com/v1/moderations"
+	m, err := newModerator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.apiKey != "" {
+		t.Fatalf("llm.apiKey sent to another host: %q", m.apiKey)
+	}
+
+	cfg.Gateway.Moderation.APIKey = "sk-mod"
+	if m, _ = newModerator(cfg); m.apiKey != "sk-mod" {
+		t.Fatalf("apiKey=%q", m.apiKey)
+	}
+
+	cfg.Gateway.Moderation.APIKey = ""
+	cfg.LLM.BaseURL = "https://API.openai.com/v1"
+	cfg.Gateway.Moderation.Endpoint = "https://api.openai.com/v1/moderations"
+	if m, _ = newModerator(cfg); m.apiKey != "sk-main" {
+		t.Fatalf("same-host fallback: apiKey=%q", m.apiKey)
+	}
+}
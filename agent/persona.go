@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPersona opens the system prompt when agents.defaults.personaFile
+// is unset or unreadable.
+const defaultPersona = "You are clawlet, a helpful AI assistant."
+
+// personaFile serves agents.defaults.personaFile. The file is checked every
+// turn and only re-read when its modification time or size changes, so
+// edits apply on the next message without a restart. The zero value is
+// ready to use.
+type personaFile struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	text    string
+	failed  bool
+}
+
+// load returns the persona text at path, or defaultPersona when path is
+// empty, missing, or empty.
+func (p *personaFile) load(path string) string {
+	if path == "" {
+		return defaultPersona
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fi, err := os.Stat(path)
+	if err == nil && path == p.path && fi.ModTime().Equal(p.modTime) && fi.Size() == p.size {
+		return p.persona()
+	}
+	var b []byte
+	if err == nil {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		// Log once per failure, not every turn.
+		if !p.failed || path != p.path {
+			log.Printf("agent: persona file: %v; using the default persona", err)
+		}
+		p.path, p.modTime, p.size, p.text, p.failed = path, time.Time{}, 0, "", true
+		return defaultPersona
+	}
+	p.path, p.modTime, p.size, p.text, p.failed = path, fi.ModTime(), fi.Size(), strings.TrimSpace(string(b)), false
+	return p.persona()
+}
+
+func (p *personaFile) persona() string {
+	if p.text == "" {
+		return defaultPersona
+	}
+	return p.text
+}
+
+// personaPath resolves agents.defaults.personaFile against the workspace.
+func personaPath(workspace, file string) string {
+	file = strings.TrimSpace(file)
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(workspace, file)
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestPersonaFile_ReloadsOnChange(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "persona.md")
+	var p personaFile
+
+	if got := p.load(path); got != defaultPersona {
+		t.Fatalf("missing file: %q", got)
+	}
+	if err := os.WriteFile(path, []byte("You are Mochi, a cheerful cat.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.load(path); got != "You are Mochi, a cheerful cat." {
+		t.Fatalf("got %q", got)
+	}
+
+	// Unchanged mtime and size: the cached text is served.
+	p.text = "cached"
+	if got := p.load(path); got != "cached" {
+		t.Fatalf("expected cached text, got %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("You are Mochi, a grumpy cat.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.load(path); got != "You are Mochi, a grumpy cat." {
+		t.Fatalf("after edit: %q", got)
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.load(path); got != defaultPersona {
+		t.Fatalf("empty file: %q", got)
+	}
+}
+
+func TestAgentSystemPrompt_PersonaFile(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "persona.md"), []byte("You are Mochi."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Agents.Defaults.PersonaFile = "persona.md"
+	a := &Agent{cfg: cfg, workspace: ws}
+	got := a.systemPrompt()
+	if !strings.HasPrefix(got, "# clawlet\n\nYou are Mochi.\n") || strings.Contains(got, defaultPersona) {
+		t.Fatalf("prompt=%q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// Trim priorities for system prompt sections. Under a budget, sections with
+// the highest value are trimmed first; promptKeep sections are never trimmed.
+const (
+	promptKeep = iota
+	promptTrimBootstrap
+	promptTrimLongTermMemory
+	promptTrimSkills
+	promptTrimTodayNotes
+)
+
+const promptTrimmedMarker = "\n[... trimmed to fit the system prompt budget]\n\n"
+
+type promptSection struct {
+	name string
+	text string
+	trim int
+}
+
+// promptBuilder assembles the system prompt from sections so that a size
+// budget can trim the least important ones.
+type promptBuilder struct {
+	sections []promptSection
+}
+
+// bootstrapSection renders a workspace bootstrap file under its own heading.
+func bootstrapSection(fn string, body []byte) string {
+	s := "## " + fn + "\n\n" + string(body)
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return s + "\n"
+}
+
+// addMemory adds the "# Memory" block with long-term memory and today's
+// notes as separately trimmable sections.
+func (p *promptBuilder) addMemory(longTerm, today string) {
+	if longTerm == "" && today == "" {
+		return
+	}
+	p.WriteString("# Memory\n\n")
+	if longTerm != "" {
+		p.add("long-term memory", longTerm+"\n\n", promptTrimLongTermMemory)
+	}
+	if today != "" {
+		p.add("today's notes", today+"\n\n", promptTrimTodayNotes)
+	}
+}
+
+// WriteString adds text that is always kept.
+func (p *promptBuilder) WriteString(s string) {
+	p.add("", s, promptKeep)
+}
+
+func (p *promptBuilder) add(name, text string, trim int) {
+	if text != "" {
+		p.sections = append(p.sections, promptSection{name: name, text: text, trim: trim})
+	}
+}
+
+// build joins the sections, trimming them so the result fits in maxChars
+// bytes when maxChars > 0. Within a priority, later sections are trimmed
+// first. Each trimmed section is logged.
+func (p *promptBuilder) build(maxChars int) string {
+	sections := append([]promptSection(nil), p.sections...)
+	total := 0
+	for _, s := range sections {
+		total += len(s.text)
+	}
+	for prio := promptTrimTodayNotes; prio > promptKeep && maxChars > 0 && total > maxChars; prio-- {
+		for i := len(sections) - 1; i >= 0 && total > maxChars; i-- {
+			s := &sections[i]
+			if s.trim != prio {
+				continue
+			}
+			before := len(s.text)
+			keep := before - (total - maxChars) - len(promptTrimmedMarker)
+			if keep > 0 {
+				s.text = truncateUTF8(s.text, keep) + promptTrimmedMarker
+			} else {
+				s.text = ""
+			}
+			total -= before - len(s.text)
+			log.Printf("agent: system prompt over %d chars: trimmed %s from %d to %d", maxChars, s.name, before, len(s.text))
+		}
+	}
+	var b strings.Builder
+	b.Grow(total)
+	for _, s := range sections {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
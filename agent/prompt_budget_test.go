@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestPromptBuilder_TrimsLowestPriorityFirst(t *testing.T) {
+	var b promptBuilder
+	b.WriteString("# clawlet\n\n")
+	b.add("SOUL.md", bootstrapSection("SOUL.md", []byte(strings.Repeat("s", 200))), promptTrimBootstrap)
+	b.addMemory("## Long-term Memory\n"+strings.Repeat("m", 200), "## Today's Notes\n"+strings.Repeat("t", 200))
+	b.WriteString("## Channel Instructions\nBe brief.\n")
+	full := b.build(0)
+
+	// Just over the limit: only today's notes are cut.
+	out := b.build(len(full) - 100)
+	if len(out) > len(full)-100 || !strings.Contains(out, strings.Repeat("m", 200)) || !strings.Contains(out, promptTrimmedMarker) {
+		t.Fatalf("len=%d prompt=%q", len(out), out)
+	}
+	if strings.Contains(out, strings.Repeat("t", 200)) {
+		t.Fatal("today's notes should be trimmed")
+	}
+
+	// Too small for any memory: memory goes, persona is trimmed, fixed text stays.
+	out = b.build(150)
+	if strings.Contains(out, "Long-term Memory") || strings.Contains(out, "Today's Notes") {
+		t.Fatalf("memory should be dropped: %q", out)
+	}
+	if !strings.HasPrefix(out, "# clawlet") || !strings.Contains(out, "Be brief.") || !strings.Contains(out, "## SOUL.md") {
+		t.Fatalf("prompt=%q", out)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("aé", 2); got != "a" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestAgentSystemPrompt_Budget(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, "memory"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "memory", "MEMORY.md"), []byte(strings.Repeat("fact\n", 2000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Agents.Defaults.SystemPromptMaxChars = 2000
+	a := &Agent{cfg: cfg, workspace: ws}
+	if got := a.systemPrompt(); len(got) > 2000 || !strings.Contains(got, promptTrimmedMarker) {
+		t.Fatalf("len=%d", len(got))
+	}
+}
@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mosaxiv/clawlet/session"
+)
+
+// replyLanguageMetaKey is the session metadata key holding the language last
+// detected in the user's messages.
+const replyLanguageMetaKey = "replyLanguage"
+
+// replyLanguageNoise matches text that says nothing about the user's
+// language: code blocks, inline code, and URLs.
+var replyLanguageNoise = regexp.MustCompile("(?s)```.*?```|`[^`]*`|\\S+://\\S+")
+
+// latinStopwords are common short words that tell Latin-script languages
+// apart. A word listed for several languages counts for each.
+var latinStopwords = map[string][]string{
+	"English":    {"the", "and", "is", "are", "you", "what", "how", "this", "that", "with", "for", "have", "can", "please", "i", "my", "it", "to", "of", "does", "was", "thanks", "hello"},
+	"Spanish":    {"el", "los", "las", "que", "por", "para", "con", "una", "y", "está", "pero", "hola", "gracias", "qué", "cómo", "muy", "puedes", "es", "del"},
+	"French":     {"le", "les", "est", "et", "je", "vous", "pas", "une", "des", "du", "pour", "avec", "qui", "bonjour", "merci", "mon", "sur", "dans", "nous", "c'est", "ça"},
+	"German":     {"der", "die", "das", "und", "ist", "nicht", "ich", "du", "ein", "eine", "mit", "für", "auf", "wie", "was", "bitte", "danke", "hallo", "kannst", "mir"},
+	"Portuguese": {"os", "é", "não", "você", "um", "uma", "para", "com", "do", "da", "em", "obrigado", "olá", "isso", "que", "está"},
+	"Italian":    {"il", "che", "è", "non", "di", "per", "una", "sono", "ciao", "grazie", "questo", "della", "gli", "puoi"},
+	"Dutch":      {"het", "een", "van", "niet", "ik", "je", "wat", "dat", "dank", "dit", "zijn", "voor", "hoe", "kun", "alsjeblieft"},
+}
+
+var latinStopwordIndex = func() map[string][]string {
+	idx := map[string][]string{}
+	for lang, words := range latinStopwords {
+		for _, w := range words {
+			idx[w] = append(idx[w], lang)
+		}
+	}
+	return idx
+}()
+
+// detectLanguage guesses the language of text from its script and, for
+// Latin script, from common words. It returns an English language name, or
+// "" when the text is too short or ambiguous to tell.
+func detectLanguage(text string) string {
+	text = replyLanguageNoise.ReplaceAllString(text, " ")
+	var letters, latin, kana, han, hangul, cyrillic, arabic, hebrew, greek, thai, devanagari int
+	var ukrainian, persian bool
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+			persian = persian || strings.ContainsRune("پچژگکی", r)
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	// A non-Latin script wins when it makes up a fair share of the letters,
+	// so English identifiers in a Japanese question do not flip it.
+	major := func(n int) bool { return n >= 2 && n*3 >= letters }
+	switch {
+	case kana > 0 && major(kana+han):
+		return "Japanese"
+	case major(han):
+		return "Chinese"
+	case major(hangul):
+		return "Korean"
+	case major(cyrillic) && ukrainian:
+		return "Ukrainian"
+	case major(cyrillic):
+		return "Russian"
+	case major(arabic) && persian:
+		return "Persian"
+	case major(arabic):
+		return "Arabic"
+	case major(hebrew):
+		return "Hebrew"
+	case major(greek):
+		return "Greek"
+	case major(thai):
+		return "Thai"
+	case major(devanagari):
+		return "Hindi"
+	}
+	if latin == 0 {
+		return ""
+	}
+
+	scores := map[string]int{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for _, lang := range latinStopwordIndex[strings.Trim(w, "'")] {
+			scores[lang]++
+		}
+	}
+	if strings.ContainsAny(text, "ñ¿¡") {
+		scores["Spanish"] += 2
+	}
+	if strings.ContainsRune(text, 'ß') {
+		scores["German"] += 2
+	}
+	if strings.ContainsAny(text, "ãõ") {
+		scores["Portuguese"] += 2
+	}
+	best, bestScore, second := "", 0, 0
+	for lang, s := range scores {
+		switch {
+		case s > bestScore:
+			best, second, bestScore = lang, bestScore, s
+		case s > second:
+			second = s
+		}
+	}
+	if bestScore < 2 || bestScore == second {
+		return ""
+	}
+	return best
+}
+
+// noteReplyLanguage detects the language of userText, records it in the
+// session when it changed, and returns the language to reply in: the one
+// just detected, or the last one recorded when userText is too short to
+// tell (e.g. "ok").
+func noteReplyLanguage(sess *session.Session, userText string) string {
+	if lang := detectLanguage(userText); lang != "" {
+		if lang != sess.MetaString(replyLanguageMetaKey) {
+			sess.SetMeta(replyLanguageMetaKey, lang)
+		}
+		return lang
+	}
+	return sess.MetaString(replyLanguageMetaKey)
+}
+
+// withReplyLanguage appends a directive to reply in lang, if any, to the
+// system prompt.
+func withReplyLanguage(system, lang string) string {
+	if lang == "" {
+		return system
+	}
+	return strings.TrimRight(system, "\n") + "\n\n## Reply Language\nReply in " + lang + ", the language of the user's messages, unless the user asks for another language.\n"
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/session"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"What is the weather like in Tokyo this week?":        "English",
+		"¿Cuál es el clima en Madrid para esta semana?":       "Spanish",
+		"Bonjour, est-ce que vous pouvez m'aider avec ça ?":   "French",
+		"Kannst du mir bitte das Wetter für morgen sagen?":    "German",
+		"Você pode me ajudar com isso? Não está funcionando.": "Portuguese",
+		"Ciao, puoi aiutarmi con questo file? Non funziona.":  "Italian",
+		"Kun je me helpen met dit bestand? Het werkt niet.":   "Dutch",
+		"このファイルの `main.go` を読んで要約してください":                      "Japanese",
+		"请帮我总结一下这个文件的内容":                                      "Chinese",
+		"이 파일을 요약해 주세요":                                       "Korean",
+		"Пожалуйста, помоги мне с этим файлом":                "Russian",
+		"Будь ласка, допоможи мені з цим файлом і поясни":     "Ukrainian",
+		"ok": "",
+		"```go\nfunc main() {}\n``` https://example.com/the/x": "",
+	}
+	for in, want := range cases {
+		if got := detectLanguage(in); got != want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNoteReplyLanguage_KeepsLastDetected(t *testing.T) {
+	sess := &session.Session{Key: "telegram:1"}
+	if got := noteReplyLanguage(sess, "¿Puedes ayudarme con el informe, por favor?"); got != "Spanish" {
+		t.Fatalf("got %q", got)
+	}
+	if got := noteReplyLanguage(sess, "ok"); got != "Spanish" {
+		t.Fatalf("short message changed language: %q", got)
+	}
+	if got := noteReplyLanguage(sess, "Can you also check the other report for me?"); got != "English" {
+		t.Fatalf("switch not detected: %q", got)
+	}
+	if sess.MetaString(replyLanguageMetaKey) != "English" {
+		t.Fatalf("meta = %q", sess.MetaString(replyLanguageMetaKey))
+	}
+	if sys := withReplyLanguage("base\n", "English"); !strings.Contains(sys, "## Reply Language\nReply in English") {
+		t.Fatalf("prompt: %q", sys)
+	}
+	if withReplyLanguage("base\n", "") != "base\n" {
+		t.Fatal("empty language changed the prompt")
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+// runningSummaryMetaKey is the session metadata key holding the running
+// summary of messages consolidation removed (agents.defaults.runningSummary).
+const runningSummaryMetaKey = "conversationSummary"
+
+// runningSummaryFunc folds conversation into the previous running summary
+// and returns the new one.
+type runningSummaryFunc func(ctx context.Context, previous, conversation string) (string, error)
+
+// rollRunningSummary asks the model for the updated summary, capped at
+// maxChars.
+func rollRunningSummary(ctx context.Context, chat consolidationChatFunc, previous, conversation string, maxChars int) (string, error) {
+	if chat == nil {
+		return "", errors.New("no model for the running summary")
+	}
+	reply, err := chat(ctx, []llm.Message{
+		{Role: "system", Content: "You maintain the running summary of a conversation. Reply with the updated summary as plain text and nothing else."},
+		{Role: "user", Content: buildRunningSummaryPrompt(previous, conversation, maxChars)},
+	})
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(reply)
+	if summary == "" {
+		return "", errors.New("empty running summary")
+	}
+	return truncateUTF8(summary, maxChars), nil
+}
+
+func buildRunningSummaryPrompt(previous, conversation string, maxChars int) string {
+	previous = strings.TrimSpace(previous)
+	if previous == "" {
+		previous = "(none yet)"
+	}
+	return fmt.Sprintf(`Update the running summary of this conversation with the messages below, which are about to be removed from the context.
+
+Keep what later turns may need: the user's goals and preferences, decisions made, open questions and tasks, and important facts, names, paths, and results. Drop greetings and small talk. Merge with the current summary rather than appending, and keep it under %d characters.
+
+## Current Summary
+%s
+
+## Messages To Fold In
+%s`, maxChars, previous, conversation)
+}
+
+// withRunningSummary appends the session's running summary, if any, to the
+// system prompt.
+func withRunningSummary(system, summary string) string {
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return system
+	}
+	return strings.TrimRight(system, "\n") + "\n\n## Conversation Summary\nEarlier messages of this conversation, summarized. The most recent messages follow in full.\n\n" + summary + "\n"
+}
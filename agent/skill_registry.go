@@ -18,5 +18,6 @@ func buildSkillRegistry(cfg *config.Config) (tools.SkillRegistry, int) {
 		TimeoutSec:       cfg.Tools.Skills.Registry.TimeoutSec,
 		MaxZipBytes:      cfg.Tools.Skills.Registry.MaxZipBytes,
 		MaxResponseBytes: cfg.Tools.Skills.Registry.MaxResponseBytes,
+		EgressAllowHosts: cfg.Tools.EgressAllowHosts,
 	}), cfg.Tools.Skills.MaxResults
 }
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
 
 	"github.com/mosaxiv/clawlet/bus"
 	"github.com/mosaxiv/clawlet/llm"
@@ -14,10 +16,37 @@ import (
 
 type SubagentManager struct {
 	loop *Loop
+
+	// batches holds finished results per origin chat while other subagents
+	// from that chat are still running (agents.defaults.subagentResults
+	// "summarized").
+	mu      sync.Mutex
+	batches map[string]*subagentBatch
 }
 
 func NewSubagentManager(loop *Loop) *SubagentManager {
-	return &SubagentManager{loop: loop}
+	return &SubagentManager{loop: loop, batches: map[string]*subagentBatch{}}
+}
+
+// subagentStats describes the work one subagent did. Tokens is estimated
+// from message sizes (about 4 characters per token).
+type subagentStats struct {
+	Calls     int
+	ToolCalls int
+	Tokens    int
+}
+
+type subagentResult struct {
+	ID    string
+	Label string
+	Task  string
+	Out   string
+	Stats subagentStats
+}
+
+type subagentBatch struct {
+	running int
+	results []subagentResult
 }
 
 func (m *SubagentManager) Spawn(ctx context.Context, task, label, originChannel, originChatID string) (string, error) {
@@ -29,8 +58,13 @@ func (m *SubagentManager) Spawn(ctx context.Context, task, label, originChannel,
 		return "", fmt.Errorf("task is empty")
 	}
 	id := "sa_" + randID()
+	origin := originChannel + ":" + originChatID
+	summarized := m.loop.cfg != nil && m.loop.cfg.Agents.Defaults.SubagentResults == "summarized"
+	if summarized {
+		m.start(origin)
+	}
 	go func() {
-		out, err := m.runSubagent(ctx, task)
+		out, stats, err := m.runSubagent(ctx, task)
 		if err != nil {
 			out = "error: " + err.Error()
 		}
@@ -38,20 +72,24 @@ func (m *SubagentManager) Spawn(ctx context.Context, task, label, originChannel,
 		if display == "" {
 			display = shortLabel(task)
 		}
-		announce := fmt.Sprintf(`[Background task '%s' completed]
-
-Task: %s
+		res := subagentResult{ID: id, Label: display, Task: task, Out: out, Stats: stats}
 
-Result:
-%s
-
-Summarize this naturally for the user. Keep it brief (1-2 sentences). Do not mention technical details like "subagent" or task IDs.`, display, task, out)
+		announce := subagentAnnouncement(res)
+		if summarized {
+			results, done := m.finish(origin, res)
+			if !done {
+				return
+			}
+			if len(results) > 1 {
+				announce = subagentBatchAnnouncement(results, m.mergeResults(context.Background(), results))
+			}
+		}
 
 		// Announce back to origin via system channel; main loop routes and replies.
 		_ = m.loop.bus.PublishInbound(context.Background(), bus.InboundMessage{
 			Channel:    "system",
 			SenderID:   id,
-			ChatID:     originChannel + ":" + originChatID,
+			ChatID:     origin,
 			Content:    announce,
 			SessionKey: "",
 		})
@@ -59,21 +97,117 @@ Summarize this naturally for the user. Keep it brief (1-2 sentences). Do not men
 	return id, nil
 }
 
-func (m *SubagentManager) runSubagent(ctx context.Context, task string) (string, error) {
+// start counts a subagent spawned from origin.
+func (m *SubagentManager) start(origin string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.batches[origin]
+	if b == nil {
+		b = &subagentBatch{}
+		m.batches[origin] = b
+	}
+	b.running++
+}
+
+// finish records res and, once no subagent from origin is still running,
+// returns the whole batch.
+func (m *SubagentManager) finish(origin string, res subagentResult) ([]subagentResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.batches[origin]
+	if b == nil {
+		return []subagentResult{res}, true
+	}
+	b.results = append(b.results, res)
+	b.running--
+	if b.running > 0 {
+		return nil, false
+	}
+	delete(m.batches, origin)
+	return b.results, true
+}
+
+const subagentAnnounceFooter = `Summarize this naturally for the user. Keep it brief (1-2 sentences). Do not mention technical details like "subagent" or task IDs.`
+
+func (s subagentStats) String() string {
+	return fmt.Sprintf("%d model calls, %d tool calls, ~%d tokens", s.Calls, s.ToolCalls, s.Tokens)
+}
+
+func subagentAnnouncement(r subagentResult) string {
+	return fmt.Sprintf(`[Background task '%s' completed]
+
+Task: %s
+Run: %s (%s)
+
+Result:
+%s
+
+%s`, r.Label, r.Task, r.ID, r.Stats, r.Out, subagentAnnounceFooter)
+}
+
+func subagentBatchAnnouncement(results []subagentResult, merged string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%d background tasks completed]\n\nTasks:\n", len(results))
+	for _, r := range results {
+		fmt.Fprintf(&b, "- '%s' (%s: %s): %s\n", r.Label, r.ID, r.Stats, r.Task)
+	}
+	fmt.Fprintf(&b, "\nResults:\n%s\n\n%s", merged, subagentAnnounceFooter)
+	return b.String()
+}
+
+const subagentMergeInstructions = "Merge these background task results into one report for the agent that started them. Keep every concrete finding, file path, number, and error, say which task each came from, and drop repetition."
+
+// mergeResults condenses a batch with one model call. On failure the raw
+// results are returned, so nothing is lost.
+func (m *SubagentManager) mergeResults(ctx context.Context, results []subagentResult) string {
+	var raw strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&raw, "## Task '%s'\n%s\n\n### Result\n%s\n\n", r.Label, r.Task, strings.TrimSpace(r.Out))
+	}
+	text := strings.TrimSpace(raw.String())
+	summarize := newSummarizeFunc(m.loop.llm, m.loop.cfg.Tools.Summarize.Model)
+	if summarize == nil {
+		return text
+	}
+	merged, err := summarize(ctx, text, subagentMergeInstructions)
+	if err != nil {
+		log.Printf("agent: merge subagent results: %v", err)
+		return text
+	}
+	return merged
+}
+
+func (m *SubagentManager) runSubagent(ctx context.Context, task string) (string, subagentStats, error) {
+	var stats subagentStats
 	l := m.loop
 	if l == nil || l.llm == nil || l.cfg == nil {
-		return "", fmt.Errorf("subagent loop not configured")
+		return "", stats, fmt.Errorf("subagent loop not configured")
 	}
 
 	// Subagent tools: a restricted subset (no message, no spawn, no cron).
 	treg := &tools.Registry{
-		WorkspaceDir:        l.workspace,
-		RestrictToWorkspace: l.cfg.Tools.RestrictToWorkspaceValue(),
-		ExecTimeout:         l.tools.ExecTimeout,
-		BraveAPIKey:         l.tools.BraveAPIKey,
+		WorkspaceDir:           l.workspace,
+		RestrictToWorkspace:    l.cfg.Tools.RestrictToWorkspaceValue(),
+		ExecTimeout:            l.tools.ExecTimeout,
+		ToolTimeouts:           l.tools.ToolTimeouts,
+		WriteDenyGlobs:         l.tools.WriteDenyGlobs,
+		TruncateMode:           l.tools.TruncateMode,
+		RequireReadBeforeWrite: l.tools.RequireReadBeforeWrite,
+		// No Approve: a background run has nobody to ask, so approval-gated
+		// tools are refused.
+		RequireApproval:   l.tools.RequireApproval,
+		Audit:             l.tools.Audit,
+		ExecCleanOutput:   l.tools.ExecCleanOutput,
+		ExecExtraEnv:      l.tools.ExecExtraEnv,
+		ExecAllowCommands: l.tools.ExecAllowCommands,
+		SafeMode:          l.tools.SafeMode,
+		EgressAllowHosts:  l.tools.EgressAllowHosts,
+		BraveAPIKey:       l.tools.BraveAPIKey,
+		WebFetchRetries:   l.tools.WebFetchRetries,
 		AllowTools: []string{
 			"read_file",
 			"write_file",
+			"write_files",
 			"list_dir",
 			"exec",
 			"web_search",
@@ -91,17 +225,20 @@ func (m *SubagentManager) runSubagent(ctx context.Context, task string) (string,
 
 	const maxIters = 15
 	var final string
+	reads := tools.NewReadSet()
 	for range maxIters {
-		res, err := l.llm.Chat(ctx, messages, toolsDefs)
+		res, err := chatWithEmptyRetry(ctx, l.llm, messages, toolsDefs, l.retryEmpty)
 		if err != nil {
-			return "", err
+			return "", stats, err
 		}
+		stats.add(messages, res)
 		if res.HasToolCalls() {
-			messages = appendToolRound(messages, res.Content, res.ToolCalls, func(tc llm.ToolCall) string {
+			messages, _ = appendToolRound(messages, res, 1, func(tc llm.ToolCall) string {
 				out, err := treg.Execute(ctx, tools.Context{
 					Channel:    "cli",
 					ChatID:     "subagent",
 					SessionKey: "",
+					Reads:      reads,
 				}, tc.Name, tc.Arguments)
 				if err != nil {
 					return "error: " + err.Error()
@@ -116,7 +253,21 @@ func (m *SubagentManager) runSubagent(ctx context.Context, task string) (string,
 	if strings.TrimSpace(final) == "" {
 		final = "(no response)"
 	}
-	return final, nil
+	return final, stats, nil
+}
+
+// add counts one model call with its request and reply.
+func (s *subagentStats) add(messages []llm.Message, res *llm.ChatResult) {
+	chars := len(res.Content)
+	for _, m := range messages {
+		chars += len(m.Content)
+	}
+	for _, tc := range res.ToolCalls {
+		chars += len(tc.Name) + len(tc.Arguments)
+	}
+	s.Calls++
+	s.ToolCalls += len(res.ToolCalls)
+	s.Tokens += chars / 4
 }
 
 func buildSubagentPrompt(workspace string, task string) string {
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func TestSubagentManager_BatchesByOrigin(t *testing.T) {
+	m := NewSubagentManager(&Loop{})
+	m.start("telegram:1")
+	m.start("telegram:1")
+	m.start("slack:C1")
+
+	if _, done := m.finish("telegram:1", subagentResult{ID: "sa_a"}); done {
+		t.Fatal("batch flushed while another subagent is running")
+	}
+	if got, done := m.finish("slack:C1", subagentResult{ID: "sa_c"}); !done || len(got) != 1 {
+		t.Fatalf("other origin: done=%v results=%v", done, got)
+	}
+	got, done := m.finish("telegram:1", subagentResult{ID: "sa_b"})
+	if !done || len(got) != 2 || got[0].ID != "sa_a" || got[1].ID != "sa_b" {
+		t.Fatalf("done=%v results=%v", done, got)
+	}
+	if len(m.batches) != 0 {
+		t.Fatalf("batches left: %v", m.batches)
+	}
+}
+
+func TestSubagentManager_MergeResults(t *testing.T) {
+	doer := &scriptedDoer{bodies: []string{`{"choices":[{"message":{"content":"merged report"}}]}`}}
+	l := &Loop{
+		cfg: config.Default(),
+		llm: &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer},
+	}
+	m := NewSubagentManager(l)
+	results := []subagentResult{
+		{ID: "sa_a", Label: "docs", Task: "read the docs", Out: "docs say X", Stats: subagentStats{Calls: 2, ToolCalls: 1, Tokens: 900}},
+		{ID: "sa_b", Label: "code", Task: "grep the code", Out: "code does Y", Stats: subagentStats{Calls: 1, Tokens: 300}},
+	}
+	merged := m.mergeResults(context.Background(), results)
+	if merged != "merged report" || doer.calls != 1 {
+		t.Fatalf("merged=%q calls=%d", merged, doer.calls)
+	}
+	announce := subagentBatchAnnouncement(results, merged)
+	for _, want := range []string{"[2 background tasks completed]", "'docs' (sa_a: 2 model calls, 1 tool calls, ~900 tokens)", "Results:\nmerged report"} {
+		if !strings.Contains(announce, want) {
+			t.Fatalf("announcement missing %q:\n%s", want, announce)
+		}
+	}
+
+	l.llm = &llm.Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: &scriptedDoer{bodies: []string{`{"choices":[]}`}}}
+	if raw := m.mergeResults(context.Background(), results); !strings.Contains(raw, "docs say X") || !strings.Contains(raw, "code does Y") {
+		t.Fatalf("fallback should keep raw results: %q", raw)
+	}
+}
+
+func TestSubagentStats_Add(t *testing.T) {
+	var s subagentStats
+	s.add([]llm.Message{{Content: strings.Repeat("a", 400)}}, &llm.ChatResult{Content: strings.Repeat("b", 40), ToolCalls: []llm.ToolCall{{Name: "exec"}}})
+	if s.Calls != 1 || s.ToolCalls != 1 || s.Tokens != 111 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
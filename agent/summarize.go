@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+// newSummarizeFunc returns the tools.Registry.Summarize hook. It reuses base
+// with model swapped in (when set) and without reasoning or continuations,
+// so summaries stay cheap.
+func newSummarizeFunc(base *llm.Client, model string) func(ctx context.Context, text, instructions string) (string, error) {
+	if base == nil {
+		return nil
+	}
+	c := *base
+	if m := strings.TrimSpace(model); m != "" {
+		c.Model = m
+	}
+	c.ReasoningEffort = ""
+	c.MaxContinuations = 0
+	return func(ctx context.Context, text, instructions string) (string, error) {
+		res, err := c.Chat(ctx, []llm.Message{
+			{Role: "system", Content: "You are a summarization assistant. " + instructions},
+			{Role: "user", Content: text},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		out := strings.TrimSpace(res.Content)
+		if out == "" {
+			return "", fmt.Errorf("empty summary response")
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/tools"
+)
+
+// toolCallDeduper catches a model repeating itself within one turn. A
+// repeated read-only call gets the earlier result back until a call with
+// side effects may have changed things. A side-effecting call identical to
+// the one just before it is not run again. Create one per turn.
+type toolCallDeduper struct {
+	nonIdempotent []string
+
+	mu    sync.Mutex
+	cache map[string]string
+	last  string
+}
+
+// newToolCallDeduper returns nil when agents.defaults.toolCallDedup is off.
+func newToolCallDeduper(cfg *config.Config) *toolCallDeduper {
+	if cfg == nil || !cfg.Agents.Defaults.ToolCallDedupValue() {
+		return nil
+	}
+	return &toolCallDeduper{
+		nonIdempotent: append([]string{"context_info"}, cfg.Tools.NonIdempotent...),
+		cache:         map[string]string{},
+	}
+}
+
+func (d *toolCallDeduper) run(tc llm.ToolCall, exec func() string) string {
+	if d == nil || slices.Contains(d.nonIdempotent, tc.Name) {
+		return exec()
+	}
+	key := toolCallKey(tc)
+	d.mu.Lock()
+	prev := d.last
+	d.last = key
+
+	if tools.HasSideEffects(tc.Name) {
+		if key == prev {
+			d.mu.Unlock()
+			return fmt.Sprintf("Not run: this %s call is identical to the previous one. Use its result, or change the arguments if you need a different outcome.", tc.Name)
+		}
+		clear(d.cache)
+		d.mu.Unlock()
+		return exec()
+	}
+	out, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok {
+		return "(Same call as earlier in this turn; returning the earlier result.)\n" + out
+	}
+	out = exec()
+	// Errors may be transient, so a retry runs again.
+	if !strings.HasPrefix(out, "error: ") {
+		d.mu.Lock()
+		d.cache[key] = out
+		d.mu.Unlock()
+	}
+	return out
+}
+
+// toolCallKey identifies a call by name and arguments, ignoring JSON key
+// order and whitespace.
+func toolCallKey(tc llm.ToolCall) string {
+	args := string(tc.Arguments)
+	var v any
+	if err := json.Unmarshal(tc.Arguments, &v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			args = string(b)
+		}
+	}
+	return tc.Name + "\x00" + args
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func TestToolCallDeduper(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.NonIdempotent = []string{"web_fetch"}
+	d := newToolCallDeduper(cfg)
+	runs := map[string]int{}
+	call := func(name, args string) string {
+		return d.run(llm.ToolCall{Name: name, Arguments: json.RawMessage(args)}, func() string {
+			runs[name]++
+			return name + " result"
+		})
+	}
+
+	call("read_file", `{"path":"a.txt"}`)
+	out := call("read_file", `{ "path": "a.txt" }`)
+	if runs["read_file"] != 1 || !strings.Contains(out, "earlier result") || !strings.HasSuffix(out, "read_file result") {
+		t.Fatalf("runs=%d out=%q", runs["read_file"], out)
+	}
+
+	call("exec", `{"command":"go test ./..."}`)
+	if out := call("exec", `{"command":"go test ./..."}`); runs["exec"] != 1 || !strings.HasPrefix(out, "Not run:") {
+		t.Fatalf("runs=%d out=%q", runs["exec"], out)
+	}
+	// A side effect since the last read means the file may have changed.
+	call("read_file", `{"path":"a.txt"}`)
+	if runs["read_file"] != 2 {
+		t.Fatalf("read_file runs=%d, want 2", runs["read_file"])
+	}
+	// Not consecutive: the earlier exec result is stale.
+	call("exec", `{"command":"go test ./..."}`)
+	if runs["exec"] != 2 {
+		t.Fatalf("exec runs=%d, want 2", runs["exec"])
+	}
+
+	call("context_info", `{}`)
+	call("context_info", `{}`)
+	call("web_fetch", `{"url":"https://example.com"}`)
+	call("web_fetch", `{"url":"https://example.com"}`)
+	if runs["context_info"] != 2 || runs["web_fetch"] != 2 {
+		t.Fatalf("non-idempotent runs: %v", runs)
+	}
+
+	off := false
+	cfg.Agents.Defaults.ToolCallDedup = &off
+	if newToolCallDeduper(cfg) != nil {
+		t.Fatal("dedup should be disabled")
+	}
+}
+
+func TestToolCallDeduper_ErrorsRunAgain(t *testing.T) {
+	d := newToolCallDeduper(config.Default())
+	runs := 0
+	for range 2 {
+		d.run(llm.ToolCall{Name: "web_search", Arguments: json.RawMessage(`{"query":"x"}`)}, func() string {
+			runs++
+			return "error: timeout"
+		})
+	}
+	if runs != 2 {
+		t.Fatalf("runs=%d, want 2", runs)
+	}
+}
@@ -1,15 +1,26 @@
 package agent
 
-import "github.com/mosaxiv/clawlet/llm"
+import (
+	"sync"
 
+	"github.com/mosaxiv/clawlet/llm"
+	"github.com/mosaxiv/clawlet/tools"
+)
+
+// appendToolRound runs the tool calls of one model reply and appends the
+// assistant message and the results, in call order. It also returns the
+// results, indexed like res.ToolCalls. Up to maxParallel consecutive calls
+// without side effects run concurrently; a call with side effects waits for
+// the calls before it and runs alone.
 func appendToolRound(
 	messages []llm.Message,
-	assistantContent string,
-	toolCalls []llm.ToolCall,
+	res *llm.ChatResult,
+	maxParallel int,
 	exec func(tc llm.ToolCall) string,
-) []llm.Message {
+) ([]llm.Message, []string) {
+	toolCalls := res.ToolCalls
 	if len(toolCalls) == 0 {
-		return messages
+		return messages, nil
 	}
 
 	tcs := make([]llm.ToolCallPayload, 0, len(toolCalls))
@@ -23,17 +34,45 @@ func appendToolRound(
 			},
 		})
 	}
-	messages = append(messages, llm.Message{Role: "assistant", Content: assistantContent, ToolCalls: tcs})
+	messages = append(messages, llm.Message{Role: "assistant", Content: res.Content, ToolCalls: tcs, Reasoning: res.Reasoning})
 
-	for _, tc := range toolCalls {
-		out := exec(tc)
+	outs := runToolCalls(toolCalls, maxParallel, exec)
+	for i, tc := range toolCalls {
 		messages = append(messages, llm.Message{
 			Role:       "tool",
 			ToolCallID: tc.ID,
 			Name:       tc.Name,
-			Content:    out,
+			Content:    outs[i],
 		})
 	}
 
-	return append(messages, llm.Message{Role: "user", Content: "Reflect on the results and decide next steps."})
+	return append(messages, llm.Message{Role: "user", Content: "Reflect on the results and decide next steps."}), outs
+}
+
+func runToolCalls(toolCalls []llm.ToolCall, maxParallel int, exec func(tc llm.ToolCall) string) []string {
+	outs := make([]string, len(toolCalls))
+	if maxParallel <= 1 || len(toolCalls) == 1 {
+		for i, tc := range toolCalls {
+			outs[i] = exec(tc)
+		}
+		return outs
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallel)
+	for i, tc := range toolCalls {
+		if tools.HasSideEffects(tc.Name) {
+			wg.Wait()
+			outs[i] = exec(tc)
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			outs[i] = exec(tc)
+		}()
+	}
+	wg.Wait()
+	return outs
 }
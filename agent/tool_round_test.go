@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func TestAppendToolRound_ParallelKeepsOrder(t *testing.T) {
+	res := &llm.ChatResult{ToolCalls: []llm.ToolCall{
+		{ID: "1", Name: "read_file"},
+		{ID: "2", Name: "web_fetch"},
+		{ID: "3", Name: "write_file"},
+		{ID: "4", Name: "read_file"},
+	}}
+	var (
+		running, peak atomic.Int32
+		mu            sync.Mutex
+		order         []string
+	)
+	exec := func(tc llm.ToolCall) string {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		if tc.Name == "write_file" && n != 1 {
+			t.Errorf("write_file ran alongside %d other calls", n-1)
+		}
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		order = append(order, tc.ID)
+		mu.Unlock()
+		running.Add(-1)
+		return "out " + tc.ID
+	}
+
+	msgs, outs := appendToolRound(nil, res, 4, exec)
+	if len(msgs) != 6 {
+		t.Fatalf("messages=%d", len(msgs))
+	}
+	for i, id := range []string{"1", "2", "3", "4"} {
+		if m := msgs[i+1]; m.ToolCallID != id || m.Content != "out "+id {
+			t.Fatalf("message %d = %+v", i+1, m)
+		}
+		if outs[i] != "out "+id {
+			t.Fatalf("outs[%d]=%q", i, outs[i])
+		}
+	}
+	if peak.Load() != 2 {
+		t.Fatalf("peak concurrency=%d, want 2", peak.Load())
+	}
+	if order[2] != "3" {
+		t.Fatalf("write_file should run after the reads before it: %v", order)
+	}
+
+	peak.Store(0)
+	appendToolRound(nil, res, 1, exec)
+	if peak.Load() != 1 {
+		t.Fatalf("maxParallel=1 ran %d at once", peak.Load())
+	}
+}
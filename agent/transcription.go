@@ -0,0 +1,21 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+func buildLocalTranscriber(cfg *config.Config) *llm.LocalTranscriber {
+	if cfg == nil || !cfg.LLM.Transcription.Local() {
+		return nil
+	}
+	t := cfg.LLM.Transcription
+	return &llm.LocalTranscriber{
+		Command: t.Command,
+		Model:   t.Model,
+		Args:    append([]string(nil), t.Args...),
+		Timeout: time.Duration(t.TimeoutSec) * time.Second,
+	}
+}
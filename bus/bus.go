@@ -2,7 +2,13 @@ package bus
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Delivery struct {
@@ -46,6 +52,11 @@ type InboundMessage struct {
 	Attachments []Attachment
 	SessionKey  string // usually "channel:chat_id"
 	Delivery    Delivery
+	// SentAt is when the platform says the message was sent. Zero if unknown.
+	SentAt time.Time
+	// TrackingID is copied to the reply so the sender can wait for its
+	// delivery result with TrackDelivery.
+	TrackingID string
 }
 
 type OutboundMessage struct {
@@ -54,11 +65,53 @@ type OutboundMessage struct {
 	Content  string
 	ReplyTo  string
 	Delivery Delivery
+	// Attachments are files to upload with the message, read from
+	// LocalPath or Data. The channel manager fits them to the channel's
+	// upload limit first (see channels.FitAttachments).
+	Attachments []Attachment
+	// TrackingID, when set, reports the send result to TrackDelivery.
+	TrackingID string
+	// IdempotencyKey identifies this message across retries and restarts.
+	// PublishOutbound sets it when empty; channels pass it to platforms
+	// that deduplicate sends.
+	IdempotencyKey string
+
+	// queueID links the message to its OutboundStore record.
+	queueID string
+	// attempts counts failed sends, including those of earlier runs.
+	attempts int
 }
 
+// Attempts returns how many times sending msg has failed so far.
+func (m OutboundMessage) Attempts() int { return m.attempts }
+
+// idempotencyKey hashes the destination and content with the turn the
+// message answers (the inbound message ID or tracking ID), so the same reply
+// to the same turn gets the same key. Messages that answer no turn are
+// keyed by publish time instead and are never treated as duplicates.
+func idempotencyKey(msg OutboundMessage, now time.Time) string {
+	turn := msg.Delivery.MessageID + "\x00" + msg.TrackingID
+	if turn == "\x00" {
+		turn = strconv.FormatInt(now.UnixNano(), 10)
+	}
+	sum := sha256.Sum256([]byte(msg.Channel + "\x00" + msg.ChatID + "\x00" + turn + "\x00" + msg.Content))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ErrNoReply is reported for a tracked message that produced no reply.
+var ErrNoReply = errors.New("no reply was produced")
+
+// Bus carries messages between channels and the agent. Inbound and outbound
+// queues are each FIFO: messages are consumed in the order they were
+// published. The gateway loop keeps that order per session key and may
+// handle different sessions concurrently (gateway.inboundConcurrency).
 type Bus struct {
-	in  chan InboundMessage
-	out chan OutboundMessage
+	in    chan InboundMessage
+	out   chan OutboundMessage
+	store *OutboundStore
+
+	trackMu  sync.Mutex
+	trackers map[string]chan error
 }
 
 func New(buffer int) *Bus {
@@ -80,7 +133,23 @@ func (b *Bus) PublishInbound(ctx context.Context, msg InboundMessage) error {
 	}
 }
 
+// SetOutboundStore enables persistence of outbound messages. Call it before
+// anything is published, then RequeuePending once consumers are running.
+func (b *Bus) SetOutboundStore(s *OutboundStore) {
+	b.store = s
+}
+
 func (b *Bus) PublishOutbound(ctx context.Context, msg OutboundMessage) error {
+	if msg.IdempotencyKey == "" {
+		msg.IdempotencyKey = idempotencyKey(msg, time.Now())
+	}
+	if b.store != nil && msg.queueID == "" {
+		id, err := b.store.add(msg)
+		if err != nil {
+			return err
+		}
+		msg.queueID = id
+	}
 	select {
 	case b.out <- msg:
 		return nil
@@ -98,6 +167,75 @@ func (b *Bus) ConsumeInbound(ctx context.Context) (InboundMessage, error) {
 	}
 }
 
+// AckOutbound removes a delivered (or undeliverable) message from the
+// outbound store. It is a no-op without a store.
+func (b *Bus) AckOutbound(msg OutboundMessage) error {
+	if b.store == nil || msg.queueID == "" {
+		return nil
+	}
+	return b.store.remove(msg.queueID)
+}
+
+// FailOutbound records a failed send of msg and returns it with its attempt
+// count raised. The count is saved with the stored record, so it carries
+// over to the next start.
+func (b *Bus) FailOutbound(msg OutboundMessage) (OutboundMessage, error) {
+	msg.attempts++
+	if b.store == nil || msg.queueID == "" {
+		return msg, nil
+	}
+	return msg, b.store.setAttempts(msg.queueID, msg.attempts)
+}
+
+// RequeuePending republishes messages left in the outbound store by a
+// previous run.
+func (b *Bus) RequeuePending(ctx context.Context) error {
+	if b.store == nil {
+		return nil
+	}
+	for _, msg := range b.store.Pending() {
+		if err := b.PublishOutbound(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrackDelivery returns a channel that receives the result of the first
+// send reported for id: nil once it reached the channel, or the error.
+// Call cancel when no longer waiting.
+func (b *Bus) TrackDelivery(id string) (<-chan error, func()) {
+	ch := make(chan error, 1)
+	b.trackMu.Lock()
+	if b.trackers == nil {
+		b.trackers = map[string]chan error{}
+	}
+	b.trackers[id] = ch
+	b.trackMu.Unlock()
+	return ch, func() {
+		b.trackMu.Lock()
+		if b.trackers[id] == ch {
+			delete(b.trackers, id)
+		}
+		b.trackMu.Unlock()
+	}
+}
+
+// ReportDelivery completes TrackDelivery for id. It is a no-op for an empty
+// or untracked id.
+func (b *Bus) ReportDelivery(id string, err error) {
+	if id == "" {
+		return
+	}
+	b.trackMu.Lock()
+	ch := b.trackers[id]
+	delete(b.trackers, id)
+	b.trackMu.Unlock()
+	if ch != nil {
+		ch <- err
+	}
+}
+
 func (b *Bus) ConsumeOutbound(ctx context.Context) (OutboundMessage, error) {
 	select {
 	case msg := <-b.out:
@@ -0,0 +1,157 @@
+package bus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutboundStore persists outbound messages to a JSONL file until they are
+// acknowledged, so undelivered replies survive a restart.
+type OutboundStore struct {
+	path string
+
+	mu      sync.Mutex
+	pending []storedOutbound
+	seq     uint64
+}
+
+type storedOutbound struct {
+	ID      string          `json:"id"`
+	Message OutboundMessage `json:"message"`
+	// Attempts counts failed sends of Message.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// OpenOutboundStore loads any pending messages left in path.
+func OpenOutboundStore(path string) (*OutboundStore, error) {
+	s := &OutboundStore{path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 8<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var rec storedOutbound
+		if err := json.Unmarshal([]byte(line), &rec); err != nil || rec.ID == "" {
+			continue
+		}
+		s.pending = append(s.pending, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Pending returns the messages that have not been acknowledged yet.
+func (s *OutboundStore) Pending() []OutboundMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]OutboundMessage, 0, len(s.pending))
+	for _, rec := range s.pending {
+		msg := rec.Message
+		msg.queueID = rec.ID
+		msg.attempts = rec.Attempts
+		out = append(out, msg)
+	}
+	return out
+}
+
+func (s *OutboundStore) add(msg OutboundMessage) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	rec := storedOutbound{ID: fmt.Sprintf("%d-%d", time.Now().UnixNano(), s.seq), Message: msg}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return "", err
+	}
+	s.pending = append(s.pending, rec)
+	return rec.ID, nil
+}
+
+func (s *OutboundStore) setAttempts(id string, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.pending {
+		if s.pending[i].ID == id {
+			s.pending[i].Attempts = n
+			return s.rewriteLocked()
+		}
+	}
+	return nil
+}
+
+func (s *OutboundStore) remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := -1
+	for i, rec := range s.pending {
+		if rec.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	s.pending = append(s.pending[:idx], s.pending[idx+1:]...)
+	return s.rewriteLocked()
+}
+
+func (s *OutboundStore) rewriteLocked() error {
+	if len(s.pending) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, rec := range s.pending {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		_, _ = w.Write(append(b, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
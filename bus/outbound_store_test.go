@@ -0,0 +1,140 @@
+package bus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutboundStore_PersistsUntilAcked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbound.jsonl")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	store, err := OpenOutboundStore(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	b := New(4)
+	b.SetOutboundStore(store)
+	for _, c := range []string{"one", "two"} {
+		if err := b.PublishOutbound(ctx, OutboundMessage{Channel: "slack", ChatID: "C1", Content: c}); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	first, err := b.ConsumeOutbound(ctx)
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if err := b.AckOutbound(first); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+
+	// Simulate a restart: the unacked message is replayed from disk.
+	reopened, err := OpenOutboundStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := reopened.Pending(); len(got) != 1 || got[0].Content != "two" {
+		t.Fatalf("pending=%+v", got)
+	}
+	b2 := New(4)
+	b2.SetOutboundStore(reopened)
+	if err := b2.RequeuePending(ctx); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	msg, err := b2.ConsumeOutbound(ctx)
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if msg.Content != "two" {
+		t.Fatalf("content=%q", msg.Content)
+	}
+	if got := reopened.Pending(); len(got) != 1 {
+		t.Fatalf("requeue duplicated records: %d", len(got))
+	}
+	if err := b2.AckOutbound(msg); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("queue file should be removed when empty: %v", err)
+	}
+}
+
+func TestOutboundStore_KeepsAttemptsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbound.jsonl")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	store, err := OpenOutboundStore(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	b := New(4)
+	b.SetOutboundStore(store)
+	if err := b.PublishOutbound(ctx, OutboundMessage{Channel: "slack", ChatID: "C1", Content: "hi"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	msg, err := b.ConsumeOutbound(ctx)
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	for range 2 {
+		if msg, err = b.FailOutbound(msg); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+
+	reopened, err := OpenOutboundStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := reopened.Pending(); len(got) != 1 || got[0].Attempts() != 2 {
+		t.Fatalf("pending=%+v", got)
+	}
+}
+
+func TestIdempotencyKey_StableAcrossReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbound.jsonl")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	store, err := OpenOutboundStore(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	b := New(4)
+	b.SetOutboundStore(store)
+	reply := OutboundMessage{Channel: "slack", ChatID: "C1", Content: "hi", Delivery: Delivery{MessageID: "1700.1"}}
+	if err := b.PublishOutbound(ctx, reply); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	sent, err := b.ConsumeOutbound(ctx)
+	if err != nil || sent.IdempotencyKey == "" {
+		t.Fatalf("key=%q err=%v", sent.IdempotencyKey, err)
+	}
+
+	reopened, err := OpenOutboundStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := reopened.Pending(); len(got) != 1 || got[0].IdempotencyKey != sent.IdempotencyKey {
+		t.Fatalf("replayed=%+v want key %q", got, sent.IdempotencyKey)
+	}
+
+	now := time.Now()
+	if idempotencyKey(reply, now) != idempotencyKey(reply, now.Add(time.Hour)) {
+		t.Fatal("replies to the same turn should share a key")
+	}
+	other := reply
+	other.Delivery.MessageID = "1700.2"
+	if idempotencyKey(other, now) == idempotencyKey(reply, now) {
+		t.Fatal("replies to different turns should differ")
+	}
+	standalone := OutboundMessage{Channel: "slack", ChatID: "C1", Content: "hi"}
+	if idempotencyKey(standalone, now) == idempotencyKey(standalone, now.Add(time.Second)) {
+		t.Fatal("messages without a turn should not share keys")
+	}
+}
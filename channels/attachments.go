@@ -0,0 +1,39 @@
+package channels
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+)
+
+// LimitAttachments drops attachments that exceed the channel's per-message
+// count or known size limit, and appends a note to content describing what
+// was skipped so the reply can tell the user.
+func LimitAttachments(content string, atts []bus.Attachment, limits config.ChannelAttachmentConfig) (string, []bus.Attachment) {
+	if len(atts) == 0 {
+		return content, atts
+	}
+	var notes []string
+	kept := make([]bus.Attachment, 0, len(atts))
+	for _, att := range atts {
+		if limits.MaxAttachmentBytes > 0 && att.SizeBytes > limits.MaxAttachmentBytes {
+			name := strings.TrimSpace(att.Name)
+			if name == "" {
+				name = "attachment"
+			}
+			notes = append(notes, fmt.Sprintf("[Attachment %s skipped: %d bytes exceeds the %d byte limit]", name, att.SizeBytes, limits.MaxAttachmentBytes))
+			continue
+		}
+		kept = append(kept, att)
+	}
+	if limits.MaxAttachmentsPerMessage > 0 && len(kept) > limits.MaxAttachmentsPerMessage {
+		notes = append(notes, fmt.Sprintf("[%d attachments skipped: at most %d per message]", len(kept)-limits.MaxAttachmentsPerMessage, limits.MaxAttachmentsPerMessage))
+		kept = kept[:limits.MaxAttachmentsPerMessage]
+	}
+	if len(notes) == 0 {
+		return content, atts
+	}
+	return strings.TrimSpace(content + "\n\n" + strings.Join(notes, "\n")), kept
+}
@@ -0,0 +1,38 @@
+package channels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestLimitAttachments_DropsOversizedAndExcess(t *testing.T) {
+	atts := []bus.Attachment{
+		{Name: "a.txt", SizeBytes: 10},
+		{Name: "huge.bin", SizeBytes: 5000},
+		{Name: "b.txt", SizeBytes: 20},
+		{Name: "c.txt"},
+	}
+	limits := config.ChannelAttachmentConfig{MaxAttachmentBytes: 1000, MaxAttachmentsPerMessage: 2}
+
+	content, kept := LimitAttachments("hello", atts, limits)
+	if len(kept) != 2 || kept[0].Name != "a.txt" || kept[1].Name != "b.txt" {
+		t.Fatalf("kept=%+v", kept)
+	}
+	if !strings.HasPrefix(content, "hello") {
+		t.Fatalf("content=%q", content)
+	}
+	if !strings.Contains(content, "huge.bin skipped") || !strings.Contains(content, "1 attachments skipped") {
+		t.Fatalf("missing notes: %q", content)
+	}
+}
+
+func TestLimitAttachments_NoLimits(t *testing.T) {
+	atts := []bus.Attachment{{Name: "a", SizeBytes: 1 << 30}}
+	content, kept := LimitAttachments("hi", atts, config.ChannelAttachmentConfig{})
+	if content != "hi" || len(kept) != 1 {
+		t.Fatalf("content=%q kept=%d", content, len(kept))
+	}
+}
@@ -16,6 +16,13 @@ type Channel interface {
 	IsRunning() bool
 }
 
+// Approver is implemented by channels that can ask a chat to approve a tool
+// call, e.g. with buttons. RequestApproval blocks until the user answers, the
+// channel's approval timeout passes (a denial), or ctx ends.
+type Approver interface {
+	RequestApproval(ctx context.Context, chatID, prompt string) (bool, error)
+}
+
 type AllowList struct {
 	AllowFrom []string
 }
@@ -46,3 +53,28 @@ func (a AllowList) Allowed(senderID string) bool {
 	}
 	return false
 }
+
+// maxQuotedRunes caps how much of a replied-to message QuoteReply includes.
+const maxQuotedRunes = 1000
+
+// QuoteReply prepends quoted, the text of the message the user replied to,
+// to content as a Markdown block quote so the agent knows what the reply
+// refers to. Long quotes are truncated; an empty quote leaves content as is.
+func QuoteReply(quoted, content string) string {
+	quoted = strings.TrimSpace(quoted)
+	if quoted == "" {
+		return content
+	}
+	if r := []rune(quoted); len(r) > maxQuotedRunes {
+		quoted = strings.TrimSpace(string(r[:maxQuotedRunes])) + "…"
+	}
+	lines := strings.Split(quoted, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("> "+l, " ")
+	}
+	out := strings.Join(lines, "\n")
+	if content == "" {
+		return out
+	}
+	return out + "\n\n" + content
+}
@@ -0,0 +1,24 @@
+package channels
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteReply(t *testing.T) {
+	if got := QuoteReply("", "hello"); got != "hello" {
+		t.Fatalf("empty quote: %q", got)
+	}
+	if got := QuoteReply("line one\n\nline two", "what about this?"); got != "> line one\n>\n> line two\n\nwhat about this?" {
+		t.Fatalf("multi-line quote: %q", got)
+	}
+	if got := QuoteReply("photo caption", ""); got != "> photo caption" {
+		t.Fatalf("empty content: %q", got)
+	}
+
+	got := QuoteReply(strings.Repeat("é", maxQuotedRunes+50), "ok")
+	quote, _, _ := strings.Cut(got, "\n\n")
+	if !strings.HasSuffix(quote, "…") || len([]rune(quote)) != maxQuotedRunes+3 {
+		t.Fatalf("long quote not truncated: %d runes", len([]rune(quote)))
+	}
+}
@@ -0,0 +1,177 @@
+package channels
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const fenceMarker = "```"
+
+// isFenceLine reports whether line opens or closes a fenced code block.
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), fenceMarker)
+}
+
+// LabelCodeFences adds a detected language to fenced code blocks that have
+// none, so platforms that highlight code can do so. Labeled fences and code
+// that matches no heuristic are left as they are.
+func LabelCodeFences(text string) string {
+	if !strings.Contains(text, fenceMarker) {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		if !isFenceLine(lines[i]) {
+			continue
+		}
+		open := i
+		end := -1
+		for j := i + 1; j < len(lines); j++ {
+			if isFenceLine(lines[j]) {
+				end = j
+				break
+			}
+		}
+		if end < 0 {
+			break
+		}
+		if strings.TrimSpace(lines[open]) == fenceMarker {
+			if lang := DetectCodeLanguage(strings.Join(lines[open+1:end], "\n")); lang != "" {
+				indent := lines[open][:strings.Index(lines[open], fenceMarker)]
+				lines[open] = indent + fenceMarker + lang
+			}
+		}
+		i = end
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeLanguageRules are checked in order; the first match wins. They favor
+// precision: a wrong label highlights worse than none.
+var codeLanguageRules = []struct {
+	lang string
+	re   *regexp.Regexp
+}{
+	{"diff", regexp.MustCompile(`(?m)^(--- \S.*\n\+\+\+ \S|@@ -\d+(,\d+)? \+\d+(,\d+)? @@)`)},
+	{"go", regexp.MustCompile(`(?m)^package \w+\s*$|^func (\(\w+ \*?\w+\) )?\w+\(.*\)[^\n]*\{\s*$|\w+ := `)},
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+.*\{|\blet mut \w+|println!\(|^use \w+(::\w+)+;`)},
+	{"python", regexp.MustCompile(`(?m)^\s*(def|class) \w+.*:\s*$|^\s*(from \w+(\.\w+)* )?import \w+[^;{]*$|^\s*print\(|^\s*if __name__ == `)},
+	{"typescript", regexp.MustCompile(`(?m)^\s*(export )?(interface|type) \w+\s*(=|\{)|\b(const|let) \w+: \w+`)},
+	{"javascript", regexp.MustCompile(`(?m)\bconsole\.log\(|^\s*(const|let|var) \w+ = |\bfunction \w*\(|=> \{|\brequire\(['"]`)},
+	{"java", regexp.MustCompile(`(?m)\bpublic (static )?(class|void|final) \w+|System\.out\.println`)},
+	{"c", regexp.MustCompile(`(?m)^#include [<"]|\bint main\(`)},
+	{"sql", regexp.MustCompile(`(?im)^\s*(SELECT .+ FROM |INSERT INTO |UPDATE \w+ SET |DELETE FROM |CREATE (TABLE|INDEX|VIEW) |ALTER TABLE )`)},
+	{"html", regexp.MustCompile(`(?i)^\s*(<!DOCTYPE html|<html|<div|<head|<body)`)},
+	{"dockerfile", regexp.MustCompile(`(?m)^FROM \S+`)},
+	{"bash", regexp.MustCompile(`(?m)^#!/(usr/)?bin/(env )?(ba|z)?sh|^\$ \S|^\s*(sudo|apt(-get)?|brew|npm|pnpm|yarn|pip3?|go|git|docker|kubectl|curl|cd|export|mkdir|chmod) \S`)},
+	{"yaml", regexp.MustCompile(`(?m)\A(---\n)?(\s*(- )?[\w.-]+:( .*)?\n){2,}`)},
+}
+
+// DetectCodeLanguage guesses the language of a code snippet for a fence
+// label. It returns "" when unsure.
+func DetectCodeLanguage(code string) string {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ""
+	}
+	if (code[0] == '{' || code[0] == '[') && json.Valid([]byte(code)) {
+		return "json"
+	}
+	for _, r := range codeLanguageRules {
+		if r.re.MatchString(code + "\n") {
+			return r.lang
+		}
+	}
+	return ""
+}
+
+// SplitMessage splits text into chunks of at most maxRunes runes, breaking
+// at line boundaries where possible. A code block cut by a boundary is
+// closed at the end of one chunk and reopened, with its language label, at
+// the start of the next, so each chunk renders on its own.
+func SplitMessage(text string, maxRunes int) []string {
+	if maxRunes <= 0 || utf8.RuneCountInString(text) <= maxRunes {
+		return []string{text}
+	}
+	closing := "\n" + fenceMarker
+	var (
+		chunks []string
+		cur    strings.Builder
+		curLen int
+		// fence is the opening line of the code block open at the end of cur.
+		fence     string
+		headerLen int
+	)
+	emit := func(s string) {
+		if s = strings.TrimRight(s, "\n"); strings.TrimSpace(s) != "" {
+			chunks = append(chunks, s)
+		}
+	}
+	flush := func() {
+		s := cur.String()
+		if fence != "" {
+			s += closing
+		}
+		emit(s)
+		cur.Reset()
+		curLen, headerLen = 0, 0
+		if fence != "" {
+			cur.WriteString(fence)
+			curLen = utf8.RuneCountInString(fence)
+			headerLen = curLen
+		}
+	}
+	add := func(line string, n int) {
+		next := fence
+		if isFenceLine(line) {
+			if fence == "" {
+				next = strings.TrimSpace(line)
+			} else {
+				next = ""
+			}
+		}
+		reserve := 0
+		if next != "" {
+			reserve = len(closing)
+		}
+		sep := 0
+		if curLen > 0 {
+			sep = 1
+		}
+		if curLen > headerLen && curLen+sep+n+reserve > maxRunes {
+			flush()
+			sep = 0
+			if curLen > 0 {
+				sep = 1
+			}
+		}
+		if sep == 1 {
+			cur.WriteByte('\n')
+		}
+		cur.WriteString(line)
+		curLen += sep + n
+		fence = next
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		n := utf8.RuneCountInString(line)
+		// Hard-split lines that cannot fit even in an empty chunk.
+		limit := maxRunes
+		if fence != "" {
+			limit -= utf8.RuneCountInString(fence) + 1 + len(closing)
+		}
+		if limit < 1 {
+			limit = maxRunes
+		}
+		for n > limit {
+			r := []rune(line)
+			add(string(r[:limit]), limit)
+			line, n = string(r[limit:]), n-limit
+		}
+		add(line, n)
+	}
+	emit(cur.String())
+	return chunks
+}
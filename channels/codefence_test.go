@@ -0,0 +1,84 @@
+package channels
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDetectCodeLanguage(t *testing.T) {
+	cases := map[string]string{
+		"package main\n\nfunc main() {\n}":                     "go",
+		"def greet(name):\n    return f\"hi {name}\"":          "python",
+		`{"a": 1, "b": [true]}`:                                "json",
+		"const x = require('fs');\nconsole.log(x)":             "javascript",
+		"interface User {\n  name: string\n}":                  "typescript",
+		"#!/bin/bash\nset -e\necho hi":                         "bash",
+		"go test ./...":                                        "bash",
+		"SELECT id, name FROM users WHERE id = 1;":             "sql",
+		"fn main() {\n    println!(\"hi\");\n}":                "rust",
+		"--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b":          "diff",
+		"name: clawlet\nversion: 1\n":                          "yaml",
+		"#include <stdio.h>\nint main() { return 0; }":         "c",
+		"FROM golang:1.24\nRUN go build ./...":                 "dockerfile",
+		"Just some words, nothing code-like about them at all": "",
+	}
+	for code, want := range cases {
+		if got := DetectCodeLanguage(code); got != want {
+			t.Errorf("DetectCodeLanguage(%q)=%q want %q", code, got, want)
+		}
+	}
+}
+
+func TestLabelCodeFences(t *testing.T) {
+	in := "Try:\n```\npackage main\n```\nor\n```sh\nls\n```\nand\n```\nhello there\n```"
+	want := "Try:\n```go\npackage main\n```\nor\n```sh\nls\n```\nand\n```\nhello there\n```"
+	if got := LabelCodeFences(in); got != want {
+		t.Fatalf("got %q", got)
+	}
+	if got := LabelCodeFences("unterminated\n```\npackage main"); got != "unterminated\n```\npackage main" {
+		t.Fatalf("unterminated fence changed: %q", got)
+	}
+}
+
+func TestSplitMessage_ReopensFences(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Here is the code:\n```go\n")
+	for i := range 40 {
+		b.WriteString("fmt.Println(\"line ")
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString("\")\n")
+	}
+	b.WriteString("```\nDone.")
+	chunks := SplitMessage(b.String(), 200)
+	if len(chunks) < 3 {
+		t.Fatalf("chunks=%d", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 200 {
+			t.Fatalf("chunk %d has %d runes", i, n)
+		}
+		if strings.Count(c, "```")%2 != 0 {
+			t.Fatalf("chunk %d has an unbalanced fence:\n%s", i, c)
+		}
+		if i > 0 && i < len(chunks)-1 && !strings.HasPrefix(c, "```go\n") {
+			t.Fatalf("chunk %d does not reopen the fence:\n%s", i, c)
+		}
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "```\nDone.") {
+		t.Fatalf("last chunk=%q", chunks[len(chunks)-1])
+	}
+	if got := strings.ReplaceAll(strings.Join(chunks, "\n"), "\n```\n```go", ""); got != b.String() {
+		t.Fatalf("content lost:\n%s", got)
+	}
+}
+
+func TestSplitMessage_ShortAndLongLines(t *testing.T) {
+	if got := SplitMessage("short", 100); len(got) != 1 || got[0] != "short" {
+		t.Fatalf("got %q", got)
+	}
+	got := SplitMessage(strings.Repeat("é", 250), 100)
+	if len(got) != 3 || utf8.RuneCountInString(got[0]) != 100 || utf8.RuneCountInString(got[2]) != 50 {
+		t.Fatalf("got %d chunks", len(got))
+	}
+}
@@ -0,0 +1,173 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordApprovalPrefix starts the custom ID of Approve/Deny buttons:
+// "clawlet-approval:<approve|deny>:<id>".
+const discordApprovalPrefix = "clawlet-approval:"
+
+// RequestApproval posts prompt to chID with Approve and Deny buttons and
+// waits for an allowed user to press one. When cfg.ApprovalTimeout passes
+// first the call is denied and the buttons are removed.
+func (c *Channel) RequestApproval(ctx context.Context, chID, prompt string) (bool, error) {
+	c.mu.Lock()
+	dg := c.dg
+	c.mu.Unlock()
+	if dg == nil {
+		return false, fmt.Errorf("discord not connected")
+	}
+	chID = strings.TrimSpace(chID)
+	if chID == "" {
+		return false, fmt.Errorf("chat_id is empty")
+	}
+	if n := len([]rune(prompt)); n > discordMaxMessageRunes-100 {
+		// A cut-off prompt would hide part of what is being approved.
+		return false, fmt.Errorf("approval prompt is too long (%d characters)", n)
+	}
+
+	id := fmt.Sprintf("%d-%d", time.Now().UnixNano(), c.approvalSeq.Add(1))
+	answer := make(chan bool, 1)
+	c.mu.Lock()
+	if c.approvals == nil {
+		c.approvals = map[string]chan bool{}
+	}
+	c.approvals[id] = answer
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.approvals, id)
+		c.mu.Unlock()
+	}()
+
+	msg, err := dg.ChannelMessageSendComplex(chID, &discordgo.MessageSend{
+		Content:         prompt,
+		Components:      discordApprovalComponents(id),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return false, fmt.Errorf("post approval prompt: %w", err)
+	}
+
+	timer := time.NewTimer(c.cfg.ApprovalTimeout())
+	defer timer.Stop()
+	select {
+	case ok := <-answer:
+		return ok, nil
+	case <-timer.C:
+		c.closeApproval(dg, chID, msg.ID, prompt+"\n\nTimed out; denied.")
+		return false, nil
+	case <-ctx.Done():
+		c.closeApproval(dg, chID, msg.ID, prompt+"\n\nCancelled.")
+		return false, ctx.Err()
+	}
+}
+
+// closeApproval replaces an unanswered prompt with content and drops its
+// buttons.
+func (c *Channel) closeApproval(dg *discordgo.Session, chID, msgID, content string) {
+	_, err := dg.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         msgID,
+		Channel:    chID,
+		Content:    &content,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("discord: close approval prompt: %v", err)
+	}
+}
+
+func (c *Channel) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil || i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	id, approve, ok := parseDiscordApprovalID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	user := i.User
+	if i.Member != nil && i.Member.User != nil {
+		user = i.Member.User
+	}
+	if user == nil || !c.allow.Allowed(user.ID) {
+		c.respondInteraction(s, i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "You are not allowed to answer this.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	c.mu.Lock()
+	answer := c.approvals[id]
+	delete(c.approvals, id)
+	c.mu.Unlock()
+
+	prompt := ""
+	if i.Message != nil {
+		prompt = i.Message.Content
+	}
+	var outcome string
+	switch {
+	case answer == nil:
+		outcome = "This request has expired."
+	case approve:
+		outcome = fmt.Sprintf("Approved by <@%s>.", user.ID)
+	default:
+		outcome = fmt.Sprintf("Denied by <@%s>.", user.ID)
+	}
+	if answer != nil {
+		answer <- approve
+	}
+	c.respondInteraction(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         strings.TrimSpace(prompt + "\n\n" + outcome),
+			Components:      []discordgo.MessageComponent{},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (c *Channel) respondInteraction(s *discordgo.Session, i *discordgo.Interaction, resp *discordgo.InteractionResponse) {
+	if err := s.InteractionRespond(i, resp); err != nil {
+		log.Printf("discord: respond to interaction: %v", err)
+	}
+}
+
+func discordApprovalComponents(id string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Approve", Style: discordgo.SuccessButton, CustomID: discordApprovalPrefix + "approve:" + id},
+			discordgo.Button{Label: "Deny", Style: discordgo.DangerButton, CustomID: discordApprovalPrefix + "deny:" + id},
+		}},
+	}
+}
+
+// parseDiscordApprovalID reads a custom ID made by discordApprovalComponents.
+func parseDiscordApprovalID(customID string) (id string, approve, ok bool) {
+	rest, found := strings.CutPrefix(customID, discordApprovalPrefix)
+	if !found {
+		return "", false, false
+	}
+	action, id, found := strings.Cut(rest, ":")
+	if !found || id == "" {
+		return "", false, false
+	}
+	switch action {
+	case "approve":
+		return id, true, true
+	case "deny":
+		return id, false, true
+	}
+	return "", false, false
+}
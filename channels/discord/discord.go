@@ -1,6 +1,7 @@
 package discord
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -18,6 +19,9 @@ import (
 	"github.com/mosaxiv/clawlet/config"
 )
 
+// discordMaxMessageRunes is Discord's message content limit.
+const discordMaxMessageRunes = 2000
+
 type Channel struct {
 	cfg   config.DiscordConfig
 	bus   *bus.Bus
@@ -29,6 +33,9 @@ type Channel struct {
 	dg  *discordgo.Session
 	hc  *http.Client
 	ctx context.Context
+	// approvals holds the pending RequestApproval answers by approval ID.
+	approvals   map[string]chan bool
+	approvalSeq atomic.Uint64
 }
 
 func New(cfg config.DiscordConfig, b *bus.Bus) *Channel {
@@ -61,6 +68,7 @@ func (c *Channel) Start(ctx context.Context) error {
 		dg.Identify.Intents = discordgo.Intent(c.cfg.Intents)
 	}
 	dg.AddHandler(c.onMessageCreate)
+	dg.AddHandler(c.onInteractionCreate)
 
 	c.mu.Lock()
 	c.dg = dg
@@ -100,10 +108,10 @@ func (c *Channel) Stop() error {
 func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 	chID := strings.TrimSpace(msg.ChatID)
 	if chID == "" {
-		return fmt.Errorf("chat_id is empty")
+		return channels.Undeliverable(errors.New("chat_id is empty"))
 	}
 	content := strings.TrimSpace(msg.Content)
-	if content == "" {
+	if content == "" && len(msg.Attachments) == 0 {
 		return nil
 	}
 
@@ -122,10 +130,54 @@ func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 	default:
 	}
 
+	// Long replies are split; only the first chunk replies to the user's
+	// message.
 	replyToID := resolveDiscordReplyTarget(msg)
+	var chunks []string
+	if content != "" {
+		chunks = channels.SplitMessage(content, discordMaxMessageRunes)
+	}
+	for i, chunk := range chunks {
+		if err := c.sendWithRetry(ctx, dg, chID, chunk, replyToID, discordNonce(msg.IdempotencyKey, i)); err != nil {
+			return discordSendError(err)
+		}
+		replyToID = ""
+	}
+	for _, att := range msg.Attachments {
+		if err := sendDiscordFile(dg, chID, att, replyToID); err != nil {
+			return fmt.Errorf("upload %s: %w", channels.AttachmentName(att), err)
+		}
+		replyToID = ""
+	}
+	return nil
+}
+
+// MaxUploadBytes implements channels.Uploader.
+func (c *Channel) MaxUploadBytes() int64 {
+	return cmp.Or(c.cfg.MaxUploadBytes, config.DefaultDiscordMaxUploadBytes)
+}
+
+func sendDiscordFile(dg *discordgo.Session, chID string, att bus.Attachment, replyToID string) error {
+	rc, err := channels.OpenAttachment(att)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	send := &discordgo.MessageSend{
+		Files:           []*discordgo.File{{Name: channels.AttachmentName(att), ContentType: att.MIMEType, Reader: rc}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if replyToID != "" {
+		send.Reference = &discordgo.MessageReference{MessageID: replyToID, ChannelID: chID}
+	}
+	_, err = dg.ChannelMessageSendComplex(chID, send)
+	return err
+}
+
+func (c *Channel) sendWithRetry(ctx context.Context, dg *discordgo.Session, chID, content, replyToID, nonce string) error {
 	const maxAttempts = 3
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		err := sendDiscordMessage(dg, chID, content, replyToID)
+		err := sendDiscordMessage(dg, chID, content, replyToID, nonce)
 		if err == nil {
 			return nil
 		}
@@ -158,9 +210,13 @@ func (c *Channel) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCrea
 	chID := strings.TrimSpace(m.ChannelID)
 	content := strings.TrimSpace(m.Content)
 	attachments := discordInboundAttachments(m)
+	content, attachments = channels.LimitAttachments(content, attachments, c.cfg.ChannelAttachmentConfig)
 	if chID == "" || (content == "" && len(attachments) == 0) {
 		return
 	}
+	if m.ReferencedMessage != nil {
+		content = channels.QuoteReply(m.ReferencedMessage.Content, content)
+	}
 
 	ctx := context.Background()
 	c.mu.Lock()
@@ -177,6 +233,7 @@ func (c *Channel) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCrea
 		Attachments: attachments,
 		SessionKey:  "discord:" + chID,
 		Delivery:    buildDiscordDelivery(m),
+		SentAt:      m.Timestamp,
 	})
 }
 
@@ -233,21 +290,55 @@ func buildDiscordDelivery(m *discordgo.MessageCreate) bus.Delivery {
 	return d
 }
 
-func sendDiscordMessage(dg *discordgo.Session, chID, content, replyToID string) error {
-	if replyToID == "" {
-		_, err := dg.ChannelMessageSend(chID, content)
-		return err
+// discordOutgoingMessage is the create-message body. discordgo's
+// MessageSend has no nonce fields, so this is posted directly.
+type discordOutgoingMessage struct {
+	Content         string                            `json:"content"`
+	Reference       *discordgo.MessageReference       `json:"message_reference,omitempty"`
+	AllowedMentions *discordgo.MessageAllowedMentions `json:"allowed_mentions,omitempty"`
+	// With enforce_nonce, Discord returns the original message instead of
+	// posting again when a retry reuses the nonce within a few minutes.
+	Nonce        string `json:"nonce,omitempty"`
+	EnforceNonce bool   `json:"enforce_nonce,omitempty"`
+}
+
+func buildDiscordMessage(chID, content, replyToID, nonce string) discordOutgoingMessage {
+	m := discordOutgoingMessage{Content: content, Nonce: nonce, EnforceNonce: nonce != ""}
+	if replyToID != "" {
+		m.Reference = &discordgo.MessageReference{MessageID: replyToID, ChannelID: chID}
+		m.AllowedMentions = &discordgo.MessageAllowedMentions{RepliedUser: false}
+	}
+	return m
+}
+
+func sendDiscordMessage(dg *discordgo.Session, chID, content, replyToID, nonce string) error {
+	endpoint := discordgo.EndpointChannelMessages(chID)
+	_, err := dg.RequestWithBucketID(http.MethodPost, endpoint, buildDiscordMessage(chID, content, replyToID, nonce), endpoint)
+	return err
+}
+
+// discordNonce fits an idempotency key into Discord's 25-character nonce.
+// Chunks after the first of a split message get their own suffixed nonce.
+func discordNonce(key string, part int) string {
+	if key != "" && part > 0 {
+		suffix := fmt.Sprintf("-%d", part)
+		key = key[:min(len(key), 25-len(suffix))] + suffix
+	}
+	if len(key) > 25 {
+		return key[:25]
+	}
+	return key
+}
+
+// discordSendError marks 4xx responses other than 429 (unknown channel,
+// missing access, invalid message) as undeliverable.
+func discordSendError(err error) error {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		if code := restErr.Response.StatusCode; code >= 400 && code < 500 && code != http.StatusTooManyRequests {
+			return channels.Undeliverable(err)
+		}
 	}
-	_, err := dg.ChannelMessageSendComplex(chID, &discordgo.MessageSend{
-		Content: content,
-		Reference: &discordgo.MessageReference{
-			MessageID: replyToID,
-			ChannelID: chID,
-		},
-		AllowedMentions: &discordgo.MessageAllowedMentions{
-			RepliedUser: false,
-		},
-	})
 	return err
 }
 
@@ -2,12 +2,14 @@ package discord
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/channels"
 )
 
 func TestResolveDiscordReplyTarget(t *testing.T) {
@@ -140,3 +142,47 @@ func TestDiscordInboundAttachments(t *testing.T) {
 		t.Fatalf("unexpected kinds: %+v", got)
 	}
 }
+
+func TestBuildDiscordMessage(t *testing.T) {
+	m := buildDiscordMessage("C1", "hi", "", discordNonce("0123456789abcdef0123456789abcdef", 0))
+	if m.Nonce != "0123456789abcdef012345678" || !m.EnforceNonce || m.Reference != nil {
+		t.Fatalf("message=%+v", m)
+	}
+	if n := discordNonce("0123456789abcdef0123456789abcdef", 2); n != "0123456789abcdef0123456-2" {
+		t.Fatalf("chunk nonce=%q", n)
+	}
+	m = buildDiscordMessage("C1", "hi", "M1", "")
+	if m.Nonce != "" || m.EnforceNonce || m.Reference == nil || m.Reference.MessageID != "M1" || m.AllowedMentions == nil {
+		t.Fatalf("reply=%+v", m)
+	}
+}
+
+func TestDiscordApprovalComponents(t *testing.T) {
+	row, ok := discordApprovalComponents("42-1")[0].(discordgo.ActionsRow)
+	if !ok || len(row.Components) != 2 {
+		t.Fatalf("components=%#v", row)
+	}
+	for i, want := range []bool{true, false} {
+		b := row.Components[i].(discordgo.Button)
+		id, approve, ok := parseDiscordApprovalID(b.CustomID)
+		if !ok || id != "42-1" || approve != want {
+			t.Fatalf("%s: id=%q approve=%v ok=%v", b.Label, id, approve, ok)
+		}
+	}
+	for _, bad := range []string{"", "other:approve:1", discordApprovalPrefix + "approve:", discordApprovalPrefix + "maybe:1"} {
+		if _, _, ok := parseDiscordApprovalID(bad); ok {
+			t.Fatalf("%q should not parse", bad)
+		}
+	}
+}
+
+func TestDiscordSendError(t *testing.T) {
+	missing := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"}}
+	if !errors.Is(discordSendError(missing), channels.ErrUndeliverable) {
+		t.Fatal("404 should be undeliverable")
+	}
+	busy := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}}
+	if errors.Is(discordSendError(busy), channels.ErrUndeliverable) {
+		t.Fatal("5xx should be retried")
+	}
+}
@@ -0,0 +1,139 @@
+package channels
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileLinksPath is the route FileLinks serves under.
+const FileLinksPath = "/files/"
+
+// FileLinks hands out temporary signed download links for files too large
+// to upload to a channel, and serves them. Links live in memory, so they
+// stop working when the gateway restarts.
+type FileLinks struct {
+	baseURL string
+	ttl     time.Duration
+	secret  []byte
+
+	mu    sync.Mutex
+	files map[string]linkedFile
+}
+
+type linkedFile struct {
+	path     string
+	name     string
+	mimeType string
+	expires  time.Time
+}
+
+// NewFileLinks returns FileLinks whose URLs start with baseURL, the
+// gateway's public address, and expire after ttl.
+func NewFileLinks(baseURL string, ttl time.Duration) (*FileLinks, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("file links need a public URL")
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &FileLinks{baseURL: baseURL, ttl: ttl, secret: secret, files: map[string]linkedFile{}}, nil
+}
+
+// TTL is how long a link stays valid.
+func (l *FileLinks) TTL() time.Duration {
+	return l.ttl
+}
+
+// Add registers the file at path and returns its download URL. The file is
+// read when the link is opened, not copied.
+func (l *FileLinks) Add(path, name, mimeType string, now time.Time) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+	exp := now.Add(l.ttl)
+
+	l.mu.Lock()
+	for k, f := range l.files {
+		if !now.Before(f.expires) {
+			delete(l.files, k)
+		}
+	}
+	l.files[id] = linkedFile{path: path, name: name, mimeType: mimeType, expires: exp}
+	l.mu.Unlock()
+
+	exps := strconv.FormatInt(exp.Unix(), 10)
+	return fmt.Sprintf("%s%s%s/%s?exp=%s&sig=%s", l.baseURL, FileLinksPath, id, url.PathEscape(name), exps, l.sign(id, exps)), nil
+}
+
+func (l *FileLinks) sign(id, exp string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(id + "\x00" + exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves GET /files/<id>/<name>?exp=&sig= while the link is valid.
+func (l *FileLinks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, FileLinksPath), "/")
+	exp := r.URL.Query().Get("exp")
+	sig := r.URL.Query().Get("sig")
+	if id == "" || !hmac.Equal([]byte(sig), []byte(l.sign(id, exp))) {
+		http.NotFound(w, r)
+		return
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(expUnix, 0)) {
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	}
+	l.mu.Lock()
+	f, ok := l.files[id]
+	l.mu.Unlock()
+	if !ok {
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	}
+	fh, err := os.Open(f.path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer fh.Close()
+	fi, err := fh.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+	ct := f.mimeType
+	if ct == "" {
+		ct = mime.TypeByExtension(filepath.Ext(f.name))
+	}
+	if ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.name}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, r, f.name, fi.ModTime(), fh)
+}
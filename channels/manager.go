@@ -6,18 +6,54 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/mosaxiv/clawlet/activity"
 	"github.com/mosaxiv/clawlet/bus"
 )
 
 type Manager struct {
 	bus      *bus.Bus
 	channels map[string]Channel
+	activity *activity.Hub
 
 	mu                 sync.RWMutex
 	running            bool
 	stopOnce           sync.Once
 	lastErrorByChannel map[string]string
+
+	// dedupWindow suppresses a message whose idempotency key was sent this
+	// recently. sent is only touched by dispatchOutbound.
+	dedupWindow time.Duration
+	sent        map[string]time.Time
+
+	labelCode bool
+	fileLinks *FileLinks
+
+	// retryBase is the wait before the first resend of a failed message; it
+	// doubles after each failure.
+	retryBase time.Duration
+}
+
+const (
+	// outboundMaxAttempts is how many failed sends, across restarts, a
+	// message gets before it is dropped from the outbound store.
+	outboundMaxAttempts = 5
+	// outboundRetryMaxDelay caps the wait between resends.
+	outboundRetryMaxDelay = 5 * time.Minute
+)
+
+// ErrUndeliverable marks a send error that retrying cannot fix, such as a
+// deleted chat or a bot removed from it. Channels wrap such errors with
+// Undeliverable so the message is dropped instead of resent.
+var ErrUndeliverable = errors.New("message cannot be delivered")
+
+// Undeliverable wraps err with ErrUndeliverable.
+func Undeliverable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrUndeliverable, err)
 }
 
 func NewManager(b *bus.Bus) *Manager {
@@ -25,9 +61,59 @@ func NewManager(b *bus.Bus) *Manager {
 		bus:                b,
 		channels:           map[string]Channel{},
 		lastErrorByChannel: map[string]string{},
+		retryBase:          2 * time.Second,
 	}
 }
 
+// SetActivity publishes an event for every outbound message sent. Call it
+// before StartAll.
+func (m *Manager) SetActivity(h *activity.Hub) {
+	m.activity = h
+}
+
+// SetDedupWindow drops outbound messages whose idempotency key was already
+// sent within d. 0 disables it. Call it before StartAll.
+func (m *Manager) SetDedupWindow(d time.Duration) {
+	m.dedupWindow = d
+}
+
+// SetCodeLanguageDetection labels unlabeled code fences in outbound
+// messages (see LabelCodeFences). Call it before StartAll.
+func (m *Manager) SetCodeLanguageDetection(on bool) {
+	m.labelCode = on
+}
+
+// SetFileLinks shares attachments too large for a channel as download links
+// from l. Without it they are left out with a note. Call it before StartAll.
+func (m *Manager) SetFileLinks(l *FileLinks) {
+	m.fileLinks = l
+}
+
+// sentRecently reports whether key was sent within the dedup window and
+// forgets keys that have left it.
+func (m *Manager) sentRecently(key string, now time.Time) bool {
+	if m.dedupWindow <= 0 || key == "" {
+		return false
+	}
+	for k, at := range m.sent {
+		if now.Sub(at) > m.dedupWindow {
+			delete(m.sent, k)
+		}
+	}
+	_, ok := m.sent[key]
+	return ok
+}
+
+func (m *Manager) markSent(key string, now time.Time) {
+	if m.dedupWindow <= 0 || key == "" {
+		return
+	}
+	if m.sent == nil {
+		m.sent = map[string]time.Time{}
+	}
+	m.sent[key] = now
+}
+
 func (m *Manager) Add(ch Channel) {
 	if ch == nil {
 		return
@@ -106,6 +192,46 @@ func (m *Manager) Status() map[string]map[string]any {
 	return out
 }
 
+func (m *Manager) ackOutbound(msg bus.OutboundMessage) {
+	if err := m.bus.AckOutbound(msg); err != nil {
+		log.Printf("channels: outbound ack failed: %v", err)
+	}
+}
+
+// retryOutbound resends a failed message after an exponential backoff.
+// Undeliverable errors and messages that failed outboundMaxAttempts times
+// are dropped from the outbound store instead, and only then is the failure
+// reported to TrackDelivery, so a later successful resend still counts as
+// delivered. On shutdown the message is left in the store for the next start.
+func (m *Manager) retryOutbound(ctx context.Context, msg bus.OutboundMessage, sendErr error) {
+	if ctx.Err() != nil {
+		return
+	}
+	msg, err := m.bus.FailOutbound(msg)
+	if err != nil {
+		log.Printf("channels: outbound store update failed: %v", err)
+	}
+	if errors.Is(sendErr, ErrUndeliverable) || msg.Attempts() >= outboundMaxAttempts {
+		log.Printf("channels: dropping outbound to %s:%s after %d failed attempts: %v", msg.Channel, msg.ChatID, msg.Attempts(), sendErr)
+		m.ackOutbound(msg)
+		m.bus.ReportDelivery(msg.TrackingID, sendErr)
+		return
+	}
+	wait := min(m.retryBase<<(msg.Attempts()-1), outboundRetryMaxDelay)
+	go func() {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := m.bus.PublishOutbound(ctx, msg); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("channels: outbound resend failed: %v", err)
+		}
+	}()
+}
+
 func (m *Manager) dispatchOutbound(ctx context.Context) {
 	for {
 		msg, err := m.bus.ConsumeOutbound(ctx)
@@ -117,12 +243,44 @@ func (m *Manager) dispatchOutbound(ctx context.Context) {
 		m.mu.RUnlock()
 		if ch == nil {
 			// Unknown channel; drop.
+			m.ackOutbound(msg)
+			m.bus.ReportDelivery(msg.TrackingID, fmt.Errorf("channel not found: %s", msg.Channel))
+			continue
+		}
+		if m.sentRecently(msg.IdempotencyKey, time.Now()) {
+			log.Printf("channels: dropping duplicate outbound to %s:%s", msg.Channel, msg.ChatID)
+			m.ackOutbound(msg)
+			m.bus.ReportDelivery(msg.TrackingID, nil)
 			continue
 		}
-		if err := ch.Send(ctx, msg); err != nil && !errors.Is(err, context.Canceled) {
-			m.setChannelError(msg.Channel, err.Error())
-			log.Printf("channels: outbound send failed via %s: %v", msg.Channel, err)
+		orig := msg
+		if len(msg.Attachments) > 0 {
+			var limit int64
+			if u, ok := ch.(Uploader); ok {
+				limit = u.MaxUploadBytes()
+			}
+			msg = FitAttachments(msg, limit, m.fileLinks, time.Now())
 		}
+		if m.labelCode {
+			msg.Content = LabelCodeFences(msg.Content)
+		}
+		if err := ch.Send(ctx, msg); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				m.setChannelError(msg.Channel, err.Error())
+				log.Printf("channels: outbound send failed via %s: %v", msg.Channel, err)
+			}
+			m.retryOutbound(ctx, orig, err)
+			continue
+		}
+		m.markSent(msg.IdempotencyKey, time.Now())
+		m.ackOutbound(msg)
+		m.bus.ReportDelivery(msg.TrackingID, nil)
+		m.activity.Publish(activity.Event{
+			Kind:    activity.KindOutbound,
+			Channel: msg.Channel,
+			ChatID:  msg.ChatID,
+			Text:    msg.Content,
+		})
 	}
 }
 
@@ -136,6 +294,23 @@ func (m *Manager) Require(name string) (Channel, error) {
 	return ch, nil
 }
 
+// ErrApprovalUnavailable is returned by RequestApproval for channels that
+// cannot ask for approval.
+var ErrApprovalUnavailable = errors.New("approval is not available in this chat")
+
+// RequestApproval asks chatID on channel to approve prompt.
+func (m *Manager) RequestApproval(ctx context.Context, channel, chatID, prompt string) (bool, error) {
+	ch, err := m.Require(channel)
+	if err != nil {
+		return false, ErrApprovalUnavailable
+	}
+	a, ok := ch.(Approver)
+	if !ok || !ch.IsRunning() {
+		return false, ErrApprovalUnavailable
+	}
+	return a.RequestApproval(ctx, chatID, prompt)
+}
+
 func (m *Manager) setChannelError(name, msg string) {
 	if name == "" {
 		return
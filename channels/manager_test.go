@@ -3,6 +3,8 @@ package channels
 import (
 	"context"
 	"errors"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -94,3 +96,190 @@ func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
 	}
 	t.Fatal("condition not met in time")
 }
+
+func TestManagerDispatchOutbound_ReportsTrackedDelivery(t *testing.T) {
+	b := bus.New(16)
+	m := NewManager(b)
+	// A failed send is reported once its retries are used up.
+	m.retryBase = time.Millisecond
+	sendErr := errors.New("send failed")
+	m.Add(&stubChannel{name: "ok"})
+	m.Add(&stubChannel{name: "bad", sendErr: sendErr})
+
+	ctx := t.Context()
+	if err := m.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll returned error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		channel string
+		want    func(error) bool
+	}{
+		{"ok", func(err error) bool { return err == nil }},
+		{"bad", func(err error) bool { return errors.Is(err, sendErr) }},
+		{"missing", func(err error) bool { return err != nil }},
+	} {
+		result, cancel := b.TrackDelivery("t-" + tc.channel)
+		if err := b.PublishOutbound(ctx, bus.OutboundMessage{Channel: tc.channel, ChatID: "c1", Content: "hi", TrackingID: "t-" + tc.channel}); err != nil {
+			t.Fatalf("PublishOutbound failed: %v", err)
+		}
+		select {
+		case err := <-result:
+			if !tc.want(err) {
+				t.Fatalf("%s: unexpected result %v", tc.channel, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: no delivery result", tc.channel)
+		}
+		cancel()
+	}
+}
+
+type countingChannel struct {
+	stubChannel
+	sends chan bus.OutboundMessage
+}
+
+func (c *countingChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	c.sends <- msg
+	return nil
+}
+
+func TestManagerDispatchOutbound_DropsDuplicates(t *testing.T) {
+	b := bus.New(16)
+	m := NewManager(b)
+	m.SetDedupWindow(time.Minute)
+	ch := &countingChannel{stubChannel: stubChannel{name: "stub"}, sends: make(chan bus.OutboundMessage, 4)}
+	m.Add(ch)
+
+	ctx := t.Context()
+	if err := m.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll returned error: %v", err)
+	}
+	msg := bus.OutboundMessage{Channel: "stub", ChatID: "c1", Content: "hi", IdempotencyKey: "k1"}
+	for range 2 {
+		if err := b.PublishOutbound(ctx, msg); err != nil {
+			t.Fatalf("PublishOutbound failed: %v", err)
+		}
+	}
+	msg.IdempotencyKey = "k2"
+	if err := b.PublishOutbound(ctx, msg); err != nil {
+		t.Fatalf("PublishOutbound failed: %v", err)
+	}
+
+	var keys []string
+	for len(keys) < 2 {
+		select {
+		case got := <-ch.sends:
+			keys = append(keys, got.IdempotencyKey)
+		case <-time.After(time.Second):
+			t.Fatalf("sent=%v", keys)
+		}
+	}
+	if keys[0] != "k1" || keys[1] != "k2" {
+		t.Fatalf("sent=%v", keys)
+	}
+	select {
+	case got := <-ch.sends:
+		t.Fatalf("unexpected extra send %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManagerDispatchOutbound_LabelsCodeFences(t *testing.T) {
+	b := bus.New(16)
+	m := NewManager(b)
+	m.SetCodeLanguageDetection(true)
+	ch := &countingChannel{stubChannel: stubChannel{name: "stub"}, sends: make(chan bus.OutboundMessage, 1)}
+	m.Add(ch)
+
+	ctx := t.Context()
+	if err := m.StartAll(ctx); err != nil {
+		t.Fatalf("StartAll returned error: %v", err)
+	}
+	if err := b.PublishOutbound(ctx, bus.OutboundMessage{Channel: "stub", ChatID: "c1", Content: "```\npackage main\n```"}); err != nil {
+		t.Fatalf("PublishOutbound failed: %v", err)
+	}
+	select {
+	case got := <-ch.sends:
+		if got.Content != "```go\npackage main\n```" {
+			t.Fatalf("content=%q", got.Content)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no send")
+	}
+}
+
+type failingChannel struct {
+	stubChannel
+	mu    sync.Mutex
+	sends int
+	// fail returns the error for the nth send (1-based).
+	fail func(n int) error
+}
+
+func (c *failingChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sends++
+	return c.fail(c.sends)
+}
+
+func (c *failingChannel) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sends
+}
+
+func TestManagerDispatchOutbound_RetriesFailedSends(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		fail      func(n int) error
+		wantSends int
+		delivered bool
+	}{
+		{"always fails", func(int) error { return errors.New("timeout") }, outboundMaxAttempts, false},
+		{"undeliverable", func(int) error { return Undeliverable(errors.New("chat not found")) }, 1, false},
+		{"recovers", func(n int) error {
+			if n < 3 {
+				return errors.New("timeout")
+			}
+			return nil
+		}, 3, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store, err := bus.OpenOutboundStore(filepath.Join(t.TempDir(), "outbound.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := bus.New(16)
+			b.SetOutboundStore(store)
+			m := NewManager(b)
+			m.retryBase = time.Millisecond
+			ch := &failingChannel{stubChannel: stubChannel{name: "stub"}, fail: tc.fail}
+			m.Add(ch)
+			ctx := t.Context()
+			if err := m.StartAll(ctx); err != nil {
+				t.Fatalf("StartAll returned error: %v", err)
+			}
+			result, cancel := b.TrackDelivery("t1")
+			defer cancel()
+			if err := b.PublishOutbound(ctx, bus.OutboundMessage{Channel: "stub", ChatID: "c1", Content: "hi", TrackingID: "t1"}); err != nil {
+				t.Fatalf("PublishOutbound failed: %v", err)
+			}
+			waitFor(t, 2*time.Second, func() bool { return len(store.Pending()) == 0 })
+			time.Sleep(50 * time.Millisecond)
+			if got := ch.count(); got != tc.wantSends {
+				t.Fatalf("sends=%d want %d", got, tc.wantSends)
+			}
+			select {
+			case err := <-result:
+				if (err == nil) != tc.delivered {
+					t.Fatalf("delivery result=%v want delivered=%v", err, tc.delivered)
+				}
+			default:
+				t.Fatal("no delivery result reported")
+			}
+		})
+	}
+}
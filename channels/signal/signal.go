@@ -0,0 +1,498 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/channels"
+	"github.com/mosaxiv/clawlet/config"
+)
+
+const (
+	signalSendTimeout  = 30 * time.Second
+	signalMaxLineBytes = 8 << 20
+	signalMaxBackoff   = 30 * time.Second
+)
+
+// Channel talks to a signal-cli daemon over its JSON-RPC socket
+// ("signal-cli -a <number> daemon --socket" or "--tcp").
+type Channel struct {
+	cfg   config.SignalConfig
+	bus   *bus.Bus
+	allow channels.AllowList
+
+	running atomic.Bool
+
+	mu   sync.Mutex
+	conn *rpcConn
+}
+
+func New(cfg config.SignalConfig, b *bus.Bus) *Channel {
+	return &Channel{
+		cfg:   cfg,
+		bus:   b,
+		allow: channels.AllowList{AllowFrom: cfg.AllowFrom},
+	}
+}
+
+func (c *Channel) Name() string    { return "signal" }
+func (c *Channel) IsRunning() bool { return c.running.Load() }
+
+func (c *Channel) Start(ctx context.Context) error {
+	network, addr, err := signalEndpoint(c.cfg)
+	if err != nil {
+		return err
+	}
+
+	c.running.Store(true)
+	defer c.running.Store(false)
+
+	// signal-cli may be restarted independently; reconnect until shutdown.
+	backoff := time.Second
+	for {
+		err := c.serve(ctx, network, addr)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("signal: connection to %s lost, retry in %s: %v", addr, backoff, err)
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		backoff = min(backoff*2, signalMaxBackoff)
+	}
+}
+
+func (c *Channel) serve(ctx context.Context, network, addr string) error {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return err
+	}
+	rc := newRPCConn(nc)
+	c.mu.Lock()
+	c.conn = rc
+	c.mu.Unlock()
+	defer func() {
+		_ = rc.Close()
+		c.mu.Lock()
+		if c.conn == rc {
+			c.conn = nil
+		}
+		c.mu.Unlock()
+	}()
+
+	stop := context.AfterFunc(ctx, func() { _ = rc.Close() })
+	defer stop()
+	return rc.readLoop(func(method string, params json.RawMessage) {
+		if method == "receive" {
+			c.onReceive(ctx, params)
+		}
+	})
+}
+
+func (c *Channel) Stop() error {
+	c.mu.Lock()
+	rc := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+	if rc != nil {
+		return rc.Close()
+	}
+	return nil
+}
+
+func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	chatID := strings.TrimSpace(msg.ChatID)
+	if chatID == "" {
+		return fmt.Errorf("chat_id is empty")
+	}
+	content := strings.TrimSpace(msg.Content)
+	if content == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	rc := c.conn
+	c.mu.Unlock()
+	if rc == nil {
+		return fmt.Errorf("signal not connected")
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, signalSendTimeout)
+	defer cancel()
+	_, err := rc.call(sendCtx, "send", buildSignalSendParams(c.cfg.Account, chatID, content, resolveSignalReplyTarget(msg)))
+	return err
+}
+
+type signalReceiveParams struct {
+	Envelope signalEnvelope `json:"envelope"`
+}
+
+type signalEnvelope struct {
+	Source       string             `json:"source"`
+	SourceNumber string             `json:"sourceNumber"`
+	SourceUUID   string             `json:"sourceUuid"`
+	Timestamp    int64              `json:"timestamp"`
+	DataMessage  *signalDataMessage `json:"dataMessage"`
+}
+
+type signalDataMessage struct {
+	Timestamp   int64              `json:"timestamp"`
+	Message     string             `json:"message"`
+	GroupInfo   *signalGroupInfo   `json:"groupInfo"`
+	Attachments []signalAttachment `json:"attachments"`
+	Quote       *signalQuote       `json:"quote"`
+}
+
+type signalGroupInfo struct {
+	GroupID string `json:"groupId"`
+}
+
+type signalAttachment struct {
+	ID          string `json:"id"`
+	ContentType string `json:"contentType"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+}
+
+type signalQuote struct {
+	Text string `json:"text"`
+}
+
+func (c *Channel) onReceive(ctx context.Context, params json.RawMessage) {
+	var p signalReceiveParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		log.Printf("signal: invalid receive notification: %v", err)
+		return
+	}
+	env := p.Envelope
+	dm := env.DataMessage
+	if dm == nil {
+		// Receipts, typing indicators and sync messages carry no content.
+		return
+	}
+	senderID := signalSenderID(env)
+	if senderID == "" || !c.allow.Allowed(senderID) {
+		return
+	}
+
+	content := strings.TrimSpace(dm.Message)
+	attachments := signalInboundAttachments(dm.Attachments, c.attachmentsDir())
+	content, attachments = channels.LimitAttachments(content, attachments, c.cfg.ChannelAttachmentConfig)
+	if content == "" && len(attachments) == 0 {
+		return
+	}
+	if dm.Quote != nil {
+		content = channels.QuoteReply(dm.Quote.Text, content)
+	}
+
+	chatID := signalChatID(env)
+	ts := dm.Timestamp
+	if ts == 0 {
+		ts = env.Timestamp
+	}
+	var sentAt time.Time
+	if ts > 0 {
+		sentAt = time.UnixMilli(ts)
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_ = c.bus.PublishInbound(publishCtx, bus.InboundMessage{
+		Channel:     "signal",
+		SenderID:    senderID,
+		ChatID:      chatID,
+		Content:     content,
+		Attachments: attachments,
+		SessionKey:  "signal:" + chatID,
+		Delivery: bus.Delivery{
+			MessageID: signalMessageID(signalAuthor(env), ts),
+			IsDirect:  dm.GroupInfo == nil,
+		},
+		SentAt: sentAt,
+	})
+}
+
+func (c *Channel) attachmentsDir() string {
+	if dir := strings.TrimSpace(c.cfg.AttachmentsDir); dir != "" {
+		return dir
+	}
+	return defaultSignalAttachmentsDir()
+}
+
+// defaultSignalAttachmentsDir is where signal-cli stores received
+// attachments unless its --config directory is changed.
+func defaultSignalAttachmentsDir() string {
+	if dir := strings.TrimSpace(os.Getenv("XDG_DATA_HOME")); dir != "" {
+		return filepath.Join(dir, "signal-cli", "attachments")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "signal-cli", "attachments")
+}
+
+func signalEndpoint(cfg config.SignalConfig) (network, addr string, err error) {
+	if sock := strings.TrimSpace(cfg.Socket); sock != "" {
+		return "unix", sock, nil
+	}
+	if tcp := strings.TrimSpace(cfg.TCPAddr); tcp != "" {
+		return "tcp", tcp, nil
+	}
+	return "", "", fmt.Errorf("signal socket and tcpAddr are both empty")
+}
+
+// signalSenderID returns the sender's phone number and UUID as a compound ID
+// so allowFrom may list either.
+func signalSenderID(env signalEnvelope) string {
+	var parts []string
+	number := strings.TrimSpace(env.SourceNumber)
+	if number == "" && strings.HasPrefix(env.Source, "+") {
+		number = strings.TrimSpace(env.Source)
+	}
+	if number != "" {
+		parts = append(parts, number)
+	}
+	if uuid := strings.TrimSpace(env.SourceUUID); uuid != "" {
+		parts = append(parts, uuid)
+	}
+	if len(parts) == 0 {
+		if src := strings.TrimSpace(env.Source); src != "" {
+			parts = append(parts, src)
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// signalAuthor is the address used to reply to or quote the sender.
+func signalAuthor(env signalEnvelope) string {
+	if v := strings.TrimSpace(env.SourceNumber); v != "" {
+		return v
+	}
+	if v := strings.TrimSpace(env.SourceUUID); v != "" {
+		return v
+	}
+	return strings.TrimSpace(env.Source)
+}
+
+// signalChatID is the sender for direct messages and "group:<id>" for
+// group messages.
+func signalChatID(env signalEnvelope) string {
+	if g := env.DataMessage.GroupInfo; g != nil && strings.TrimSpace(g.GroupID) != "" {
+		return "group:" + strings.TrimSpace(g.GroupID)
+	}
+	return signalAuthor(env)
+}
+
+// Signal identifies a message by its author and sent timestamp, so the
+// delivery message ID carries both ("<timestamp>:<author>").
+func signalMessageID(author string, ts int64) string {
+	if author == "" || ts <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(ts, 10) + ":" + author
+}
+
+func parseSignalMessageID(id string) (author string, ts int64, ok bool) {
+	tsStr, author, found := strings.Cut(strings.TrimSpace(id), ":")
+	if !found || author == "" {
+		return "", 0, false
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil || ts <= 0 {
+		return "", 0, false
+	}
+	return author, ts, true
+}
+
+func resolveSignalReplyTarget(msg bus.OutboundMessage) string {
+	if replyTo := strings.TrimSpace(msg.Delivery.ReplyToID); replyTo != "" {
+		return replyTo
+	}
+	return strings.TrimSpace(msg.ReplyTo)
+}
+
+func buildSignalSendParams(account, chatID, content, replyToID string) map[string]any {
+	params := map[string]any{"message": content}
+	if groupID, ok := strings.CutPrefix(chatID, "group:"); ok {
+		params["groupId"] = groupID
+	} else {
+		params["recipient"] = []string{chatID}
+	}
+	if account = strings.TrimSpace(account); account != "" {
+		params["account"] = account
+	}
+	if author, ts, ok := parseSignalMessageID(replyToID); ok {
+		params["quoteAuthor"] = author
+		params["quoteTimestamp"] = ts
+	}
+	return params
+}
+
+func signalInboundAttachments(atts []signalAttachment, dir string) []bus.Attachment {
+	if len(atts) == 0 || dir == "" {
+		return nil
+	}
+	out := make([]bus.Attachment, 0, len(atts))
+	for _, a := range atts {
+		id := strings.TrimSpace(a.ID)
+		// IDs are file names inside the attachments directory.
+		if id == "" || id != filepath.Base(id) {
+			continue
+		}
+		mimeType := strings.TrimSpace(a.ContentType)
+		out = append(out, bus.Attachment{
+			ID:        id,
+			Name:      strings.TrimSpace(a.Filename),
+			MIMEType:  mimeType,
+			Kind:      bus.InferAttachmentKind(mimeType),
+			SizeBytes: a.Size,
+			LocalPath: filepath.Join(dir, id),
+		})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// rpcConn is a newline-delimited JSON-RPC 2.0 connection to signal-cli.
+type rpcConn struct {
+	nc net.Conn
+
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcMessage
+	closed  chan struct{}
+	once    sync.Once
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("signal-cli error %d: %s", e.Code, e.Message)
+}
+
+func newRPCConn(nc net.Conn) *rpcConn {
+	return &rpcConn{
+		nc:      nc,
+		pending: map[int64]chan rpcMessage{},
+		closed:  make(chan struct{}),
+	}
+}
+
+func (rc *rpcConn) Close() error {
+	var err error
+	rc.once.Do(func() {
+		close(rc.closed)
+		err = rc.nc.Close()
+	})
+	return err
+}
+
+func (rc *rpcConn) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan rpcMessage, 1)
+	rc.mu.Lock()
+	rc.nextID++
+	id := rc.nextID
+	rc.pending[id] = ch
+	rc.mu.Unlock()
+	defer func() {
+		rc.mu.Lock()
+		delete(rc.pending, id)
+		rc.mu.Unlock()
+	}()
+
+	line, err := json.Marshal(rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: rawParams})
+	if err != nil {
+		return nil, err
+	}
+	rc.wmu.Lock()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = rc.nc.SetWriteDeadline(dl)
+	}
+	_, err = rc.nc.Write(append(line, '\n'))
+	_ = rc.nc.SetWriteDeadline(time.Time{})
+	rc.wmu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-rc.closed:
+		return nil, errors.New("signal connection closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop dispatches responses to pending calls and notifications to
+// notify until the connection fails or is closed.
+func (rc *rpcConn) readLoop(notify func(method string, params json.RawMessage)) error {
+	sc := bufio.NewScanner(rc.nc)
+	sc.Buffer(make([]byte, 0, 64<<10), signalMaxLineBytes)
+	for sc.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Method != "" {
+			notify(msg.Method, msg.Params)
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+		rc.mu.Lock()
+		ch := rc.pending[*msg.ID]
+		rc.mu.Unlock()
+		if ch != nil {
+			ch <- msg
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return errors.New("signal-cli closed the connection")
+}
@@ -0,0 +1,141 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestSignalMessageIDRoundTrip(t *testing.T) {
+	id := signalMessageID("+15551234567", 1700000000123)
+	author, ts, ok := parseSignalMessageID(id)
+	if !ok || author != "+15551234567" || ts != 1700000000123 {
+		t.Fatalf("id=%q author=%q ts=%d ok=%v", id, author, ts, ok)
+	}
+	if _, _, ok := parseSignalMessageID("not-an-id"); ok {
+		t.Fatal("expected parse failure")
+	}
+}
+
+func TestBuildSignalSendParams(t *testing.T) {
+	p := buildSignalSendParams("+1000", "+1555", "hi", "1700:+1555")
+	if rec, _ := p["recipient"].([]string); len(rec) != 1 || rec[0] != "+1555" {
+		t.Fatalf("recipient=%v", p["recipient"])
+	}
+	if p["account"] != "+1000" || p["quoteAuthor"] != "+1555" || p["quoteTimestamp"] != int64(1700) {
+		t.Fatalf("params=%v", p)
+	}
+
+	p = buildSignalSendParams("", "group:abc=", "hi", "")
+	if p["groupId"] != "abc=" || p["recipient"] != nil || p["account"] != nil || p["quoteAuthor"] != nil {
+		t.Fatalf("group params=%v", p)
+	}
+}
+
+func TestSignalSenderID(t *testing.T) {
+	env := signalEnvelope{Source: "+1555", SourceNumber: "+1555", SourceUUID: "u-1"}
+	if got := signalSenderID(env); got != "+1555|u-1" {
+		t.Fatalf("got %q", got)
+	}
+	if got := signalSenderID(signalEnvelope{Source: "u-2", SourceUUID: "u-2"}); got != "u-2" {
+		t.Fatalf("uuid only: %q", got)
+	}
+}
+
+func TestSignalInboundAttachments(t *testing.T) {
+	atts := signalInboundAttachments([]signalAttachment{
+		{ID: "abc.jpg", ContentType: "image/jpeg", Filename: "photo.jpg", Size: 42},
+		{ID: "../escape", ContentType: "text/plain"},
+	}, "/data/att")
+	if len(atts) != 1 {
+		t.Fatalf("atts=%+v", atts)
+	}
+	if atts[0].LocalPath != filepath.Join("/data/att", "abc.jpg") || atts[0].Kind != "image" || atts[0].Name != "photo.jpg" {
+		t.Fatalf("att=%+v", atts[0])
+	}
+}
+
+func TestChannel_ReceiveAndSend(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "signal.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	b := bus.New(4)
+	ch := New(config.SignalConfig{Socket: sock, AllowFrom: []string{"+1555"}}, b)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() { _ = ch.Start(ctx) }()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	notify := func(source, text string) {
+		line, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"method":  "receive",
+			"params": map[string]any{"envelope": map[string]any{
+				"source":       source,
+				"sourceNumber": source,
+				"timestamp":    1700000000000,
+				"dataMessage": map[string]any{
+					"timestamp": 1700000000000,
+					"message":   text,
+					"quote":     map[string]any{"text": "earlier"},
+				},
+			}},
+		})
+		_, _ = conn.Write(append(line, '\n'))
+	}
+	notify("+1999", "blocked")
+	notify("+1555", "hello")
+
+	in, err := b.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.SessionKey != "signal:+1555" || in.Content != "> earlier\n\nhello" || !in.Delivery.IsDirect {
+		t.Fatalf("inbound=%+v", in)
+	}
+	if in.Delivery.MessageID != "1700000000000:+1555" || in.SentAt.UnixMilli() != 1700000000000 {
+		t.Fatalf("delivery=%+v sentAt=%v", in.Delivery, in.SentAt)
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- ch.Send(ctx, bus.OutboundMessage{Channel: "signal", ChatID: in.ChatID, Content: "hi back"})
+	}()
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		ID     int64          `json:"id"`
+		Method string         `json:"method"`
+		Params map[string]any `json:"params"`
+	}
+	if err := json.Unmarshal(line, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "send" || req.Params["message"] != "hi back" {
+		t.Fatalf("request=%s", line)
+	}
+	resp, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{"timestamp": 1}})
+	_, _ = conn.Write(append(resp, '\n'))
+	if err := <-sendErr; err != nil {
+		t.Fatalf("send: %v", err)
+	}
+}
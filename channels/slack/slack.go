@@ -1,9 +1,12 @@
 package slack
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -31,8 +34,16 @@ type Channel struct {
 
 	botUserID string
 	cancel    context.CancelFunc
+
+	// threads holds "channel:thread_ts" roots the bot has posted in, with
+	// the last post time, for autoThread follow-ups.
+	threads map[string]time.Time
 }
 
+// slackThreadTTL is how long a thread keeps accepting follow-ups without a
+// mention after the bot's last post in it.
+const slackThreadTTL = 7 * 24 * time.Hour
+
 func New(cfg config.SlackConfig, b *bus.Bus) *Channel {
 	hc := &http.Client{Timeout: 20 * time.Second}
 	return &Channel{
@@ -151,7 +162,7 @@ func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 		return fmt.Errorf("chat_id is empty")
 	}
 	text := strings.TrimSpace(msg.Content)
-	if text == "" {
+	if text == "" && len(msg.Attachments) == 0 {
 		return nil
 	}
 	c.mu.Lock()
@@ -176,17 +187,114 @@ func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 	}
 
 	threadTS, direct := slackThreadMeta(msg)
-	opts := []slack.MsgOption{
-		slack.MsgOptionText(text, false),
+	if text != "" {
+		opts := []slack.MsgOption{
+			slack.MsgOptionText(slackCodeFences(text), false),
+		}
+		// Keep channel conversations in thread; DMs/MPIMs do not use thread_ts.
+		if threadTS != "" && !direct {
+			opts = append(opts, slack.MsgOptionTS(threadTS))
+		}
+		_, postedTS, err := api.PostMessageContext(ctx, ch, opts...)
+		if err != nil {
+			return slackSendError(err)
+		}
+		if c.cfg.AutoThread && !direct {
+			// A top-level post starts a thread of its own.
+			if threadTS == "" {
+				threadTS = postedTS
+			}
+			c.trackThread(ch, threadTS, time.Now())
+		}
+	}
+	if direct {
+		threadTS = ""
 	}
-	// Keep channel conversations in thread; DMs/MPIMs do not use thread_ts.
-	if threadTS != "" && !direct {
-		opts = append(opts, slack.MsgOptionTS(threadTS))
+	for _, att := range msg.Attachments {
+		if err := uploadSlackFile(ctx, api, ch, threadTS, att); err != nil {
+			return fmt.Errorf("upload %s: %w", channels.AttachmentName(att), err)
+		}
 	}
-	_, _, err := api.PostMessageContext(ctx, ch, opts...)
+	return nil
+}
+
+// MaxUploadBytes implements channels.Uploader.
+func (c *Channel) MaxUploadBytes() int64 {
+	return cmp.Or(c.cfg.MaxUploadBytes, config.DefaultSlackMaxUploadBytes)
+}
+
+func uploadSlackFile(ctx context.Context, api *slack.Client, ch, threadTS string, att bus.Attachment) error {
+	rc, err := channels.OpenAttachment(att)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	name := channels.AttachmentName(att)
+	_, err = api.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Reader:          rc,
+		FileSize:        int(channels.AttachmentSize(att)),
+		Filename:        name,
+		Title:           name,
+		Channel:         ch,
+		ThreadTimestamp: threadTS,
+	})
 	return err
 }
 
+// slackCodeFences drops fence language labels, which Slack mrkdwn does not
+// support and would show as the first line of the code block.
+func slackCodeFences(text string) string {
+	if !strings.Contains(text, "```") {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+		if !inFence && len(trimmed) > 3 && !strings.Contains(trimmed[3:], "```") {
+			lines[i] = line[:strings.Index(line, "```")+3]
+		}
+		inFence = !inFence
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *Channel) trackThread(ch, threadTS string, now time.Time) {
+	if threadTS == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.threads == nil {
+		c.threads = map[string]time.Time{}
+	}
+	for k, at := range c.threads {
+		if now.Sub(at) > slackThreadTTL {
+			delete(c.threads, k)
+		}
+	}
+	c.threads[ch+":"+threadTS] = now
+}
+
+// followsBotThread reports whether a plain message is a reply in a thread
+// the bot has posted in, so autoThread can answer it without a mention.
+// Replies that mention the bot arrive as app_mention and are left to it.
+func (c *Channel) followsBotThread(eventType, ch, ts, threadTS, text string, now time.Time) bool {
+	if !c.cfg.AutoThread || eventType != "message" || threadTS == "" || threadTS == ts {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.botUserID != "" && strings.Contains(text, "<@"+c.botUserID+">") {
+		return false
+	}
+	at, ok := c.threads[ch+":"+threadTS]
+	return ok && now.Sub(at) <= slackThreadTTL
+}
+
 func (c *Channel) runSocketEventLoop(ctx context.Context, sm *socketmode.Client) {
 	for {
 		select {
@@ -212,6 +320,21 @@ func (c *Channel) runSocketEventLoop(ctx context.Context, sm *socketmode.Client)
 	}
 }
 
+// slackThreadParentText fetches the text of the message that started a
+// thread. Failures are ignored; the reply is then delivered without a quote.
+func slackThreadParentText(ctx context.Context, api *slack.Client, ch, threadTS string) string {
+	msgs, _, _, err := api.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+		ChannelID: ch,
+		Timestamp: threadTS,
+		Limit:     1,
+		Inclusive: true,
+	})
+	if err != nil || len(msgs) == 0 {
+		return ""
+	}
+	return msgs[0].Text
+}
+
 func (c *Channel) publishInbound(ctx context.Context, eventType, user, ch, channelType, ts, threadTS, text string, attachments []bus.Attachment) {
 	user = strings.TrimSpace(user)
 	ch = strings.TrimSpace(ch)
@@ -225,38 +348,53 @@ func (c *Channel) publishInbound(ctx context.Context, eventType, user, ch, chann
 	if !c.allow.Allowed(user) {
 		return
 	}
-	if !c.allowedByPolicy(eventType, ch, channelType, text) {
+	if !c.allowedByPolicy(eventType, ch, channelType, text) && !c.followsBotThread(eventType, ch, ts, threadTS, text, time.Now()) {
 		return
 	}
 	text = c.stripBotMention(text)
 	if strings.TrimSpace(text) == "" {
 		return
 	}
+	isThreadReply := threadTS != "" && threadTS != ts
 	if threadTS == "" {
 		threadTS = ts
 	}
 
+	c.mu.Lock()
+	api := c.api
+	c.mu.Unlock()
 	// Best-effort :eyes: reaction
-	if ts != "" {
-		c.mu.Lock()
-		api := c.api
-		c.mu.Unlock()
-		if api != nil {
-			_ = api.AddReactionContext(ctx, "eyes", slack.ItemRef{Channel: ch, Timestamp: ts})
-		}
+	if ts != "" && api != nil {
+		_ = api.AddReactionContext(ctx, "eyes", slack.ItemRef{Channel: ch, Timestamp: ts})
 	}
 
+	text, attachments = channels.LimitAttachments(text, attachments, c.cfg.ChannelAttachmentConfig)
+	if isThreadReply && api != nil {
+		text = channels.QuoteReply(slackThreadParentText(ctx, api, ch, threadTS), text)
+	}
+	delivery := buildSlackDelivery(ts, threadTS, channelType)
 	_ = c.bus.PublishInbound(ctx, bus.InboundMessage{
 		Channel:     "slack",
 		SenderID:    user,
 		ChatID:      ch,
 		Content:     text,
 		Attachments: attachments,
-		SessionKey:  "slack:" + ch,
-		Delivery:    buildSlackDelivery(ts, threadTS, channelType),
+		SessionKey:  slackSessionKey(ch, threadTS, delivery.IsDirect, c.cfg.AutoThread),
+		Delivery:    delivery,
+		SentAt:      slackTSTime(ts),
 	})
 }
 
+// slackSessionKey is "slack:<channel>", or "slack:<channel>:<thread_ts>"
+// for channel threads when autoThread is on, so each thread is its own
+// conversation.
+func slackSessionKey(ch, threadTS string, direct, autoThread bool) string {
+	if autoThread && !direct && threadTS != "" {
+		return "slack:" + ch + ":" + threadTS
+	}
+	return "slack:" + ch
+}
+
 func slackInboundAttachments(ev *slackevents.MessageEvent, botToken string) []bus.Attachment {
 	if ev == nil || ev.Message == nil || len(ev.Message.Files) == 0 {
 		return nil
@@ -361,6 +499,21 @@ func slackThreadMeta(msg bus.OutboundMessage) (threadTS string, direct bool) {
 	return threadTS, msg.Delivery.IsDirect
 }
 
+// slackTSTime converts a Slack message ts ("1700000000.000100") to a time.
+func slackTSTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(strings.TrimSpace(ts), ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil || s <= 0 {
+		return time.Time{}
+	}
+	var us int64
+	if frac != "" {
+		frac = (frac + "000000")[:6]
+		us, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	return time.Unix(s, us*int64(time.Microsecond))
+}
+
 func buildSlackDelivery(ts, threadTS, channelType string) bus.Delivery {
 	ts = strings.TrimSpace(ts)
 	threadTS = strings.TrimSpace(threadTS)
@@ -374,3 +527,23 @@ func buildSlackDelivery(ts, threadTS, channelType string) bus.Delivery {
 		IsDirect:  channelType == "im" || channelType == "mpim",
 	}
 }
+
+// slackUndeliverable lists chat.postMessage errors that resending cannot fix.
+var slackUndeliverable = map[string]bool{
+	"channel_not_found": true,
+	"not_in_channel":    true,
+	"is_archived":       true,
+	"msg_too_long":      true,
+	"no_text":           true,
+	"restricted_action": true,
+	"account_inactive":  true,
+}
+
+// slackSendError marks errors that resending cannot fix as undeliverable.
+func slackSendError(err error) error {
+	var apiErr slack.SlackErrorResponse
+	if errors.As(err, &apiErr) && slackUndeliverable[apiErr.Err] {
+		return channels.Undeliverable(err)
+	}
+	return err
+}
@@ -1,9 +1,12 @@
 package slack
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/channels"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 )
@@ -148,3 +151,70 @@ func TestSlackInboundAttachments(t *testing.T) {
 		t.Fatalf("missing url")
 	}
 }
+
+func TestSlackTSTime(t *testing.T) {
+	got := slackTSTime("1700000000.000100")
+	if got.Unix() != 1700000000 || got.Nanosecond() != 100000 {
+		t.Fatalf("got %v", got)
+	}
+	if !slackTSTime("").IsZero() || !slackTSTime("bogus").IsZero() {
+		t.Fatalf("expected zero time for invalid ts")
+	}
+}
+
+func TestSlackSessionKey(t *testing.T) {
+	if got := slackSessionKey("C1", "1700.1", false, false); got != "slack:C1" {
+		t.Fatalf("autoThread off: %q", got)
+	}
+	if got := slackSessionKey("C1", "1700.1", false, true); got != "slack:C1:1700.1" {
+		t.Fatalf("thread session: %q", got)
+	}
+	if got := slackSessionKey("D1", "1700.1", true, true); got != "slack:D1" {
+		t.Fatalf("DMs stay one session: %q", got)
+	}
+}
+
+func TestFollowsBotThread(t *testing.T) {
+	now := time.Now()
+	c := &Channel{botUserID: "UBOT"}
+	c.cfg.AutoThread = true
+	c.trackThread("C1", "1700.1", now)
+
+	if !c.followsBotThread("message", "C1", "1700.5", "1700.1", "and tomorrow?", now) {
+		t.Fatal("expected follow-up in bot thread to be accepted")
+	}
+	if c.followsBotThread("message", "C1", "1700.5", "1700.1", "<@UBOT> and tomorrow?", now) {
+		t.Fatal("mentions are handled by app_mention")
+	}
+	if c.followsBotThread("message", "C1", "1800.1", "", "new topic", now) {
+		t.Fatal("top-level messages still need a mention")
+	}
+	if c.followsBotThread("message", "C2", "1700.5", "1700.1", "other channel", now) {
+		t.Fatal("untracked thread accepted")
+	}
+	if c.followsBotThread("message", "C1", "1700.5", "1700.1", "late", now.Add(slackThreadTTL+time.Minute)) {
+		t.Fatal("expired thread accepted")
+	}
+
+	c.cfg.AutoThread = false
+	if c.followsBotThread("message", "C1", "1700.5", "1700.1", "and tomorrow?", now) {
+		t.Fatal("autoThread off should not accept follow-ups")
+	}
+}
+
+func TestSlackCodeFences(t *testing.T) {
+	in := "See:\n```go\nfmt.Println(1)\n```\n```\nplain\n```"
+	want := "See:\n```\nfmt.Println(1)\n```\n```\nplain\n```"
+	if got := slackCodeFences(in); got != want {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestSlackSendError(t *testing.T) {
+	if !errors.Is(slackSendError(slack.SlackErrorResponse{Err: "channel_not_found"}), channels.ErrUndeliverable) {
+		t.Fatal("channel_not_found should be undeliverable")
+	}
+	if errors.Is(slackSendError(slack.SlackErrorResponse{Err: "ratelimited"}), channels.ErrUndeliverable) {
+		t.Fatal("ratelimited should be retried")
+	}
+}
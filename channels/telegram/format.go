@@ -8,7 +8,7 @@ import (
 )
 
 var (
-	reMarkdownCodeBlock  = regexp.MustCompile("(?s)```[\\w-]*\\n?([\\s\\S]*?)```")
+	reMarkdownCodeBlock  = regexp.MustCompile("(?s)```([\\w+#.-]*)\\n?([\\s\\S]*?)```")
 	reMarkdownInlineCode = regexp.MustCompile("`([^`]+)`")
 	reMarkdownHeading    = regexp.MustCompile("(?m)^#{1,6}\\s+(.+)$")
 	reMarkdownQuote      = regexp.MustCompile("(?m)^>\\s*(.*)$")
@@ -36,14 +36,18 @@ func markdownToTelegramHTML(text string) string {
 
 	text = reMarkdownCodeBlock.ReplaceAllStringFunc(text, func(src string) string {
 		m := reMarkdownCodeBlock.FindStringSubmatch(src)
-		code := ""
-		if len(m) >= 2 {
-			code = m[1]
+		lang, code := "", ""
+		if len(m) >= 3 {
+			lang, code = m[1], m[2]
+		}
+		open := "<pre><code>"
+		if lang != "" {
+			open = `<pre><code class="language-` + html.EscapeString(lang) + `">`
 		}
 		token := fmt.Sprintf("\x00CB%d\x00", len(replacements))
 		replacements = append(replacements, replacement{
 			token: token,
-			html:  "<pre><code>" + html.EscapeString(code) + "</code></pre>",
+			html:  open + html.EscapeString(code) + "</code></pre>",
 		})
 		return token
 	})
@@ -1,9 +1,11 @@
 package telegram
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net"
 	"net/http"
 	"strconv"
@@ -19,6 +21,10 @@ import (
 	"github.com/mosaxiv/clawlet/config"
 )
 
+// telegramMaxMessageRunes is Telegram's limit on message text after entity
+// parsing, so HTML tags do not count toward it.
+const telegramMaxMessageRunes = 4096
+
 type Channel struct {
 	cfg   config.TelegramConfig
 	bus   *bus.Bus
@@ -32,6 +38,8 @@ type Channel struct {
 	mu     sync.Mutex
 	bot    *tgbot.Bot
 	cancel context.CancelFunc
+	// fatal is set when polling gave up, e.g. on repeated getUpdates conflicts.
+	fatal error
 }
 
 func New(cfg config.TelegramConfig, b *bus.Bus) *Channel {
@@ -44,7 +52,7 @@ func New(cfg config.TelegramConfig, b *bus.Bus) *Channel {
 	}
 }
 
-func (c *Channel) Name() string    { return "telegram" }
+func (c *Channel) Name() string    { return c.cfg.ChannelName() }
 func (c *Channel) IsRunning() bool { return c.running.Load() }
 
 func (c *Channel) Start(ctx context.Context) error {
@@ -65,6 +73,7 @@ func (c *Channel) Start(ctx context.Context) error {
 			models.AllowedUpdateEditedMessage,
 		}),
 		tgbot.WithDefaultHandler(c.onUpdate),
+		tgbot.WithErrorsHandler(c.pollErrorHandler(cancel)),
 	}
 	if baseURL := strings.TrimSpace(c.cfg.BaseURL); baseURL != "" {
 		opts = append(opts, tgbot.WithServerURL(baseURL))
@@ -79,6 +88,7 @@ func (c *Channel) Start(ctx context.Context) error {
 	c.mu.Lock()
 	c.bot = b
 	c.cancel = cancel
+	c.fatal = nil
 	c.mu.Unlock()
 	defer func() {
 		c.mu.Lock()
@@ -93,9 +103,54 @@ func (c *Channel) Start(ctx context.Context) error {
 	defer c.running.Store(false)
 
 	b.Start(runCtx)
+	c.mu.Lock()
+	fatal := c.fatal
+	c.mu.Unlock()
+	if fatal != nil {
+		return fatal
+	}
 	return runCtx.Err()
 }
 
+// telegramConflictWindow groups getUpdates conflicts into one episode. Two
+// pollers on one token alternate between success and conflict, so conflicts
+// are counted over time rather than as consecutive failures.
+const telegramConflictWindow = time.Minute
+
+// pollErrorHandler logs polling errors like the library does, and stops the
+// bot once getUpdates has reported more conflicts than the configured limit.
+func (c *Channel) pollErrorHandler(stop context.CancelFunc) tgbot.ErrorsHandler {
+	limit := c.cfg.ConflictRetriesValue()
+	var (
+		mu    sync.Mutex
+		count int
+		last  time.Time
+	)
+	return func(err error) {
+		if !errors.Is(err, tgbot.ErrorConflict) {
+			log.Printf("telegram: %s: %v", c.Name(), err)
+			return
+		}
+		mu.Lock()
+		now := time.Now()
+		if now.Sub(last) > telegramConflictWindow {
+			count = 0
+		}
+		count++
+		last = now
+		n := count
+		mu.Unlock()
+		if limit < 0 || n <= limit {
+			log.Printf("telegram: %s: getUpdates conflict (%d/%d): another process is polling this bot token", c.Name(), n, limit)
+			return
+		}
+		c.mu.Lock()
+		c.fatal = fmt.Errorf("telegram: another instance is polling this bot token (getUpdates returned 409 Conflict %d times); stop the other gateway or use a different token", n)
+		c.mu.Unlock()
+		stop()
+	}
+}
+
 func (c *Channel) Stop() error {
 	c.mu.Lock()
 	cancel := c.cancel
@@ -110,13 +165,13 @@ func (c *Channel) Stop() error {
 
 func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 	text := strings.TrimSpace(msg.Content)
-	if text == "" {
+	if text == "" && len(msg.Attachments) == 0 {
 		return nil
 	}
 
 	chatIDAny, err := parseTelegramChatID(msg.ChatID)
 	if err != nil {
-		return err
+		return channels.Undeliverable(err)
 	}
 
 	c.mu.Lock()
@@ -126,26 +181,65 @@ func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 		return fmt.Errorf("telegram not connected")
 	}
 
-	params := &tgbot.SendMessageParams{
-		ChatID:    chatIDAny,
-		Text:      markdownToTelegramHTML(text),
-		ParseMode: models.ParseModeHTML,
+	// Long replies are split; only the first chunk replies to the user's
+	// message.
+	replyTo := resolveTelegramReplyTarget(msg)
+	var chunks []string
+	if text != "" {
+		chunks = channels.SplitMessage(text, telegramMaxMessageRunes)
 	}
-	if replyTo := resolveTelegramReplyTarget(msg); replyTo > 0 {
-		params.ReplyParameters = &models.ReplyParameters{
-			MessageID:                int(replyTo),
-			AllowSendingWithoutReply: true,
+	for _, chunk := range chunks {
+		params := &tgbot.SendMessageParams{
+			ChatID:    chatIDAny,
+			Text:      markdownToTelegramHTML(chunk),
+			ParseMode: models.ParseModeHTML,
+		}
+		if replyTo > 0 {
+			params.ReplyParameters = &models.ReplyParameters{
+				MessageID:                int(replyTo),
+				AllowSendingWithoutReply: true,
+			}
+			replyTo = 0
+		}
+		err := c.sendMessageWithRetry(ctx, b, params)
+		if err != nil && isTelegramParseError(err) {
+			params.Text = chunk
+			params.ParseMode = ""
+			err = c.sendMessageWithRetry(ctx, b, params)
+		}
+		if err != nil {
+			return telegramSendError(err)
 		}
 	}
-	if err := c.sendMessageWithRetry(ctx, b, params); err == nil {
-		return nil
-	} else if !isTelegramParseError(err) {
-		return err
+	for _, att := range msg.Attachments {
+		if err := sendTelegramDocument(ctx, b, chatIDAny, att, replyTo); err != nil {
+			return fmt.Errorf("upload %s: %w", channels.AttachmentName(att), err)
+		}
+		replyTo = 0
 	}
+	return nil
+}
+
+// MaxUploadBytes implements channels.Uploader.
+func (c *Channel) MaxUploadBytes() int64 {
+	return cmp.Or(c.cfg.MaxUploadBytes, config.DefaultTelegramMaxUploadBytes)
+}
 
-	params.Text = text
-	params.ParseMode = ""
-	return c.sendMessageWithRetry(ctx, b, params)
+func sendTelegramDocument(ctx context.Context, b *tgbot.Bot, chatID any, att bus.Attachment, replyTo int64) error {
+	rc, err := channels.OpenAttachment(att)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	params := &tgbot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: channels.AttachmentName(att), Data: rc},
+	}
+	if replyTo > 0 {
+		params.ReplyParameters = &models.ReplyParameters{MessageID: int(replyTo), AllowSendingWithoutReply: true}
+	}
+	_, err = b.SendDocument(ctx, params)
+	return err
 }
 
 func (c *Channel) onUpdate(ctx context.Context, b *tgbot.Bot, up *models.Update) {
@@ -167,26 +261,52 @@ func (c *Channel) onUpdate(ctx context.Context, b *tgbot.Bot, up *models.Update)
 
 	content := telegramMessageContent(msg)
 	attachments := c.telegramInboundAttachments(ctx, b, msg)
+	content, attachments = channels.LimitAttachments(content, attachments, c.cfg.ChannelAttachmentConfig)
 	if content == "" && len(attachments) == 0 {
 		return
 	}
+	content = channels.QuoteReply(telegramQuotedText(msg), content)
 
 	chatID := strconv.FormatInt(msg.Chat.ID, 10)
 	c.sendTypingHint(chatID)
 	// Avoid blocking telegram worker goroutines indefinitely when bus is saturated.
 	publishCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	_ = c.bus.PublishInbound(publishCtx, bus.InboundMessage{
-		Channel:     "telegram",
+		Channel:     c.Name(),
 		SenderID:    senderID,
 		ChatID:      chatID,
 		Content:     content,
 		Attachments: attachments,
-		SessionKey:  "telegram:" + chatID,
+		SessionKey:  c.Name() + ":" + chatID,
 		Delivery:    buildTelegramDelivery(msg),
+		SentAt:      telegramSentAt(msg),
 	})
 	cancel()
 }
 
+// telegramQuotedText returns the text the user replied to: the selected
+// quote when there is one, otherwise the whole replied-to message. Replies
+// to a forum topic's creation message are implicit and ignored.
+func telegramQuotedText(msg *models.Message) string {
+	if msg.Quote != nil && strings.TrimSpace(msg.Quote.Text) != "" {
+		return msg.Quote.Text
+	}
+	reply := msg.ReplyToMessage
+	if reply == nil || reply.ForumTopicCreated != nil {
+		return ""
+	}
+	return telegramMessageContent(reply)
+}
+
+// telegramSentAt returns when msg was sent, or last edited for edits.
+func telegramSentAt(msg *models.Message) time.Time {
+	unix := max(msg.Date, msg.EditDate)
+	if unix <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(unix), 0)
+}
+
 func (c *Channel) sendMessageWithRetry(ctx context.Context, b *tgbot.Bot, params *tgbot.SendMessageParams) error {
 	const maxAttempts = 3
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -247,6 +367,15 @@ func parseTelegramChatID(v string) (any, error) {
 	return v, nil
 }
 
+// telegramSendError marks errors that resending cannot fix (bot blocked or
+// removed, chat not found, rejected message) as undeliverable.
+func telegramSendError(err error) error {
+	if errors.Is(err, tgbot.ErrorForbidden) || errors.Is(err, tgbot.ErrorNotFound) || errors.Is(err, tgbot.ErrorBadRequest) {
+		return channels.Undeliverable(err)
+	}
+	return err
+}
+
 func shouldRetryTelegramSend(err error, attempt int) (bool, time.Duration) {
 	if err == nil {
 		return false, 0
@@ -3,13 +3,19 @@ package telegram
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	tgbot "github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/channels"
+	"github.com/mosaxiv/clawlet/config"
 )
 
 func TestResolveTelegramReplyTarget(t *testing.T) {
@@ -82,6 +88,21 @@ func TestBuildTelegramDelivery(t *testing.T) {
 	}
 }
 
+func TestTelegramQuotedText(t *testing.T) {
+	reply := &models.Message{ID: 7, Text: "the original"}
+	if got := telegramQuotedText(&models.Message{ReplyToMessage: reply}); got != "the original" {
+		t.Fatalf("reply: %q", got)
+	}
+	msg := &models.Message{ReplyToMessage: reply, Quote: &models.TextQuote{Text: "original"}}
+	if got := telegramQuotedText(msg); got != "original" {
+		t.Fatalf("quote: %q", got)
+	}
+	topic := &models.Message{ID: 1, ForumTopicCreated: &models.ForumTopicCreated{Name: "general"}}
+	if got := telegramQuotedText(&models.Message{ReplyToMessage: topic}); got != "" {
+		t.Fatalf("topic root should be ignored: %q", got)
+	}
+}
+
 func TestClampTelegramPollTimeout(t *testing.T) {
 	if got := clampTelegramPollTimeout(0); got != 25 {
 		t.Fatalf("expected default 25, got %d", got)
@@ -149,6 +170,16 @@ func TestMarkdownToTelegramHTML(t *testing.T) {
 	}
 }
 
+func TestMarkdownToTelegramHTML_CodeLanguage(t *testing.T) {
+	got := markdownToTelegramHTML("```go\nif a < b {}\n```\n```\nplain\n```")
+	if !strings.Contains(got, `<pre><code class="language-go">if a &lt; b {}`) {
+		t.Fatalf("labeled block: %q", got)
+	}
+	if !strings.Contains(got, "<pre><code>plain") {
+		t.Fatalf("unlabeled block: %q", got)
+	}
+}
+
 func TestIsTelegramParseError(t *testing.T) {
 	err := errors.New("error response from telegram for method sendMessage, 400 Bad Request: can't parse entities")
 	if !isTelegramParseError(err) {
@@ -189,3 +220,42 @@ func TestTelegramFileURL(t *testing.T) {
 		}
 	})
 }
+
+func TestStart_StopsOnGetUpdatesConflict(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			polls.Add(1)
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":409,"description":"Conflict: terminated by other getUpdates request"}`))
+		default:
+			_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(config.TelegramConfig{Token: "123:abc", BaseURL: srv.URL, ConflictRetries: 1}, bus.New(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := c.Start(ctx)
+	if err == nil || !strings.Contains(err.Error(), "another instance is polling this bot token") {
+		t.Fatalf("err=%v", err)
+	}
+	if n := polls.Load(); n != 2 {
+		t.Fatalf("polls=%d, want 2", n)
+	}
+}
+
+func TestTelegramSendError(t *testing.T) {
+	blocked := fmt.Errorf("%w, Forbidden: bot was blocked by the user", tgbot.ErrorForbidden)
+	if !errors.Is(telegramSendError(blocked), channels.ErrUndeliverable) {
+		t.Fatal("forbidden should be undeliverable")
+	}
+	if errors.Is(telegramSendError(&tgbot.TooManyRequestsError{RetryAfter: 1}), channels.ErrUndeliverable) {
+		t.Fatal("rate limit should be retried")
+	}
+}
@@ -0,0 +1,167 @@
+package channels
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+)
+
+// Uploader is implemented by channels whose Send uploads msg.Attachments.
+// MaxUploadBytes is the largest file the platform accepts from the bot.
+type Uploader interface {
+	MaxUploadBytes() int64
+}
+
+// maxCompressBytes bounds the files FitAttachments tries to gzip in memory.
+const maxCompressBytes = 256 << 20
+
+// FitAttachments makes msg's attachments deliverable through a channel that
+// uploads files up to maxBytes (0: the channel cannot upload). A file over
+// the limit is gzipped when that brings it under; otherwise it is shared as
+// a download link from links, or, without links, left out with a note.
+// Links and notes are appended to the message text.
+func FitAttachments(msg bus.OutboundMessage, maxBytes int64, links *FileLinks, now time.Time) bus.OutboundMessage {
+	if len(msg.Attachments) == 0 {
+		return msg
+	}
+	var notes []string
+	kept := make([]bus.Attachment, 0, len(msg.Attachments))
+	for _, att := range msg.Attachments {
+		name := AttachmentName(att)
+		size := AttachmentSize(att)
+		if maxBytes > 0 && size <= maxBytes {
+			kept = append(kept, att)
+			continue
+		}
+		if maxBytes > 0 && size <= maxCompressBytes && compressible(att) {
+			if gz, ok := gzipAttachment(att, maxBytes); ok {
+				kept = append(kept, gz)
+				continue
+			}
+		}
+		if links != nil && att.LocalPath != "" {
+			url, err := links.Add(att.LocalPath, name, att.MIMEType, now)
+			if err == nil {
+				notes = append(notes, fmt.Sprintf("%s (%s): %s (link expires in %s)", name, formatBytes(size), url, formatTTL(links.TTL())))
+				continue
+			}
+		}
+		if maxBytes > 0 {
+			notes = append(notes, fmt.Sprintf("[File %s not sent: %s exceeds the %s upload limit]", name, formatBytes(size), formatBytes(maxBytes)))
+		} else {
+			notes = append(notes, fmt.Sprintf("[File %s not sent: this channel cannot upload files]", name))
+		}
+	}
+	msg.Attachments = kept
+	if len(notes) > 0 {
+		msg.Content = strings.TrimSpace(msg.Content + "\n\n" + strings.Join(notes, "\n"))
+	}
+	return msg
+}
+
+// OpenAttachment returns the content of att: Data when set, else the file
+// at LocalPath.
+func OpenAttachment(att bus.Attachment) (io.ReadCloser, error) {
+	if att.Data != nil {
+		return io.NopCloser(bytes.NewReader(att.Data)), nil
+	}
+	if att.LocalPath == "" {
+		return nil, fmt.Errorf("attachment %s has no content", AttachmentName(att))
+	}
+	return os.Open(att.LocalPath)
+}
+
+// AttachmentName is the file name to upload att under.
+func AttachmentName(att bus.Attachment) string {
+	if name := strings.TrimSpace(att.Name); name != "" {
+		return name
+	}
+	if att.LocalPath != "" {
+		return filepath.Base(att.LocalPath)
+	}
+	return "file"
+}
+
+// AttachmentSize is the size of att in bytes, 0 if unknown.
+func AttachmentSize(att bus.Attachment) int64 {
+	if att.Data != nil {
+		return int64(len(att.Data))
+	}
+	if att.SizeBytes > 0 {
+		return att.SizeBytes
+	}
+	if att.LocalPath != "" {
+		if fi, err := os.Stat(att.LocalPath); err == nil {
+			return fi.Size()
+		}
+	}
+	return 0
+}
+
+// compressible reports whether att is worth gzipping: not media or an
+// archive, which are compressed already.
+func compressible(att bus.Attachment) bool {
+	switch bus.InferAttachmentKind(att.MIMEType) {
+	case "image", "audio", "video":
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(AttachmentName(att))) {
+	case ".gz", ".tgz", ".zip", ".7z", ".rar", ".xz", ".bz2", ".zst", ".jpg", ".jpeg", ".png", ".gif", ".webp", ".mp3", ".mp4", ".pdf", ".docx", ".xlsx", ".pptx":
+		return false
+	}
+	return true
+}
+
+// gzipAttachment returns att gzipped in memory, if the result fits maxBytes.
+func gzipAttachment(att bus.Attachment, maxBytes int64) (bus.Attachment, bool) {
+	rc, err := OpenAttachment(att)
+	if err != nil {
+		return bus.Attachment{}, false
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Name = AttachmentName(att)
+	if _, err := io.Copy(zw, rc); err != nil {
+		return bus.Attachment{}, false
+	}
+	if err := zw.Close(); err != nil || int64(buf.Len()) > maxBytes {
+		return bus.Attachment{}, false
+	}
+	return bus.Attachment{
+		Name:      AttachmentName(att) + ".gz",
+		MIMEType:  "application/gzip",
+		Kind:      "file",
+		SizeBytes: int64(buf.Len()),
+		Data:      buf.Bytes(),
+	}, true
+}
+
+func formatTTL(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,97 @@
+package channels
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mosaxiv/clawlet/bus"
+)
+
+func TestFitAttachments(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) bus.Attachment {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return bus.Attachment{Name: name, SizeBytes: int64(len(data)), LocalPath: p}
+	}
+	small := write("small.txt", []byte("hi"))
+	logs := write("big.log", bytes.Repeat([]byte("same line\n"), 1000))
+	photo := write("photo.png", bytes.Repeat([]byte{0x89}, 5000))
+	photo.MIMEType = "image/png"
+	now := time.Now()
+
+	msg := FitAttachments(bus.OutboundMessage{Content: "Files:", Attachments: []bus.Attachment{small, logs, photo}}, 1000, nil, now)
+	if len(msg.Attachments) != 2 || msg.Attachments[0].Name != "small.txt" || msg.Attachments[1].Name != "big.log.gz" {
+		t.Fatalf("attachments=%+v", msg.Attachments)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(msg.Attachments[1].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); len(b) != 10000 {
+		t.Fatalf("gunzipped %d bytes", len(b))
+	}
+	if !strings.Contains(msg.Content, "[File photo.png not sent: 4.9 KB exceeds the 1000 bytes upload limit]") {
+		t.Fatalf("content=%q", msg.Content)
+	}
+
+	links, err := NewFileLinks("https://bot.example.com/", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg = FitAttachments(bus.OutboundMessage{Attachments: []bus.Attachment{photo}}, 0, links, now)
+	if len(msg.Attachments) != 0 || !strings.Contains(msg.Content, "photo.png (4.9 KB): https://bot.example.com/files/") || !strings.Contains(msg.Content, "(link expires in 1h)") {
+		t.Fatalf("link fallback: %+v", msg)
+	}
+}
+
+func TestFileLinks(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(p, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links, err := NewFileLinks("http://gw.test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(raw string) *httptest.ResponseRecorder {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		links.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+		return w
+	}
+
+	link, err := links.Add(p, "report.csv", "text/csv", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := get(link)
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n" || !strings.Contains(w.Header().Get("Content-Disposition"), "report.csv") {
+		t.Fatalf("download: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if w := get(strings.Replace(link, "sig=", "sig=0", 1)); w.Code != http.StatusNotFound {
+		t.Fatalf("tampered link: %d", w.Code)
+	}
+
+	old, err := links.Add(p, "report.csv", "", time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := get(old); w.Code != http.StatusGone {
+		t.Fatalf("expired link: %d", w.Code)
+	}
+}
@@ -2,6 +2,8 @@ package whatsapp
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
@@ -162,7 +164,7 @@ func (c *Channel) Send(ctx context.Context, msg bus.OutboundMessage) error {
 
 	const maxAttempts = 3
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		_, err = wa.SendMessage(ctx, to, payload)
+		_, err = wa.SendMessage(ctx, to, payload, whatsmeow.SendRequestExtra{ID: whatsappMessageID(msg.IdempotencyKey)})
 		if err == nil {
 			return nil
 		}
@@ -211,10 +213,16 @@ func (c *Channel) handleIncomingMessage(evt *events.Message) {
 	c.mu.Lock()
 	wa := c.wa
 	c.mu.Unlock()
-	attachments := whatsappInboundAttachments(context.Background(), wa, evt.Message, config.DefaultMediaMaxFileBytes)
+	maxBytes := c.cfg.MaxAttachmentBytes
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultMediaMaxFileBytes
+	}
+	attachments := whatsappInboundAttachments(context.Background(), wa, evt.Message, maxBytes)
+	content, attachments = channels.LimitAttachments(content, attachments, c.cfg.ChannelAttachmentConfig)
 	if content == "" && len(attachments) == 0 {
 		return
 	}
+	content = channels.QuoteReply(whatsappQuotedContent(evt.Message), content)
 
 	chatID := evt.Info.Chat.String()
 	delivery := bus.Delivery{
@@ -234,6 +242,7 @@ func (c *Channel) handleIncomingMessage(evt *events.Message) {
 		Attachments: attachments,
 		SessionKey:  "whatsapp:" + chatID,
 		Delivery:    delivery,
+		SentAt:      evt.Info.Timestamp,
 	})
 	cancel()
 }
@@ -493,6 +502,14 @@ func whatsappReplyToID(msg *waE2E.Message) string {
 	return ""
 }
 
+// whatsappQuotedContent returns the content of the message being replied to.
+func whatsappQuotedContent(msg *waE2E.Message) string {
+	if ext := msg.GetExtendedTextMessage(); ext != nil {
+		return whatsappMessageContent(ext.GetContextInfo().GetQuotedMessage())
+	}
+	return ""
+}
+
 func whatsappInboundAttachments(ctx context.Context, wa *whatsmeow.Client, msg *waE2E.Message, maxBytes int64) []bus.Attachment {
 	if msg == nil {
 		return nil
@@ -503,7 +520,7 @@ func whatsappInboundAttachments(ctx context.Context, wa *whatsmeow.Client, msg *
 	out := make([]bus.Attachment, 0, 4)
 	if image := msg.GetImageMessage(); image != nil {
 		mimeType := strings.TrimSpace(image.GetMimetype())
-		data := whatsappDownloadAttachment(ctx, wa, image, maxBytes)
+		data := whatsappDownloadAttachment(ctx, wa, image, int64(image.GetFileLength()), maxBytes)
 		out = append(out, bus.Attachment{
 			Name:      "image",
 			MIMEType:  mimeType,
@@ -514,7 +531,7 @@ func whatsappInboundAttachments(ctx context.Context, wa *whatsmeow.Client, msg *
 	}
 	if video := msg.GetVideoMessage(); video != nil {
 		mimeType := strings.TrimSpace(video.GetMimetype())
-		data := whatsappDownloadAttachment(ctx, wa, video, maxBytes)
+		data := whatsappDownloadAttachment(ctx, wa, video, int64(video.GetFileLength()), maxBytes)
 		out = append(out, bus.Attachment{
 			Name:      "video",
 			MIMEType:  mimeType,
@@ -525,7 +542,7 @@ func whatsappInboundAttachments(ctx context.Context, wa *whatsmeow.Client, msg *
 	}
 	if doc := msg.GetDocumentMessage(); doc != nil {
 		mimeType := strings.TrimSpace(doc.GetMimetype())
-		data := whatsappDownloadAttachment(ctx, wa, doc, maxBytes)
+		data := whatsappDownloadAttachment(ctx, wa, doc, int64(doc.GetFileLength()), maxBytes)
 		out = append(out, bus.Attachment{
 			Name:      strings.TrimSpace(doc.GetFileName()),
 			MIMEType:  mimeType,
@@ -536,7 +553,7 @@ func whatsappInboundAttachments(ctx context.Context, wa *whatsmeow.Client, msg *
 	}
 	if audio := msg.GetAudioMessage(); audio != nil {
 		mimeType := strings.TrimSpace(audio.GetMimetype())
-		data := whatsappDownloadAttachment(ctx, wa, audio, maxBytes)
+		data := whatsappDownloadAttachment(ctx, wa, audio, int64(audio.GetFileLength()), maxBytes)
 		out = append(out, bus.Attachment{
 			Name:      "voice",
 			MIMEType:  mimeType,
@@ -556,10 +573,14 @@ func whatsappInboundAttachments(ctx context.Context, wa *whatsmeow.Client, msg *
 	return out
 }
 
-func whatsappDownloadAttachment(ctx context.Context, wa *whatsmeow.Client, media whatsmeow.DownloadableMessage, maxBytes int64) []byte {
+func whatsappDownloadAttachment(ctx context.Context, wa *whatsmeow.Client, media whatsmeow.DownloadableMessage, declaredSize, maxBytes int64) []byte {
 	if wa == nil || media == nil {
 		return nil
 	}
+	// Skip the download entirely when the declared size is already over the limit.
+	if maxBytes > 0 && declaredSize > maxBytes {
+		return nil
+	}
 	dlCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
@@ -583,3 +604,14 @@ func appendUniqueTrimmed(parts []string, v string) []string {
 	}
 	return append(parts, v)
 }
+
+// whatsappMessageID derives a stable message ID from the idempotency key, so
+// a resend is treated as the same message by recipients. An empty key lets
+// whatsmeow generate a random ID.
+func whatsappMessageID(key string) types.MessageID {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return types.MessageID(whatsmeow.WebMessageIDPrefix + strings.ToUpper(hex.EncodeToString(sum[:9])))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mosaxiv/clawlet/bus"
@@ -213,3 +214,32 @@ func TestSQLiteFileDSN(t *testing.T) {
 		t.Fatalf("expected %q, got %q", want, got)
 	}
 }
+
+func TestWhatsAppQuotedContent(t *testing.T) {
+	msg := &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+		Text: new("and this?"),
+		ContextInfo: &waE2E.ContextInfo{
+			StanzaID:      new("wamid.1"),
+			QuotedMessage: &waE2E.Message{Conversation: new("earlier")},
+		},
+	}}
+	if got := whatsappQuotedContent(msg); got != "earlier" {
+		t.Fatalf("got %q", got)
+	}
+	if got := whatsappQuotedContent(&waE2E.Message{Conversation: new("hi")}); got != "" {
+		t.Fatalf("plain message: %q", got)
+	}
+}
+
+func TestWhatsAppMessageID(t *testing.T) {
+	id := whatsappMessageID("k1")
+	if id != whatsappMessageID("k1") || id == whatsappMessageID("k2") {
+		t.Fatalf("ids should be stable per key: %q", id)
+	}
+	if !strings.HasPrefix(string(id), "3EB0") || len(id) != 22 {
+		t.Fatalf("id=%q", id)
+	}
+	if whatsappMessageID("") != "" {
+		t.Fatal("empty key should leave the ID to whatsmeow")
+	}
+}
@@ -4,20 +4,28 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/mosaxiv/clawlet/agent"
+	"github.com/mosaxiv/clawlet/bus"
 	"github.com/urfave/cli/v3"
 )
 
 func cmdAgent() *cli.Command {
 	return &cli.Command{
-		Name:  "agent",
-		Usage: "run an agent in CLI mode",
+		Name:      "agent",
+		Usage:     "run an agent in CLI mode",
+		ArgsUsage: "[message]",
 		Flags: []cli.Flag{
 			&cli.StringFlag{Name: "message", Aliases: []string{"m"}, Usage: "single message (non-interactive)"},
+			&cli.StringSliceFlag{Name: "file", Aliases: []string{"f"}, Usage: "attach a file to the message (repeatable)"},
+			&cli.BoolFlag{Name: "stdin", Usage: "read message content from stdin (appended to --message)"},
 			&cli.StringFlag{Name: "session", Aliases: []string{"s"}, Value: "cli:default", Usage: "session key"},
 			&cli.StringFlag{Name: "workspace", Usage: "workspace directory (default: ~/.clawlet/workspace or CLAWLET_WORKSPACE)"},
 			&cli.IntFlag{Name: "max-iters", Value: 20, Usage: "max tool-call iterations"},
@@ -46,8 +54,22 @@ func cmdAgent() *cli.Command {
 			}
 
 			msg := cmd.String("message")
-			if msg != "" {
-				out, err := a.Process(ctx, msg)
+			if msg == "" {
+				msg = strings.Join(cmd.Args().Slice(), " ")
+			}
+			if cmd.Bool("stdin") {
+				b, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("read stdin: %w", err)
+				}
+				msg = joinPromptAndStdin(msg, string(b))
+			}
+			attachments, err := fileAttachments(cmd.StringSlice("file"))
+			if err != nil {
+				return err
+			}
+			if msg != "" || len(attachments) > 0 {
+				out, err := a.ProcessWithAttachments(ctx, msg, attachments)
 				if err != nil {
 					return err
 				}
@@ -84,3 +106,61 @@ func cmdAgent() *cli.Command {
 		},
 	}
 }
+
+// joinPromptAndStdin puts piped input after the prompt, fenced so the model
+// can tell the instruction from the data.
+func joinPromptAndStdin(prompt, stdin string) string {
+	stdin = strings.TrimRight(stdin, "\n")
+	if strings.TrimSpace(stdin) == "" {
+		return prompt
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return stdin
+	}
+	return prompt + "\n\n```\n" + stdin + "\n```"
+}
+
+// fileAttachments turns --file paths into attachments. The MIME type comes
+// from the extension, else from the file's first bytes.
+func fileAttachments(paths []string) ([]bus.Attachment, error) {
+	out := make([]bus.Attachment, 0, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		st, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("--file: %w", err)
+		}
+		if st.IsDir() {
+			return nil, fmt.Errorf("--file: %s is a directory", p)
+		}
+		mimeType := mime.TypeByExtension(filepath.Ext(abs))
+		if mimeType == "" {
+			mimeType = sniffMIMEType(abs)
+		}
+		out = append(out, bus.Attachment{
+			Name:      filepath.Base(abs),
+			MIMEType:  mimeType,
+			Kind:      bus.InferAttachmentKind(mimeType),
+			SizeBytes: st.Size(),
+			LocalPath: abs,
+		})
+	}
+	return out, nil
+}
+
+func sniffMIMEType(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	if n == 0 {
+		return ""
+	}
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJoinPromptAndStdin(t *testing.T) {
+	if got := joinPromptAndStdin("explain this", "panic: boom\n"); got != "explain this\n\n```\npanic: boom\n```" {
+		t.Fatalf("got %q", got)
+	}
+	if got := joinPromptAndStdin("", "just data\n"); got != "just data" {
+		t.Fatalf("got %q", got)
+	}
+	if got := joinPromptAndStdin("prompt", "  \n"); got != "prompt" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestFileAttachments(t *testing.T) {
+	dir := t.TempDir()
+	png := filepath.Join(dir, "shot.png")
+	notes := filepath.Join(dir, "NOTES")
+	if err := os.WriteFile(png, []byte("\x89PNG\r\n\x1a\n0000"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(notes, []byte("plain text notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	atts, err := fileAttachments([]string{png, notes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atts[0].Kind != "image" || atts[0].MIMEType != "image/png" || atts[0].LocalPath != png {
+		t.Fatalf("png=%+v", atts[0])
+	}
+	if atts[1].Kind != "file" || atts[1].MIMEType != "text/plain; charset=utf-8" || atts[1].Name != "NOTES" {
+		t.Fatalf("notes=%+v", atts[1])
+	}
+	if _, err := fileAttachments([]string{dir}); err == nil {
+		t.Fatal("directories should be rejected")
+	}
+	if _, err := fileAttachments([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Fatal("missing files should be rejected")
+	}
+}
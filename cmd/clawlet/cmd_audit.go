@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/mosaxiv/clawlet/paths"
+	"github.com/mosaxiv/clawlet/tools"
+	"github.com/urfave/cli/v3"
+)
+
+func cmdAudit() *cli.Command {
+	return &cli.Command{
+		Name:  "audit",
+		Usage: "inspect the tool audit log (tools.auditLog)",
+		Commands: []*cli.Command{
+			auditTailCmd(),
+		},
+	}
+}
+
+func auditTailCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "tail",
+		Usage: "print the latest tool calls from ~/.clawlet/audit.jsonl",
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "lines", Aliases: []string{"n"}, Value: 20, Usage: "number of entries to show (0 = all)"},
+			&cli.BoolFlag{Name: "follow", Aliases: []string{"f"}, Usage: "keep printing new entries"},
+			&cli.StringFlag{Name: "session", Usage: "only show calls from this session key"},
+			&cli.StringFlag{Name: "tool", Usage: "only show calls to this tool"},
+			&cli.BoolFlag{Name: "json", Usage: "print raw JSON lines"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			path := paths.AuditLogPath()
+			f, err := os.Open(path)
+			switch {
+			case errors.Is(err, fs.ErrNotExist) && !cmd.Bool("follow"):
+				return cli.Exit(fmt.Sprintf("no audit log at %s; enable tools.auditLog", path), 1)
+			case err != nil && !errors.Is(err, fs.ErrNotExist):
+				return err
+			}
+			filter := auditFilter{session: cmd.String("session"), tool: cmd.String("tool"), raw: cmd.Bool("json")}
+			var offset int64
+			if err == nil {
+				offset, err = printAuditTail(f, os.Stdout, filter, cmd.Int("lines"))
+				f.Close()
+				if err != nil {
+					return err
+				}
+			}
+			if !cmd.Bool("follow") {
+				return nil
+			}
+			return followAudit(ctx, path, offset, os.Stdout, filter)
+		},
+	}
+}
+
+type auditFilter struct {
+	session string
+	tool    string
+	raw     bool
+}
+
+// printAuditTail writes the last n matching entries of r (all when n <= 0)
+// and returns how many bytes it read.
+func printAuditTail(r io.Reader, w io.Writer, filter auditFilter, n int) (int64, error) {
+	var (
+		lines []string
+		read  int64
+	)
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			read += int64(len(line))
+			if s, ok := filter.format(line); ok {
+				lines = append(lines, s)
+				if n > 0 && len(lines) > n {
+					lines = lines[1:]
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return read, err
+		}
+	}
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+	return read, nil
+}
+
+// followAudit prints entries appended to path after offset until ctx ends.
+func followAudit(ctx context.Context, path string, offset int64, w io.Writer, filter auditFilter) error {
+	t := time.NewTicker(500 * time.Millisecond)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if fi, err := f.Stat(); err == nil && fi.Size() < offset {
+			// Rotated or truncated: start over.
+			offset = 0
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err == nil {
+			n, err := printAuditTail(f, w, filter, 0)
+			offset += n
+			if err != nil {
+				f.Close()
+				return err
+			}
+		}
+		f.Close()
+	}
+}
+
+func (f auditFilter) format(line string) (string, bool) {
+	var e tools.AuditEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil || e.Tool == "" {
+		return "", false
+	}
+	if (f.session != "" && e.SessionKey != f.session) || (f.tool != "" && e.Tool != f.tool) {
+		return "", false
+	}
+	if f.raw {
+		return string(line[:len(line)-1]), true
+	}
+	status := "ok"
+	if !e.OK {
+		status = "ERR"
+	}
+	s := fmt.Sprintf("%s %-3s %6dms %s %s %s", e.Time.Local().Format("2006-01-02 15:04:05"), status, e.DurationMS, e.SessionKey, e.Tool, oneLine(string(e.Args), 160))
+	if e.Error != "" {
+		s += " error: " + oneLine(e.Error, 160)
+	}
+	return s, true
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintAuditTail(t *testing.T) {
+	log := `{"time":"2026-01-02T03:04:05Z","session":"slack:C1","tool":"exec","args":{"command":"ls"},"ok":true,"durationMs":12}
+{"time":"2026-01-02T03:04:06Z","session":"slack:C2","tool":"read_file","args":{"path":"x"},"ok":false,"error":"no such file","durationMs":1}
+not json
+{"time":"2026-01-02T03:04:07Z","session":"slack:C1","tool":"read_file","args":{"path":"y"},"ok":true,"durationMs":2}
+{"time":"2026-01-02T03:04:08Z","session":"slack:C1","tool":"exec"`
+
+	var out bytes.Buffer
+	n, err := printAuditTail(strings.NewReader(log), &out, auditFilter{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(strings.LastIndex(log, "\n") + 1); n != want {
+		t.Fatalf("read %d bytes, want %d (the partial last line is left for --follow)", n, want)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "ERR") || !strings.Contains(lines[0], "error: no such file") || !strings.Contains(lines[1], `read_file {"path":"y"}`) {
+		t.Fatalf("tail:\n%s", out.String())
+	}
+
+	out.Reset()
+	if _, err := printAuditTail(strings.NewReader(log), &out, auditFilter{session: "slack:C1", tool: "exec", raw: true}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); !strings.HasPrefix(got, `{"time":"2026-01-02T03:04:05Z"`) || strings.Contains(got, "\n") {
+		t.Fatalf("filtered raw tail: %q", got)
+	}
+}
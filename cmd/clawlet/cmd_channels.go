@@ -30,7 +30,11 @@ func cmdChannels() *cli.Command {
 					fmt.Printf("discord.enabled=%v\n", cfg.Channels.Discord.Enabled)
 					fmt.Printf("slack.enabled=%v\n", cfg.Channels.Slack.Enabled)
 					fmt.Printf("telegram.enabled=%v\n", cfg.Channels.Telegram.Enabled)
+					for _, inst := range cfg.Channels.Telegram.Instances {
+						fmt.Printf("%s.enabled=%v\n", inst.ChannelName(), inst.Enabled)
+					}
 					fmt.Printf("whatsapp.enabled=%v\n", cfg.Channels.WhatsApp.Enabled)
+					fmt.Printf("signal.enabled=%v\n", cfg.Channels.Signal.Enabled)
 					return nil
 				},
 			},
@@ -41,7 +41,14 @@ func cronListCmd() *cli.Command {
 				return nil
 			}
 			for _, j := range jobs {
-				fmt.Printf("- %s id=%s enabled=%v kind=%s next=%d\n", j.Name, j.ID, j.Enabled, j.Schedule.Kind, j.State.NextRunAtMS)
+				fmt.Printf("- %s id=%s enabled=%v kind=%s next=%d", j.Name, j.ID, j.Enabled, j.Schedule.Kind, j.State.NextRunAtMS)
+				if j.State.LastStatus != "" {
+					fmt.Printf(" last=%s", j.State.LastStatus)
+				}
+				if j.State.LastDelivery != "" {
+					fmt.Printf(" delivery=%s", j.State.LastDelivery)
+				}
+				fmt.Println()
 			}
 			return nil
 		},
@@ -63,7 +70,7 @@ func cronAddCmd() *cli.Command {
 			&cli.StringFlag{Name: "to", Usage: "delivery chat/user id"},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			_, _, err := loadConfig()
+			cfg, _, err := loadConfig()
 			if err != nil {
 				return err
 			}
@@ -124,6 +131,7 @@ func cronAddCmd() *cli.Command {
 			}
 
 			svc := cron.NewService(paths.CronStorePath(), nil)
+			svc.SetLimits(cfg.Cron.MaxJobsValue(), cfg.Cron.MaxMessageCharsValue())
 			j, err := svc.Add(jname, sched, payload)
 			if err != nil {
 				return err
@@ -194,10 +202,11 @@ func cronToggleCmd() *cli.Command {
 func cronRunCmd() *cli.Command {
 	return &cli.Command{
 		Name:      "run",
-		Usage:     "trigger a job immediately",
+		Usage:     "trigger a job immediately in the running gateway and report the result",
 		ArgsUsage: "<job_id>",
 		Flags: []cli.Flag{
 			&cli.BoolFlag{Name: "force", Usage: "run even if disabled"},
+			&cli.DurationFlag{Name: "wait", Value: 6 * time.Minute, Usage: "how long to wait for the run to finish (0 = do not wait)"},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			_, _, err := loadConfig()
@@ -205,16 +214,53 @@ func cronRunCmd() *cli.Command {
 				return err
 			}
 			if cmd.Args().Len() < 1 {
-				return cli.Exit("usage: clawlet cron run [--force] <job_id>", 2)
+				return cli.Exit("usage: clawlet cron run [--force] [--wait 6m] <job_id>", 2)
 			}
 			id := cmd.Args().Get(0)
 			svc := cron.NewService(paths.CronStorePath(), nil)
-			_, err = svc.RunNow(ctx, id, cmd.Bool("force"))
+			job, err := svc.RequestRun(id, cmd.Bool("force"))
 			if err != nil {
 				return err
 			}
-			fmt.Println("Triggered:", id)
-			return nil
+			wait := cmd.Duration("wait")
+			if wait <= 0 {
+				fmt.Println("Triggered:", id)
+				return nil
+			}
+			return waitCronRun(ctx, svc, job, wait)
 		},
 	}
 }
+
+// waitCronRun polls the store until the gateway has run the requested job
+// and prints its status and delivery outcome.
+func waitCronRun(ctx context.Context, svc *cron.Service, requested cron.Job, wait time.Duration) error {
+	requestedAt := requested.State.RunRequestedAtMS
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+		j, ok := svc.Get(requested.ID)
+		if !ok {
+			// One-shot jobs with deleteAfterRun are removed once they ran.
+			fmt.Println("Ran:", requested.ID, "(job no longer exists; one-shot jobs with deleteAfterRun are removed after running)")
+			return nil
+		}
+		if j.State.RunRequestedAtMS != 0 || j.State.LastRunAtMS < requestedAt {
+			continue
+		}
+		fmt.Printf("Ran: %s status=%s", j.ID, j.State.LastStatus)
+		if j.State.LastDelivery != "" {
+			fmt.Printf(" delivery=%s", j.State.LastDelivery)
+		}
+		fmt.Println()
+		if j.State.LastStatus != "ok" {
+			return cli.Exit("error: "+j.State.LastError, 1)
+		}
+		return nil
+	}
+	return cli.Exit(fmt.Sprintf("job %s was not run within %s; is `clawlet gateway` running?", requested.ID, wait), 1)
+}
@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/mosaxiv/clawlet/activity"
 	"github.com/mosaxiv/clawlet/agent"
 	"github.com/mosaxiv/clawlet/bus"
 	"github.com/mosaxiv/clawlet/channels"
 	"github.com/mosaxiv/clawlet/channels/discord"
+	signalch "github.com/mosaxiv/clawlet/channels/signal"
 	"github.com/mosaxiv/clawlet/channels/slack"
 	"github.com/mosaxiv/clawlet/channels/telegram"
 	"github.com/mosaxiv/clawlet/channels/whatsapp"
@@ -50,28 +55,37 @@ func cmdGateway() *cli.Command {
 			defer stop()
 
 			b := bus.New(256)
+			if cfg.Gateway.PersistOutbound {
+				store, err := bus.OpenOutboundStore(paths.OutboundQueuePath())
+				if err != nil {
+					return fmt.Errorf("open outbound queue: %w", err)
+				}
+				b.SetOutboundStore(store)
+			}
 			smgr := session.NewManager(paths.SessionsDir())
 
+			var hub *activity.Hub
+			if cfg.Gateway.ActivitySocket {
+				hub = activity.NewHub()
+				go func() {
+					if err := hub.Serve(ctx, paths.ActivitySocketPath()); err != nil {
+						fmt.Fprintf(os.Stderr, "gateway: activity socket failed: %v\n", err)
+					}
+				}()
+			}
+
 			var cronSvc *cron.Service
 			if cfg.Cron.EnabledValue() {
 				cronSvc = cron.NewService(paths.CronStorePath(), func(ctx context.Context, job cron.Job) (string, error) {
 					if job.Payload.Kind != "" && job.Payload.Kind != "agent_turn" {
 						return "", nil
 					}
-					ch := job.Payload.Channel
-					to := job.Payload.To
-					if !job.Payload.Deliver || strings.TrimSpace(ch) == "" || strings.TrimSpace(to) == "" {
+					if !job.Payload.Deliverable() {
 						return "", nil
 					}
-					_ = b.PublishInbound(ctx, bus.InboundMessage{
-						Channel:    ch,
-						SenderID:   "cron:" + job.ID,
-						ChatID:     to,
-						Content:    job.Payload.Message,
-						SessionKey: ch + ":" + to,
-					})
-					return "", nil
+					return runCronDelivery(ctx, b, cfg.Cron, job)
 				})
+				cronSvc.SetLimits(cfg.Cron.MaxJobsValue(), cfg.Cron.MaxMessageCharsValue())
 			}
 
 			loop, err := agent.NewLoop(agent.LoopOptions{
@@ -83,6 +97,8 @@ func cmdGateway() *cli.Command {
 				Sessions:     smgr,
 				Cron:         cronSvc,
 				Spawn:        nil,
+				Activity:     hub,
+				PostProcess:  replyTemplatePostProcess(cfg.Gateway.ReplyTemplate),
 				Verbose:      cmd.Bool("verbose"),
 			})
 			if err != nil {
@@ -98,16 +114,43 @@ func cmdGateway() *cli.Command {
 				}
 			}
 
+			quiet, err := heartbeat.ParseQuietHours(cfg.Heartbeat.QuietHours)
+			if err != nil {
+				return fmt.Errorf("heartbeat.quietHours: %w", err)
+			}
+			hbState := ""
+			if cfg.Heartbeat.PersistLastRun {
+				hbState = paths.HeartbeatStatePath()
+			}
 			hb := heartbeat.New(wsAbs, heartbeat.Options{
 				Enabled:     cfg.Heartbeat.EnabledValue(),
 				IntervalSec: cfg.Heartbeat.IntervalSec,
 				OnHeartbeat: func(ctx context.Context, prompt string) (string, error) {
 					return loop.ProcessDirect(ctx, prompt, "heartbeat", "cli", "heartbeat")
 				},
+				StatePath:  hbState,
+				QuietHours: quiet,
+				Location:   cfg.Agents.Defaults.Location(),
 			})
 			hb.Start(ctx)
 
 			cm := channels.NewManager(b)
+			cm.SetDedupWindow(cfg.Gateway.OutboundDedupWindow())
+			cm.SetCodeLanguageDetection(cfg.Channels.DetectCodeLanguageValue())
+			cm.SetActivity(hub)
+			loop.SetApprover(cm.RequestApproval)
+			if fl := cfg.Gateway.FileLinks; fl.Enabled {
+				links, err := channels.NewFileLinks(fl.PublicURL, fl.TTL())
+				if err != nil {
+					return err
+				}
+				srv, err := serveGatewayHTTP(cfg.Gateway.Listen, links)
+				if err != nil {
+					return err
+				}
+				defer srv.Close()
+				cm.SetFileLinks(links)
+			}
 			if cfg.Channels.Discord.Enabled {
 				cm.Add(discord.New(cfg.Channels.Discord, b))
 			}
@@ -122,11 +165,11 @@ func cmdGateway() *cli.Command {
 				sl = slack.New(cfg.Channels.Slack, b)
 				cm.Add(sl)
 			}
-			if cfg.Channels.Telegram.Enabled {
-				if strings.TrimSpace(cfg.Channels.Telegram.Token) == "" {
-					return fmt.Errorf("telegram enabled but token is empty")
+			for _, bot := range cfg.Channels.Telegram.Bots() {
+				if strings.TrimSpace(bot.Token) == "" {
+					return fmt.Errorf("%s enabled but token is empty", bot.ChannelName())
 				}
-				cm.Add(telegram.New(cfg.Channels.Telegram, b))
+				cm.Add(telegram.New(bot, b))
 			}
 			if cfg.Channels.WhatsApp.Enabled {
 				linked, err := whatsapp.IsLinked(ctx, cfg.Channels.WhatsApp)
@@ -138,11 +181,22 @@ func cmdGateway() *cli.Command {
 				}
 				cm.Add(whatsapp.New(cfg.Channels.WhatsApp, b))
 			}
+			if cfg.Channels.Signal.Enabled {
+				if cfg.Channels.Signal.Socket == "" && cfg.Channels.Signal.TCPAddr == "" {
+					return fmt.Errorf("signal enabled but socket and tcpAddr are empty")
+				}
+				cm.Add(signalch.New(cfg.Channels.Signal, b))
+			}
 
 			if err := cm.StartAll(ctx); err != nil {
 				return err
 			}
 
+			go func() {
+				if err := b.RequeuePending(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					fmt.Fprintf(os.Stderr, "gateway: requeue pending outbound failed: %v\n", err)
+				}
+			}()
 			go func() { _ = loop.Run(ctx) }()
 
 			fmt.Printf("gateway running\n- workspace: %s\n- sessions: %s\n", wsAbs, paths.SessionsDir())
@@ -159,6 +213,24 @@ func cmdGateway() *cli.Command {
 	}
 }
 
+// serveGatewayHTTP serves the gateway's HTTP endpoints on listen until the
+// returned server is closed.
+func serveGatewayHTTP(listen string, links *channels.FileLinks) (*http.Server, error) {
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("gateway listen %s: %w", listen, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(channels.FileLinksPath, links)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "gateway: http server stopped: %v\n", err)
+		}
+	}()
+	return srv, nil
+}
+
 func validateGatewayBindPolicy(cfg config.GatewayConfig) error {
 	listen := strings.TrimSpace(cfg.Listen)
 	if listen == "" {
@@ -197,3 +269,54 @@ func isLocalGatewayHost(host string) bool {
 	}
 	return false
 }
+
+// replyTemplatePostProcess returns a reply post-processor for
+// gateway.replyTemplate, or nil when no template is set.
+func replyTemplatePostProcess(tmpl string) func(ctx context.Context, channel, text string) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return nil
+	}
+	return func(ctx context.Context, channel, text string) (string, error) {
+		r := strings.NewReplacer("{reply}", text, "{channel}", channel)
+		return r.Replace(tmpl), nil
+	}
+}
+
+// runCronDelivery hands a delivering cron job to the agent loop and, unless
+// cron.confirmDelivery is off, waits for the reply's send result so the job
+// state records whether it reached the channel.
+func runCronDelivery(ctx context.Context, b *bus.Bus, cfg config.CronConfig, job cron.Job) (string, error) {
+	ch, to := job.Payload.Channel, job.Payload.To
+	msg := bus.InboundMessage{
+		Channel:    ch,
+		SenderID:   "cron:" + job.ID,
+		ChatID:     to,
+		Content:    job.Payload.Message,
+		SessionKey: ch + ":" + to,
+	}
+	if !cfg.ConfirmDeliveryValue() {
+		_ = b.PublishInbound(ctx, msg)
+		return "", nil
+	}
+
+	msg.TrackingID = fmt.Sprintf("cron:%s:%d", job.ID, time.Now().UnixNano())
+	result, cancel := b.TrackDelivery(msg.TrackingID)
+	defer cancel()
+	if err := b.PublishInbound(ctx, msg); err != nil {
+		return "", err
+	}
+	timeout := time.Duration(cfg.DeliveryTimeoutSecValue()) * time.Second
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case err := <-result:
+		if err != nil {
+			return "", &cron.DeliveryError{Err: err}
+		}
+		return "delivered to " + ch + ":" + to, nil
+	case <-t.C:
+		return "", &cron.DeliveryError{Err: fmt.Errorf("no send result within %s", timeout)}
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/cron"
+)
+
+func TestReplyTemplatePostProcess(t *testing.T) {
+	if replyTemplatePostProcess("  ") != nil {
+		t.Fatalf("expected nil hook for empty template")
+	}
+	fn := replyTemplatePostProcess("{reply}\n\n_via {channel}_")
+	got, err := fn(context.Background(), "telegram", "hello {channel}")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if got != "hello {channel}\n\n_via telegram_" {
+		t.Fatalf("got=%q", got)
+	}
+}
+
+func TestRunCronDelivery_WaitsForSendResult(t *testing.T) {
+	job := cron.Job{ID: "j1", Payload: cron.Payload{Message: "ping", Deliver: true, Channel: "telegram", To: "42"}}
+	for _, tc := range []struct {
+		name    string
+		sendErr error
+	}{
+		{name: "delivered"},
+		{name: "failed", sendErr: errors.New("chat not found")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := bus.New(1)
+			go func() {
+				in, err := b.ConsumeInbound(context.Background())
+				if err == nil {
+					b.ReportDelivery(in.TrackingID, tc.sendErr)
+				}
+			}()
+			out, err := runCronDelivery(context.Background(), b, config.CronConfig{}, job)
+			var derr *cron.DeliveryError
+			if tc.sendErr == nil {
+				if err != nil || out != "delivered to telegram:42" {
+					t.Fatalf("out=%q err=%v", out, err)
+				}
+			} else if !errors.As(err, &derr) || !errors.Is(err, tc.sendErr) {
+				t.Fatalf("expected DeliveryError, got %v", err)
+			}
+		})
+	}
+
+	off := false
+	b := bus.New(1)
+	if _, err := runCronDelivery(context.Background(), b, config.CronConfig{ConfirmDelivery: &off}, job); err != nil {
+		t.Fatalf("unconfirmed delivery: %v", err)
+	}
+}
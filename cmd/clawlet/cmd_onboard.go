@@ -24,6 +24,7 @@ func cmdOnboard() *cli.Command {
 		Usage: "initialize config and workspace scaffolding",
 		Flags: []cli.Flag{
 			&cli.BoolFlag{Name: "overwrite", Usage: "overwrite existing config if present"},
+			&cli.BoolFlag{Name: "interactive", Aliases: []string{"i"}, Usage: "pick a provider interactively and verify it before saving"},
 			&cli.StringFlag{Name: "workspace", Usage: "workspace directory to initialize (default: ~/.clawlet/workspace or CLAWLET_WORKSPACE)"},
 			&cli.StringFlag{Name: "model", Usage: "set agents.defaults.model (e.g. openrouter/anthropic/claude-sonnet-4-5)"},
 			&cli.StringFlag{Name: "openrouter-api-key", Usage: "write env.OPENROUTER_API_KEY into config.json"},
@@ -47,6 +48,25 @@ func cmdOnboard() *cli.Command {
 			oaKey := cmd.String("openai-api-key")
 			anthropicKey := cmd.String("anthropic-api-key")
 			geminiKey := cmd.String("gemini-api-key")
+			if cmd.Bool("interactive") {
+				sel, err := runInteractiveOnboard(ctx, os.Stdin, os.Stdout, checkLLM, func(ctx context.Context) error {
+					return loginOpenAICodex(ctx, false)
+				})
+				if err != nil {
+					return err
+				}
+				model = sel.Model
+				switch sel.EnvKey {
+				case "OPENROUTER_API_KEY":
+					orKey = sel.APIKey
+				case "OPENAI_API_KEY":
+					oaKey = sel.APIKey
+				case "ANTHROPIC_API_KEY":
+					anthropicKey = sel.APIKey
+				case "GEMINI_API_KEY":
+					geminiKey = sel.APIKey
+				}
+			}
 			if err := saveMinimalConfig(cfgPath, model, orKey, oaKey, anthropicKey, geminiKey); err != nil {
 				return err
 			}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/mosaxiv/clawlet/llm"
 	"github.com/urfave/cli/v3"
@@ -10,6 +11,10 @@ import (
 
 const oauthProviderOpenAICodex = "openai-codex"
 
+// oauthProviders are the providers that store credentials under the auth
+// directory; `provider logout --all` clears each of them.
+var oauthProviders = []string{oauthProviderOpenAICodex}
+
 func cmdProvider() *cli.Command {
 	return &cli.Command{
 		Name:  "provider",
@@ -37,10 +42,56 @@ func cmdProvider() *cli.Command {
 					}
 				},
 			},
+			{
+				Name:      "logout",
+				Usage:     "remove stored OAuth credentials",
+				ArgsUsage: "<provider>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "remove the credentials of every OAuth provider",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Bool("all") {
+						for _, p := range oauthProviders {
+							if err := logoutProvider(p); err != nil {
+								return err
+							}
+						}
+						return nil
+					}
+					if cmd.Args().Len() < 1 {
+						return cli.Exit("usage: clawlet provider logout <provider> | --all", 2)
+					}
+					return logoutProvider(cmd.Args().Get(0))
+				},
+			},
 		},
 	}
 }
 
+func logoutProvider(provider string) error {
+	switch provider {
+	case oauthProviderOpenAICodex:
+		removed, codexCLIAuth, err := llm.LogoutCodexOAuth()
+		if err != nil {
+			return err
+		}
+		if removed != "" {
+			fmt.Printf("removed %s\n", removed)
+		} else {
+			fmt.Println("no OpenAI Codex credentials stored")
+		}
+		if codexCLIAuth != "" {
+			fmt.Printf("note: the Codex CLI login in %s is still present and will be imported on next use; run `codex logout` to remove it\n", codexCLIAuth)
+		}
+		return nil
+	default:
+		return cli.Exit(fmt.Sprintf("unsupported oauth provider: %s (supported: %s)", provider, strings.Join(oauthProviders, ", ")), 1)
+	}
+}
+
 func loginOpenAICodex(ctx context.Context, useDeviceCode bool) error {
 	if tok, err := llm.LoadCodexOAuthToken(); err == nil && tok.Valid() {
 		fmt.Printf("already authenticated with OpenAI Codex (%s)\n", tok.AccountID)
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/agent"
+	"github.com/mosaxiv/clawlet/paths"
+	"github.com/mosaxiv/clawlet/session"
+	"github.com/urfave/cli/v3"
+)
+
+func cmdSession() *cli.Command {
+	return &cli.Command{
+		Name:  "session",
+		Usage: "inspect and replay sessions",
+		Commands: []*cli.Command{
+			sessionReplayCmd(),
+		},
+	}
+}
+
+func sessionReplayCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "replay",
+		Usage:     "re-run a session's user turns against a (different) model and compare replies",
+		ArgsUsage: "<session-key>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "model", Usage: "model to replay with (default: configured model)"},
+			&cli.StringFlag{Name: "workspace", Usage: "workspace directory (default: ~/.clawlet/workspace or CLAWLET_WORKSPACE)"},
+			&cli.IntFlag{Name: "max-iters", Value: 20, Usage: "max tool-call iterations per turn"},
+			&cli.IntFlag{Name: "limit", Usage: "replay at most N user turns (0 = all)"},
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "verbose (print tool calls)"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			key := strings.TrimSpace(cmd.Args().First())
+			if key == "" {
+				return cli.Exit("usage: clawlet session replay <session-key> [--model <model>]", 2)
+			}
+			cfg, _, err := loadConfigWithModel(cmd.String("model"))
+			if err != nil {
+				return err
+			}
+			wsAbs, err := resolveWorkspace(cmd.String("workspace"))
+			if err != nil {
+				return err
+			}
+
+			src, err := session.Load(paths.SessionsDir(), key)
+			if err != nil {
+				return err
+			}
+			if src == nil {
+				return cli.Exit(fmt.Sprintf("session not found: %s", key), 1)
+			}
+			turns := replayTurns(src.Messages)
+			if limit := cmd.Int("limit"); limit > 0 && limit < len(turns) {
+				turns = turns[:limit]
+			}
+			if len(turns) == 0 {
+				fmt.Println("No user turns.")
+				return nil
+			}
+
+			a, err := agent.New(agent.Options{
+				Config:       cfg,
+				WorkspaceDir: wsAbs,
+				SessionKey:   "replay:" + key,
+				MaxIters:     cmd.Int("max-iters"),
+				Verbose:      cmd.Bool("verbose"),
+				Ephemeral:    true,
+				Replay:       true,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("replaying %d turns from %s with %s/%s\n", len(turns), key, cfg.LLM.Provider, cfg.LLM.Model)
+			for i, t := range turns {
+				reply, err := a.Process(ctx, t.User)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "turn %d error: %v\n", i+1, err)
+					reply = "(error: " + err.Error() + ")"
+				}
+				fmt.Printf("\n=== turn %d ===\n", i+1)
+				fmt.Printf("--- user ---\n%s\n", t.User)
+				fmt.Printf("--- original ---\n%s\n", t.Original)
+				fmt.Printf("--- replay ---\n%s\n", reply)
+			}
+			return nil
+		},
+	}
+}
+
+type replayTurn struct {
+	User     string
+	Original string
+}
+
+// replayTurns pairs each user message with the assistant reply that followed it.
+func replayTurns(msgs []session.Message) []replayTurn {
+	var out []replayTurn
+	for i, m := range msgs {
+		if m.Role != "user" || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		t := replayTurn{User: m.Content}
+		for _, next := range msgs[i+1:] {
+			if next.Role == "user" {
+				break
+			}
+			if next.Role == "assistant" {
+				t.Original = next.Content
+				break
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
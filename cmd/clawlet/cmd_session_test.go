@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mosaxiv/clawlet/session"
+)
+
+func TestReplayTurns_PairsUserWithReply(t *testing.T) {
+	msgs := []session.Message{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hello"},
+		{Role: "user", Content: "no reply"},
+		{Role: "user", Content: "again"},
+		{Role: "assistant", Content: "sure"},
+	}
+	turns := replayTurns(msgs)
+	if len(turns) != 3 {
+		t.Fatalf("turns=%d", len(turns))
+	}
+	if turns[0].User != "hi" || turns[0].Original != "hello" {
+		t.Fatalf("turn0=%+v", turns[0])
+	}
+	if turns[1].Original != "" {
+		t.Fatalf("turn1=%+v", turns[1])
+	}
+	if turns[2].User != "again" || turns[2].Original != "sure" {
+		t.Fatalf("turn2=%+v", turns[2])
+	}
+}
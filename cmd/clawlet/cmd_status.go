@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"strings"
@@ -13,6 +14,9 @@ func cmdStatus() *cli.Command {
 	return &cli.Command{
 		Name:  "status",
 		Usage: "print effective configuration status",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "check", Usage: "send a test prompt to verify the LLM provider works"},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			cfg, cfgPath, err := loadConfig()
 			if err != nil {
@@ -23,31 +27,163 @@ func cmdStatus() *cli.Command {
 			fmt.Printf("llm.provider: %s\n", cfg.LLM.Provider)
 			fmt.Printf("llm.baseURL: %s\n", cfg.LLM.BaseURL)
 			fmt.Printf("llm.model: %s\n", cfg.LLM.Model)
+			if cfg.LLM.Provider == "azure" {
+				fmt.Printf("llm.azureApiVersion: %s\n", cmp.Or(cfg.LLM.AzureAPIVersion, "2024-10-21"))
+			}
+			fmt.Printf("llm.retryEmptyResponses: %v\n", cfg.LLM.RetryEmptyResponses)
+			fmt.Printf("llm.maxContinuations: %d\n", cfg.LLM.MaxContinuations)
+			fmt.Printf("llm.rateLimit.requestsPerSecond: %g\n", cfg.LLM.RateLimit.RequestsPerSecond)
+			fmt.Printf("llm.rateLimit.maxConcurrent: %d\n", cfg.LLM.RateLimit.MaxConcurrent)
+			if d := cfg.LLM.TimeoutFor(cfg.LLM.Provider); d > 0 {
+				fmt.Printf("llm.timeout: %s\n", d)
+			}
+			fmt.Printf("llm.reasoningEffort: %s\n", cfg.LLM.ReasoningEffort)
+			fmt.Printf("llm.headers: %d\n", len(cfg.LLM.HeadersFor(cfg.LLM.Provider)))
+			if cfg.LLM.Transcription.Local() {
+				fmt.Printf("llm.transcription: local (%s)\n", cmp.Or(cfg.LLM.Transcription.Command, "whisper-cli"))
+			}
 			if strings.TrimSpace(cfg.Agents.Defaults.Model) != "" {
 				fmt.Printf("agents.defaults.model: %s\n", cfg.Agents.Defaults.Model)
 			}
 			fmt.Printf("agents.defaults.maxTokens: %d\n", cfg.Agents.Defaults.MaxTokensValue())
 			fmt.Printf("agents.defaults.temperature: %.2f\n", cfg.Agents.Defaults.TemperatureValue())
+			fmt.Printf("agents.defaults.memoryWindow: %d\n", cfg.Agents.Defaults.MemoryWindowValue())
+			fmt.Printf("agents.defaults.historyWindow: %d\n", cfg.Agents.Defaults.HistoryWindowValue())
+			if pf := cfg.Agents.Defaults.PersonaFile; pf != "" {
+				fmt.Printf("agents.defaults.personaFile: %s\n", pf)
+			}
+			fmt.Printf("agents.defaults.timezone: %s\n", cfg.Agents.Defaults.Location())
+			fmt.Printf("agents.defaults.promptTime: %v\n", cfg.Agents.Defaults.PromptTimeValue())
+			fmt.Printf("agents.defaults.idleConsolidation.afterSec: %d\n", cfg.Agents.Defaults.IdleConsolidation.AfterSec)
+			fmt.Printf("agents.defaults.consolidationRepair: %v\n", cfg.Agents.Defaults.ConsolidationRepairValue())
+			fmt.Printf("agents.defaults.toolCallDedup: %v\n", cfg.Agents.Defaults.ToolCallDedupValue())
+			fmt.Printf("agents.defaults.maxParallelTools: %d\n", cfg.Agents.Defaults.MaxParallelToolsValue())
+			fmt.Printf("agents.defaults.systemPromptMaxChars: %d\n", cfg.Agents.Defaults.SystemPromptMaxChars)
+			fmt.Printf("agents.defaults.subagentResults: %s\n", cmp.Or(cfg.Agents.Defaults.SubagentResults, "raw"))
+			if sm := cfg.Agents.Defaults.SimpleModel; sm.Enabled() {
+				fmt.Printf("agents.defaults.simpleModel: %s (classifier: %s, maxChars: %d)\n", sm.Model, cmp.Or(sm.Classifier, "heuristic"), sm.MaxCharsValue())
+			}
+			fmt.Printf("agents.defaults.historyRotation.maxKB: %d\n", cfg.Agents.Defaults.HistoryRotation.MaxBytes()>>10)
+			fmt.Printf("agents.defaults.historyRotation.summarize: %v\n", cfg.Agents.Defaults.HistoryRotation.Summarize)
+			fmt.Printf("agents.defaults.idleConsolidation.checkIntervalSec: %d\n", cfg.Agents.Defaults.IdleConsolidation.CheckIntervalSecValue())
+			if rs := cfg.Agents.Defaults.RunningSummary; rs.Enabled {
+				fmt.Printf("agents.defaults.runningSummary: keep %d messages, up to %d chars\n", rs.KeepMessagesValue(), rs.MaxCharsValue())
+			}
+			if cfg.Agents.Defaults.DetectReplyLanguage {
+				fmt.Println("agents.defaults.detectReplyLanguage: true")
+			}
+			if n := cfg.Agents.Defaults.ConsolidationToolResultChars; n > 0 {
+				fmt.Printf("agents.defaults.consolidationToolResultChars: %d\n", n)
+			}
+			if w := cfg.Agents.Defaults.IdleWrapUp; w.Enabled() {
+				fmt.Printf("agents.defaults.idleWrapUp: %s after %ds\n", w.ActionValue(), w.AfterSec)
+			}
 			fmt.Printf("tools.restrictToWorkspace: %v\n", cfg.Tools.RestrictToWorkspaceValue())
+			fmt.Printf("tools.writeDenyGlobs: %v\n", cfg.Tools.WriteDenyGlobs)
+			fmt.Printf("tools.requireReadBeforeWrite: %v\n", cfg.Tools.RequireReadBeforeWrite)
+			if len(cfg.Tools.RequireApproval) > 0 {
+				fmt.Printf("tools.requireApproval: %s\n", strings.Join(cfg.Tools.RequireApproval, ", "))
+			}
+			fmt.Printf("tools.auditLog: %v\n", cfg.Tools.AuditLog)
+			fmt.Printf("tools.truncateMode: %s\n", cfg.Tools.TruncateMode)
+			fmt.Printf("tools.safeMode: %v\n", cfg.Tools.SafeMode)
+			fmt.Printf("tools.egressAllowHosts: %v\n", cfg.Tools.EgressAllowHosts)
+			fmt.Printf("tools.timeouts: %v\n", cfg.Tools.Timeouts)
 			fmt.Printf("tools.exec.timeoutSec: %d\n", cfg.Tools.Exec.TimeoutSec)
+			fmt.Printf("tools.exec.cleanOutput: %v\n", cfg.Tools.Exec.CleanOutputValue())
+			fmt.Printf("tools.exec.extraEnv: %v\n", envNames(cfg.Tools.Exec.ExtraEnv))
+			fmt.Printf("tools.exec.scriptInterpreters: %v\n", cfg.Tools.Exec.ScriptInterpreters)
+			fmt.Printf("tools.exec.helpCommands: %v\n", cfg.Tools.Exec.HelpCommands)
+			if allow := cfg.Tools.Exec.AllowedCommands(); len(allow) > 0 {
+				fmt.Printf("tools.exec allowlist: %v\n", allow)
+			}
+			fmt.Printf("tools.summarize.enabled: %v\n", cfg.Tools.Summarize.EnabledValue())
+			fmt.Printf("tools.summarize.model: %s\n", cfg.Tools.Summarize.Model)
+			fmt.Printf("tools.message.allowedTargets: %v\n", cfg.Tools.Message.AllowedTargets)
+			fmt.Printf("tools.spillResultKB: %d\n", cfg.Tools.SpillResultKB)
+			fmt.Printf("tools.profile: %s\n", cfg.Tools.Profile)
+			fmt.Printf("tools.channelProfiles: %v\n", cfg.Tools.ChannelProfiles)
+			fmt.Printf("tools.memory.read: %v\n", cfg.Tools.Memory.ReadValue())
+			fmt.Printf("tools.memory.write: %v\n", cfg.Tools.Memory.Write)
+			fmt.Printf("tools.memory.inPrompt: %v\n", cfg.Tools.Memory.InPromptValue())
+			fmt.Printf("tools.weather.enabled: %v\n", cfg.Tools.Weather.Enabled)
+			fmt.Printf("tools.weather.provider: %s\n", cfg.Tools.Weather.ProviderValue())
+			fmt.Printf("tools.systemInfo.enabled: %v\n", cfg.Tools.SystemInfo.Enabled)
+			fmt.Printf("tools.systemInfo.processes: %v\n", cfg.Tools.SystemInfo.Processes)
+			if gh := cfg.Tools.GitHub; gh.Enabled {
+				fmt.Printf("tools.github: %s (token: %v)\n", gh.APIURLValue(), strings.TrimSpace(gh.Token) != "")
+			}
 			fmt.Printf("tools.web.braveApiKey: %v\n", cfg.Tools.Web.BraveAPIKey != "")
 			fmt.Printf("tools.web.allowedDomains: %v\n", cfg.Tools.Web.AllowedDomains)
 			fmt.Printf("tools.web.blockedDomains: %v\n", cfg.Tools.Web.BlockedDomains)
 			fmt.Printf("tools.web.maxResponseBytes: %d\n", cfg.Tools.Web.MaxResponseBytes)
 			fmt.Printf("tools.web.fetchTimeoutSec: %d\n", cfg.Tools.Web.FetchTimeoutSec)
+			fmt.Printf("tools.web.fetchRetries: %d\n", cfg.Tools.Web.FetchRetriesValue())
+			fmt.Printf("tools.web.summarizeOverChars: %d\n", cfg.Tools.Web.SummarizeOverChars)
 			fmt.Printf("tools.skills.enabled: %v\n", cfg.Tools.Skills.EnabledValue())
 			fmt.Printf("tools.skills.registry.baseURL: %s\n", cfg.Tools.Skills.Registry.BaseURL)
 			fmt.Printf("tools.skills.registry.authToken: %v\n", cfg.Tools.Skills.Registry.AuthToken != "")
 			fmt.Printf("cron.enabled: %v\n", cfg.Cron.EnabledValue())
+			fmt.Printf("cron.confirmDelivery: %v\n", cfg.Cron.ConfirmDeliveryValue())
+			fmt.Printf("cron.deliveryTimeoutSec: %d\n", cfg.Cron.DeliveryTimeoutSecValue())
+			fmt.Printf("cron.maxJobs: %d\n", cfg.Cron.MaxJobsValue())
+			fmt.Printf("cron.maxMessageChars: %d\n", cfg.Cron.MaxMessageCharsValue())
 			fmt.Printf("heartbeat.enabled: %v\n", cfg.Heartbeat.EnabledValue())
 			fmt.Printf("heartbeat.intervalSec: %d\n", cfg.Heartbeat.IntervalSec)
+			fmt.Printf("heartbeat.persistLastRun: %v\n", cfg.Heartbeat.PersistLastRun)
+			fmt.Printf("heartbeat.quietHours: %q\n", cfg.Heartbeat.QuietHours)
 			fmt.Printf("gateway.listen: %s\n", cfg.Gateway.Listen)
 			fmt.Printf("gateway.allowPublicBind: %v\n", cfg.Gateway.AllowPublicBind)
+			fmt.Printf("gateway.persistOutbound: %v\n", cfg.Gateway.PersistOutbound)
+			fmt.Printf("gateway.activitySocket: %v\n", cfg.Gateway.ActivitySocket)
+			fmt.Printf("gateway.replyTemplate: %q\n", cfg.Gateway.ReplyTemplate)
+			fmt.Printf("gateway.errorReply.detail: %v\n", cfg.Gateway.ErrorReply.Detail)
+			fmt.Printf("gateway.fileLinks.enabled: %v\n", cfg.Gateway.FileLinks.Enabled)
+			if lc := cfg.Gateway.LLMCircuit; lc.Enabled {
+				fmt.Printf("gateway.llmCircuit: pause after %d failures, probe every %s\n", lc.FailuresValue(), lc.ProbeInterval())
+			}
+			fmt.Printf("gateway.inboundConcurrency: %d\n", cfg.Gateway.InboundConcurrencyValue())
+			fmt.Printf("gateway.outboundDedupWindow: %s\n", cfg.Gateway.OutboundDedupWindow())
+			fmt.Printf("gateway.moderation.enabled: %v\n", cfg.Gateway.Moderation.Enabled())
+			fmt.Printf("gateway.moderation.outbound: %v\n", cfg.Gateway.Moderation.Outbound)
+			fmt.Printf("channels.maxMessageAgeSec: %d\n", cfg.Channels.MaxMessageAgeSec)
+			fmt.Printf("channels.detectCodeLanguage: %v\n", cfg.Channels.DetectCodeLanguageValue())
+			if len(cfg.Channels.EnabledCommands) > 0 {
+				fmt.Printf("channels.enabledCommands: %s\n", strings.Join(cfg.Channels.EnabledCommands, ", "))
+			}
+			fmt.Printf("channels.archiveOnNew: %v\n", cfg.Channels.ArchiveOnNew)
+			if len(cfg.Channels.AdminCommands) > 0 {
+				fmt.Printf("channels.adminCommands: %s (%d admins)\n", strings.Join(cfg.Channels.AdminCommands, ", "), len(cfg.Channels.Admins))
+			}
 			fmt.Printf("channels.discord.enabled: %v\n", cfg.Channels.Discord.Enabled)
 			fmt.Printf("channels.slack.enabled: %v\n", cfg.Channels.Slack.Enabled)
+			fmt.Printf("channels.slack.autoThread: %v\n", cfg.Channels.Slack.AutoThread)
 			fmt.Printf("channels.telegram.enabled: %v\n", cfg.Channels.Telegram.Enabled)
+			for _, inst := range cfg.Channels.Telegram.Instances {
+				fmt.Printf("channels.telegram.instances.%s.enabled: %v\n", inst.ID, inst.Enabled)
+			}
 			fmt.Printf("channels.whatsapp.enabled: %v\n", cfg.Channels.WhatsApp.Enabled)
+			fmt.Printf("channels.signal.enabled: %v\n", cfg.Channels.Signal.Enabled)
+			if cmd.Bool("check") {
+				reply, err := checkLLM(ctx, cfg)
+				if err != nil {
+					fmt.Printf("llm.check: error: %v\n", err)
+					return cli.Exit("", 1)
+				}
+				fmt.Printf("llm.check: ok (%s)\n", reply)
+			}
 			return nil
 		},
 	}
 }
+
+// envNames returns the variable names of NAME or NAME=value entries, so
+// status output does not print values that may be secrets.
+func envNames(entries []string) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		k, _, _ := strings.Cut(strings.TrimSpace(e), "=")
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/activity"
+	"github.com/mosaxiv/clawlet/paths"
+	"github.com/urfave/cli/v3"
+)
+
+func cmdTail() *cli.Command {
+	return &cli.Command{
+		Name:  "tail",
+		Usage: "stream live gateway activity (requires gateway.activitySocket=true)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "session", Usage: "only show events for this session key"},
+			&cli.BoolFlag{Name: "json", Usage: "print raw JSON events"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "unix", paths.ActivitySocketPath())
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("cannot connect to gateway activity socket: %v\nstart `clawlet gateway` with gateway.activitySocket=true", err), 1)
+			}
+			defer conn.Close()
+			go func() {
+				<-ctx.Done()
+				_ = conn.Close()
+			}()
+			err = tailEvents(conn, os.Stdout, cmd.String("session"), cmd.Bool("json"))
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+// tailEvents copies events from r to w until r is closed.
+func tailEvents(r io.Reader, w io.Writer, session string, raw bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 8<<20)
+	for sc.Scan() {
+		var ev activity.Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			continue
+		}
+		if session != "" && eventSessionKey(ev) != session {
+			continue
+		}
+		if raw {
+			fmt.Fprintln(w, sc.Text())
+			continue
+		}
+		fmt.Fprintln(w, formatEvent(ev))
+	}
+	return sc.Err()
+}
+
+func eventSessionKey(ev activity.Event) string {
+	if ev.SessionKey != "" {
+		return ev.SessionKey
+	}
+	return ev.Channel + ":" + ev.ChatID
+}
+
+func formatEvent(ev activity.Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %-9s %s", ev.Time.Local().Format("15:04:05"), ev.Kind, eventSessionKey(ev))
+	if ev.Tool != "" {
+		sb.WriteString(" " + ev.Tool)
+	}
+	if text := oneLine(ev.Text, 160); text != "" {
+		sb.WriteString(" " + text)
+	}
+	return sb.String()
+}
+
+func oneLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/agent"
+	"github.com/urfave/cli/v3"
+)
+
+func cmdTool() *cli.Command {
+	return &cli.Command{
+		Name:  "tool",
+		Usage: "run agent tools directly",
+		Commands: []*cli.Command{
+			toolRunCmd(),
+		},
+	}
+}
+
+func toolRunCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "run",
+		Usage:     "run a single tool with JSON arguments and print the result",
+		ArgsUsage: "<name>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "args", Aliases: []string{"a"}, Value: "{}", Usage: "tool arguments as a JSON object"},
+			&cli.StringFlag{Name: "workspace", Usage: "workspace directory (default: ~/.clawlet/workspace or CLAWLET_WORKSPACE)"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name := strings.TrimSpace(cmd.Args().First())
+			if name == "" {
+				return cli.Exit("usage: clawlet tool run <name> [--args '<json>']", 2)
+			}
+			args, err := toolRunArgs(cmd.String("args"))
+			if err != nil {
+				return cli.Exit(err.Error(), 2)
+			}
+			cfg, _, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			wsAbs, err := resolveWorkspace(cmd.String("workspace"))
+			if err != nil {
+				return err
+			}
+			a, err := agent.New(agent.Options{
+				Config:       cfg,
+				WorkspaceDir: wsAbs,
+				SessionKey:   "cli:tool",
+				Ephemeral:    true,
+			})
+			if err != nil {
+				return err
+			}
+			out, err := a.RunTool(ctx, name, args)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+}
+
+// toolRunArgs checks that s is a JSON object, so mistakes are reported here
+// rather than as a confusing error from the tool.
+func toolRunArgs(s string) (json.RawMessage, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		s = "{}"
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(s), &obj); err != nil {
+		return nil, fmt.Errorf("--args must be a JSON object: %v", err)
+	}
+	return json.RawMessage(s), nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestToolRunArgs(t *testing.T) {
+	if got, err := toolRunArgs(""); err != nil || string(got) != "{}" {
+		t.Fatalf("got=%s err=%v", got, err)
+	}
+	if got, err := toolRunArgs(` {"path":"a.txt"} `); err != nil || string(got) != `{"path":"a.txt"}` {
+		t.Fatalf("got=%s err=%v", got, err)
+	}
+	for _, bad := range []string{`[1]`, `"x"`, `{path: a}`} {
+		if _, err := toolRunArgs(bad); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
@@ -12,6 +12,12 @@ import (
 )
 
 func loadConfig() (*config.Config, string, error) {
+	return loadConfigWithModel("")
+}
+
+// loadConfigWithModel is loadConfig with agents.defaults.model overridden
+// (when non-empty) before routing is applied.
+func loadConfigWithModel(model string) (*config.Config, string, error) {
 	cfgPath, err := paths.ConfigPath()
 	if err != nil {
 		return nil, "", err
@@ -22,6 +28,9 @@ func loadConfig() (*config.Config, string, error) {
 	}
 
 	applyEnvOverrides(cfg)
+	if model = strings.TrimSpace(model); model != "" {
+		cfg.Agents.Defaults.Model = model
+	}
 	cfg.ApplyLLMRouting()
 
 	if strings.TrimSpace(cfg.LLM.APIKey) == "" && providerNeedsAPIKey(cfg.LLM.Provider) {
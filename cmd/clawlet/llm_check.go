@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+const llmCheckTimeout = 30 * time.Second
+
+// checkLLM sends a tiny prompt using cfg's effective LLM settings and returns
+// the reply. cfg must already have routing applied.
+func checkLLM(ctx context.Context, cfg *config.Config) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, llmCheckTimeout)
+	defer cancel()
+
+	c := &llm.Client{
+		Provider:   cfg.LLM.Provider,
+		BaseURL:    cfg.LLM.BaseURL,
+		APIKey:     cfg.LLM.APIKey,
+		Model:      cfg.LLM.Model,
+		MaxTokens:  16,
+		Headers:    cfg.LLM.HeadersFor(cfg.LLM.Provider),
+		Timeout:    cfg.LLM.TimeoutFor(cfg.LLM.Provider),
+		APIVersion: cfg.LLM.AzureAPIVersion,
+	}
+	res, err := c.Chat(ctx, []llm.Message{{Role: "user", Content: "Reply with the single word: OK"}}, nil)
+	if err != nil {
+		return "", err
+	}
+	reply := strings.TrimSpace(res.Content)
+	if reply == "" {
+		return "", errors.New("empty response")
+	}
+	if r := []rune(reply); len(r) > 60 {
+		reply = string(r[:60]) + "..."
+	}
+	return reply, nil
+}
@@ -22,6 +22,10 @@ func main() {
 			cmdProvider(),
 			cmdChannels(),
 			cmdCron(),
+			cmdSession(),
+			cmdTool(),
+			cmdTail(),
+			cmdAudit(),
 		},
 	}
 
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+type onboardProvider struct {
+	Name         string
+	Label        string
+	DefaultModel string
+	EnvKey       string // empty when no API key is needed
+	OAuth        bool
+}
+
+var onboardProviders = []onboardProvider{
+	{Name: "openrouter", Label: "OpenRouter", DefaultModel: "openrouter/anthropic/claude-sonnet-4-5", EnvKey: "OPENROUTER_API_KEY"},
+	{Name: "openai", Label: "OpenAI", DefaultModel: "openai/gpt-4o-mini", EnvKey: "OPENAI_API_KEY"},
+	{Name: "anthropic", Label: "Anthropic", DefaultModel: "anthropic/claude-sonnet-4-5", EnvKey: "ANTHROPIC_API_KEY"},
+	{Name: "gemini", Label: "Gemini", DefaultModel: "gemini/gemini-2.5-flash", EnvKey: "GEMINI_API_KEY"},
+	{Name: oauthProviderOpenAICodex, Label: "OpenAI Codex (OAuth)", DefaultModel: "openai-codex/gpt-5.1-codex", OAuth: true},
+	{Name: "ollama", Label: "Local (Ollama / OpenAI-compatible)", DefaultModel: "ollama/qwen2.5:14b"},
+}
+
+// onboardSelection is the verified result of the interactive onboarding.
+type onboardSelection struct {
+	Model  string
+	EnvKey string
+	APIKey string
+}
+
+type onboardPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *onboardPrompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+func (p *onboardPrompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	ans, err := p.ask(question+" ("+hint+")", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(ans) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// runInteractiveOnboard asks for a provider, model and API key, then verifies
+// the choice with check before returning it. login runs the OAuth flow for
+// providers that need one.
+func runInteractiveOnboard(
+	ctx context.Context,
+	in io.Reader,
+	out io.Writer,
+	check func(context.Context, *config.Config) (string, error),
+	login func(context.Context) error,
+) (*onboardSelection, error) {
+	p := &onboardPrompter{in: bufio.NewReader(in), out: out}
+
+	for {
+		fmt.Fprintln(out, "Select an LLM provider:")
+		for i, op := range onboardProviders {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, op.Label)
+		}
+		choice, err := p.ask("Provider", "1")
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(choice)
+		if err != nil || n < 1 || n > len(onboardProviders) {
+			fmt.Fprintf(out, "invalid choice: %s\n", choice)
+			continue
+		}
+		op := onboardProviders[n-1]
+
+		model, err := p.ask("Model", op.DefaultModel)
+		if err != nil {
+			return nil, err
+		}
+		sel := &onboardSelection{Model: model, EnvKey: op.EnvKey}
+
+		if op.EnvKey != "" {
+			if v := strings.TrimSpace(os.Getenv(op.EnvKey)); v != "" {
+				use, err := p.confirm(fmt.Sprintf("Found %s in the environment. Use it?", op.EnvKey), true)
+				if err != nil {
+					return nil, err
+				}
+				if use {
+					sel.APIKey = v
+				}
+			}
+			if sel.APIKey == "" {
+				if sel.APIKey, err = p.ask(op.EnvKey, ""); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if op.OAuth && login != nil {
+			run, err := p.confirm("Run the "+op.Label+" login now?", true)
+			if err != nil {
+				return nil, err
+			}
+			if run {
+				if err := login(ctx); err != nil {
+					fmt.Fprintf(out, "login failed: %v\n", err)
+				}
+			}
+		}
+
+		cfg := config.Default()
+		cfg.Agents.Defaults.Model = sel.Model
+		if sel.EnvKey != "" && sel.APIKey != "" {
+			cfg.Env[sel.EnvKey] = sel.APIKey
+		}
+		cfg.ApplyLLMRouting()
+
+		fmt.Fprintf(out, "testing %s (%s)...\n", cfg.LLM.Provider, cfg.LLM.Model)
+		reply, err := check(ctx, cfg)
+		if err == nil {
+			fmt.Fprintf(out, "ok: %s\n", reply)
+			return sel, nil
+		}
+		fmt.Fprintf(out, "test failed: %v\n", err)
+
+		retry, cerr := p.confirm("Try different settings?", true)
+		if cerr != nil {
+			return nil, cerr
+		}
+		if retry {
+			continue
+		}
+		save, cerr := p.confirm("Save this config anyway?", false)
+		if cerr != nil {
+			return nil, cerr
+		}
+		if save {
+			return sel, nil
+		}
+		return nil, fmt.Errorf("onboarding aborted: %w", err)
+	}
+}
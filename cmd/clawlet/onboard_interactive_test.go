@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/config"
+)
+
+func TestRunInteractiveOnboard_VerifiesSelection(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	in := strings.NewReader("2\n\nsk-test\n")
+	var out bytes.Buffer
+	var checked *config.Config
+	check := func(ctx context.Context, cfg *config.Config) (string, error) {
+		checked = cfg
+		return "OK", nil
+	}
+
+	sel, err := runInteractiveOnboard(context.Background(), in, &out, check, nil)
+	if err != nil {
+		t.Fatalf("onboard error: %v\n%s", err, out.String())
+	}
+	if sel.Model != "openai/gpt-4o-mini" || sel.EnvKey != "OPENAI_API_KEY" || sel.APIKey != "sk-test" {
+		t.Fatalf("selection=%+v", sel)
+	}
+	if checked == nil || checked.LLM.Provider != "openai" || checked.LLM.APIKey != "sk-test" {
+		t.Fatalf("checked config=%+v", checked)
+	}
+}
+
+func TestRunInteractiveOnboard_RetriesAfterFailedCheck(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	// First attempt: anthropic with a bad key, then retry with ollama.
+	in := strings.NewReader("3\n\nbad\ny\n6\n\n")
+	var out bytes.Buffer
+	calls := 0
+	check := func(ctx context.Context, cfg *config.Config) (string, error) {
+		calls++
+		if cfg.LLM.Provider == "anthropic" {
+			return "", errors.New("llm http 401: invalid key")
+		}
+		return "OK", nil
+	}
+
+	sel, err := runInteractiveOnboard(context.Background(), in, &out, check, nil)
+	if err != nil {
+		t.Fatalf("onboard error: %v\n%s", err, out.String())
+	}
+	if calls != 2 || sel.Model != "ollama/qwen2.5:14b" || sel.APIKey != "" {
+		t.Fatalf("calls=%d selection=%+v", calls, sel)
+	}
+	if !strings.Contains(out.String(), "test failed: llm http 401") {
+		t.Fatalf("missing failure output: %s", out.String())
+	}
+}
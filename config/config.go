@@ -3,11 +3,18 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/textproto"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
+var telegramInstanceIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 type Config struct {
 	Env map[string]string `json:"env"`
 	// Agent configuration (model, iterations, etc.). Kept small on purpose.
@@ -28,6 +35,101 @@ type LLMConfig struct {
 	BaseURL  string            `json:"baseURL"`
 	Model    string            `json:"model"`
 	Headers  map[string]string `json:"headers,omitempty"`
+	// BaseURLs overrides the default endpoint per provider (e.g. "openai",
+	// "anthropic", "gemini") so routed models can go through a proxy. An
+	// explicit BaseURL still wins.
+	BaseURLs map[string]string `json:"baseUrls,omitempty"`
+	// ProviderHeaders adds request headers for one provider (e.g. OpenRouter's
+	// HTTP-Referer and X-Title), on top of Headers. Credential headers are
+	// rejected unless AllowAuthHeaders is set.
+	ProviderHeaders map[string]map[string]string `json:"providerHeaders,omitempty"`
+	// AllowAuthHeaders lets ProviderHeaders set Authorization or API key
+	// headers, replacing the ones built from APIKey.
+	AllowAuthHeaders bool `json:"allowAuthHeaders,omitempty"`
+	// RetryEmptyResponses retries the LLM call once when a turn ends with
+	// no content and no tool calls.
+	RetryEmptyResponses bool `json:"retryEmptyResponses,omitempty"`
+	// MaxContinuations automatically asks the model to continue when a reply
+	// is cut off by maxTokens, up to this many times. 0 disables it.
+	MaxContinuations int `json:"maxContinuations,omitempty"`
+	// RateLimit throttles all LLM requests made by the process (chat,
+	// transcription, consolidation, subagents).
+	RateLimit LLMRateLimitConfig `json:"rateLimit,omitempty"`
+	// ReasoningEffort sets OpenAI reasoning_effort, Anthropic extended thinking,
+	// or the Gemini thinking budget: "low", "medium", "high", or a token budget.
+	ReasoningEffort ReasoningEffort `json:"reasoningEffort,omitempty"`
+	// Transcription selects how voice attachments are transcribed.
+	Transcription TranscriptionConfig `json:"transcription,omitempty"`
+	// Timeouts overrides the 120s request timeout per provider with Go
+	// durations, e.g. {"ollama": "10m", "openai": "30s"}. A "default" entry
+	// applies to providers without their own.
+	Timeouts map[string]string `json:"timeouts,omitempty"`
+	// AzureAPIVersion is the api-version sent to Azure OpenAI. Default:
+	// 2024-10-21.
+	AzureAPIVersion string `json:"azureApiVersion,omitempty"`
+}
+
+// TimeoutFor returns the request timeout configured for provider, or 0 to
+// use the client default.
+func (c LLMConfig) TimeoutFor(provider string) time.Duration {
+	provider = canonicalProvider(provider)
+	var fallback time.Duration
+	for k, v := range c.Timeouts {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d <= 0 {
+			continue
+		}
+		switch canonicalProvider(k) {
+		case provider:
+			return d
+		case "default":
+			fallback = d
+		}
+	}
+	return fallback
+}
+
+// TranscriptionConfig picks the audio transcription backend. The default
+// uses the chat provider's API; "local" runs a whisper.cpp binary so audio
+// stays on the host.
+type TranscriptionConfig struct {
+	// Provider is empty (the chat provider) or "local".
+	Provider string `json:"provider,omitempty"`
+	// Command is the local binary. Default: "whisper-cli".
+	Command string `json:"command,omitempty"`
+	// Model is the whisper model file passed as {model}.
+	Model string `json:"model,omitempty"`
+	// Args replaces the whisper.cpp arguments; {input}, {model}, and
+	// {language} are substituted.
+	Args       []string `json:"args,omitempty"`
+	TimeoutSec int      `json:"timeoutSec,omitempty"`
+}
+
+func (c TranscriptionConfig) Local() bool { return c.Provider == "local" }
+
+// ReasoningEffort is a level ("low", "medium", "high") or a token budget.
+// The budget may be written as a JSON number.
+type ReasoningEffort string
+
+func (r *ReasoningEffort) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err == nil {
+		*r = ReasoningEffort(n.String())
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("reasoningEffort: %w", err)
+	}
+	*r = ReasoningEffort(s)
+	return nil
+}
+
+type LLMRateLimitConfig struct {
+	// RequestsPerSecond is the sustained request rate. 0 disables rate limiting.
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+	// MaxConcurrent caps in-flight requests. 0 means unlimited.
+	MaxConcurrent int `json:"maxConcurrent,omitempty"`
 }
 
 type AgentsConfig struct {
@@ -35,11 +137,231 @@ type AgentsConfig struct {
 }
 
 type AgentDefaultsConfig struct {
-	Model        string             `json:"model"`
-	MaxTokens    int                `json:"maxTokens,omitempty"`
-	Temperature  *float64           `json:"temperature,omitempty"`
-	MemoryWindow int                `json:"memoryWindow,omitempty"`
-	MemorySearch MemorySearchConfig `json:"memorySearch"`
+	Model        string   `json:"model"`
+	MaxTokens    int      `json:"maxTokens,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	MemoryWindow int      `json:"memoryWindow,omitempty"`
+	// HistoryWindow caps how many recent session messages are sent to the
+	// model each turn. 0 means memoryWindow.
+	HistoryWindow int                `json:"historyWindow,omitempty"`
+	MemorySearch  MemorySearchConfig `json:"memorySearch"`
+	// Timezone is the IANA zone (e.g. "Asia/Tokyo") used for the current
+	// time in the system prompt and context_info. Empty uses the host zone.
+	Timezone string `json:"timezone,omitempty"`
+	// PromptTime adds the current date and time to the system prompt each
+	// turn. Default: true. Disable it for reproducible prompts.
+	PromptTime *bool `json:"promptTime,omitempty"`
+	// IdleConsolidation consolidates sessions that have gone quiet, even when
+	// they never reached memoryWindow.
+	IdleConsolidation IdleConsolidationConfig `json:"idleConsolidation,omitempty"`
+	// RunningSummary keeps a rolling summary of consolidated messages with
+	// the session and sends it in place of them.
+	RunningSummary RunningSummaryConfig `json:"runningSummary,omitempty"`
+	// DetectReplyLanguage tells the model to reply in the language detected
+	// in the user's messages, unless the channel sets language. Default: false.
+	DetectReplyLanguage bool `json:"detectReplyLanguage,omitempty"`
+	// IdleWrapUp closes chat sessions that have gone quiet with a message, a
+	// summary, or by archiving them.
+	IdleWrapUp IdleWrapUpConfig `json:"idleWrapUp,omitempty"`
+	// ConsolidationRepair retries consolidation once when the model returns
+	// invalid JSON, then archives a conversation excerpt to HISTORY.md without
+	// a memory update so the session is still trimmed. Default: true.
+	// Disable it to abort on invalid JSON and retry on the next turn.
+	ConsolidationRepair *bool `json:"consolidationRepair,omitempty"`
+	// ConsolidationToolResultChars keeps up to this many characters of each
+	// tool result with the session, so consolidation summaries capture what
+	// tools found or did (e.g. "3 tests failed"), not just that they ran.
+	// 0 (default) leaves tool results out.
+	ConsolidationToolResultChars int `json:"consolidationToolResultChars,omitempty"`
+	// RefusalReply is sent when the provider blocks a reply under its
+	// content policy without any text of its own.
+	RefusalReply string `json:"refusalReply,omitempty"`
+	// ToolCallDedup stops the model from repeating an identical tool call
+	// within one turn. Default: true.
+	ToolCallDedup *bool `json:"toolCallDedup,omitempty"`
+	// MaxParallelTools is how many tool calls without side effects from one
+	// model reply run at once. 0 uses the default (4); 1 runs them in order.
+	MaxParallelTools int `json:"maxParallelTools,omitempty"`
+	// HistoryRotation caps memory/HISTORY.md.
+	HistoryRotation HistoryRotationConfig `json:"historyRotation,omitempty"`
+	// PersonaFile replaces the opening "You are clawlet, a helpful AI
+	// assistant." of the system prompt with the contents of this file
+	// (relative to the workspace, e.g. "persona.md"). It is re-read when it
+	// changes, so edits apply on the next message.
+	PersonaFile string `json:"personaFile,omitempty"`
+	// SystemPromptMaxChars caps the assembled system prompt. Over the limit,
+	// today's notes are trimmed first, then the skills summary, long-term
+	// memory, and the workspace bootstrap files. 0 means no limit.
+	SystemPromptMaxChars int `json:"systemPromptMaxChars,omitempty"`
+	// SimpleModel sends messages judged simple (greetings, short questions)
+	// to a cheaper model.
+	SimpleModel SimpleModelConfig `json:"simpleModel,omitempty"`
+	// SubagentResults controls how spawn results reach the parent: "raw"
+	// (default) announces each one as it finishes; "summarized" waits for
+	// every subagent started from the same chat and merges their results
+	// with one model call (tools.summarize.model when set).
+	SubagentResults string `json:"subagentResults,omitempty"`
+}
+
+// SimpleModelConfig routes simple inbound messages to a cheaper model.
+type SimpleModelConfig struct {
+	// Model handles simple messages (same provider as llm). Empty disables
+	// routing.
+	Model string `json:"model,omitempty"`
+	// Classifier decides what is simple: "heuristic" (default) checks
+	// length, keywords, code, links and attachments; "llm" additionally asks
+	// ClassifierModel about messages that pass the heuristic.
+	Classifier string `json:"classifier,omitempty"`
+	// ClassifierModel answers the "llm" classifier. Empty uses Model.
+	ClassifierModel string `json:"classifierModel,omitempty"`
+	// MaxChars is the longest message that can count as simple. 0 uses the
+	// default (280).
+	MaxChars int `json:"maxChars,omitempty"`
+	// ComplexKeywords mark a message as complex when any appears in it
+	// (case-insensitive). Empty uses a built-in list (code, debug, explain,
+	// plan, ...).
+	ComplexKeywords []string `json:"complexKeywords,omitempty"`
+}
+
+func (c SimpleModelConfig) Enabled() bool {
+	return strings.TrimSpace(c.Model) != ""
+}
+
+func (c SimpleModelConfig) MaxCharsValue() int {
+	if c.MaxChars <= 0 {
+		return DefaultSimpleModelMaxChars
+	}
+	return c.MaxChars
+}
+
+type HistoryRotationConfig struct {
+	// MaxKB archives HISTORY.md as HISTORY-<date>.md once it grows past this
+	// size. 0 uses the default (1024); a negative value disables rotation.
+	MaxKB int `json:"maxKB,omitempty"`
+	// Summarize asks the model to fold durable facts from the archived
+	// history into MEMORY.md after a rotation. Default: false.
+	Summarize bool `json:"summarize,omitempty"`
+}
+
+// MaxBytes is the rotation threshold in bytes, or 0 when disabled.
+func (c HistoryRotationConfig) MaxBytes() int64 {
+	switch {
+	case c.MaxKB < 0:
+		return 0
+	case c.MaxKB == 0:
+		return DefaultHistoryRotationMaxKB << 10
+	}
+	return int64(c.MaxKB) << 10
+}
+
+func (c AgentDefaultsConfig) MaxParallelToolsValue() int {
+	if c.MaxParallelTools == 0 {
+		return DefaultMaxParallelTools
+	}
+	return max(c.MaxParallelTools, 1)
+}
+
+func (c AgentDefaultsConfig) ToolCallDedupValue() bool {
+	if c.ToolCallDedup == nil {
+		return true
+	}
+	return *c.ToolCallDedup
+}
+
+func (c AgentDefaultsConfig) RefusalReplyValue() string {
+	if strings.TrimSpace(c.RefusalReply) == "" {
+		return DefaultRefusalReply
+	}
+	return c.RefusalReply
+}
+
+func (c AgentDefaultsConfig) ConsolidationRepairValue() bool {
+	if c.ConsolidationRepair == nil {
+		return true
+	}
+	return *c.ConsolidationRepair
+}
+
+type RunningSummaryConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// KeepMessages is how many recent messages consolidation leaves in the
+	// session next to the summary. 0 uses the default (20). It must be
+	// below memoryWindow.
+	KeepMessages int `json:"keepMessages,omitempty"`
+	// MaxChars caps the summary. 0 uses the default (4000).
+	MaxChars int `json:"maxChars,omitempty"`
+}
+
+func (c RunningSummaryConfig) KeepMessagesValue() int {
+	if c.KeepMessages <= 0 {
+		return DefaultRunningSummaryKeepMessages
+	}
+	return c.KeepMessages
+}
+
+func (c RunningSummaryConfig) MaxCharsValue() int {
+	if c.MaxChars <= 0 {
+		return DefaultRunningSummaryMaxChars
+	}
+	return c.MaxChars
+}
+
+type IdleConsolidationConfig struct {
+	AfterSec         int `json:"afterSec,omitempty"`         // idle time before consolidating; 0 disables
+	CheckIntervalSec int `json:"checkIntervalSec,omitempty"` // how often sessions are checked
+}
+
+func (c IdleConsolidationConfig) Enabled() bool {
+	return c.AfterSec > 0
+}
+
+func (c IdleConsolidationConfig) CheckIntervalSecValue() int {
+	if c.CheckIntervalSec <= 0 {
+		return DefaultIdleConsolidationCheckIntervalSec
+	}
+	return c.CheckIntervalSec
+}
+
+// Idle wrap-up actions.
+const (
+	IdleWrapUpMessage = "message"
+	IdleWrapUpSummary = "summary"
+	IdleWrapUpArchive = "archive"
+)
+
+type IdleWrapUpConfig struct {
+	AfterSec int `json:"afterSec,omitempty"` // idle time before wrapping up; 0 disables
+	// Action is "message" (default: send Message), "summary" (send a
+	// model-written recap), or "archive" (consolidate, archive, and clear
+	// the session without a message).
+	Action           string `json:"action,omitempty"`
+	Message          string `json:"message,omitempty"`
+	CheckIntervalSec int    `json:"checkIntervalSec,omitempty"` // how often sessions are checked
+}
+
+func (c IdleWrapUpConfig) Enabled() bool {
+	return c.AfterSec > 0
+}
+
+func (c IdleWrapUpConfig) ActionValue() string {
+	if c.Action == "" {
+		return IdleWrapUpMessage
+	}
+	return c.Action
+}
+
+func (c IdleWrapUpConfig) MessageValue() string {
+	if strings.TrimSpace(c.Message) == "" {
+		return DefaultIdleWrapUpMessage
+	}
+	return c.Message
+}
+
+func (c IdleWrapUpConfig) CheckIntervalSecValue() int {
+	if c.CheckIntervalSec <= 0 {
+		return DefaultIdleWrapUpCheckIntervalSec
+	}
+	return c.CheckIntervalSec
 }
 
 func (c AgentDefaultsConfig) MaxTokensValue() int {
@@ -63,6 +385,34 @@ func (c AgentDefaultsConfig) MemoryWindowValue() int {
 	return c.MemoryWindow
 }
 
+func (c AgentDefaultsConfig) PromptTimeValue() bool {
+	if c.PromptTime == nil {
+		return true
+	}
+	return *c.PromptTime
+}
+
+// Location returns the configured timezone, or the host zone when it is
+// empty or unknown.
+func (c AgentDefaultsConfig) Location() *time.Location {
+	name := strings.TrimSpace(c.Timezone)
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+func (c AgentDefaultsConfig) HistoryWindowValue() int {
+	if c.HistoryWindow <= 0 {
+		return c.MemoryWindowValue()
+	}
+	return c.HistoryWindow
+}
+
 type MemorySearchConfig struct {
 	Enabled *bool `json:"enabled,omitempty"`
 
@@ -148,11 +498,77 @@ func (c MemorySearchSyncConfig) OnSearchValue() bool {
 }
 
 type ToolsConfig struct {
-	RestrictToWorkspace *bool             `json:"restrictToWorkspace"`
-	Exec                ExecToolConfig    `json:"exec"`
-	Web                 WebToolsConfig    `json:"web"`
-	Skills              SkillsToolsConfig `json:"skills"`
-	Media               MediaToolsConfig  `json:"media"`
+	RestrictToWorkspace *bool `json:"restrictToWorkspace"`
+	// WriteDenyGlobs blocks write/edit tools on matching paths (e.g. ".git/**", "*.lock").
+	WriteDenyGlobs []string             `json:"writeDenyGlobs,omitempty"`
+	Exec           ExecToolConfig       `json:"exec"`
+	Web            WebToolsConfig       `json:"web"`
+	Skills         SkillsToolsConfig    `json:"skills"`
+	Media          MediaToolsConfig     `json:"media"`
+	Summarize      SummarizeToolConfig  `json:"summarize"`
+	Message        MessageToolConfig    `json:"message"`
+	Memory         MemoryToolsConfig    `json:"memory"`
+	Weather        WeatherToolConfig    `json:"weather"`
+	SystemInfo     SystemInfoToolConfig `json:"systemInfo"`
+	GitHub         GitHubToolConfig     `json:"github"`
+
+	// NonIdempotent lists tools whose identical calls may return different
+	// results and so always run, even with agents.defaults.toolCallDedup.
+	// context_info is always included.
+	NonIdempotent []string `json:"nonIdempotent,omitempty"`
+	// SpillResultKB saves tool results larger than this to
+	// {workspace}/.tool-results and gives the model a preview plus the path
+	// to page through with read_file. 0 (default) disables it.
+	SpillResultKB int `json:"spillResultKB,omitempty"`
+	// Profiles names tool subsets, e.g. {"chat": ["web_search", "web_fetch"]},
+	// to keep the tool list small for a context. list_tools is always
+	// included.
+	Profiles map[string][]string `json:"profiles,omitempty"`
+	// Profile is the default profile. Empty offers every tool.
+	Profile string `json:"profile,omitempty"`
+	// ChannelProfiles overrides Profile per channel, e.g. {"slack": "coding"}.
+	// The /tools command overrides both for a session.
+	ChannelProfiles map[string]string `json:"channelProfiles,omitempty"`
+	// RequireReadBeforeWrite makes write and edit tools refuse to change an
+	// existing file the agent has not read earlier in the same turn.
+	// Default: false.
+	RequireReadBeforeWrite bool `json:"requireReadBeforeWrite,omitempty"`
+	// RequireApproval lists tools (e.g. "exec") the user must approve in
+	// chat before each call. Channels that cannot ask (only Discord can,
+	// with buttons) refuse these calls, as do cron and heartbeat turns.
+	RequireApproval []string `json:"requireApproval,omitempty"`
+	// AuditLog appends every tool call (time, session, tool, redacted
+	// arguments, result, duration) to ~/.clawlet/audit.jsonl. View it with
+	// `clawlet audit tail`. Default: false.
+	AuditLog bool `json:"auditLog,omitempty"`
+	// TruncateMode controls which part of oversized tool output is kept:
+	// "head" (default), "tail", or "middle" (head + tail).
+	TruncateMode string `json:"truncateMode,omitempty"`
+	// SafeMode disables every tool that can modify files, run commands, or
+	// schedule work, leaving read/search/fetch tools.
+	SafeMode bool `json:"safeMode,omitempty"`
+	// EgressAllowHosts limits network tools (web_fetch, web_search, skill
+	// registry) to these hosts, enforced when connections are dialed.
+	EgressAllowHosts []string `json:"egressAllowHosts,omitempty"`
+	// Timeouts overrides per-tool timeouts by tool name with Go durations,
+	// e.g. {"exec": "300s", "web_fetch": "15s"}. Tools without an entry keep
+	// their own setting (tools.exec.timeoutSec, tools.web.fetchTimeoutSec).
+	Timeouts map[string]string `json:"timeouts,omitempty"`
+}
+
+// TimeoutDurations returns the valid, positive entries of Timeouts.
+func (c ToolsConfig) TimeoutDurations() map[string]time.Duration {
+	if len(c.Timeouts) == 0 {
+		return nil
+	}
+	out := make(map[string]time.Duration, len(c.Timeouts))
+	for name, v := range c.Timeouts {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err == nil && d > 0 {
+			out[strings.TrimSpace(name)] = d
+		}
+	}
+	return out
 }
 
 func (c ToolsConfig) RestrictToWorkspaceValue() bool {
@@ -164,14 +580,202 @@ func (c ToolsConfig) RestrictToWorkspaceValue() bool {
 
 type ExecToolConfig struct {
 	TimeoutSec int `json:"timeoutSec"`
+	// CleanOutput strips ANSI escapes and collapses blank lines in command
+	// output before it is returned to the model. Default: true.
+	CleanOutput *bool `json:"cleanOutput,omitempty"`
+	// ExtraEnv opts extra variables into the otherwise minimal exec
+	// environment: "GOPATH" copies the gateway's value, "GOFLAGS=-mod=mod"
+	// sets one. NO_COLOR=1 and CI=1 are set by default and can be overridden.
+	ExtraEnv []string `json:"extraEnv,omitempty"`
+	// ScriptInterpreters enables run_script for these interpreters (e.g.
+	// "python3", "bash", "node"). Empty disables run_script.
+	ScriptInterpreters []string `json:"scriptInterpreters,omitempty"`
+	// HelpCommands enables command_help for these commands (e.g. "git",
+	// "ffmpeg"). Empty disables command_help.
+	HelpCommands []string `json:"helpCommands,omitempty"`
+	// AllowCommands turns on allowlist mode: exec only runs commands whose
+	// every pipeline stage starts with one of these programs (e.g. "ls",
+	// "rg"). The deny-pattern guard still applies.
+	AllowCommands []string `json:"allowCommands,omitempty"`
+	// AllowProfile adds a curated set of commands to AllowCommands and turns
+	// on allowlist mode. "coding" allows common build, test, and version
+	// control tools (see ExecCodingCommands).
+	AllowProfile string `json:"allowProfile,omitempty"`
+}
+
+// Exec allowlist profiles.
+const ExecAllowProfileCoding = "coding"
+
+// ExecCodingCommands are the programs the "coding" exec profile allows.
+var ExecCodingCommands = []string{"go", "npm", "pnpm", "yarn", "pip", "pip3", "cargo", "make", "pytest", "git"}
+
+// AllowedCommands returns the exec allowlist, AllowCommands plus the
+// profile's commands, or nil when allowlist mode is off.
+func (c ExecToolConfig) AllowedCommands() []string {
+	var out []string
+	if c.AllowProfile == ExecAllowProfileCoding {
+		out = append(out, ExecCodingCommands...)
+	}
+	for _, name := range c.AllowCommands {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func (c ExecToolConfig) CleanOutputValue() bool {
+	if c.CleanOutput == nil {
+		return true
+	}
+	return *c.CleanOutput
 }
 
 type WebToolsConfig struct {
-	BraveAPIKey      string   `json:"braveApiKey"`
-	AllowedDomains   []string `json:"allowedDomains,omitempty"`
-	BlockedDomains   []string `json:"blockedDomains,omitempty"`
-	MaxResponseBytes int64    `json:"maxResponseBytes,omitempty"`
-	FetchTimeoutSec  int      `json:"fetchTimeoutSec,omitempty"`
+	BraveAPIKey    string   `json:"braveApiKey"`
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+	BlockedDomains []string `json:"blockedDomains,omitempty"`
+	// SearchBlockedDomains removes web_search results on these domains, in
+	// addition to BlockedDomains, which also applies to results.
+	SearchBlockedDomains []string `json:"searchBlockedDomains,omitempty"`
+	MaxResponseBytes     int64    `json:"maxResponseBytes,omitempty"`
+	FetchTimeoutSec      int      `json:"fetchTimeoutSec,omitempty"`
+	// FetchRetries is how many times web_fetch retries after a transient
+	// network error (DNS blip, connection reset), with backoff. 0 uses the
+	// default (2); a negative value disables retries.
+	FetchRetries int `json:"fetchRetries,omitempty"`
+	// SummarizeOverChars summarizes web_fetch text longer than this with the
+	// tools.summarize model, focused on the user's request. 0 disables it.
+	SummarizeOverChars int `json:"summarizeOverChars,omitempty"`
+}
+
+func (c WebToolsConfig) FetchRetriesValue() int {
+	switch {
+	case c.FetchRetries < 0:
+		return 0
+	case c.FetchRetries == 0:
+		return DefaultWebFetchRetries
+	}
+	return c.FetchRetries
+}
+
+// WeatherToolConfig configures get_weather.
+type WeatherToolConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Provider is "open-meteo" (default, no key needed) or "openweathermap".
+	Provider string `json:"provider,omitempty"`
+	APIKey   string `json:"apiKey,omitempty"`
+	// Units is "metric" (default) or "imperial".
+	Units string `json:"units,omitempty"`
+}
+
+func (c WeatherToolConfig) ProviderValue() string {
+	if p := strings.TrimSpace(c.Provider); p != "" {
+		return p
+	}
+	return DefaultWeatherProvider
+}
+
+// GitHubToolConfig configures the github tool, which reads files,
+// directories, issues, and pull requests through the GitHub REST API.
+type GitHubToolConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Token is sent as a bearer token. It is needed for private repositories
+	// and raises the API rate limit; read-only scopes are enough.
+	Token string `json:"token,omitempty"`
+	// APIURL is the REST API root, e.g. "https://github.example.com/api/v3"
+	// for GitHub Enterprise. Default: "https://api.github.com".
+	APIURL string `json:"apiURL,omitempty"`
+}
+
+func (c GitHubToolConfig) APIURLValue() string {
+	if u := strings.TrimRight(strings.TrimSpace(c.APIURL), "/"); u != "" {
+		return u
+	}
+	return DefaultGitHubAPIURL
+}
+
+// SystemInfoToolConfig configures system_info, which reports host CPU,
+// memory, and disk usage. It is off by default since it reveals details of
+// the machine.
+type SystemInfoToolConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Processes also allows listing the busiest processes (PID, name, user,
+	// CPU, memory; no command lines). Default: false.
+	Processes bool `json:"processes,omitempty"`
+	// MaxProcesses caps the process list. Default: 20.
+	MaxProcesses int `json:"maxProcesses,omitempty"`
+}
+
+func (c SystemInfoToolConfig) MaxProcessesValue() int {
+	if c.MaxProcesses > 0 {
+		return c.MaxProcesses
+	}
+	return DefaultSystemInfoMaxProcesses
+}
+
+// MemoryToolsConfig configures read_memory and write_memory.
+type MemoryToolsConfig struct {
+	// Read enables read_memory, which returns MEMORY.md and today's notes.
+	// Default: true.
+	Read *bool `json:"read,omitempty"`
+	// Write enables write_memory, which appends a fact to MEMORY.md.
+	// Default: false.
+	Write bool `json:"write,omitempty"`
+	// InPrompt includes memory in the system prompt every turn. Default:
+	// true. Disable it to have the agent call read_memory when it needs to.
+	InPrompt *bool `json:"inPrompt,omitempty"`
+}
+
+// ProfileFor returns the tool profile for channel: its channelProfiles
+// entry, else the "telegram" entry for a Telegram instance, else profile.
+func (c ToolsConfig) ProfileFor(channel string) string {
+	if p, ok := c.ChannelProfiles[channel]; ok {
+		return p
+	}
+	if base, _, ok := strings.Cut(channel, "."); ok {
+		if p, ok := c.ChannelProfiles[base]; ok {
+			return p
+		}
+	}
+	return c.Profile
+}
+
+func (c MemoryToolsConfig) ReadValue() bool {
+	if c.Read == nil {
+		return true
+	}
+	return *c.Read
+}
+
+func (c MemoryToolsConfig) InPromptValue() bool {
+	if c.InPrompt == nil {
+		return true
+	}
+	return *c.InPrompt
+}
+
+// MessageToolConfig configures the message tool.
+type MessageToolConfig struct {
+	// AllowedTargets limits where message can send. Entries are
+	// "channel:chat_id" or "channel:*" for any chat on that channel, e.g.
+	// ["slack:C0123OPS", "telegram:*"]. Empty allows any destination.
+	AllowedTargets []string `json:"allowedTargets,omitempty"`
+}
+
+// SummarizeToolConfig configures summarize_file.
+type SummarizeToolConfig struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// Model overrides the model used for summaries (same provider as llm).
+	// Empty uses the main model.
+	Model string `json:"model,omitempty"`
+}
+
+func (c SummarizeToolConfig) EnabledValue() bool {
+	if c.Enabled == nil {
+		return true
+	}
+	return *c.Enabled
 }
 
 type SkillsToolsConfig struct {
@@ -199,15 +803,21 @@ type SkillsRegistryConfig struct {
 }
 
 type MediaToolsConfig struct {
-	Enabled             *bool `json:"enabled,omitempty"`
-	AudioEnabled        *bool `json:"audioEnabled,omitempty"`
-	ImageEnabled        *bool `json:"imageEnabled,omitempty"`
-	AttachmentEnabled   *bool `json:"attachmentEnabled,omitempty"`
+	Enabled           *bool `json:"enabled,omitempty"`
+	AudioEnabled      *bool `json:"audioEnabled,omitempty"`
+	ImageEnabled      *bool `json:"imageEnabled,omitempty"`
+	AttachmentEnabled *bool `json:"attachmentEnabled,omitempty"`
+	// DocumentEnabled extracts the text of PDF and .docx attachments up to
+	// maxFileBytes. Default: true.
+	DocumentEnabled     *bool `json:"documentEnabled,omitempty"`
 	MaxAttachments      int   `json:"maxAttachments,omitempty"`
 	MaxFileBytes        int64 `json:"maxFileBytes,omitempty"`
 	MaxInlineImageBytes int64 `json:"maxInlineImageBytes,omitempty"`
 	MaxTextChars        int   `json:"maxTextChars,omitempty"`
 	DownloadTimeoutSec  int   `json:"downloadTimeoutSec,omitempty"`
+	// TranscriptionLanguage is the ISO 639-1 code of spoken audio (e.g. "ja").
+	// Empty or "auto" lets the provider detect it.
+	TranscriptionLanguage string `json:"transcriptionLanguage,omitempty"`
 }
 
 func (c MediaToolsConfig) EnabledValue() bool {
@@ -238,8 +848,54 @@ func (c MediaToolsConfig) AttachmentEnabledValue() bool {
 	return *c.AttachmentEnabled
 }
 
+func (c MediaToolsConfig) DocumentEnabledValue() bool {
+	if c.DocumentEnabled == nil {
+		return true
+	}
+	return *c.DocumentEnabled
+}
+
 type CronConfig struct {
 	Enabled *bool `json:"enabled"`
+	// ConfirmDelivery makes each delivering job wait until its reply was sent
+	// (or failed) and record the outcome in the job state. Default: true.
+	ConfirmDelivery *bool `json:"confirmDelivery,omitempty"`
+	// DeliveryTimeoutSec bounds that wait, including the agent turn.
+	DeliveryTimeoutSec int `json:"deliveryTimeoutSec,omitempty"`
+	// MaxJobs caps the number of enabled jobs; adding one more fails.
+	// Default: 100. Negative removes the limit.
+	MaxJobs int `json:"maxJobs,omitempty"`
+	// MaxMessageChars caps the length of a job's message. Default: 4000.
+	// Negative removes the limit.
+	MaxMessageChars int `json:"maxMessageChars,omitempty"`
+}
+
+func (c CronConfig) MaxJobsValue() int {
+	if c.MaxJobs == 0 {
+		return DefaultCronMaxJobs
+	}
+	return c.MaxJobs
+}
+
+func (c CronConfig) MaxMessageCharsValue() int {
+	if c.MaxMessageChars == 0 {
+		return DefaultCronMaxMessageChars
+	}
+	return c.MaxMessageChars
+}
+
+func (c CronConfig) ConfirmDeliveryValue() bool {
+	if c.ConfirmDelivery == nil {
+		return true
+	}
+	return *c.ConfirmDelivery
+}
+
+func (c CronConfig) DeliveryTimeoutSecValue() int {
+	if c.DeliveryTimeoutSec <= 0 {
+		return DefaultCronDeliveryTimeoutSec
+	}
+	return c.DeliveryTimeoutSec
 }
 
 func (c CronConfig) EnabledValue() bool {
@@ -252,6 +908,12 @@ func (c CronConfig) EnabledValue() bool {
 type HeartbeatConfig struct {
 	Enabled     *bool `json:"enabled"`
 	IntervalSec int   `json:"intervalSec"`
+	// PersistLastRun stores the last heartbeat time in
+	// ~/.clawlet/heartbeat.json so restarts keep the schedule.
+	PersistLastRun bool `json:"persistLastRun,omitempty"`
+	// QuietHours is a daily "HH:MM-HH:MM" window, in agents.defaults.timezone,
+	// during which heartbeats wait, e.g. "22:00-07:00".
+	QuietHours string `json:"quietHours,omitempty"`
 }
 
 func (c HeartbeatConfig) EnabledValue() bool {
@@ -262,12 +924,181 @@ func (c HeartbeatConfig) EnabledValue() bool {
 }
 
 type GatewayConfig struct {
-	// Listen address for HTTP endpoints needed by channels (reserved for future use).
+	// Listen address for the gateway's HTTP endpoints (gateway.fileLinks).
 	// Default: "127.0.0.1:18790"
 	Listen string `json:"listen"`
 	// Allow binding gateway to non-localhost addresses.
 	// Keep false unless you intentionally expose it behind a trusted tunnel/proxy.
 	AllowPublicBind bool `json:"allowPublicBind,omitempty"`
+	// Persist outbound replies to ~/.clawlet/outbound.jsonl until they are sent,
+	// so replies queued during a crash or restart are delivered on the next start.
+	PersistOutbound bool `json:"persistOutbound,omitempty"`
+	// Expose live events on the local unix socket ~/.clawlet/activity.sock
+	// for `clawlet tail`.
+	ActivitySocket bool `json:"activitySocket,omitempty"`
+	// ReplyTemplate rewrites every channel reply, e.g. "{reply}\n\n_Automated reply._".
+	// Placeholders: {reply}, {channel}. Empty sends replies unchanged.
+	ReplyTemplate string `json:"replyTemplate,omitempty"`
+	// ErrorReply is sent to the chat when a message cannot be processed
+	// (LLM auth, rate limit, or network failures). The error itself is logged.
+	ErrorReply ErrorReplyConfig `json:"errorReply,omitempty"`
+	// InboundConcurrency is how many sessions are handled at once. Messages
+	// in one session always run in arrival order. 1 handles every message
+	// serially. Default: 4
+	InboundConcurrency int `json:"inboundConcurrency,omitempty"`
+	// OutboundDedupSec drops an outbound message already sent within this
+	// many seconds, e.g. a reply replayed after an ambiguous send failure.
+	// Default: 600. Negative disables it.
+	OutboundDedupSec int `json:"outboundDedupSec,omitempty"`
+	// Moderation checks chat messages before they reach the model. Off
+	// unless deny patterns or an endpoint are set.
+	Moderation ModerationConfig `json:"moderation,omitempty"`
+	// FileLinks serves files too large to upload to a channel from
+	// temporary signed links on Listen.
+	FileLinks FileLinksConfig `json:"fileLinks,omitempty"`
+	// LLMCircuit pauses message handling while LLM calls keep failing and
+	// resumes once a health probe succeeds.
+	LLMCircuit LLMCircuitConfig `json:"llmCircuit,omitempty"`
+}
+
+// LLMCircuitConfig pauses message handling during an LLM provider outage.
+type LLMCircuitConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Failures is how many turns in a row must fail with an auth, server,
+	// or network error before pausing. Default: 3.
+	Failures int `json:"failures,omitempty"`
+	// ProbeIntervalSec is how often the provider is probed while paused.
+	// Default: 30.
+	ProbeIntervalSec int `json:"probeIntervalSec,omitempty"`
+	// Reply is sent once to each chat that writes while paused.
+	Reply string `json:"reply,omitempty"`
+}
+
+func (c LLMCircuitConfig) FailuresValue() int {
+	if c.Failures > 0 {
+		return c.Failures
+	}
+	return DefaultLLMCircuitFailures
+}
+
+func (c LLMCircuitConfig) ProbeInterval() time.Duration {
+	if c.ProbeIntervalSec > 0 {
+		return time.Duration(c.ProbeIntervalSec) * time.Second
+	}
+	return DefaultLLMCircuitProbeIntervalSec * time.Second
+}
+
+func (c LLMCircuitConfig) ReplyValue() string {
+	if s := strings.TrimSpace(c.Reply); s != "" {
+		return s
+	}
+	return DefaultLLMCircuitReply
+}
+
+// FileLinksConfig configures download links for oversized attachments.
+type FileLinksConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// PublicURL is where users reach gateway.listen, e.g. through a tunnel
+	// or reverse proxy: "https://bot.example.com". Required when enabled.
+	PublicURL string `json:"publicURL,omitempty"`
+	// TTLSec is how long a link works. Default: 86400 (24h).
+	TTLSec int `json:"ttlSec,omitempty"`
+}
+
+func (c FileLinksConfig) TTL() time.Duration {
+	if c.TTLSec > 0 {
+		return time.Duration(c.TTLSec) * time.Second
+	}
+	return DefaultFileLinksTTLSec * time.Second
+}
+
+// ModerationConfig blocks chat messages that match a local denylist or are
+// flagged by an OpenAI-compatible /moderations endpoint.
+type ModerationConfig struct {
+	// Deny lists regular expressions. Add "(?i)" for case-insensitive matching.
+	Deny []string `json:"deny,omitempty"`
+	// Endpoint is a moderations URL, e.g. "https://api.openai.com/v1/moderations".
+	Endpoint string `json:"endpoint,omitempty"`
+	// APIKey for Endpoint. Empty uses llm.apiKey when Endpoint is on the
+	// llm.baseURL host.
+	APIKey string `json:"apiKey,omitempty"`
+	// Model is sent to Endpoint. Default: "omni-moderation-latest".
+	Model string `json:"model,omitempty"`
+	// Outbound also checks the agent's replies before they are sent.
+	Outbound bool `json:"outbound,omitempty"`
+	// Reply is sent instead of a blocked message or reply.
+	Reply string `json:"reply,omitempty"`
+}
+
+func (c ModerationConfig) Enabled() bool {
+	return len(c.Deny) > 0 || strings.TrimSpace(c.Endpoint) != ""
+}
+
+func (c ModerationConfig) ReplyValue() string {
+	if strings.TrimSpace(c.Reply) == "" {
+		return DefaultModerationReply
+	}
+	return c.Reply
+}
+
+func (c GatewayConfig) OutboundDedupWindow() time.Duration {
+	switch {
+	case c.OutboundDedupSec < 0:
+		return 0
+	case c.OutboundDedupSec == 0:
+		return DefaultGatewayOutboundDedupSec * time.Second
+	default:
+		return time.Duration(c.OutboundDedupSec) * time.Second
+	}
+}
+
+func (c GatewayConfig) InboundConcurrencyValue() int {
+	if c.InboundConcurrency <= 0 {
+		return DefaultGatewayInboundConcurrency
+	}
+	return c.InboundConcurrency
+}
+
+// ErrorReplyConfig overrides the reply for each error class. Empty fields use
+// the built-in messages.
+type ErrorReplyConfig struct {
+	Default   string `json:"default,omitempty"`
+	Auth      string `json:"auth,omitempty"`
+	RateLimit string `json:"rateLimit,omitempty"`
+	Transient string `json:"transient,omitempty"`
+	// Detail appends the error message to the reply (useful while debugging).
+	Detail bool `json:"detail,omitempty"`
+}
+
+const (
+	DefaultErrorReply          = "Sorry, something went wrong while handling your message. Please try again."
+	DefaultErrorReplyAuth      = "I can't reach the model right now because of a configuration problem. Please ask the operator to check the API key."
+	DefaultErrorReplyRateLimit = "I'm getting too many requests right now. Please try again in a minute."
+	DefaultErrorReplyTransient = "I'm having trouble reaching the model. Please try again shortly."
+
+	DefaultModerationReply = "Sorry, I can't help with that message."
+	DefaultModerationModel = "omni-moderation-latest"
+)
+
+// Text returns the reply for an error class ("auth", "rateLimit",
+// "transient"); other classes get Default.
+func (c ErrorReplyConfig) Text(class string) string {
+	pick := func(v, def string) string {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+		return def
+	}
+	switch class {
+	case "auth":
+		return pick(c.Auth, DefaultErrorReplyAuth)
+	case "rateLimit":
+		return pick(c.RateLimit, DefaultErrorReplyRateLimit)
+	case "transient":
+		return pick(c.Transient, DefaultErrorReplyTransient)
+	default:
+		return pick(c.Default, DefaultErrorReply)
+	}
 }
 
 type ChannelsConfig struct {
@@ -275,6 +1106,179 @@ type ChannelsConfig struct {
 	Slack    SlackConfig    `json:"slack"`
 	Telegram TelegramConfig `json:"telegram"`
 	WhatsApp WhatsAppConfig `json:"whatsapp"`
+	Signal   SignalConfig   `json:"signal"`
+
+	// MaxMessageAgeSec drops inbound messages sent longer ago than this, such
+	// as a backlog delivered after downtime. 0 disables the check.
+	MaxMessageAgeSec int `json:"maxMessageAgeSec,omitempty"`
+	// DetectCodeLanguage labels code blocks the model left without a
+	// language so channels can highlight them. Default: true.
+	DetectCodeLanguage *bool `json:"detectCodeLanguage,omitempty"`
+	// EnabledCommands limits which chat slash commands (e.g. "tools") run.
+	// Empty enables all. A disabled command is answered as unknown.
+	EnabledCommands []string `json:"enabledCommands,omitempty"`
+	// AdminCommands are slash commands only Admins may run.
+	AdminCommands []string `json:"adminCommands,omitempty"`
+	// Admins are "channel:senderId" entries, e.g. "telegram:12345", allowed
+	// to run AdminCommands. A base channel name also covers its instances.
+	Admins []string `json:"admins,omitempty"`
+	// ArchiveOnNew saves the conversation to {workspace}/archive before /new
+	// clears it. Default: false.
+	ArchiveOnNew bool `json:"archiveOnNew,omitempty"`
+}
+
+// CommandEnabled reports whether the slash command name may run at all.
+func (c ChannelsConfig) CommandEnabled(name string) bool {
+	return len(c.EnabledCommands) == 0 || commandListed(c.EnabledCommands, name)
+}
+
+// CommandAllowed reports whether senderID on channel may run the slash
+// command name under AdminCommands.
+func (c ChannelsConfig) CommandAllowed(name, channel, senderID string) bool {
+	if !commandListed(c.AdminCommands, name) {
+		return true
+	}
+	senderID = strings.TrimSpace(senderID)
+	if senderID == "" {
+		return false
+	}
+	base, _, _ := strings.Cut(channel, ".")
+	for _, a := range c.Admins {
+		ch, id, ok := strings.Cut(strings.TrimSpace(a), ":")
+		if ok && id == senderID && (ch == channel || ch == base) {
+			return true
+		}
+	}
+	return false
+}
+
+func commandListed(list []string, name string) bool {
+	for _, n := range list {
+		if strings.TrimPrefix(strings.ToLower(strings.TrimSpace(n)), "/") == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c ChannelsConfig) DetectCodeLanguageValue() bool {
+	if c.DetectCodeLanguage == nil {
+		return true
+	}
+	return *c.DetectCodeLanguage
+}
+
+// ChannelPromptConfig is embedded in each channel config and adds
+// per-channel directives to the system prompt.
+type ChannelPromptConfig struct {
+	// Language makes the assistant always reply in the given language (e.g. "Japanese").
+	Language string `json:"language,omitempty"`
+	// SystemPromptAppend is appended verbatim to the system prompt.
+	SystemPromptAppend string `json:"systemPromptAppend,omitempty"`
+}
+
+// Prompt returns the prompt settings for the named channel
+// ("discord", "slack", "telegram", "whatsapp", "signal", or a Telegram
+// instance such as "telegram.support").
+func (c ChannelsConfig) Prompt(channel string) ChannelPromptConfig {
+	switch channel {
+	case "discord":
+		return c.Discord.ChannelPromptConfig
+	case "slack":
+		return c.Slack.ChannelPromptConfig
+	case "telegram":
+		return c.Telegram.ChannelPromptConfig
+	case "whatsapp":
+		return c.WhatsApp.ChannelPromptConfig
+	case "signal":
+		return c.Signal.ChannelPromptConfig
+	default:
+		if tc, ok := c.telegramInstance(channel); ok {
+			return tc.ChannelPromptConfig
+		}
+		return ChannelPromptConfig{}
+	}
+}
+
+// Model returns the model override for the named channel, or "" for the
+// default model. Only Telegram bots set one today.
+func (c ChannelsConfig) Model(channel string) string {
+	if channel == "telegram" {
+		return strings.TrimSpace(c.Telegram.Model)
+	}
+	if tc, ok := c.telegramInstance(channel); ok {
+		return strings.TrimSpace(tc.Model)
+	}
+	return ""
+}
+
+func (c ChannelsConfig) telegramInstance(channel string) (TelegramConfig, bool) {
+	id, ok := strings.CutPrefix(channel, "telegram.")
+	if !ok {
+		return TelegramConfig{}, false
+	}
+	for _, inst := range c.Telegram.Instances {
+		if inst.ID == id {
+			return inst, true
+		}
+	}
+	return TelegramConfig{}, false
+}
+
+// ChannelAttachmentConfig is embedded in each channel config and caps the
+// inbound attachments accepted per message.
+type ChannelAttachmentConfig struct {
+	// MaxAttachmentBytes skips attachments larger than this. 0 uses tools.media.maxFileBytes.
+	MaxAttachmentBytes int64 `json:"maxAttachmentBytes,omitempty"`
+	// MaxAttachmentsPerMessage keeps at most this many attachments. 0 means no channel limit.
+	MaxAttachmentsPerMessage int `json:"maxAttachmentsPerMessage,omitempty"`
+	// MaxUploadBytes caps files the bot uploads, e.g. for a Discord server
+	// with a boosted limit. 0 uses the platform's default limit.
+	MaxUploadBytes int64 `json:"maxUploadBytes,omitempty"`
+	// TranscriptionLanguage overrides tools.media.transcriptionLanguage for
+	// voice messages on this channel.
+	TranscriptionLanguage string `json:"transcriptionLanguage,omitempty"`
+}
+
+// Attachments returns the attachment limits for the named channel.
+func (c ChannelsConfig) Attachments(channel string) ChannelAttachmentConfig {
+	switch channel {
+	case "discord":
+		return c.Discord.ChannelAttachmentConfig
+	case "slack":
+		return c.Slack.ChannelAttachmentConfig
+	case "telegram":
+		return c.Telegram.ChannelAttachmentConfig
+	case "whatsapp":
+		return c.WhatsApp.ChannelAttachmentConfig
+	case "signal":
+		return c.Signal.ChannelAttachmentConfig
+	default:
+		if tc, ok := c.telegramInstance(channel); ok {
+			return tc.ChannelAttachmentConfig
+		}
+		return ChannelAttachmentConfig{}
+	}
+}
+
+// ApplyTo tightens the media limits in m with the channel limits and applies
+// the channel's transcription language.
+func (c ChannelAttachmentConfig) ApplyTo(m MediaToolsConfig) MediaToolsConfig {
+	if c.MaxAttachmentBytes > 0 {
+		if m.MaxFileBytes <= 0 || c.MaxAttachmentBytes < m.MaxFileBytes {
+			m.MaxFileBytes = c.MaxAttachmentBytes
+		}
+		if m.MaxInlineImageBytes <= 0 || c.MaxAttachmentBytes < m.MaxInlineImageBytes {
+			m.MaxInlineImageBytes = c.MaxAttachmentBytes
+		}
+	}
+	if c.MaxAttachmentsPerMessage > 0 && (m.MaxAttachments <= 0 || c.MaxAttachmentsPerMessage < m.MaxAttachments) {
+		m.MaxAttachments = c.MaxAttachmentsPerMessage
+	}
+	if lang := strings.TrimSpace(c.TranscriptionLanguage); lang != "" {
+		m.TranscriptionLanguage = lang
+	}
+	return m
 }
 
 type DiscordConfig struct {
@@ -283,6 +1287,18 @@ type DiscordConfig struct {
 	AllowFrom  []string `json:"allowFrom"`
 	GatewayURL string   `json:"gatewayURL,omitempty"`
 	Intents    int      `json:"intents,omitempty"`
+	// ApprovalTimeoutSec is how long an Approve/Deny prompt waits before
+	// denying the tool call. Default: 120.
+	ApprovalTimeoutSec int `json:"approvalTimeoutSec,omitempty"`
+	ChannelPromptConfig
+	ChannelAttachmentConfig
+}
+
+func (c DiscordConfig) ApprovalTimeout() time.Duration {
+	if c.ApprovalTimeoutSec <= 0 {
+		return DefaultDiscordApprovalTimeoutSec * time.Second
+	}
+	return time.Duration(c.ApprovalTimeoutSec) * time.Second
 }
 
 // Slack (Socket Mode).
@@ -297,6 +1313,12 @@ type SlackConfig struct {
 	GroupPolicy    string         `json:"groupPolicy,omitempty"`
 	GroupAllowFrom []string       `json:"groupAllowFrom,omitempty"` // channel IDs allowed when groupPolicy="allowlist"
 	DM             *SlackDMConfig `json:"dm,omitempty"`
+	// AutoThread gives each channel thread its own session and keeps
+	// answering follow-ups in threads the bot has posted in, without
+	// another mention.
+	AutoThread bool `json:"autoThread,omitempty"`
+	ChannelPromptConfig
+	ChannelAttachmentConfig
 }
 
 type SlackDMConfig struct {
@@ -311,6 +1333,53 @@ type TelegramConfig struct {
 	BaseURL        string   `json:"baseURL,omitempty"` // optional: custom Bot API server URL
 	PollTimeoutSec int      `json:"pollTimeoutSec,omitempty"`
 	Workers        int      `json:"workers,omitempty"`
+	// ConflictRetries is how many "409 Conflict" replies from getUpdates
+	// (another process polling the same token) are tolerated within a minute
+	// before the bot stops with an error. 0 uses the default (5); a negative
+	// value keeps retrying.
+	ConflictRetries int `json:"conflictRetries,omitempty"`
+	// Model overrides the LLM model for this bot. Empty uses the default model.
+	Model string `json:"model,omitempty"`
+	ChannelPromptConfig
+	ChannelAttachmentConfig
+	// ID names an entry in Instances. Its channel is "telegram.<id>", so its
+	// sessions and routing are separate from the other bots.
+	ID string `json:"id,omitempty"`
+	// Instances runs additional bots, each with its own token, allowFrom,
+	// model, and prompt settings. Nested instances are ignored.
+	Instances []TelegramConfig `json:"instances,omitempty"`
+}
+
+func (c TelegramConfig) ConflictRetriesValue() int {
+	if c.ConflictRetries == 0 {
+		return DefaultTelegramConflictRetries
+	}
+	return c.ConflictRetries
+}
+
+// ChannelName is "telegram" for the main bot and "telegram.<id>" for an instance.
+func (c TelegramConfig) ChannelName() string {
+	if c.ID == "" {
+		return "telegram"
+	}
+	return "telegram." + c.ID
+}
+
+// Bots returns the enabled bots: the main one, then each enabled instance.
+func (c TelegramConfig) Bots() []TelegramConfig {
+	var out []TelegramConfig
+	if c.Enabled {
+		main := c
+		main.ID, main.Instances = "", nil
+		out = append(out, main)
+	}
+	for _, inst := range c.Instances {
+		if inst.Enabled {
+			inst.Instances = nil
+			out = append(out, inst)
+		}
+	}
+	return out
 }
 
 // WhatsApp (whatsmeow / WhatsApp Web Multi-Device).
@@ -318,42 +1387,84 @@ type WhatsAppConfig struct {
 	Enabled          bool     `json:"enabled"`
 	AllowFrom        []string `json:"allowFrom"`
 	SessionStorePath string   `json:"sessionStorePath,omitempty"` // optional: sqlite store path for persistent login
+	ChannelPromptConfig
+	ChannelAttachmentConfig
+}
+
+// Signal (signal-cli daemon JSON-RPC).
+// Connects to a running "signal-cli daemon" over its unix socket or TCP port.
+type SignalConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Account        string   `json:"account,omitempty"` // bot phone number; required when the daemon serves several accounts
+	AllowFrom      []string `json:"allowFrom"`
+	Socket         string   `json:"socket,omitempty"`         // unix socket of "signal-cli daemon --socket"
+	TCPAddr        string   `json:"tcpAddr,omitempty"`        // host:port of "signal-cli daemon --tcp", used when socket is empty
+	AttachmentsDir string   `json:"attachmentsDir,omitempty"` // optional: signal-cli attachments directory
+	ChannelPromptConfig
+	ChannelAttachmentConfig
 }
 
 const (
-	DefaultAgentMaxTokens                  = 8192
-	DefaultAgentTemperature                = 0.7
-	DefaultAgentMemoryWindow               = 50
-	DefaultMemorySearchChunkTokens         = 400
-	DefaultMemorySearchChunkOverlap        = 80
-	DefaultMemorySearchMaxResults          = 6
-	DefaultMemorySearchMinScore            = 0.35
-	DefaultMemorySearchHybridVectorWeight  = 0.7
-	DefaultMemorySearchHybridTextWeight    = 0.3
-	DefaultMemorySearchCandidateMultiplier = 4
-	DefaultOpenAIBaseURL                   = "https://api.openai.com/v1"
-	DefaultOpenAICodexBaseURL              = "https://chatgpt.com/backend-api"
-	DefaultOpenRouterBaseURL               = "https://openrouter.ai/api/v1"
-	DefaultAnthropicBaseURL                = "https://api.anthropic.com"
-	DefaultGeminiBaseURL                   = "https://generativelanguage.googleapis.com/v1beta"
-	DefaultShengSuanYunBaseURL             = "https://router.shengsuanyun.com/api/v1"
-	DefaultNovitaBaseURL                   = "https://api.novita.ai/openai"
-	DefaultOllamaBaseURL                   = "http://localhost:11434/v1"
-	DefaultWebFetchMaxResponseBytes        = int64(500_000)
-	DefaultWebFetchTimeoutSec              = 30
-	DefaultSkillsMaxResults                = 5
-	DefaultSkillsRegistryBaseURL           = "https://clawhub.ai"
-	DefaultSkillsRegistrySearchPath        = "/api/v1/search"
-	DefaultSkillsRegistrySkillsPath        = "/api/v1/skills"
-	DefaultSkillsRegistryDownloadPath      = "/api/v1/download"
-	DefaultSkillsRegistryTimeoutSec        = 30
-	DefaultSkillsRegistryMaxZipBytes       = int64(50 << 20)
-	DefaultSkillsRegistryMaxResponseBytes  = int64(2 << 20)
-	DefaultMediaMaxAttachments             = 4
-	DefaultMediaMaxFileBytes               = int64(20 << 20)
-	DefaultMediaMaxInlineImageBytes        = int64(5 << 20)
-	DefaultMediaMaxTextChars               = 12000
-	DefaultMediaDownloadTimeoutSec         = 20
+	DefaultAgentMaxTokens                    = 8192
+	DefaultAgentTemperature                  = 0.7
+	DefaultAgentMemoryWindow                 = 50
+	DefaultRefusalReply                      = "Sorry, I can't help with that. The model provider declined to respond under its content policy."
+	DefaultIdleConsolidationCheckIntervalSec = 600
+	DefaultRunningSummaryKeepMessages        = 20
+	DefaultRunningSummaryMaxChars            = 4000
+	DefaultIdleWrapUpCheckIntervalSec        = 60
+	DefaultIdleWrapUpMessage                 = "Anything else I can help with?"
+	DefaultHistoryRotationMaxKB              = 1024
+	DefaultWeatherProvider                   = "open-meteo"
+	DefaultGitHubAPIURL                      = "https://api.github.com"
+	DefaultSystemInfoMaxProcesses            = 20
+	DefaultFileLinksTTLSec                   = 24 * 60 * 60
+	DefaultLLMCircuitFailures                = 3
+	DefaultLLMCircuitProbeIntervalSec        = 30
+	DefaultLLMCircuitReply                   = "The assistant is temporarily unavailable. Messages sent now won't be answered; please try again later."
+	DefaultDiscordMaxUploadBytes             = 10 << 20
+	DefaultTelegramMaxUploadBytes            = 50 << 20
+	DefaultSlackMaxUploadBytes               = 1 << 30
+	DefaultCronDeliveryTimeoutSec            = 300
+	DefaultCronMaxJobs                       = 100
+	DefaultCronMaxMessageChars               = 4000
+	DefaultGatewayInboundConcurrency         = 4
+	DefaultGatewayOutboundDedupSec           = 600
+	DefaultMemorySearchChunkTokens           = 400
+	DefaultMemorySearchChunkOverlap          = 80
+	DefaultMemorySearchMaxResults            = 6
+	DefaultMemorySearchMinScore              = 0.35
+	DefaultMemorySearchHybridVectorWeight    = 0.7
+	DefaultMemorySearchHybridTextWeight      = 0.3
+	DefaultMemorySearchCandidateMultiplier   = 4
+	DefaultOpenAIBaseURL                     = "https://api.openai.com/v1"
+	DefaultOpenAICodexBaseURL                = "https://chatgpt.com/backend-api"
+	DefaultOpenRouterBaseURL                 = "https://openrouter.ai/api/v1"
+	DefaultAnthropicBaseURL                  = "https://api.anthropic.com"
+	DefaultGeminiBaseURL                     = "https://generativelanguage.googleapis.com/v1beta"
+	DefaultShengSuanYunBaseURL               = "https://router.shengsuanyun.com/api/v1"
+	DefaultNovitaBaseURL                     = "https://api.novita.ai/openai"
+	DefaultOllamaBaseURL                     = "http://localhost:11434/v1"
+	DefaultWebFetchMaxResponseBytes          = int64(500_000)
+	DefaultWebFetchTimeoutSec                = 30
+	DefaultWebFetchRetries                   = 2
+	DefaultSkillsMaxResults                  = 5
+	DefaultSkillsRegistryBaseURL             = "https://clawhub.ai"
+	DefaultSkillsRegistrySearchPath          = "/api/v1/search"
+	DefaultSkillsRegistrySkillsPath          = "/api/v1/skills"
+	DefaultSkillsRegistryDownloadPath        = "/api/v1/download"
+	DefaultSkillsRegistryTimeoutSec          = 30
+	DefaultSkillsRegistryMaxZipBytes         = int64(50 << 20)
+	DefaultSkillsRegistryMaxResponseBytes    = int64(2 << 20)
+	DefaultTelegramConflictRetries           = 5
+	DefaultDiscordApprovalTimeoutSec         = 120
+	DefaultMaxParallelTools                  = 4
+	DefaultSimpleModelMaxChars               = 280
+	DefaultMediaMaxAttachments               = 4
+	DefaultMediaMaxFileBytes                 = int64(20 << 20)
+	DefaultMediaMaxInlineImageBytes          = int64(5 << 20)
+	DefaultMediaMaxTextChars                 = 12000
+	DefaultMediaDownloadTimeoutSec           = 20
 )
 
 func Default() *Config {
@@ -516,9 +1627,118 @@ func Load(path string) (*Config, error) {
 	if cfg.Env == nil {
 		cfg.Env = map[string]string{}
 	}
+	for provider, hs := range cfg.LLM.ProviderHeaders {
+		for k := range hs {
+			if IsAuthHeader(k) && !cfg.LLM.AllowAuthHeaders {
+				return nil, fmt.Errorf("parse %s: llm.providerHeaders.%s: %s replaces the API key; set llm.allowAuthHeaders to allow it", path, provider, k)
+			}
+		}
+	}
 	if cfg.Tools.Exec.TimeoutSec <= 0 {
 		cfg.Tools.Exec.TimeoutSec = 60
 	}
+	if tz := strings.TrimSpace(cfg.Agents.Defaults.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("parse %s: agents.defaults.timezone: %w", path, err)
+		}
+	}
+	switch c := cfg.Agents.Defaults.SimpleModel.Classifier; c {
+	case "", "heuristic", "llm":
+	default:
+		return nil, fmt.Errorf("parse %s: agents.defaults.simpleModel.classifier: want heuristic or llm, got %q", path, c)
+	}
+	switch p := cfg.LLM.Transcription.Provider; p {
+	case "", "local":
+	default:
+		return nil, fmt.Errorf("parse %s: llm.transcription.provider: want local or empty, got %q", path, p)
+	}
+	switch a := cfg.Agents.Defaults.IdleWrapUp.Action; a {
+	case "", IdleWrapUpMessage, IdleWrapUpSummary, IdleWrapUpArchive:
+	default:
+		return nil, fmt.Errorf("parse %s: agents.defaults.idleWrapUp.action: want message, summary, or archive, got %q", path, a)
+	}
+	if rs := cfg.Agents.Defaults.RunningSummary; rs.Enabled && rs.KeepMessagesValue() >= cfg.Agents.Defaults.MemoryWindowValue() {
+		return nil, fmt.Errorf("parse %s: agents.defaults.runningSummary.keepMessages: %d must be below memoryWindow (%d)", path, rs.KeepMessagesValue(), cfg.Agents.Defaults.MemoryWindowValue())
+	}
+	switch p := cfg.Tools.Exec.AllowProfile; p {
+	case "", ExecAllowProfileCoding:
+	default:
+		return nil, fmt.Errorf("parse %s: tools.exec.allowProfile: want coding or empty, got %q", path, p)
+	}
+	switch m := cfg.Agents.Defaults.SubagentResults; m {
+	case "", "raw", "summarized":
+	default:
+		return nil, fmt.Errorf("parse %s: agents.defaults.subagentResults: want raw or summarized, got %q", path, m)
+	}
+	for i, pat := range cfg.Gateway.Moderation.Deny {
+		if _, err := regexp.Compile(pat); err != nil {
+			return nil, fmt.Errorf("parse %s: gateway.moderation.deny[%d]: %w", path, i, err)
+		}
+	}
+	seenBots := map[string]bool{}
+	for i, inst := range cfg.Channels.Telegram.Instances {
+		id := strings.TrimSpace(inst.ID)
+		if !telegramInstanceIDRe.MatchString(id) {
+			return nil, fmt.Errorf("parse %s: channels.telegram.instances[%d].id: must be letters, digits, '-' or '_', got %q", path, i, inst.ID)
+		}
+		if seenBots[id] {
+			return nil, fmt.Errorf("parse %s: channels.telegram.instances[%d].id: duplicate %q", path, i, id)
+		}
+		seenBots[id] = true
+		cfg.Channels.Telegram.Instances[i].ID = id
+	}
+	switch p := strings.ToLower(strings.TrimSpace(cfg.Tools.Weather.Provider)); p {
+	case "":
+		cfg.Tools.Weather.Provider = DefaultWeatherProvider
+	case "open-meteo", "openweathermap":
+		cfg.Tools.Weather.Provider = p
+	default:
+		return nil, fmt.Errorf("parse %s: tools.weather.provider: unknown provider %q (want open-meteo or openweathermap)", path, cfg.Tools.Weather.Provider)
+	}
+	switch u := strings.ToLower(strings.TrimSpace(cfg.Tools.Weather.Units)); u {
+	case "", "metric":
+		cfg.Tools.Weather.Units = "metric"
+	case "imperial":
+		cfg.Tools.Weather.Units = u
+	default:
+		return nil, fmt.Errorf("parse %s: tools.weather.units: want metric or imperial, got %q", path, cfg.Tools.Weather.Units)
+	}
+	if cfg.Tools.Weather.Enabled && cfg.Tools.Weather.Provider == "openweathermap" && strings.TrimSpace(cfg.Tools.Weather.APIKey) == "" {
+		return nil, fmt.Errorf("parse %s: tools.weather.apiKey: required for openweathermap", path)
+	}
+	if gh := cfg.Tools.GitHub; gh.Enabled && strings.TrimSpace(gh.APIURL) != "" {
+		u, err := url.Parse(strings.TrimSpace(gh.APIURL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("parse %s: tools.github.apiURL: want an http(s) URL, got %q", path, gh.APIURL)
+		}
+	}
+	if p := cfg.Tools.Profile; p != "" && cfg.Tools.Profiles[p] == nil {
+		return nil, fmt.Errorf("parse %s: tools.profile: unknown profile %q", path, p)
+	}
+	for ch, p := range cfg.Tools.ChannelProfiles {
+		if p != "" && cfg.Tools.Profiles[p] == nil {
+			return nil, fmt.Errorf("parse %s: tools.channelProfiles.%s: unknown profile %q", path, ch, p)
+		}
+	}
+	for name, v := range cfg.Tools.Timeouts {
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err != nil || d <= 0 {
+			return nil, fmt.Errorf("parse %s: tools.timeouts.%s: invalid duration %q", path, name, v)
+		}
+	}
+	for name, v := range cfg.LLM.Timeouts {
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err != nil || d <= 0 {
+			return nil, fmt.Errorf("parse %s: llm.timeouts.%s: invalid duration %q", path, name, v)
+		}
+	}
+	cfg.LLM.ReasoningEffort = ReasoningEffort(strings.ToLower(strings.TrimSpace(string(cfg.LLM.ReasoningEffort))))
+	switch mode := strings.ToLower(strings.TrimSpace(cfg.Tools.TruncateMode)); mode {
+	case "":
+		cfg.Tools.TruncateMode = "head"
+	case "head", "tail", "middle":
+		cfg.Tools.TruncateMode = mode
+	default:
+		return nil, fmt.Errorf("parse %s: tools.truncateMode: want head, tail, or middle, got %q", path, cfg.Tools.TruncateMode)
+	}
 	if cfg.Tools.Web.AllowedDomains == nil {
 		cfg.Tools.Web.AllowedDomains = []string{"*"}
 	} else {
@@ -619,6 +1839,12 @@ func Load(path string) (*Config, error) {
 	if cfg.Gateway.Listen == "" {
 		cfg.Gateway.Listen = "127.0.0.1:18790"
 	}
+	if fl := cfg.Gateway.FileLinks; fl.Enabled {
+		u, err := url.Parse(strings.TrimSpace(fl.PublicURL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("parse %s: gateway.fileLinks.publicURL: want an http(s) URL, got %q", path, fl.PublicURL)
+		}
+	}
 	if cfg.Agents.Defaults.MemorySearch.Enabled == nil {
 		v := false
 		cfg.Agents.Defaults.MemorySearch.Enabled = &v
@@ -697,6 +1923,8 @@ func Load(path string) (*Config, error) {
 		cfg.Channels.Telegram.Workers = 2
 	}
 	cfg.Channels.WhatsApp.SessionStorePath = strings.TrimSpace(cfg.Channels.WhatsApp.SessionStorePath)
+	cfg.Channels.Signal.Socket = strings.TrimSpace(cfg.Channels.Signal.Socket)
+	cfg.Channels.Signal.TCPAddr = strings.TrimSpace(cfg.Channels.Signal.TCPAddr)
 
 	// Apply model routing to populate cfg.LLM for runtime use.
 	cfg.ApplyLLMRouting()
@@ -741,7 +1969,8 @@ func Save(path string, cfg *Config) error {
 
 // ApplyLLMRouting resolves the effective LLM endpoint and API key from:
 // - agents.defaults.model (preferred) or llm.model
-// - env keys OPENAI_API_KEY / OPENROUTER_API_KEY / ANTHROPIC_API_KEY / GEMINI_API_KEY / GOOGLE_API_KEY
+// - env keys OPENAI_API_KEY / OPENROUTER_API_KEY / ANTHROPIC_API_KEY / GEMINI_API_KEY / GOOGLE_API_KEY / AZURE_OPENAI_API_KEY
+// The base URL is llm.baseURL if set, else llm.baseUrls[provider], else the provider default.
 // It mutates cfg.LLM to the effective values used at runtime.
 func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 	providerHint := canonicalProvider(cfg.LLM.Provider)
@@ -761,6 +1990,9 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 		cfg.LLM.Provider = provider
 
 		// No routing prefix; treat cfg.LLM as already effective.
+		if strings.TrimSpace(cfg.LLM.BaseURL) == "" {
+			cfg.LLM.BaseURL = cfg.LLM.baseURLOverride(provider)
+		}
 		if strings.TrimSpace(cfg.LLM.BaseURL) == "" {
 			switch provider {
 			case "anthropic":
@@ -771,6 +2003,8 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 				cfg.LLM.BaseURL = DefaultOllamaBaseURL
 			case "openai-codex":
 				cfg.LLM.BaseURL = DefaultOpenAICodexBaseURL
+			case "azure":
+				cfg.LLM.BaseURL = strings.TrimSpace(cfg.Env["AZURE_OPENAI_ENDPOINT"])
 			default:
 				cfg.LLM.BaseURL = DefaultOpenAIBaseURL
 			}
@@ -782,6 +2016,8 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 			switch provider {
 			case "anthropic":
 				cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["ANTHROPIC_API_KEY"])
+			case "azure":
+				cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["AZURE_OPENAI_API_KEY"])
 			case "gemini":
 				cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["GEMINI_API_KEY"])
 				if cfg.LLM.APIKey == "" {
@@ -796,6 +2032,9 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 	cfg.LLM.Provider = provider
 	cfg.LLM.Model = model
 
+	if strings.TrimSpace(cfg.LLM.BaseURL) == "" {
+		cfg.LLM.BaseURL = cfg.LLM.baseURLOverride(provider)
+	}
 	if strings.TrimSpace(cfg.LLM.BaseURL) == "" {
 		switch provider {
 		case "openai":
@@ -814,6 +2053,9 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 			cfg.LLM.BaseURL = DefaultShengSuanYunBaseURL
 		case "novita":
 			cfg.LLM.BaseURL = DefaultNovitaBaseURL
+		case "azure":
+			// Azure endpoints are per resource; there is no default.
+			cfg.LLM.BaseURL = strings.TrimSpace(cfg.Env["AZURE_OPENAI_ENDPOINT"])
 		}
 	}
 
@@ -827,6 +2069,8 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 			cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["SHENGSUANYUN_API_KEY"])
 		case "novita":
 			cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["NOVITA_API_KEY"])
+		case "azure":
+			cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["AZURE_OPENAI_API_KEY"])
 		case "anthropic":
 			cfg.LLM.APIKey = strings.TrimSpace(cfg.Env["ANTHROPIC_API_KEY"])
 		case "gemini":
@@ -840,6 +2084,56 @@ func (cfg *Config) ApplyLLMRouting() (provider string, configuredModel string) {
 	return provider, configuredModel
 }
 
+// authHeaders carry provider credentials. ProviderHeaders may only set them
+// with llm.allowAuthHeaders.
+var authHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key", "Cookie"}
+
+// IsAuthHeader reports whether name is a credential header.
+func IsAuthHeader(name string) bool {
+	name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+	return slices.Contains(authHeaders, name)
+}
+
+// HeadersFor returns Headers merged with ProviderHeaders for provider. A
+// provider header replaces a global header of the same name.
+func (c LLMConfig) HeadersFor(provider string) map[string]string {
+	out := make(map[string]string, len(c.Headers))
+	canon := map[string]string{}
+	set := func(k, v string) {
+		ck := textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(k))
+		if prev, ok := canon[ck]; ok {
+			delete(out, prev)
+		}
+		canon[ck] = k
+		out[k] = v
+	}
+	for k, v := range c.Headers {
+		set(k, v)
+	}
+	provider = canonicalProvider(provider)
+	for p, hs := range c.ProviderHeaders {
+		if canonicalProvider(p) != provider {
+			continue
+		}
+		for k, v := range hs {
+			if IsAuthHeader(k) && !c.AllowAuthHeaders {
+				continue
+			}
+			set(k, v)
+		}
+	}
+	return out
+}
+
+func (c LLMConfig) baseURLOverride(provider string) string {
+	for k, v := range c.BaseURLs {
+		if canonicalProvider(k) == provider {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
 func parseRoutedModel(s string) (provider string, model string) {
 	s = strings.TrimSpace(s)
 	if after, ok := strings.CutPrefix(s, "openai-codex/"); ok {
@@ -851,6 +2145,9 @@ func parseRoutedModel(s string) (provider string, model string) {
 	if after, ok := strings.CutPrefix(s, "openrouter/"); ok {
 		return "openrouter", after
 	}
+	if after, ok := strings.CutPrefix(s, "azure/"); ok {
+		return "azure", after
+	}
 	if after, ok := strings.CutPrefix(s, "shengsuanyun/"); ok {
 		return "shengsuanyun", after
 	}
@@ -876,6 +2173,8 @@ func canonicalProvider(s string) string {
 	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "local":
 		return "ollama"
+	case "azure-openai":
+		return "azure"
 	default:
 		return strings.ToLower(strings.TrimSpace(s))
 	}
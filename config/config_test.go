@@ -1,6 +1,13 @@
 package config
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestAgentDefaults_MaxTokensTemperature(t *testing.T) {
 	cfg := Default()
@@ -23,6 +30,10 @@ func TestAgentDefaults_MaxTokensTemperature(t *testing.T) {
 		t.Fatalf("memorySearch.query.maxResults=%d", cfg.Agents.Defaults.MemorySearch.Query.MaxResults)
 	}
 
+	if cfg.Agents.Defaults.HistoryWindowValue() != DefaultAgentMemoryWindow {
+		t.Fatalf("historyWindow=%d", cfg.Agents.Defaults.HistoryWindowValue())
+	}
+
 	cfg.Agents.Defaults.MaxTokens = 2048
 	cfg.Agents.Defaults.MemoryWindow = 80
 	temp := 0.0
@@ -36,6 +47,13 @@ func TestAgentDefaults_MaxTokensTemperature(t *testing.T) {
 	if cfg.Agents.Defaults.MemoryWindowValue() != 80 {
 		t.Fatalf("memoryWindow=%d", cfg.Agents.Defaults.MemoryWindowValue())
 	}
+	if cfg.Agents.Defaults.HistoryWindowValue() != 80 {
+		t.Fatalf("historyWindow should follow memoryWindow, got %d", cfg.Agents.Defaults.HistoryWindowValue())
+	}
+	cfg.Agents.Defaults.HistoryWindow = 20
+	if cfg.Agents.Defaults.HistoryWindowValue() != 20 {
+		t.Fatalf("historyWindow=%d", cfg.Agents.Defaults.HistoryWindowValue())
+	}
 }
 
 func TestLoad_MemorySearchDefaultsAndClamp(t *testing.T) {
@@ -121,6 +139,82 @@ func TestApplyLLMRouting_OpenAI(t *testing.T) {
 	}
 }
 
+func TestLLMConfig_HeadersFor(t *testing.T) {
+	c := LLMConfig{
+		Headers: map[string]string{"X-Title": "global", "X-Env": "prod"},
+		ProviderHeaders: map[string]map[string]string{
+			"OpenRouter": {"HTTP-Referer": "https://example.com", "x-title": "clawlet"},
+			"anthropic":  {"anthropic-beta": "tools"},
+		},
+	}
+	got := c.HeadersFor("openrouter")
+	want := map[string]string{"X-Env": "prod", "HTTP-Referer": "https://example.com", "x-title": "clawlet"}
+	if len(got) != len(want) {
+		t.Fatalf("headers=%v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("headers=%v", got)
+		}
+	}
+	if got := c.HeadersFor("openai"); len(got) != 2 || got["X-Title"] != "global" {
+		t.Fatalf("openai headers=%v", got)
+	}
+
+	c.ProviderHeaders["openai"] = map[string]string{"authorization": "Bearer other"}
+	if _, ok := c.HeadersFor("openai")["authorization"]; ok {
+		t.Fatal("auth header applied without allowAuthHeaders")
+	}
+	c.AllowAuthHeaders = true
+	if c.HeadersFor("openai")["authorization"] != "Bearer other" {
+		t.Fatal("auth header not applied with allowAuthHeaders")
+	}
+}
+
+func TestLoad_ProviderHeadersRejectAuth(t *testing.T) {
+	cfg := Default()
+	cfg.LLM.ProviderHeaders = map[string]map[string]string{"openrouter": {"Authorization": "Bearer x"}}
+	tmp := t.TempDir() + "/cfg.json"
+	if err := Save(tmp, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := Load(tmp); err == nil || !strings.Contains(err.Error(), "llm.allowAuthHeaders") {
+		t.Fatalf("err=%v", err)
+	}
+	cfg.LLM.AllowAuthHeaders = true
+	if err := Save(tmp, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := Load(tmp); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+}
+
+func TestApplyLLMRouting_BaseURLOverride(t *testing.T) {
+	cfg := Default()
+	cfg.Agents.Defaults.Model = "openai/gpt-4o"
+	cfg.LLM.BaseURL = ""
+	cfg.LLM.BaseURLs = map[string]string{"OpenAI": "http://litellm.internal:4000/v1"}
+
+	provider, _ := cfg.ApplyLLMRouting()
+	if provider != "openai" {
+		t.Fatalf("provider=%q", provider)
+	}
+	if cfg.LLM.BaseURL != "http://litellm.internal:4000/v1" {
+		t.Fatalf("baseURL=%q", cfg.LLM.BaseURL)
+	}
+
+	// An explicit llm.baseURL takes precedence over baseUrls.
+	cfg = Default()
+	cfg.Agents.Defaults.Model = "openai/gpt-4o"
+	cfg.LLM.BaseURL = "http://explicit/v1"
+	cfg.LLM.BaseURLs = map[string]string{"openai": "http://litellm.internal:4000/v1"}
+	cfg.ApplyLLMRouting()
+	if cfg.LLM.BaseURL != "http://explicit/v1" {
+		t.Fatalf("baseURL=%q", cfg.LLM.BaseURL)
+	}
+}
+
 func TestApplyLLMRouting_Anthropic(t *testing.T) {
 	cfg := Default()
 	cfg.Env["ANTHROPIC_API_KEY"] = "sk-ant-123"
@@ -225,6 +319,32 @@ func TestApplyLLMRouting_LocalAlias(t *testing.T) {
 	}
 }
 
+func TestApplyLLMRouting_Azure(t *testing.T) {
+	cfg := Default()
+	cfg.Env["AZURE_OPENAI_API_KEY"] = "az-key"
+	cfg.Env["AZURE_OPENAI_ENDPOINT"] = "https://res.openai.azure.com"
+	cfg.Agents.Defaults.Model = "azure/gpt-4o-prod"
+	cfg.LLM.BaseURL = ""
+	cfg.LLM.APIKey = ""
+
+	provider, _ := cfg.ApplyLLMRouting()
+	if provider != "azure" || cfg.LLM.Model != "gpt-4o-prod" {
+		t.Fatalf("provider=%q model=%q", provider, cfg.LLM.Model)
+	}
+	if cfg.LLM.BaseURL != "https://res.openai.azure.com" || cfg.LLM.APIKey != "az-key" {
+		t.Fatalf("baseURL=%q apiKey=%q", cfg.LLM.BaseURL, cfg.LLM.APIKey)
+	}
+
+	cfg = Default()
+	cfg.Agents.Defaults.Model = "azure/gpt-4o-prod"
+	cfg.LLM.BaseURL = ""
+	cfg.LLM.BaseURLs = map[string]string{"azure-openai": "https://other.openai.azure.com"}
+	cfg.ApplyLLMRouting()
+	if cfg.LLM.BaseURL != "https://other.openai.azure.com" {
+		t.Fatalf("baseURL=%q", cfg.LLM.BaseURL)
+	}
+}
+
 func TestApplyLLMRouting_Novita(t *testing.T) {
 	cfg := Default()
 	cfg.Env["NOVITA_API_KEY"] = "sk-novita-123"
@@ -385,3 +505,382 @@ func TestSkillsRegistryDefaults(t *testing.T) {
 		t.Fatalf("loaded skills.registry.timeoutSec=%d", loaded.Tools.Skills.Registry.TimeoutSec)
 	}
 }
+
+func TestChannelsPrompt_FlattenedJSON(t *testing.T) {
+	tmp := t.TempDir() + "/cfg.json"
+	raw := `{"channels":{"telegram":{"enabled":true,"language":"Japanese"},"slack":{"systemPromptAppend":"Use Slack mrkdwn."}}}`
+	if err := os.WriteFile(tmp, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := loaded.Channels.Prompt("telegram").Language; got != "Japanese" {
+		t.Fatalf("telegram.language=%q", got)
+	}
+	if got := loaded.Channels.Prompt("slack").SystemPromptAppend; got != "Use Slack mrkdwn." {
+		t.Fatalf("slack.systemPromptAppend=%q", got)
+	}
+	if got := loaded.Channels.Prompt("cli"); got != (ChannelPromptConfig{}) {
+		t.Fatalf("cli prompt=%+v", got)
+	}
+}
+
+func TestLoad_ReasoningEffortAcceptsNumber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"llm":{"reasoningEffort":8192}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.LLM.ReasoningEffort != "8192" {
+		t.Fatalf("reasoningEffort=%q", cfg.LLM.ReasoningEffort)
+	}
+	if err := os.WriteFile(path, []byte(`{"llm":{"reasoningEffort":" High "}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.LLM.ReasoningEffort != "high" {
+		t.Fatalf("reasoningEffort=%q", cfg.LLM.ReasoningEffort)
+	}
+}
+
+func TestLoad_ToolTimeouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tools":{"timeouts":{"exec":"300s","web_fetch":" 15s "}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	got := cfg.Tools.TimeoutDurations()
+	if got["exec"] != 300*time.Second || got["web_fetch"] != 15*time.Second || len(got) != 2 {
+		t.Fatalf("timeouts=%v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"tools":{"timeouts":{"exec":"5 minutes"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "tools.timeouts.exec") {
+		t.Fatalf("expected invalid duration error, got %v", err)
+	}
+}
+
+func TestLoad_ToolProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"tools":{"profiles":{"chat":["web_fetch"],"coding":["exec"]},"profile":"chat","channelProfiles":{"telegram":"coding"}}}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for ch, want := range map[string]string{"discord": "chat", "telegram": "coding", "telegram.support": "coding"} {
+		if got := cfg.Tools.ProfileFor(ch); got != want {
+			t.Fatalf("ProfileFor(%q)=%q want %q", ch, got, want)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(`{"tools":{"channelProfiles":{"slack":"missing"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "tools.channelProfiles.slack") {
+		t.Fatalf("expected unknown profile error, got %v", err)
+	}
+}
+
+func TestLoad_Weather(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tools":{"weather":{"enabled":true,"units":"Imperial"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Tools.Weather.ProviderValue() != DefaultWeatherProvider || cfg.Tools.Weather.Units != "imperial" {
+		t.Fatalf("weather=%+v", cfg.Tools.Weather)
+	}
+
+	for _, raw := range []string{
+		`{"tools":{"weather":{"provider":"accuweather"}}}`,
+		`{"tools":{"weather":{"enabled":true,"provider":"openweathermap"}}}`,
+	} {
+		if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "tools.weather") {
+			t.Fatalf("%s: expected weather error, got %v", raw, err)
+		}
+	}
+}
+
+func TestLoad_Timezone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"agents":{"defaults":{"timezone":"Asia/Tokyo","promptTime":false}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	if cfg.Agents.Defaults.Location().String() != "Asia/Tokyo" || cfg.Agents.Defaults.PromptTimeValue() {
+		t.Fatalf("timezone=%s promptTime=%v", cfg.Agents.Defaults.Location(), cfg.Agents.Defaults.PromptTimeValue())
+	}
+	if !Default().Agents.Defaults.PromptTimeValue() || Default().Agents.Defaults.Location() != time.Local {
+		t.Fatal("defaults should include the prompt time in the host zone")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"agents":{"defaults":{"timezone":"Mars/Olympus"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "agents.defaults.timezone") {
+		t.Fatalf("expected timezone error, got %v", err)
+	}
+}
+
+func TestLoad_SimpleModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"agents":{"defaults":{"simpleModel":{"model":"small","classifier":"llm"}}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := cfg.Agents.Defaults.SimpleModel
+	if !sm.Enabled() || sm.Classifier != "llm" || sm.MaxCharsValue() != DefaultSimpleModelMaxChars {
+		t.Fatalf("simpleModel=%+v", sm)
+	}
+	if Default().Agents.Defaults.SimpleModel.Enabled() {
+		t.Fatal("routing should be off by default")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"agents":{"defaults":{"simpleModel":{"model":"small","classifier":"regex"}}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "agents.defaults.simpleModel.classifier") {
+		t.Fatalf("expected classifier error, got %v", err)
+	}
+}
+
+func TestLoad_TranscriptionProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"llm":{"transcription":{"provider":"local","model":"/m/ggml-base.bin"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.LLM.Transcription.Local() || cfg.LLM.Transcription.Model != "/m/ggml-base.bin" {
+		t.Fatalf("transcription=%+v", cfg.LLM.Transcription)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"llm":{"transcription":{"provider":"whisper"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "llm.transcription.provider") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+}
+
+func TestLoad_LLMTimeouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"llm":{"timeouts":{"local":"10m","default":"45s"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.LLM.TimeoutFor("ollama"); got != 10*time.Minute {
+		t.Fatalf("ollama timeout=%s", got)
+	}
+	if got := cfg.LLM.TimeoutFor("openai"); got != 45*time.Second {
+		t.Fatalf("openai timeout=%s", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"llm":{"timeouts":{"openai":"fast"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "llm.timeouts.openai") {
+		t.Fatalf("expected invalid duration error, got %v", err)
+	}
+}
+
+func TestLoad_RunningSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"agents":{"defaults":{"runningSummary":{"enabled":true}}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := cfg.Agents.Defaults.RunningSummary
+	if !rs.Enabled || rs.KeepMessagesValue() != DefaultRunningSummaryKeepMessages || rs.MaxCharsValue() != DefaultRunningSummaryMaxChars {
+		t.Fatalf("runningSummary=%+v", rs)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"agents":{"defaults":{"memoryWindow":30,"runningSummary":{"enabled":true,"keepMessages":30}}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "agents.defaults.runningSummary.keepMessages") {
+		t.Fatalf("expected keepMessages error, got %v", err)
+	}
+}
+
+func TestLoad_ExecAllowProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tools":{"exec":{"allowProfile":"coding","allowCommands":["rg","git"]}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.Tools.Exec.AllowedCommands()
+	if len(got) != len(ExecCodingCommands)+1 || got[len(got)-1] != "rg" || !slices.Contains(got, "pytest") {
+		t.Fatalf("allowed=%v", got)
+	}
+	if (ExecToolConfig{}).AllowedCommands() != nil {
+		t.Fatal("allowlist mode on by default")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"tools":{"exec":{"allowProfile":"devops"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "tools.exec.allowProfile") {
+		t.Fatalf("expected allowProfile error, got %v", err)
+	}
+}
+
+func TestLoad_TruncateMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tools":{"truncateMode":" Tail "}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Tools.TruncateMode != "tail" {
+		t.Fatalf("mode=%q", cfg.Tools.TruncateMode)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"tools":{"truncateMode":"tial"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "tools.truncateMode") {
+		t.Fatalf("expected truncateMode error, got %v", err)
+	}
+}
+
+func TestLoad_FileLinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"gateway":{"fileLinks":{"enabled":true,"publicURL":"https://bot.example.com"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Gateway.FileLinks.TTL() != 24*time.Hour {
+		t.Fatalf("ttl=%s", cfg.Gateway.FileLinks.TTL())
+	}
+
+	if err := os.WriteFile(path, []byte(`{"gateway":{"fileLinks":{"enabled":true}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "gateway.fileLinks.publicURL") {
+		t.Fatalf("expected publicURL error, got %v", err)
+	}
+}
+
+func TestLoad_TelegramInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"channels":{"telegram":{"enabled":true,"token":"main","model":"gpt-main","instances":[
+		{"id":"support","enabled":true,"token":"t2","model":"gpt-small","language":"Japanese","maxAttachmentsPerMessage":1},
+		{"id":"off","token":"t3"}
+	]}}}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	bots := cfg.Channels.Telegram.Bots()
+	if len(bots) != 2 || bots[0].ChannelName() != "telegram" || bots[1].ChannelName() != "telegram.support" || bots[1].Token != "t2" {
+		t.Fatalf("bots=%+v", bots)
+	}
+	if m := cfg.Channels.Model("telegram.support"); m != "gpt-small" {
+		t.Fatalf("instance model=%q", m)
+	}
+	if m := cfg.Channels.Model("telegram"); m != "gpt-main" {
+		t.Fatalf("main model=%q", m)
+	}
+	if cfg.Channels.Prompt("telegram.support").Language != "Japanese" || cfg.Channels.Attachments("telegram.support").MaxAttachmentsPerMessage != 1 {
+		t.Fatal("instance prompt/attachment settings not resolved")
+	}
+	if cfg.Channels.Prompt("telegram.missing").Language != "" || cfg.Channels.Model("slack") != "" {
+		t.Fatal("unknown channels should have no overrides")
+	}
+
+	for _, bad := range []string{
+		`{"channels":{"telegram":{"instances":[{"id":""}]}}}`,
+		`{"channels":{"telegram":{"instances":[{"id":"a.b"}]}}}`,
+		`{"channels":{"telegram":{"instances":[{"id":"x"},{"id":"x"}]}}}`,
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "channels.telegram.instances") {
+			t.Fatalf("%s: expected instance id error, got %v", bad, err)
+		}
+	}
+}
+
+func TestWebToolsConfig_FetchRetriesValue(t *testing.T) {
+	for in, want := range map[int]int{0: DefaultWebFetchRetries, -1: 0, 5: 5} {
+		if got := (WebToolsConfig{FetchRetries: in}).FetchRetriesValue(); got != want {
+			t.Fatalf("FetchRetries=%d: got %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestLLMCircuitConfig_Defaults(t *testing.T) {
+	var c LLMCircuitConfig
+	if c.FailuresValue() != DefaultLLMCircuitFailures || c.ProbeInterval() != DefaultLLMCircuitProbeIntervalSec*time.Second || c.ReplyValue() != DefaultLLMCircuitReply {
+		t.Fatalf("defaults: %d %s %q", c.FailuresValue(), c.ProbeInterval(), c.ReplyValue())
+	}
+	c = LLMCircuitConfig{Failures: 5, ProbeIntervalSec: 10, Reply: " down "}
+	if c.FailuresValue() != 5 || c.ProbeInterval() != 10*time.Second || c.ReplyValue() != "down" {
+		t.Fatalf("overrides: %d %s %q", c.FailuresValue(), c.ProbeInterval(), c.ReplyValue())
+	}
+}
+
+func TestLoad_GitHubAPIURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tools":{"github":{"enabled":true,"apiURL":"github.example.com"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "tools.github.apiURL") {
+		t.Fatalf("expected apiURL error, got %v", err)
+	}
+	if u := (GitHubToolConfig{APIURL: "https://github.example.com/api/v3/"}).APIURLValue(); u != "https://github.example.com/api/v3" {
+		t.Fatalf("APIURLValue = %q", u)
+	}
+	if u := (GitHubToolConfig{}).APIURLValue(); u != DefaultGitHubAPIURL {
+		t.Fatalf("default APIURLValue = %q", u)
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 type Schedule struct {
@@ -29,13 +31,38 @@ type Payload struct {
 	To      string `json:"to,omitempty"`
 }
 
+// Deliverable reports whether the job's reply is sent to a chat.
+func (p Payload) Deliverable() bool {
+	return p.Deliver && strings.TrimSpace(p.Channel) != "" && strings.TrimSpace(p.To) != ""
+}
+
 type State struct {
 	NextRunAtMS int64  `json:"nextRunAtMs,omitempty"`
 	LastRunAtMS int64  `json:"lastRunAtMs,omitempty"`
 	LastStatus  string `json:"lastStatus,omitempty"`
 	LastError   string `json:"lastError,omitempty"`
+	// LastDelivery is "ok" or "failed" for deliverable jobs, empty when the
+	// last run did not get as far as sending.
+	LastDelivery      string `json:"lastDelivery,omitempty"`
+	LastDeliveryError string `json:"lastDeliveryError,omitempty"`
+	// RunRequestedAtMS asks the running service to execute the job on its
+	// next check, regardless of schedule (see RequestRun).
+	RunRequestedAtMS int64 `json:"runRequestedAtMs,omitempty"`
+}
+
+// DeliveryError is returned by a job handler when the job ran but its reply
+// could not be delivered to the channel.
+type DeliveryError struct {
+	Err error
 }
 
+func (e *DeliveryError) Error() string { return "delivery failed: " + e.Err.Error() }
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// requestPollInterval bounds how long a running service takes to notice
+// jobs added or requested by another process (e.g. "clawlet cron run").
+const requestPollInterval = 5 * time.Second
+
 type Job struct {
 	ID             string   `json:"id"`
 	Name           string   `json:"name"`
@@ -61,6 +88,10 @@ type Service struct {
 	store   Store
 	running bool
 	timer   *time.Timer
+
+	// maxJobs and maxMessageChars limit Add; 0 means no limit.
+	maxJobs         int
+	maxMessageChars int
 }
 
 func NewService(storePath string, onJob func(ctx context.Context, job Job) (string, error)) *Service {
@@ -71,6 +102,15 @@ func NewService(storePath string, onJob func(ctx context.Context, job Job) (stri
 	}
 }
 
+// SetLimits makes Add refuse a job once maxJobs jobs are enabled, or when
+// its message is longer than maxMessageChars. Zero or negative disables a
+// limit.
+func (s *Service) SetLimits(maxJobs, maxMessageChars int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxJobs, s.maxMessageChars = max(maxJobs, 0), max(maxMessageChars, 0)
+}
+
 func (s *Service) Start(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -118,6 +158,9 @@ func (s *Service) Add(name string, sched Schedule, payload Payload) (Job, error)
 	if err := s.loadLocked(); err != nil {
 		return Job{}, err
 	}
+	if err := s.checkLimitsLocked(payload); err != nil {
+		return Job{}, err
+	}
 	now := nowMS()
 	if err := validateSchedule(sched, now); err != nil {
 		return Job{}, err
@@ -144,6 +187,25 @@ func (s *Service) Add(name string, sched Schedule, payload Payload) (Job, error)
 	return j, nil
 }
 
+func (s *Service) checkLimitsLocked(payload Payload) error {
+	if n := utf8.RuneCountInString(payload.Message); s.maxMessageChars > 0 && n > s.maxMessageChars {
+		return fmt.Errorf("message is %d characters, over the limit of %d (cron.maxMessageChars)", n, s.maxMessageChars)
+	}
+	if s.maxJobs <= 0 {
+		return nil
+	}
+	enabled := 0
+	for _, j := range s.store.Jobs {
+		if j.Enabled {
+			enabled++
+		}
+	}
+	if enabled >= s.maxJobs {
+		return fmt.Errorf("cron job limit reached: %d enabled jobs (cron.maxJobs); remove or disable a job first", enabled)
+	}
+	return nil
+}
+
 func (s *Service) Remove(id string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -204,15 +266,51 @@ func (s *Service) RunNow(ctx context.Context, id string, force bool) (string, er
 	return s.execute(ctx, *job)
 }
 
+// RequestRun marks a job to be executed by the running service (usually the
+// gateway) on its next check. Use Get to follow the result.
+func (s *Service) RequestRun(id string, force bool) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return Job{}, err
+	}
+	for i := range s.store.Jobs {
+		j := &s.store.Jobs[i]
+		if j.ID != id {
+			continue
+		}
+		if !j.Enabled && !force {
+			return Job{}, fmt.Errorf("job disabled: %s (use force)", id)
+		}
+		j.State.RunRequestedAtMS = nowMS()
+		if err := s.saveLocked(); err != nil {
+			return Job{}, err
+		}
+		return *j, nil
+	}
+	return Job{}, fmt.Errorf("job not found: %s", id)
+}
+
+func (s *Service) Get(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.loadLocked()
+	for _, j := range s.store.Jobs {
+		if j.ID == id {
+			return j, true
+		}
+	}
+	return Job{}, false
+}
+
 func (s *Service) armLocked(ctx context.Context) {
 	if !s.running {
 		return
 	}
-	next := s.nextWakeMSLocked()
-	if next <= 0 {
-		return
+	delay := requestPollInterval
+	if next := s.nextWakeMSLocked(); next > 0 {
+		delay = min(delay, time.Duration(max64(0, next-nowMS()))*time.Millisecond)
 	}
-	delay := time.Duration(max64(0, next-nowMS())) * time.Millisecond
 	if s.timer != nil {
 		s.timer.Stop()
 	}
@@ -232,6 +330,10 @@ func (s *Service) onTimer(ctx context.Context) error {
 	}
 	_ = s.loadLocked()
 	for _, j := range s.store.Jobs {
+		if j.State.RunRequestedAtMS > 0 {
+			due = append(due, j)
+			continue
+		}
 		if !j.Enabled || j.State.NextRunAtMS <= 0 {
 			continue
 		}
@@ -247,7 +349,7 @@ func (s *Service) onTimer(ctx context.Context) error {
 
 	s.mu.Lock()
 	_ = s.loadLocked()
-	s.recomputeNextRunsLocked()
+	s.fillNextRunsLocked()
 	_ = s.saveLocked()
 	s.armLocked(ctx)
 	s.mu.Unlock()
@@ -272,6 +374,7 @@ func (s *Service) execute(ctx context.Context, job Job) (string, error) {
 		j := &s.store.Jobs[i]
 		updated := nowMS()
 		j.State.LastRunAtMS = start
+		j.State.RunRequestedAtMS = 0
 		if err != nil {
 			j.State.LastStatus = "error"
 			j.State.LastError = err.Error()
@@ -279,6 +382,15 @@ func (s *Service) execute(ctx context.Context, job Job) (string, error) {
 			j.State.LastStatus = "ok"
 			j.State.LastError = ""
 		}
+		j.State.LastDelivery, j.State.LastDeliveryError = "", ""
+		var derr *DeliveryError
+		switch {
+		case errors.As(err, &derr):
+			j.State.LastDelivery = "failed"
+			j.State.LastDeliveryError = derr.Err.Error()
+		case err == nil && s.onJob != nil && j.Payload.Deliverable():
+			j.State.LastDelivery = "ok"
+		}
 		j.UpdatedAtMS = updated
 
 		// One-shot at: disable or delete
@@ -345,6 +457,22 @@ func (s *Service) recomputeNextRunsLocked() {
 	}
 }
 
+// fillNextRunsLocked schedules enabled jobs that have no next run (e.g.
+// re-enabled by another process) without moving jobs that are not yet due.
+func (s *Service) fillNextRunsLocked() {
+	now := nowMS()
+	for i := range s.store.Jobs {
+		j := &s.store.Jobs[i]
+		if !j.Enabled {
+			j.State.NextRunAtMS = 0
+			continue
+		}
+		if j.State.NextRunAtMS <= 0 || (j.Schedule.Kind != "at" && j.State.NextRunAtMS < now) {
+			j.State.NextRunAtMS = computeNextRunMS(j.Schedule, now)
+		}
+	}
+}
+
 func (s *Service) nextWakeMSLocked() int64 {
 	var best int64
 	for _, j := range s.store.Jobs {
@@ -1,7 +1,10 @@
 package cron
 
 import (
+	"context"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -89,6 +92,33 @@ func TestServiceAdd_AcceptsValidAtSchedule(t *testing.T) {
 	}
 }
 
+func TestServiceAdd_EnforcesLimits(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cron.json")
+	svc := NewService(path, nil)
+	svc.SetLimits(2, 10)
+	every := Schedule{Kind: "every", EveryMS: 60_000}
+
+	if _, err := svc.Add("long", every, Payload{Kind: "agent_turn", Message: "this is too long"}); err == nil || !strings.Contains(err.Error(), "cron.maxMessageChars") {
+		t.Fatalf("expected message length error, got %v", err)
+	}
+	first, err := svc.Add("a", every, Payload{Kind: "agent_turn", Message: strings.Repeat("あ", 10)})
+	if err != nil {
+		t.Fatalf("10-rune message should fit: %v", err)
+	}
+	if _, err := svc.Add("b", every, Payload{Kind: "agent_turn", Message: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Add("c", every, Payload{Kind: "agent_turn", Message: "c"}); err == nil || !strings.Contains(err.Error(), "cron.maxJobs") {
+		t.Fatalf("expected job limit error, got %v", err)
+	}
+	svc.Toggle(first.ID, true)
+	if _, err := svc.Add("c", every, Payload{Kind: "agent_turn", Message: "c"}); err != nil {
+		t.Fatalf("disabled jobs should not count: %v", err)
+	}
+}
+
 func TestComputeNextRunMS_CronWeekday(t *testing.T) {
 	t.Parallel()
 
@@ -101,3 +131,82 @@ func TestComputeNextRunMS_CronWeekday(t *testing.T) {
 		t.Fatalf("expected %v, got %v", want, got)
 	}
 }
+
+func TestServiceExecute_RecordsDeliveryOutcome(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cron.json")
+	var fail error
+	svc := NewService(path, func(ctx context.Context, job Job) (string, error) {
+		return "", fail
+	})
+	job, err := svc.Add("test", Schedule{Kind: "every", EveryMS: 60_000}, Payload{Kind: "agent_turn", Message: "hi", Deliver: true, Channel: "telegram", To: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.RunNow(context.Background(), job.ID, false); err != nil {
+		t.Fatalf("RunNow: %v", err)
+	}
+	if got, _ := svc.Get(job.ID); got.State.LastDelivery != "ok" || got.State.LastStatus != "ok" {
+		t.Fatalf("state=%+v", got.State)
+	}
+
+	fail = &DeliveryError{Err: errors.New("chat not found")}
+	if _, err := svc.RunNow(context.Background(), job.ID, false); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	got, _ := svc.Get(job.ID)
+	if got.State.LastDelivery != "failed" || got.State.LastDeliveryError != "chat not found" || got.State.LastStatus != "error" {
+		t.Fatalf("state=%+v", got.State)
+	}
+}
+
+func TestServiceRequestRun_ExecutedOnNextCheck(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cron.json")
+	ran := make(chan string, 2)
+	svc := NewService(path, func(ctx context.Context, job Job) (string, error) {
+		ran <- job.ID
+		return "", nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	job, err := svc.Add("test", Schedule{Kind: "every", EveryMS: 3_600_000}, Payload{Kind: "agent_turn", Message: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := svc.Add("other", Schedule{Kind: "every", EveryMS: 7_200_000}, Payload{Kind: "agent_turn", Message: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Stop()
+	before, _ := svc.Get(other.ID)
+
+	// A separate Service on the same store stands in for the CLI.
+	if _, err := NewService(path, nil).RequestRun(job.ID, false); err != nil {
+		t.Fatalf("RequestRun: %v", err)
+	}
+	if err := svc.onTimer(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-ran:
+		if id != job.ID {
+			t.Fatalf("ran %s", id)
+		}
+	default:
+		t.Fatal("requested job did not run")
+	}
+	got, _ := svc.Get(job.ID)
+	if got.State.RunRequestedAtMS != 0 || got.State.LastRunAtMS == 0 {
+		t.Fatalf("state=%+v", got.State)
+	}
+	if after, _ := svc.Get(other.ID); after.State.NextRunAtMS != before.State.NextRunAtMS {
+		t.Fatalf("job that was not due moved from %d to %d", before.State.NextRunAtMS, after.State.NextRunAtMS)
+	}
+}
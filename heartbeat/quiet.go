@@ -0,0 +1,68 @@
+package heartbeat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily window, in minutes after midnight, during which no
+// heartbeat fires. A start after the end wraps past midnight (e.g. 22:00-07:00).
+type QuietHours struct {
+	start, end int
+	set        bool
+}
+
+// ParseQuietHours parses "HH:MM-HH:MM". An empty string disables quiet hours.
+func ParseQuietHours(s string) (QuietHours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return QuietHours{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q (want HH:MM-HH:MM)", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: %w", s, err)
+	}
+	if start == end {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: start equals end", s)
+	}
+	return QuietHours{start: start, end: end, set: true}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// End reports when the quiet window containing t ends, in t's location.
+// ok is false when t is outside quiet hours.
+func (q QuietHours) End(t time.Time) (time.Time, bool) {
+	if !q.set {
+		return time.Time{}, false
+	}
+	m := t.Hour()*60 + t.Minute()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	endToday := midnight.Add(time.Duration(q.end) * time.Minute)
+	switch {
+	case q.start < q.end:
+		if m >= q.start && m < q.end {
+			return endToday, true
+		}
+	case m >= q.start:
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(q.end) * time.Minute), true
+	case m < q.end:
+		return endToday, true
+	}
+	return time.Time{}, false
+}
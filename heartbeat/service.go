@@ -2,6 +2,7 @@ package heartbeat
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
@@ -26,6 +27,10 @@ type Service struct {
 
 	enabled   bool
 	interval  time.Duration
+	statePath string
+	quiet     QuietHours
+	loc       *time.Location
+	now       func() time.Time
 	running   atomic.Bool
 	inFlight  atomic.Bool
 	stopCh    chan struct{}
@@ -36,6 +41,13 @@ type Options struct {
 	Enabled     bool
 	IntervalSec int
 	OnHeartbeat func(ctx context.Context, prompt string) (string, error)
+	// StatePath, when set, stores the last heartbeat time so the schedule
+	// continues from it after a restart instead of from process start.
+	StatePath string
+	// QuietHours defers heartbeats that fall inside the window to its end.
+	QuietHours QuietHours
+	// Location is the zone QuietHours is read in. Default: time.Local.
+	Location *time.Location
 }
 
 func New(workspace string, opts Options) *Service {
@@ -48,6 +60,10 @@ func New(workspace string, opts Options) *Service {
 		onBeat:    opts.OnHeartbeat,
 		enabled:   opts.Enabled,
 		interval:  time.Duration(sec) * time.Second,
+		statePath: opts.StatePath,
+		quiet:     opts.QuietHours,
+		loc:       opts.Location,
+		now:       time.Now,
 		stopCh:    make(chan struct{}),
 		stoppedCh: make(chan struct{}),
 	}
@@ -80,20 +96,96 @@ func (s *Service) TriggerNow(ctx context.Context) (string, error) {
 
 func (s *Service) loop(ctx context.Context) {
 	defer close(s.stoppedCh)
-	t := time.NewTicker(s.interval)
-	defer t.Stop()
+	last := s.loadLastRun()
 	for {
+		now := s.now()
+		t := time.NewTimer(s.nextRun(last, now).Sub(now))
 		select {
 		case <-ctx.Done():
+			t.Stop()
 			return
 		case <-s.stopCh:
+			t.Stop()
 			return
 		case <-t.C:
+			last = s.now()
+			s.saveLastRun(last)
 			s.tick(ctx)
 		}
 	}
 }
 
+// nextRun is one interval after the last heartbeat, or after now when none
+// is recorded. An overdue heartbeat fires once right away, and one that
+// falls inside quiet hours waits until they end.
+func (s *Service) nextRun(last, now time.Time) time.Time {
+	next := now.Add(s.interval)
+	if !last.IsZero() {
+		next = last.Add(s.interval)
+		if next.Before(now) {
+			next = now
+		}
+	}
+	if end, ok := s.quiet.End(next.In(s.location())); ok {
+		next = end
+	}
+	return next
+}
+
+func (s *Service) location() *time.Location {
+	if s.loc != nil {
+		return s.loc
+	}
+	return time.Local
+}
+
+type state struct {
+	LastRunAtMS int64 `json:"lastRunAtMs"`
+}
+
+func (s *Service) loadLastRun() time.Time {
+	if s.statePath == "" {
+		return time.Time{}
+	}
+	b, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("heartbeat: read state: %v", err)
+		}
+		return time.Time{}
+	}
+	var st state
+	if err := json.Unmarshal(b, &st); err != nil || st.LastRunAtMS <= 0 {
+		log.Printf("heartbeat: ignoring invalid state file %s", s.statePath)
+		return time.Time{}
+	}
+	return time.UnixMilli(st.LastRunAtMS)
+}
+
+func (s *Service) saveLastRun(t time.Time) {
+	if s.statePath == "" {
+		return
+	}
+	if err := writeState(s.statePath, state{LastRunAtMS: t.UnixMilli()}); err != nil {
+		log.Printf("heartbeat: save state: %v", err)
+	}
+}
+
+func writeState(path string, st state) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 func (s *Service) tick(ctx context.Context) {
 	// Ensure only one tick runs at a time.
 	if !s.inFlight.CompareAndSwap(false, true) {
@@ -1,6 +1,10 @@
 package heartbeat
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestIsHeartbeatOK(t *testing.T) {
 	cases := []struct {
@@ -43,3 +47,71 @@ func TestIsEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestNextRun(t *testing.T) {
+	s := New(t.TempDir(), Options{IntervalSec: 1800})
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	if got := s.nextRun(time.Time{}, now); !got.Equal(now.Add(30 * time.Minute)) {
+		t.Fatalf("no state: %v", got)
+	}
+	if got := s.nextRun(now.Add(-10*time.Minute), now); !got.Equal(now.Add(20 * time.Minute)) {
+		t.Fatalf("resume: %v", got)
+	}
+	if got := s.nextRun(now.Add(-2*time.Hour), now); !got.Equal(now) {
+		t.Fatalf("overdue: %v", got)
+	}
+}
+
+func TestNextRun_QuietHours(t *testing.T) {
+	quiet, err := ParseQuietHours("22:00-07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(t.TempDir(), Options{IntervalSec: 1800, QuietHours: quiet, Location: time.UTC})
+
+	// Restart at 23:00 with an overdue heartbeat waits until 07:00.
+	now := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	if got := s.nextRun(now.Add(-2*time.Hour), now); !got.Equal(time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("evening: %v", got)
+	}
+	now = time.Date(2026, 3, 3, 3, 0, 0, 0, time.UTC)
+	if got := s.nextRun(time.Time{}, now); !got.Equal(time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("night: %v", got)
+	}
+	now = time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	if got := s.nextRun(time.Time{}, now); !got.Equal(now.Add(30 * time.Minute)) {
+		t.Fatalf("day: %v", got)
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	for _, bad := range []string{"22:00", "25:00-07:00", "07:00-07:00", "a-b"} {
+		if _, err := ParseQuietHours(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	q, err := ParseQuietHours("01:30-05:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.End(time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("06:00 should be outside 01:30-05:00")
+	}
+	if end, ok := q.End(time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC)); !ok || end.Hour() != 5 || end.Day() != 2 {
+		t.Fatalf("end=%v ok=%v", end, ok)
+	}
+}
+
+func TestLastRunState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	s := New(t.TempDir(), Options{StatePath: path})
+	if got := s.loadLastRun(); !got.IsZero() {
+		t.Fatalf("missing file: %v", got)
+	}
+	at := time.UnixMilli(1700000000123)
+	s.saveLastRun(at)
+	if got := s.loadLastRun(); !got.Equal(at) {
+		t.Fatalf("got %v want %v", got, at)
+	}
+}
@@ -17,12 +17,13 @@ func (c *Client) chatAnthropic(ctx context.Context, messages []Message, tools []
 
 	anthropicMessages, systemText := toAnthropicMessages(messages)
 	reqBody := struct {
-		Model       string          `json:"model"`
-		Messages    []anthropicMsg  `json:"messages"`
-		System      string          `json:"system,omitempty"`
-		Tools       []anthropicTool `json:"tools,omitempty"`
-		MaxTokens   int             `json:"max_tokens"`
-		Temperature *float64        `json:"temperature,omitempty"`
+		Model       string             `json:"model"`
+		Messages    []anthropicMsg     `json:"messages"`
+		System      string             `json:"system,omitempty"`
+		Tools       []anthropicTool    `json:"tools,omitempty"`
+		MaxTokens   int                `json:"max_tokens"`
+		Temperature *float64           `json:"temperature,omitempty"`
+		Thinking    *anthropicThinking `json:"thinking,omitempty"`
 	}{
 		Model:       c.Model,
 		Messages:    anthropicMessages,
@@ -30,6 +31,16 @@ func (c *Client) chatAnthropic(ctx context.Context, messages []Message, tools []
 		MaxTokens:   c.maxTokensValue(),
 		Temperature: c.temperatureValue(),
 	}
+	if r, ok := c.reasoning(); ok {
+		budget := max(r.Budget, anthropicMinThinkingBudget)
+		reqBody.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: budget}
+		// max_tokens includes the thinking budget, and thinking does not
+		// allow a custom temperature.
+		if reqBody.MaxTokens <= budget {
+			reqBody.MaxTokens += budget
+		}
+		reqBody.Temperature = nil
+	}
 	if len(tools) > 0 {
 		converted, err := toAnthropicTools(tools)
 		if err != nil {
@@ -58,36 +69,57 @@ func (c *Client) chatAnthropic(ctx context.Context, messages []Message, tools []
 		req.Header.Set(k, v)
 	}
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("llm http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return nil, newAPIError("llm", c.Provider, resp.StatusCode, string(body))
 	}
 
 	var parsed struct {
-		Content []struct {
-			Type  string          `json:"type"`
-			Text  string          `json:"text,omitempty"`
-			ID    string          `json:"id,omitempty"`
-			Name  string          `json:"name,omitempty"`
-			Input json.RawMessage `json:"input,omitempty"`
-		} `json:"content"`
+		Content    []json.RawMessage `json:"content"`
+		StopReason string            `json:"stop_reason"`
+		Usage      *struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
 	}
 	if err := json.Unmarshal(body, &parsed); err != nil {
 		return nil, fmt.Errorf("parse anthropic response: %w", err)
 	}
 	if len(parsed.Content) == 0 {
+		if parsed.StopReason == "refusal" {
+			return &ChatResult{Refusal: "refusal"}, nil
+		}
 		return nil, fmt.Errorf("anthropic response: empty content")
 	}
 
-	out := &ChatResult{}
+	out := &ChatResult{Truncated: parsed.StopReason == "max_tokens"}
+	if parsed.StopReason == "refusal" {
+		out.Refusal = "refusal"
+	}
+	if u := parsed.Usage; u != nil {
+		out.setUsage(u.InputTokens, u.OutputTokens, 0)
+	}
 	var textParts []string
-	for i, part := range parsed.Content {
+	for i, raw := range parsed.Content {
+		var part struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text,omitempty"`
+			ID    string          `json:"id,omitempty"`
+			Name  string          `json:"name,omitempty"`
+			Input json.RawMessage `json:"input,omitempty"`
+		}
+		if err := json.Unmarshal(raw, &part); err != nil {
+			return nil, fmt.Errorf("parse anthropic response: %w", err)
+		}
 		switch part.Type {
+		case "thinking", "redacted_thinking":
+			// Must be sent back unchanged with the tool results.
+			out.Reasoning = append(out.Reasoning, raw)
 		case "text":
 			if strings.TrimSpace(part.Text) != "" {
 				textParts = append(textParts, part.Text)
@@ -126,6 +158,22 @@ type anthropicContentPart struct {
 	Input     json.RawMessage  `json:"input,omitempty"`
 	ToolUseID string           `json:"tool_use_id,omitempty"`
 	Content   string           `json:"content,omitempty"`
+
+	// Raw, when set, is sent verbatim (used for thinking blocks).
+	Raw json.RawMessage `json:"-"`
+}
+
+func (p anthropicContentPart) MarshalJSON() ([]byte, error) {
+	if len(p.Raw) > 0 {
+		return p.Raw, nil
+	}
+	type alias anthropicContentPart
+	return json.Marshal(alias(p))
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type anthropicSource struct {
@@ -190,6 +238,13 @@ func toAnthropicMessages(messages []Message) ([]anthropicMsg, string) {
 
 			parts := toAnthropicInputParts(m)
 			if role == "assistant" {
+				if len(m.Reasoning) > 0 {
+					thinking := make([]anthropicContentPart, 0, len(m.Reasoning)+len(parts))
+					for _, raw := range m.Reasoning {
+						thinking = append(thinking, anthropicContentPart{Raw: raw})
+					}
+					parts = append(thinking, parts...)
+				}
 				for i, tc := range m.ToolCalls {
 					toolID := strings.TrimSpace(tc.ID)
 					if toolID == "" {
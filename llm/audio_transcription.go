@@ -11,12 +11,14 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 const defaultOpenAIAudioTranscriptionModel = "gpt-4o-mini-transcribe"
 
 func (c *Client) SupportsAudioTranscription() bool {
+	if c.LocalTranscriber != nil {
+		return true
+	}
 	switch normalizeProvider(c.Provider) {
 	case "openai", "openrouter", "ollama", "gemini":
 		return true
@@ -33,7 +35,7 @@ func (c *Client) SupportsImageInput() bool {
 		return true
 	case "anthropic":
 		return strings.Contains(model, "claude")
-	case "openai", "openrouter", "ollama", "":
+	case "openai", "azure", "openrouter", "ollama", "":
 		return containsAny(model, []string{
 			"gpt-4o",
 			"gpt-4.1",
@@ -50,21 +52,35 @@ func (c *Client) SupportsImageInput() bool {
 	}
 }
 
-func (c *Client) TranscribeAudio(ctx context.Context, data []byte, mimeType, fileName string) (string, error) {
+// TranscriptionLanguageAuto asks the provider to detect the spoken language.
+const TranscriptionLanguageAuto = "auto"
+
+// TranscribeAudio transcribes data. language is an ISO 639-1 code such as
+// "ja"; empty or "auto" lets the provider detect it.
+func (c *Client) TranscribeAudio(ctx context.Context, data []byte, mimeType, fileName, language string) (string, error) {
 	if len(data) == 0 {
 		return "", fmt.Errorf("audio data is empty")
 	}
+	if c.LocalTranscriber != nil {
+		return c.LocalTranscriber.Transcribe(ctx, data, mimeType, fileName, language)
+	}
+	release, err := c.Limiter.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	language = strings.ToLower(strings.TrimSpace(language))
 	switch normalizeProvider(c.Provider) {
 	case "openai", "openrouter", "ollama", "":
-		return c.transcribeAudioOpenAICompatible(ctx, data, mimeType, fileName)
+		return c.transcribeAudioOpenAICompatible(ctx, data, mimeType, fileName, language)
 	case "gemini":
-		return c.transcribeAudioGemini(ctx, data, mimeType)
+		return c.transcribeAudioGemini(ctx, data, mimeType, language)
 	default:
 		return "", fmt.Errorf("audio transcription is unsupported for provider: %s", strings.TrimSpace(c.Provider))
 	}
 }
 
-func (c *Client) transcribeAudioOpenAICompatible(ctx context.Context, data []byte, mimeType, fileName string) (string, error) {
+func (c *Client) transcribeAudioOpenAICompatible(ctx context.Context, data []byte, mimeType, fileName, language string) (string, error) {
 	endpoint := strings.TrimRight(strings.TrimSpace(c.BaseURL), "/") + "/audio/transcriptions"
 	if strings.TrimSpace(c.BaseURL) == "" {
 		return "", fmt.Errorf("baseURL is empty for audio transcription")
@@ -89,6 +105,12 @@ func (c *Client) transcribeAudioOpenAICompatible(ctx context.Context, data []byt
 	if err := writer.WriteField("model", defaultOpenAIAudioTranscriptionModel); err != nil {
 		return "", err
 	}
+	// The API auto-detects when language is omitted.
+	if language != "" && language != TranscriptionLanguageAuto {
+		if err := writer.WriteField("language", language); err != nil {
+			return "", err
+		}
+	}
 	if err := writer.Close(); err != nil {
 		return "", err
 	}
@@ -110,7 +132,7 @@ func (c *Client) transcribeAudioOpenAICompatible(ctx context.Context, data []byt
 
 	hc := c.HTTP
 	if hc == nil {
-		hc = &http.Client{Timeout: 120 * time.Second}
+		hc = &http.Client{Timeout: c.requestTimeout()}
 	}
 	resp, err := hc.Do(req)
 	if err != nil {
@@ -119,7 +141,7 @@ func (c *Client) transcribeAudioOpenAICompatible(ctx context.Context, data []byt
 	defer resp.Body.Close()
 	payload, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("audio transcription http %d: %s", resp.StatusCode, strings.TrimSpace(string(payload)))
+		return "", newAPIError("audio transcription", c.Provider, resp.StatusCode, string(payload))
 	}
 
 	var parsed struct {
@@ -135,13 +157,13 @@ func (c *Client) transcribeAudioOpenAICompatible(ctx context.Context, data []byt
 	return text, nil
 }
 
-func (c *Client) transcribeAudioGemini(ctx context.Context, data []byte, mimeType string) (string, error) {
+func (c *Client) transcribeAudioGemini(ctx context.Context, data []byte, mimeType, language string) (string, error) {
 	endpoint := geminiGenerateContentEndpoint(c.BaseURL, c.Model)
 	if strings.TrimSpace(mimeType) == "" {
 		mimeType = "audio/ogg"
 	}
 
-	prompt := "Transcribe the following audio. Return only the transcript text."
+	prompt := geminiTranscriptionPrompt(language)
 	zero := 0.0
 	reqBody := struct {
 		Contents         []geminiContent `json:"contents"`
@@ -182,7 +204,7 @@ func (c *Client) transcribeAudioGemini(ctx context.Context, data []byte, mimeTyp
 
 	hc := c.HTTP
 	if hc == nil {
-		hc = &http.Client{Timeout: 120 * time.Second}
+		hc = &http.Client{Timeout: c.requestTimeout()}
 	}
 	resp, err := hc.Do(req)
 	if err != nil {
@@ -191,7 +213,7 @@ func (c *Client) transcribeAudioGemini(ctx context.Context, data []byte, mimeTyp
 	defer resp.Body.Close()
 	payload, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("audio transcription http %d: %s", resp.StatusCode, strings.TrimSpace(string(payload)))
+		return "", newAPIError("audio transcription", c.Provider, resp.StatusCode, string(payload))
 	}
 
 	var parsed struct {
@@ -229,6 +251,18 @@ func (c *Client) transcribeAudioGemini(ctx context.Context, data []byte, mimeTyp
 	return text, nil
 }
 
+func geminiTranscriptionPrompt(language string) string {
+	prompt := "Transcribe the following audio. Return only the transcript text."
+	switch language {
+	case "":
+		return prompt
+	case TranscriptionLanguageAuto:
+		return prompt + " Detect the spoken language and transcribe in that language; do not translate."
+	default:
+		return prompt + fmt.Sprintf(" The speech is in the language with ISO 639-1 code %q; transcribe it in that language and do not translate.", language)
+	}
+}
+
 func containsAny(s string, needles []string) bool {
 	s = strings.ToLower(strings.TrimSpace(s))
 	for _, n := range needles {
@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -38,7 +40,7 @@ func TestTranscribeAudio_OpenAICompatible(t *testing.T) {
 		Model:    "gpt-4o-mini",
 		HTTP:     srv.Client(),
 	}
-	got, err := c.TranscribeAudio(context.Background(), []byte("fake audio"), "audio/ogg", "voice.ogg")
+	got, err := c.TranscribeAudio(context.Background(), []byte("fake audio"), "audio/ogg", "voice.ogg", "")
 	if err != nil {
 		t.Fatalf("TranscribeAudio error: %v", err)
 	}
@@ -50,6 +52,43 @@ func TestTranscribeAudio_OpenAICompatible(t *testing.T) {
 	}
 }
 
+func TestTranscribeAudio_OpenAILanguage(t *testing.T) {
+	for _, tc := range []struct {
+		language string
+		want     string
+	}{
+		{language: "JA", want: "ja"},
+		{language: "auto", want: ""},
+		{language: "", want: ""},
+	} {
+		var got string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatalf("parse form: %v", err)
+			}
+			got = r.FormValue("language")
+			_ = json.NewEncoder(w).Encode(map[string]string{"text": "ok"})
+		}))
+		c := &Client{Provider: "openai", BaseURL: srv.URL, HTTP: srv.Client()}
+		if _, err := c.TranscribeAudio(context.Background(), []byte("fake audio"), "audio/ogg", "voice.ogg", tc.language); err != nil {
+			t.Fatalf("TranscribeAudio(%q) error: %v", tc.language, err)
+		}
+		srv.Close()
+		if got != tc.want {
+			t.Fatalf("language %q: form value=%q want %q", tc.language, got, tc.want)
+		}
+	}
+}
+
+func TestGeminiTranscriptionPrompt(t *testing.T) {
+	if p := geminiTranscriptionPrompt("ja"); !strings.Contains(p, `"ja"`) || !strings.Contains(p, "do not translate") {
+		t.Fatalf("prompt=%q", p)
+	}
+	if p := geminiTranscriptionPrompt("auto"); !strings.Contains(p, "Detect the spoken language") {
+		t.Fatalf("prompt=%q", p)
+	}
+}
+
 func TestTranscribeAudio_Gemini(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.HasSuffix(r.URL.Path, "/models/gemini-2.5-flash:generateContent") {
@@ -89,7 +128,7 @@ func TestTranscribeAudio_Gemini(t *testing.T) {
 		Model:    "gemini-2.5-flash",
 		HTTP:     srv.Client(),
 	}
-	got, err := c.TranscribeAudio(context.Background(), []byte("fake audio"), "audio/ogg", "voice.ogg")
+	got, err := c.TranscribeAudio(context.Background(), []byte("fake audio"), "audio/ogg", "voice.ogg", "")
 	if err != nil {
 		t.Fatalf("TranscribeAudio error: %v", err)
 	}
@@ -98,6 +137,35 @@ func TestTranscribeAudio_Gemini(t *testing.T) {
 	}
 }
 
+func TestTranscribeAudio_Local(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "whisper")
+	// Prints whisper.cpp-style segments after checking it got a real file.
+	body := "#!/bin/sh\n[ -s \"$2\" ] || exit 3\necho \"[00:00:00.000 --> 00:00:01.500]   hello ($1)\"\necho\necho \"[00:00:01.500 --> 00:00:03.000]  world\"\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{
+		Provider:         "anthropic",
+		LocalTranscriber: &LocalTranscriber{Command: script, Args: []string{"{language}", "{input}"}},
+	}
+	if !c.SupportsAudioTranscription() {
+		t.Fatal("local transcription should be supported for any provider")
+	}
+	got, err := c.TranscribeAudio(context.Background(), []byte("RIFF...."), "audio/wav", "voice.wav", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello (auto) world" {
+		t.Fatalf("transcript=%q", got)
+	}
+
+	c.LocalTranscriber = &LocalTranscriber{Command: script}
+	if _, err := c.TranscribeAudio(context.Background(), []byte("RIFF"), "audio/wav", "voice.wav", "ja"); err == nil || !strings.Contains(err.Error(), "llm.transcription.model") {
+		t.Fatalf("missing model: %v", err)
+	}
+}
+
 func TestSupportsImageInput(t *testing.T) {
 	cases := []struct {
 		provider string
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,8 +19,29 @@ type Client struct {
 	Temperature *float64
 	Headers     map[string]string
 	HTTP        HTTPDoer
+	// MaxContinuations is the number of automatic "continue" follow-ups issued
+	// when a text reply is cut off by the max token limit. 0 disables it.
+	MaxContinuations int
+	// Limiter, when set, throttles every request made by this client.
+	Limiter *Limiter
+	// ReasoningEffort is "low", "medium", "high", or a token budget such as
+	// "8192". Empty leaves the provider default.
+	ReasoningEffort string
+	// LocalTranscriber, when set, handles TranscribeAudio instead of the
+	// provider's API.
+	LocalTranscriber *LocalTranscriber
+	// Timeout bounds one request. Default: 120s. Streaming responses
+	// (openai-codex) only have to start within it, so long replies are not
+	// cut off.
+	Timeout time.Duration
+	// APIVersion is the Azure OpenAI api-version query parameter. Default:
+	// 2024-10-21.
+	APIVersion string
 }
 
+// defaultRequestTimeout applies when Client.Timeout is 0.
+const defaultRequestTimeout = 120 * time.Second
+
 type HTTPDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
@@ -33,17 +55,141 @@ type ToolCall struct {
 type ChatResult struct {
 	Content   string
 	ToolCalls []ToolCall
+	// Truncated reports that the provider stopped because of the max token limit
+	// (after any continuations).
+	Truncated bool
+	// Continuations is the number of follow-up calls merged into Content.
+	Continuations int
+	// Reasoning holds provider reasoning blocks that must accompany the
+	// assistant message in the next request (see Message.Reasoning).
+	Reasoning []json.RawMessage
+	// Refusal is set when the provider declined to answer under its content
+	// policy instead of returning an error: Anthropic's "refusal" stop
+	// reason, a Gemini block or safety finish reason, or an OpenAI refusal
+	// or content_filter. Content then holds the refusal text, if any.
+	Refusal string
+	// Usage is the token count the provider reported, summed over
+	// continuations. It is zero when UsageKnown is false.
+	Usage Usage
+	// UsageKnown is set when the provider reported usage for every call
+	// that produced this result.
+	UsageKnown bool
+}
+
+// Usage is a provider-reported token count.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// setUsage records reported usage on r. A missing total is the sum of the
+// other two.
+func (r *ChatResult) setUsage(prompt, completion, total int) {
+	if total == 0 {
+		total = prompt + completion
+	}
+	r.Usage = Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: total}
+	r.UsageKnown = true
 }
 
 func (r ChatResult) HasToolCalls() bool { return len(r.ToolCalls) > 0 }
 
+// continuePrompt is sent after a truncated reply to ask the model to resume.
+const continuePrompt = "Your previous reply was cut off. Continue exactly where you left off, without repeating anything."
+
 func (c *Client) Chat(ctx context.Context, messages []Message, tools []ToolDefinition) (*ChatResult, error) {
-	if c.HTTP == nil {
-		c.HTTP = &http.Client{Timeout: 120 * time.Second}
+	return c.chat(ctx, messages, tools, nil)
+}
+
+// ChatStream is Chat with the reply streamed: onDelta receives each content
+// chunk as it arrives, and the final result is returned as Chat would.
+// OpenAI-compatible providers stream over SSE; other providers fall back to
+// Chat and pass the whole content to onDelta once.
+func (c *Client) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, onDelta func(string)) (*ChatResult, error) {
+	if onDelta == nil {
+		onDelta = func(string) {}
 	}
-	switch normalizeProvider(c.Provider) {
-	case "", "openai", "openrouter", "ollama", "shengsuanyun", "novita":
+	if !openAICompatible(c.Provider) {
+		res, err := c.Chat(ctx, messages, tools)
+		if err == nil && res.Content != "" {
+			onDelta(res.Content)
+		}
+		return res, err
+	}
+	return c.chat(ctx, messages, tools, onDelta)
+}
+
+func (c *Client) chat(ctx context.Context, messages []Message, tools []ToolDefinition, onDelta func(string)) (*ChatResult, error) {
+	res, err := c.chatOnce(ctx, messages, tools, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	for res.Truncated && !res.HasToolCalls() && res.Continuations < c.MaxContinuations {
+		follow := append(append([]Message(nil), messages...),
+			Message{Role: "assistant", Content: res.Content},
+			Message{Role: "user", Content: continuePrompt},
+		)
+		next, err := c.chatOnce(ctx, follow, tools, onDelta)
+		if err != nil {
+			// Keep the partial reply rather than failing the whole turn.
+			return res, nil
+		}
+		prev := res
+		res = &ChatResult{
+			Content:       res.Content + next.Content,
+			ToolCalls:     next.ToolCalls,
+			Reasoning:     next.Reasoning,
+			Truncated:     next.Truncated,
+			Refusal:       next.Refusal,
+			Continuations: res.Continuations + 1,
+		}
+		if prev.UsageKnown && next.UsageKnown {
+			res.setUsage(prev.Usage.PromptTokens+next.Usage.PromptTokens, prev.Usage.CompletionTokens+next.Usage.CompletionTokens, prev.Usage.TotalTokens+next.Usage.TotalTokens)
+		}
+	}
+	return res, nil
+}
+
+// chatOnce sends one request. A non-nil onDelta streams the reply from an
+// OpenAI-compatible provider. Rate-limited openai-codex requests are retried
+// here, outside the Limiter, so a backoff does not hold a slot other
+// sessions are waiting for.
+func (c *Client) chatOnce(ctx context.Context, messages []Message, tools []ToolDefinition, onDelta func(string)) (*ChatResult, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := c.chatAttempt(ctx, messages, tools, onDelta)
+		if normalizeProvider(c.Provider) != "openai-codex" {
+			return res, err
+		}
+		wait, ok := codexRateLimitRetry(err, attempt)
+		if !ok {
+			return res, err
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) chatAttempt(ctx context.Context, messages []Message, tools []ToolDefinition, onDelta func(string)) (*ChatResult, error) {
+	release, err := c.Limiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	stream := onDelta != nil && openAICompatible(c.Provider)
+	if !c.streams() && !stream {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout())
+		defer cancel()
+	}
+	if stream {
+		return c.chatOpenAICompatibleStream(ctx, messages, tools, onDelta)
+	}
+	if openAICompatible(c.Provider) {
 		return c.chatOpenAICompatible(ctx, messages, tools)
+	}
+	switch normalizeProvider(c.Provider) {
 	case "anthropic":
 		return c.chatAnthropic(ctx, messages, tools)
 	case "gemini":
@@ -55,10 +201,69 @@ func (c *Client) Chat(ctx context.Context, messages []Message, tools []ToolDefin
 	}
 }
 
+func (c *Client) requestTimeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return defaultRequestTimeout
+}
+
+// streams reports whether the provider's chat responses are streamed.
+func (c *Client) streams() bool {
+	return normalizeProvider(c.Provider) == "openai-codex"
+}
+
+// httpClient returns c.HTTP, or a client built from the request timeout when
+// it is unset. It never writes c: one Client is shared by concurrent
+// sessions, so set HTTP when the client is made (see NewHTTPClient).
+func (c *Client) httpClient() HTTPDoer {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return NewHTTPClient(c.requestTimeout())
+}
+
+// NewHTTPClient returns a client that gives up when response headers take
+// longer than timeout but lets a started body run as long as the request
+// context allows, so streamed replies are not cut off. A timeout of 0 means
+// the 120s default. Clients with the same timeout share one transport, so
+// copies of a Client and fallback clients reuse pooled connections.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	tr := transports[timeout]
+	if tr == nil {
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+		tr.ResponseHeaderTimeout = timeout
+		transports[timeout] = tr
+	}
+	return &http.Client{Transport: tr}
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[time.Duration]*http.Transport{}
+)
+
+// openAICompatible reports whether the provider speaks the OpenAI
+// /chat/completions API.
+func openAICompatible(provider string) bool {
+	switch normalizeProvider(provider) {
+	case "", "openai", "azure", "openrouter", "ollama", "shengsuanyun", "novita":
+		return true
+	}
+	return false
+}
+
 func normalizeProvider(p string) string {
 	switch strings.ToLower(strings.TrimSpace(p)) {
 	case "local":
 		return "ollama"
+	case "azure", "azure-openai":
+		return "azure"
 	default:
 		return strings.ToLower(strings.TrimSpace(p))
 	}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChat_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	c := &Client{Provider: "openai", BaseURL: srv.URL, Model: "m", Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timeout not applied: took %s", elapsed)
+	}
+}
+
+func TestNewHTTPClient_DoesNotCutStartedBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(150 * time.Millisecond)
+		_, _ = io.WriteString(w, "data: done\n\n")
+	}))
+	defer srv.Close()
+
+	resp, err := NewHTTPClient(50 * time.Millisecond).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil || string(b) != "data: done\n\n" {
+		t.Fatalf("body=%q err=%v", b, err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+	}))
+	defer slow.Close()
+	if _, err := NewHTTPClient(50 * time.Millisecond).Get(slow.URL); err == nil {
+		t.Fatal("expected response header timeout")
+	}
+}
+
+func TestChat_Usage(t *testing.T) {
+	cases := []struct {
+		provider string
+		body     string
+		want     Usage
+		known    bool
+	}{
+		{"openai", `{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`, Usage{12, 3, 15}, true},
+		{"ollama", `{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`, Usage{}, false},
+		{"gemini", `{"candidates":[{"content":{"parts":[{"text":"hi"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":2,"totalTokenCount":9}}`, Usage{7, 2, 9}, true},
+		{"anthropic", `{"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":4}}`, Usage{5, 4, 9}, true},
+	}
+	for _, tc := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, tc.body)
+		}))
+		c := &Client{Provider: tc.provider, BaseURL: srv.URL, Model: "m"}
+		res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.provider, err)
+		}
+		if res.Usage != tc.want || res.UsageKnown != tc.known {
+			t.Fatalf("%s: usage=%+v known=%v", tc.provider, res.Usage, res.UsageKnown)
+		}
+	}
+}
+
+func TestChat_UsageSummedOverContinuations(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		finish := "length"
+		if calls > 1 {
+			finish = "stop"
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"part"},"finish_reason":"`+finish+`"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`)
+	}))
+	defer srv.Close()
+	c := &Client{Provider: "openai", BaseURL: srv.URL, Model: "m", MaxContinuations: 1}
+	res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Continuations != 1 || !res.UsageKnown || res.Usage != (Usage{20, 10, 30}) {
+		t.Fatalf("res=%+v", res)
+	}
+}
+
+func TestChat_ConcurrentCallsShareClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	for _, provider := range []string{"openai", "anthropic", "gemini"} {
+		c := &Client{Provider: provider, BaseURL: srv.URL, Model: "m"}
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+			}()
+		}
+		wg.Wait()
+		if c.HTTP != nil {
+			t.Fatalf("%s: Chat filled in Client.HTTP", provider)
+		}
+	}
+}
+
+func TestNewHTTPClient_SharesTransportPerTimeout(t *testing.T) {
+	a, b := NewHTTPClient(0), NewHTTPClient(defaultRequestTimeout)
+	if a.Transport != b.Transport {
+		t.Fatal("clients with the same timeout use different transports")
+	}
+	if c := NewHTTPClient(time.Second); c.Transport == a.Transport {
+		t.Fatal("clients with different timeouts share a transport")
+	}
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is a non-2xx response from a provider. Callers branch on it with
+// errors.As instead of parsing the message.
+type APIError struct {
+	StatusCode int
+	// Provider is the configured provider name, e.g. "openai".
+	Provider string
+	// Retryable is set for statuses worth retrying later: 408, 429 and 5xx.
+	Retryable bool
+	// Message is the provider's error body, or the status text when empty.
+	Message string
+
+	// scope prefixes Error, e.g. "llm" or "codex".
+	scope string
+	// retryAfter is the response's Retry-After header, if any.
+	retryAfter string
+}
+
+func newAPIError(scope, provider string, statusCode int, body string) *APIError {
+	msg := strings.TrimSpace(body)
+	if msg == "" {
+		msg = http.StatusText(statusCode)
+	}
+	return &APIError{
+		StatusCode: statusCode,
+		Provider:   strings.TrimSpace(provider),
+		Retryable:  statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests || statusCode >= 500,
+		Message:    msg,
+		scope:      scope,
+	}
+}
+
+func (e *APIError) Error() string {
+	scope := e.scope
+	if scope == "" {
+		scope = "llm"
+	}
+	return fmt.Sprintf("%s http %d: %s", scope, e.StatusCode, e.Message)
+}
+
+// IsAuth reports whether the provider rejected the credentials.
+func (e *APIError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsRateLimit reports whether the provider asked the caller to slow down.
+func (e *APIError) IsRateLimit() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// AsAPIError returns the *APIError in err's chain, if any.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChat_ReturnsAPIError(t *testing.T) {
+	status := http.StatusTooManyRequests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"error":"slow down"}`))
+	}))
+	defer srv.Close()
+
+	for _, provider := range []string{"openai", "anthropic", "gemini"} {
+		status = http.StatusTooManyRequests
+		c := &Client{Provider: provider, BaseURL: srv.URL, Model: "m", APIKey: "k"}
+		_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+		apiErr, ok := AsAPIError(fmt.Errorf("wrapped: %w", err))
+		if !ok {
+			t.Fatalf("%s: err=%T %v", provider, err, err)
+		}
+		if apiErr.StatusCode != 429 || apiErr.Provider != provider || !apiErr.Retryable || !apiErr.IsRateLimit() || apiErr.IsAuth() {
+			t.Fatalf("%s: %+v", provider, apiErr)
+		}
+		if got := err.Error(); got != `llm http 429: {"error":"slow down"}` {
+			t.Fatalf("%s: message=%q", provider, got)
+		}
+
+		status = http.StatusUnauthorized
+		_, err = c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+		if apiErr, ok := AsAPIError(err); !ok || !apiErr.IsAuth() || apiErr.Retryable {
+			t.Fatalf("%s: 401 err=%v", provider, err)
+		}
+	}
+
+	if _, ok := AsAPIError(fmt.Errorf("dial: connection refused")); ok {
+		t.Fatal("plain errors are not API errors")
+	}
+	if got := newAPIError("codex", "openai-codex", 502, "").Error(); got != "codex http 502: Bad Gateway" {
+		t.Fatalf("empty body=%q", got)
+	}
+}
@@ -20,8 +20,9 @@ func (c *Client) chatGemini(ctx context.Context, messages []Message, tools []Too
 		SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
 		Tools             []geminiTool    `json:"tools,omitempty"`
 		GenerationConfig  struct {
-			MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
-			Temperature     *float64 `json:"temperature,omitempty"`
+			MaxOutputTokens int                   `json:"maxOutputTokens,omitempty"`
+			Temperature     *float64              `json:"temperature,omitempty"`
+			ThinkingConfig  *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
 		} `json:"generationConfig"`
 	}{
 		Contents: contents,
@@ -40,6 +41,9 @@ func (c *Client) chatGemini(ctx context.Context, messages []Message, tools []Too
 	}
 	reqBody.GenerationConfig.MaxOutputTokens = c.maxTokensValue()
 	reqBody.GenerationConfig.Temperature = c.temperatureValue()
+	if r, ok := c.reasoning(); ok {
+		reqBody.GenerationConfig.ThinkingConfig = &geminiThinkingConfig{ThinkingBudget: r.Budget}
+	}
 
 	b, err := json.Marshal(reqBody)
 	if err != nil {
@@ -60,14 +64,14 @@ func (c *Client) chatGemini(ctx context.Context, messages []Message, tools []Too
 		req.Header.Set(k, v)
 	}
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("llm http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return nil, newAPIError("llm", c.Provider, resp.StatusCode, string(body))
 	}
 
 	var parsed struct {
@@ -81,22 +85,34 @@ func (c *Client) chatGemini(ctx context.Context, messages []Message, tools []Too
 					} `json:"functionCall,omitempty"`
 				} `json:"parts"`
 			} `json:"content"`
+			FinishReason string `json:"finishReason,omitempty"`
 		} `json:"candidates"`
 		PromptFeedback struct {
 			BlockReason string `json:"blockReason,omitempty"`
 		} `json:"promptFeedback"`
+		UsageMetadata *struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			TotalTokenCount      int `json:"totalTokenCount"`
+		} `json:"usageMetadata"`
 	}
 	if err := json.Unmarshal(body, &parsed); err != nil {
 		return nil, fmt.Errorf("parse gemini response: %w", err)
 	}
 	if len(parsed.Candidates) == 0 {
-		if strings.TrimSpace(parsed.PromptFeedback.BlockReason) != "" {
-			return nil, fmt.Errorf("gemini blocked: %s", parsed.PromptFeedback.BlockReason)
+		if reason := strings.TrimSpace(parsed.PromptFeedback.BlockReason); reason != "" {
+			return &ChatResult{Refusal: reason}, nil
 		}
 		return nil, fmt.Errorf("gemini response: no candidates")
 	}
 
-	out := &ChatResult{}
+	out := &ChatResult{Truncated: parsed.Candidates[0].FinishReason == "MAX_TOKENS"}
+	if geminiPolicyFinishReasons[parsed.Candidates[0].FinishReason] {
+		out.Refusal = parsed.Candidates[0].FinishReason
+	}
+	if u := parsed.UsageMetadata; u != nil {
+		out.setUsage(u.PromptTokenCount, u.CandidatesTokenCount, u.TotalTokenCount)
+	}
 	var textParts []string
 	callCount := 0
 	for _, part := range parsed.Candidates[0].Content.Parts {
@@ -120,6 +136,17 @@ func (c *Client) chatGemini(ctx context.Context, messages []Message, tools []Too
 	return out, nil
 }
 
+// geminiPolicyFinishReasons are the finish reasons of a candidate stopped by
+// a content policy.
+var geminiPolicyFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+	"IMAGE_SAFETY":       true,
+}
+
 type geminiContent struct {
 	Role  string       `json:"role,omitempty"`
 	Parts []geminiPart `json:"parts"`
@@ -147,6 +174,10 @@ type geminiFunctionResponse struct {
 	Response json.RawMessage `json:"response,omitempty"`
 }
 
+type geminiThinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget"`
+}
+
 type geminiTool struct {
 	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
 }
@@ -8,11 +8,74 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"time"
 )
 
 func (c *Client) chatOpenAICompatible(ctx context.Context, messages []Message, tools []ToolDefinition) (*ChatResult, error) {
+	req, err := c.newOpenAIChatRequest(ctx, messages, tools, false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError("llm", c.Provider, resp.StatusCode, string(body))
+	}
+
+	var parsed struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				Refusal   string `json:"refusal"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string          `json:"name"`
+						Arguments json.RawMessage `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parse llm response: %w", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return nil, fmt.Errorf("llm response: no choices")
+	}
+	m := parsed.Choices[0].Message
+	out := &ChatResult{Content: m.Content}
+	for _, tc := range m.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: openAIToolArguments(tc.Function.Arguments),
+		})
+	}
+	finishOpenAIResult(out, m.Refusal, parsed.Choices[0].FinishReason)
+	if u := parsed.Usage; u != nil {
+		out.setUsage(u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+	}
+	return out, nil
+}
+
+// newOpenAIChatRequest builds the /chat/completions request; stream asks
+// for an SSE response.
+func (c *Client) newOpenAIChatRequest(ctx context.Context, messages []Message, tools []ToolDefinition, stream bool) (*http.Request, error) {
+	azure := normalizeProvider(c.Provider) == "azure"
 	endpoint := strings.TrimRight(c.BaseURL, "/") + "/chat/completions"
+	if azure {
+		var err error
+		if endpoint, err = c.azureChatEndpoint(); err != nil {
+			return nil, err
+		}
+	}
 
 	type chatRequest struct {
 		Model       string           `json:"model"`
@@ -21,12 +84,36 @@ func (c *Client) chatOpenAICompatible(ctx context.Context, messages []Message, t
 		Temperature *float64         `json:"temperature,omitempty"`
 		Tools       []ToolDefinition `json:"tools,omitempty"`
 		ToolChoice  string           `json:"tool_choice,omitempty"`
+
+		MaxCompletionTokens int    `json:"max_completion_tokens,omitempty"`
+		ReasoningEffort     string `json:"reasoning_effort,omitempty"`
+		Stream              bool   `json:"stream,omitempty"`
+		StreamOptions       *struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options,omitempty"`
 	}
 	reqBody := chatRequest{
 		Model:       c.Model,
 		Messages:    toOpenAIMessages(messages),
 		MaxTokens:   c.maxTokensValue(),
 		Temperature: c.temperatureValue(),
+		Stream:      stream,
+	}
+	if stream {
+		// Ask for a final chunk carrying token usage.
+		reqBody.StreamOptions = &struct {
+			IncludeUsage bool `json:"include_usage"`
+		}{IncludeUsage: true}
+	}
+	if r, ok := c.reasoning(); ok {
+		reqBody.ReasoningEffort = r.Effort
+		// Reasoning models only accept the default temperature.
+		reqBody.Temperature = c.Temperature
+		if p := normalizeProvider(c.Provider); p == "openai" || p == "azure" {
+			// OpenAI reasoning models reject max_tokens.
+			reqBody.MaxCompletionTokens = reqBody.MaxTokens
+			reqBody.MaxTokens = 0
+		}
 	}
 	if len(tools) > 0 {
 		reqBody.Tools = tools
@@ -42,7 +129,11 @@ func (c *Client) chatOpenAICompatible(ctx context.Context, messages []Message, t
 	}
 	req.Header.Set("Content-Type", "application/json")
 	if strings.TrimSpace(c.APIKey) != "" {
-		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		if azure {
+			req.Header.Set("api-key", c.APIKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
 	}
 	for k, v := range c.Headers {
 		if strings.TrimSpace(k) == "" {
@@ -50,61 +141,43 @@ func (c *Client) chatOpenAICompatible(ctx context.Context, messages []Message, t
 		}
 		req.Header.Set(k, v)
 	}
-
-	hc := c.HTTP
-	if hc == nil {
-		hc = &http.Client{Timeout: 120 * time.Second}
-	}
-	resp, err := hc.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("llm http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
 	}
+	return req, nil
+}
 
-	var parsed struct {
-		Choices []struct {
-			Message struct {
-				Content   string `json:"content"`
-				ToolCalls []struct {
-					ID       string `json:"id"`
-					Type     string `json:"type"`
-					Function struct {
-						Name      string          `json:"name"`
-						Arguments json.RawMessage `json:"arguments"`
-					} `json:"function"`
-				} `json:"tool_calls"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-	if err := json.Unmarshal(body, &parsed); err != nil {
-		return nil, fmt.Errorf("parse llm response: %w", err)
-	}
-	if len(parsed.Choices) == 0 {
-		return nil, fmt.Errorf("llm response: no choices")
+// openAIToolArguments returns tool-call arguments as raw JSON.
+// OpenAI-compatible servers typically return arguments as a JSON string.
+// Convert it to raw JSON bytes so downstream tools can unmarshal into structs.
+func openAIToolArguments(args json.RawMessage) json.RawMessage {
+	if len(args) > 0 && args[0] == '"' {
+		var s string
+		if err := json.Unmarshal(args, &s); err == nil {
+			return []byte(s)
+		}
 	}
-	m := parsed.Choices[0].Message
-	out := &ChatResult{Content: m.Content}
-	for _, tc := range m.ToolCalls {
-		args := tc.Function.Arguments
-		// OpenAI-compatible servers typically return arguments as a JSON string.
-		// Convert it to raw JSON bytes so downstream tools can unmarshal into structs.
-		if len(args) > 0 && args[0] == '"' {
-			var s string
-			if err := json.Unmarshal(args, &s); err == nil {
-				args = []byte(s)
-			}
+	return args
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// finishOpenAIResult applies the finish reason and any refusal to out.
+func finishOpenAIResult(out *ChatResult, refusal, finishReason string) {
+	out.Truncated = finishReason == "length"
+	switch {
+	case strings.TrimSpace(refusal) != "":
+		out.Refusal = "refusal"
+		if strings.TrimSpace(out.Content) == "" {
+			out.Content = refusal
 		}
-		out.ToolCalls = append(out.ToolCalls, ToolCall{
-			ID:        tc.ID,
-			Name:      tc.Function.Name,
-			Arguments: args,
-		})
+	case finishReason == "content_filter":
+		out.Refusal = "content_filter"
 	}
-	return out, nil
 }
 
 type openAIMessage struct {
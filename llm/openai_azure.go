@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// defaultAzureAPIVersion applies when Client.APIVersion is empty.
+const defaultAzureAPIVersion = "2024-10-21"
+
+// azureChatEndpoint returns the Azure OpenAI chat completions URL. BaseURL is
+// the resource endpoint (https://<resource>.openai.azure.com) and Model is the
+// deployment name.
+func (c *Client) azureChatEndpoint() (string, error) {
+	base := strings.TrimSuffix(strings.TrimRight(strings.TrimSpace(c.BaseURL), "/"), "/openai")
+	if base == "" {
+		return "", errors.New("azure: llm.baseURL must be set to https://<resource>.openai.azure.com")
+	}
+	deployment := strings.TrimSpace(c.Model)
+	if deployment == "" {
+		return "", errors.New("azure: model must name a deployment (azure/<deployment>)")
+	}
+	version := strings.TrimSpace(c.APIVersion)
+	if version == "" {
+		version = defaultAzureAPIVersion
+	}
+	return base + "/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions?" + url.Values{"api-version": {version}}.Encode(), nil
+}
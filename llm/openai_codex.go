@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -26,6 +28,7 @@ type codexRequest struct {
 	Instructions      string           `json:"instructions"`
 	Input             []codexInputItem `json:"input"`
 	Text              codexTextConfig  `json:"text"`
+	Reasoning         *codexReasoning  `json:"reasoning,omitempty"`
 	Include           []string         `json:"include,omitempty"`
 	PromptCacheKey    string           `json:"prompt_cache_key,omitempty"`
 	ToolChoice        string           `json:"tool_choice,omitempty"`
@@ -37,6 +40,10 @@ type codexTextConfig struct {
 	Verbosity string `json:"verbosity,omitempty"`
 }
 
+type codexReasoning struct {
+	Effort string `json:"effort,omitempty"`
+}
+
 type codexTool struct {
 	Type        string          `json:"type"`
 	Name        string          `json:"name"`
@@ -87,6 +94,9 @@ func (c *Client) chatOpenAICodex(ctx context.Context, messages []Message, tools
 		ToolChoice:        "auto",
 		ParallelToolCalls: true,
 	}
+	if r, ok := c.reasoning(); ok {
+		reqBody.Reasoning = &codexReasoning{Effort: r.Effort}
+	}
 
 	if len(tools) > 0 {
 		convertedTools, err := toCodexTools(tools)
@@ -119,7 +129,7 @@ func (c *Client) chatOpenAICodex(ctx context.Context, messages []Message, tools
 		req.Header.Set(k, v)
 	}
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -127,12 +137,82 @@ func (c *Client) chatOpenAICodex(ctx context.Context, messages []Message, tools
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
-		return nil, fmt.Errorf("codex http %d: %s", resp.StatusCode, codexFriendlyError(resp.StatusCode, strings.TrimSpace(string(raw))))
+		apiErr := newAPIError("codex", c.Provider, resp.StatusCode, codexFriendlyError(resp.StatusCode, strings.TrimSpace(string(raw))))
+		apiErr.retryAfter = resp.Header.Get("Retry-After")
+		return nil, apiErr
 	}
 
 	return consumeCodexSSE(resp.Body)
 }
 
+// codexRateLimitRetry returns how long to wait before retrying a request
+// that failed with err on the given 0-based attempt. ok is false when err is
+// not a 429, the retries are used up, or the server asks for too long a wait.
+func codexRateLimitRetry(err error, attempt int) (time.Duration, bool) {
+	apiErr, isAPI := AsAPIError(err)
+	if !isAPI || !apiErr.IsRateLimit() || attempt >= codexMaxRetries {
+		return 0, false
+	}
+	return codexRetryDelay(apiErr.retryAfter, attempt, time.Now())
+}
+
+const (
+	// codexMaxRetries is how many times a rate-limited request is retried.
+	codexMaxRetries = 3
+	// codexRetryMaxDelay caps the backoff used when 429 has no Retry-After.
+	codexRetryMaxDelay = 30 * time.Second
+	// codexRetryAfterMax is the longest Retry-After worth waiting for inside
+	// a turn; a longer one (e.g. a spent quota) fails right away.
+	codexRetryAfterMax = 60 * time.Second
+)
+
+// codexRetryBaseDelay is the first backoff step; tests shorten it.
+var codexRetryBaseDelay = time.Second
+
+// codexRetryDelay returns how long to wait before retry attempt+1 after a
+// 429. retryAfter is the Retry-After header, in seconds or as an HTTP date;
+// without one the delay doubles from codexRetryBaseDelay up to
+// codexRetryMaxDelay. ok is false when the server asks for a wait longer
+// than codexRetryAfterMax.
+func codexRetryDelay(retryAfter string, attempt int, now time.Time) (time.Duration, bool) {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter != "" {
+		var d time.Duration
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			d = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(retryAfter); err == nil {
+			d = t.Sub(now)
+		} else {
+			return codexBackoff(attempt), true
+		}
+		if d > codexRetryAfterMax {
+			return 0, false
+		}
+		return max(d, 0), true
+	}
+	return codexBackoff(attempt), true
+}
+
+func codexBackoff(attempt int) time.Duration {
+	d := codexRetryBaseDelay << attempt
+	if d <= 0 || d > codexRetryMaxDelay {
+		return codexRetryMaxDelay
+	}
+	return d
+}
+
+// sleepContext waits for d or until ctx ends.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 type codexSSEEvent struct {
 	Type      string          `json:"type"`
 	Delta     string          `json:"delta"`
@@ -145,6 +225,16 @@ type codexSSEEvent struct {
 		Name      string          `json:"name"`
 		Arguments json.RawMessage `json:"arguments"`
 	} `json:"item"`
+	Response struct {
+		IncompleteDetails struct {
+			Reason string `json:"reason"`
+		} `json:"incomplete_details"`
+		Usage *struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+			TotalTokens  int `json:"total_tokens"`
+		} `json:"usage"`
+	} `json:"response"`
 }
 
 type codexToolCallBuffer struct {
@@ -273,6 +363,12 @@ func handleCodexSSEData(data string, out *ChatResult, buffers map[string]*codexT
 			Arguments: codexArgumentsToJSON(buf.Arguments),
 		})
 		delete(buffers, callID)
+	case "response.completed":
+		if u := evt.Response.Usage; u != nil {
+			out.setUsage(u.InputTokens, u.OutputTokens, u.TotalTokens)
+		}
+	case "response.incomplete":
+		out.Truncated = evt.Response.IncompleteDetails.Reason == "max_output_tokens"
 	case "error", "response.failed":
 		return fmt.Errorf("codex response failed")
 	}
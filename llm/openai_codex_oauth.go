@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"net/http"
 	"net/url"
@@ -76,6 +77,27 @@ func LoadCodexOAuthToken() (CodexOAuthToken, error) {
 	return out, nil
 }
 
+// LogoutCodexOAuth deletes the stored OpenAI Codex credentials. removed is
+// the deleted file, or "" when nothing was stored. A Codex CLI login is left
+// alone and would be imported again on next use; codexCLIAuth is its path
+// when one exists.
+func LogoutCodexOAuth() (removed, codexCLIAuth string, err error) {
+	path, err := codexTokenPath()
+	if err != nil {
+		return "", "", err
+	}
+	switch err := os.Remove(path); {
+	case err == nil:
+		removed = path
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", "", err
+	}
+	if _, err := os.Stat(codexCLIAuthPath()); err == nil {
+		codexCLIAuth = codexCLIAuthPath()
+	}
+	return removed, codexCLIAuth, nil
+}
+
 func LoginCodexOAuthInteractive(ctx context.Context) error {
 	verifier, challenge, err := generatePKCE()
 	if err != nil {
@@ -670,12 +692,16 @@ func readStoredCodexToken(path string) (codexStoredToken, error) {
 	return tok, nil
 }
 
-func importFromCodexCLI(destPath string) (codexStoredToken, error) {
+func codexCLIAuthPath() string {
 	codexHome := strings.TrimSpace(os.Getenv("CODEX_HOME"))
 	if codexHome == "" {
 		codexHome = filepath.Join(userHomeDir(), ".codex")
 	}
-	codexPath := filepath.Join(codexHome, "auth.json")
+	return filepath.Join(codexHome, "auth.json")
+}
+
+func importFromCodexCLI(destPath string) (codexStoredToken, error) {
+	codexPath := codexCLIAuthPath()
 	b, err := os.ReadFile(codexPath)
 	if err != nil {
 		return codexStoredToken{}, err
@@ -1,8 +1,13 @@
 package llm
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -83,7 +88,7 @@ func TestConsumeCodexSSE_ToolCall(t *testing.T) {
 		"",
 		`data: {"type":"response.output_item.done","item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"read_file","arguments":"{\"path\":\"README.md\"}"}}`,
 		"",
-		`data: {"type":"response.completed","response":{"status":"completed"}}`,
+		`data: {"type":"response.completed","response":{"status":"completed","usage":{"input_tokens":40,"output_tokens":8,"total_tokens":48}}}`,
 		"",
 	}, "\n")
 
@@ -94,6 +99,9 @@ func TestConsumeCodexSSE_ToolCall(t *testing.T) {
 	if out.Content != "Hello" {
 		t.Fatalf("content=%q", out.Content)
 	}
+	if !out.UsageKnown || out.Usage != (Usage{40, 8, 48}) {
+		t.Fatalf("usage=%+v known=%v", out.Usage, out.UsageKnown)
+	}
 	if len(out.ToolCalls) != 1 {
 		t.Fatalf("tool_calls=%d", len(out.ToolCalls))
 	}
@@ -158,6 +166,36 @@ func TestLoadCodexOAuthToken_FromStoredToken(t *testing.T) {
 	}
 }
 
+func TestLogoutCodexOAuth(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("CODEX_HOME", "")
+	path := filepath.Join(dir, ".clawlet", "auth", "codex.json")
+	if err := writeStoredCodexToken(path, codexStoredToken{Access: "a", Refresh: "r", Expires: 1, AccountID: "acct"}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, cliAuth, err := LogoutCodexOAuth()
+	if err != nil || removed != path || cliAuth != "" {
+		t.Fatalf("removed=%q cliAuth=%q err=%v", removed, cliAuth, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("token file still present: %v", err)
+	}
+
+	cliPath := filepath.Join(dir, ".codex", "auth.json")
+	if err := os.MkdirAll(filepath.Dir(cliPath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cliPath, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	removed, cliAuth, err = LogoutCodexOAuth()
+	if err != nil || removed != "" || cliAuth != cliPath {
+		t.Fatalf("second logout: removed=%q cliAuth=%q err=%v", removed, cliAuth, err)
+	}
+}
+
 func TestDecodeCodexAccountID_FromNestedClaim(t *testing.T) {
 	payload := struct {
 		Auth struct {
@@ -230,3 +268,140 @@ func TestCodexDeviceAuthIsPending(t *testing.T) {
 		t.Fatal("expected pending=false")
 	}
 }
+
+func storeTestCodexToken(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("CODEX_HOME", "")
+	tok := codexStoredToken{Access: "access", Refresh: "refresh", Expires: time.Now().Add(time.Hour).UnixMilli(), AccountID: "acct"}
+	if err := writeStoredCodexToken(filepath.Join(dir, ".clawlet", "auth", "codex.json"), tok); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChatOpenAICodex_RetriesRateLimit(t *testing.T) {
+	storeTestCodexToken(t)
+	var calls int
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"ok\"}\n\n")
+		_, _ = io.WriteString(w, "data: {\"type\":\"response.completed\",\"response\":{}}\n\n")
+	}))
+	defer srv.Close()
+
+	c := &Client{Provider: "openai-codex", BaseURL: srv.URL, Model: "gpt-5.2"}
+	res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Content != "ok" || calls != 3 {
+		t.Fatalf("content=%q calls=%d", res.Content, calls)
+	}
+	if bodies[0] == "" || bodies[2] != bodies[0] {
+		t.Fatal("retried request body was not resent")
+	}
+}
+
+func TestChatOpenAICodex_RateLimitGivesUp(t *testing.T) {
+	storeTestCodexToken(t)
+	old := codexRetryBaseDelay
+	codexRetryBaseDelay = time.Millisecond
+	defer func() { codexRetryBaseDelay = old }()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := &Client{Provider: "openai-codex", BaseURL: srv.URL, Model: "gpt-5.2"}
+	_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "rate limited") || calls != codexMaxRetries+1 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+
+	// A cancelled context stops the wait.
+	codexRetryBaseDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Chat(ctx, []Message{{Role: "user", Content: "hi"}}, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("retry wait ignored ctx")
+	}
+}
+
+func TestChatOpenAICodex_RetryReleasesLimiter(t *testing.T) {
+	storeTestCodexToken(t)
+	old := codexRetryBaseDelay
+	codexRetryBaseDelay = 300 * time.Millisecond
+	defer func() { codexRetryBaseDelay = old }()
+	limited := make(chan struct{})
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			close(limited)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"ok\"}\n\n")
+		_, _ = io.WriteString(w, "data: {\"type\":\"response.completed\",\"response\":{}}\n\n")
+	}))
+	defer srv.Close()
+
+	lim := NewLimiter(0, 1)
+	c := &Client{Provider: "openai-codex", BaseURL: srv.URL, Model: "gpt-5.2", Limiter: lim}
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+		done <- err
+	}()
+	<-limited
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	release, err := lim.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("limiter slot held during backoff: %v", err)
+	}
+	release()
+	if err := <-done; err != nil || calls != 2 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+}
+
+func TestCodexRetryDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		header  string
+		attempt int
+		want    time.Duration
+		ok      bool
+	}{
+		{"7", 0, 7 * time.Second, true},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 0, 10 * time.Second, true},
+		{"3600", 0, 0, false},
+		{"", 0, codexRetryBaseDelay, true},
+		{"", 2, 4 * codexRetryBaseDelay, true},
+		{"", 10, codexRetryMaxDelay, true},
+	}
+	for _, tc := range cases {
+		got, ok := codexRetryDelay(tc.header, tc.attempt, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("codexRetryDelay(%q, %d) = %s, %v; want %s, %v", tc.header, tc.attempt, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// chatOpenAICompatibleStream is chatOpenAICompatible with stream:true,
+// passing each content delta to onDelta as it is read.
+func (c *Client) chatOpenAICompatibleStream(ctx context.Context, messages []Message, tools []ToolDefinition, onDelta func(string)) (*ChatResult, error) {
+	req, err := c.newOpenAIChatRequest(ctx, messages, tools, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return nil, newAPIError("llm", c.Provider, resp.StatusCode, string(body))
+	}
+	return consumeOpenAISSE(resp.Body, onDelta)
+}
+
+type openAIStreamChunk struct {
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			Refusal   string `json:"refusal"`
+			ToolCalls []struct {
+				Index    *int   `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string          `json:"name"`
+					Arguments json.RawMessage `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIToolCallBuffer struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// consumeOpenAISSE reads a streamed /chat/completions response. Tool-call
+// fragments are merged by index, with argument pieces concatenated in
+// order. An error frame, or a stream that ends before a finish reason or
+// [DONE], is an error rather than a silently truncated reply.
+func consumeOpenAISSE(r io.Reader, onDelta func(string)) (*ChatResult, error) {
+	var (
+		content  strings.Builder
+		refusal  strings.Builder
+		calls    []*openAIToolCallBuffer
+		finish   string
+		finished bool
+		usage    *openAIUsage
+	)
+
+	handle := func(data string) error {
+		if data == "[DONE]" {
+			finished = true
+			return nil
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			// Ignore non-JSON chunks.
+			return nil
+		}
+		if chunk.Error != nil {
+			msg := strings.TrimSpace(chunk.Error.Message)
+			if msg == "" {
+				msg = "unknown error"
+			}
+			return fmt.Errorf("llm stream: %s", msg)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		ch := chunk.Choices[0]
+		if ch.Delta.Content != "" {
+			content.WriteString(ch.Delta.Content)
+			onDelta(ch.Delta.Content)
+		}
+		refusal.WriteString(ch.Delta.Refusal)
+		for _, tc := range ch.Delta.ToolCalls {
+			i := len(calls) - 1
+			switch {
+			case tc.Index != nil:
+				i = *tc.Index
+			case tc.ID != "" || i < 0:
+				// Servers that omit index send each call whole.
+				i = len(calls)
+			}
+			if i < 0 || i > len(calls) {
+				return fmt.Errorf("llm stream: unexpected tool call index %d", i)
+			}
+			if i == len(calls) {
+				calls = append(calls, &openAIToolCallBuffer{})
+			}
+			buf := calls[i]
+			if buf.id == "" {
+				buf.id = tc.ID
+			}
+			if buf.name == "" {
+				buf.name = tc.Function.Name
+			}
+			if args := tc.Function.Arguments; len(args) > 0 && string(args) != "null" {
+				var s string
+				if args[0] == '"' && json.Unmarshal(args, &s) == nil {
+					buf.args.WriteString(s)
+				} else {
+					buf.args.Write(args)
+				}
+			}
+		}
+		if ch.FinishReason == "error" {
+			return errors.New("llm stream: provider reported an error")
+		}
+		if ch.FinishReason != "" {
+			finish = ch.FinishReason
+			finished = true
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 2<<20)
+	dataLines := make([]string, 0, 2)
+	flush := func() error {
+		if len(dataLines) == 0 {
+			return nil
+		}
+		data := strings.TrimSpace(strings.Join(dataLines, "\n"))
+		dataLines = dataLines[:0]
+		if data == "" {
+			return nil
+		}
+		return handle(data)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if after, ok := strings.CutPrefix(line, "data:"); ok {
+			dataLines = append(dataLines, strings.TrimSpace(after))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("llm stream: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if !finished {
+		return nil, errors.New("llm stream ended before the reply finished")
+	}
+
+	out := &ChatResult{Content: content.String()}
+	for _, buf := range calls {
+		if buf.name == "" {
+			continue
+		}
+		args := buf.args.String()
+		if strings.TrimSpace(args) == "" {
+			args = "{}"
+		}
+		out.ToolCalls = append(out.ToolCalls, ToolCall{ID: buf.id, Name: buf.name, Arguments: json.RawMessage(args)})
+	}
+	finishOpenAIResult(out, refusal.String(), finish)
+	if usage != nil {
+		out.setUsage(usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+	}
+	return out, nil
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func sseServer(t *testing.T, frames ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &req); err != nil || !req.Stream {
+			t.Errorf("request not streamed: %s", b)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, f := range frames {
+			_, _ = io.WriteString(w, "data: "+f+"\n\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestChatStream_ContentAndToolCalls(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"choices":[{"delta":{"content":"lo"}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_file","arguments":""}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"pa"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","function":{"name":"list_dir","arguments":"{}"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"th\":\"a.go\"}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":30,"completion_tokens":12,"total_tokens":42}}`,
+		`[DONE]`,
+	)
+	c := &Client{Provider: "openai", BaseURL: srv.URL, Model: "m"}
+	var deltas []string
+	res, err := c.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" || res.Content != "Hello" {
+		t.Fatalf("deltas=%q content=%q", deltas, res.Content)
+	}
+	if !res.UsageKnown || res.Usage != (Usage{30, 12, 42}) {
+		t.Fatalf("usage=%+v known=%v", res.Usage, res.UsageKnown)
+	}
+	if len(res.ToolCalls) != 2 {
+		t.Fatalf("tool calls: %+v", res.ToolCalls)
+	}
+	if tc := res.ToolCalls[0]; tc.ID != "call_1" || tc.Name != "read_file" || string(tc.Arguments) != `{"path":"a.go"}` {
+		t.Fatalf("first call: %+v args=%s", tc, tc.Arguments)
+	}
+	if tc := res.ToolCalls[1]; tc.ID != "call_2" || tc.Name != "list_dir" || string(tc.Arguments) != `{}` {
+		t.Fatalf("second call: %+v args=%s", tc, tc.Arguments)
+	}
+}
+
+func TestChatStream_Truncated(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"delta":{"content":"partial"}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"length"}]}`,
+		`[DONE]`,
+	)
+	c := &Client{Provider: "openai", BaseURL: srv.URL, Model: "m"}
+	res, err := c.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated || res.Content != "partial" {
+		t.Fatalf("res=%+v", res)
+	}
+}
+
+func TestChatStream_ErrorMidStream(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"delta":{"content":"Hel"}}]}`,
+		`{"error":{"message":"upstream overloaded"}}`,
+	)
+	c := &Client{Provider: "openrouter", BaseURL: srv.URL, Model: "m"}
+	_, err := c.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "upstream overloaded") {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+}
+
+func TestChatStream_EndsEarly(t *testing.T) {
+	srv := sseServer(t, `{"choices":[{"delta":{"content":"Hel"}}]}`)
+	c := &Client{Provider: "openai", BaseURL: srv.URL, Model: "m"}
+	_, err := c.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "ended before") {
+		t.Fatalf("expected early-end error, got %v", err)
+	}
+}
+
+func TestChatStream_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	c := &Client{Provider: "openai", BaseURL: srv.URL, Model: "m"}
+	_, err := c.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, nil)
+	var apiErr *APIError
+	if err == nil || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected APIError 401, got %v", err)
+	}
+}
@@ -1,6 +1,12 @@
 package llm
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -192,3 +198,218 @@ func TestToGeminiMessages_ImagePart(t *testing.T) {
 		t.Fatalf("inline data=%q", converted[0].Parts[1].InlineData.Data)
 	}
 }
+
+type sequenceDoer struct {
+	bodies   []string
+	requests [][]byte
+}
+
+func (d *sequenceDoer) Do(req *http.Request) (*http.Response, error) {
+	b, _ := io.ReadAll(req.Body)
+	d.requests = append(d.requests, b)
+	body := d.bodies[min(len(d.requests)-1, len(d.bodies)-1)]
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestChat_ContinuesTruncatedReply(t *testing.T) {
+	doer := &sequenceDoer{bodies: []string{
+		`{"choices":[{"message":{"content":"func main() {"},"finish_reason":"length"}]}`,
+		`{"choices":[{"message":{"content":"\n}"},"finish_reason":"stop"}]}`,
+	}}
+	c := &Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer, MaxContinuations: 2}
+
+	res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "write code"}}, nil)
+	if err != nil {
+		t.Fatalf("chat error: %v", err)
+	}
+	if res.Content != "func main() {\n}" {
+		t.Fatalf("content=%q", res.Content)
+	}
+	if res.Truncated || res.Continuations != 1 {
+		t.Fatalf("truncated=%v continuations=%d", res.Truncated, res.Continuations)
+	}
+	if len(doer.requests) != 2 {
+		t.Fatalf("requests=%d", len(doer.requests))
+	}
+	var second struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(doer.requests[1], &second); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(second.Messages) != 3 || second.Messages[1].Role != "assistant" || second.Messages[1].Content != "func main() {" {
+		t.Fatalf("follow-up messages=%+v", second.Messages)
+	}
+}
+
+func TestChat_ContinuationBounded(t *testing.T) {
+	doer := &sequenceDoer{bodies: []string{
+		`{"choices":[{"message":{"content":"a"},"finish_reason":"length"}]}`,
+	}}
+	c := &Client{Provider: "openai", BaseURL: "http://example.invalid", Model: "m", HTTP: doer, MaxContinuations: 2}
+
+	res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "go"}}, nil)
+	if err != nil {
+		t.Fatalf("chat error: %v", err)
+	}
+	if res.Content != "aaa" || !res.Truncated || res.Continuations != 2 {
+		t.Fatalf("content=%q truncated=%v continuations=%d", res.Content, res.Truncated, res.Continuations)
+	}
+}
+
+func TestChat_DetectsRefusals(t *testing.T) {
+	tests := []struct {
+		provider, body, reason, content string
+	}{
+		{"anthropic", `{"content":[],"stop_reason":"refusal"}`, "refusal", ""},
+		{"anthropic", `{"content":[{"type":"text","text":"I can't help with that."}],"stop_reason":"refusal"}`, "refusal", "I can't help with that."},
+		{"gemini", `{"candidates":[],"promptFeedback":{"blockReason":"PROHIBITED_CONTENT"}}`, "PROHIBITED_CONTENT", ""},
+		{"gemini", `{"candidates":[{"content":{"parts":[]},"finishReason":"SAFETY"}]}`, "SAFETY", ""},
+		{"openai", `{"choices":[{"message":{"content":null,"refusal":"I'm sorry, I can't assist."},"finish_reason":"stop"}]}`, "refusal", "I'm sorry, I can't assist."},
+		{"openai", `{"choices":[{"message":{"content":""},"finish_reason":"content_filter"}]}`, "content_filter", ""},
+		{"openai", `{"choices":[{"message":{"content":"fine"},"finish_reason":"stop"}]}`, "", "fine"},
+	}
+	for _, tt := range tests {
+		c := &Client{Provider: tt.provider, BaseURL: "http://example.invalid", Model: "m", HTTP: &sequenceDoer{bodies: []string{tt.body}}}
+		res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.provider, tt.body, err)
+		}
+		if res.Refusal != tt.reason || res.Content != tt.content {
+			t.Fatalf("%s %s: refusal=%q content=%q", tt.provider, tt.body, res.Refusal, res.Content)
+		}
+	}
+}
+
+func TestChat_ReasoningEffortPerProvider(t *testing.T) {
+	cases := []struct {
+		provider string
+		effort   string
+		body     string
+		check    func(t *testing.T, req map[string]any)
+	}{
+		{
+			provider: "openai",
+			effort:   "high",
+			body:     `{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`,
+			check: func(t *testing.T, req map[string]any) {
+				if req["reasoning_effort"] != "high" || req["max_completion_tokens"] == nil || req["max_tokens"] != nil || req["temperature"] != nil {
+					t.Fatalf("openai request=%v", req)
+				}
+			},
+		},
+		{
+			provider: "anthropic",
+			effort:   "8192",
+			body:     `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`,
+			check: func(t *testing.T, req map[string]any) {
+				thinking, _ := req["thinking"].(map[string]any)
+				if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(8192) {
+					t.Fatalf("thinking=%v", req["thinking"])
+				}
+				if req["max_tokens"].(float64) <= 8192 || req["temperature"] != nil {
+					t.Fatalf("anthropic request=%v", req)
+				}
+			},
+		},
+		{
+			provider: "gemini",
+			effort:   "low",
+			body:     `{"candidates":[{"content":{"parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`,
+			check: func(t *testing.T, req map[string]any) {
+				gen, _ := req["generationConfig"].(map[string]any)
+				tc, _ := gen["thinkingConfig"].(map[string]any)
+				if tc["thinkingBudget"] != float64(1024) {
+					t.Fatalf("generationConfig=%v", gen)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		doer := &sequenceDoer{bodies: []string{tc.body}}
+		c := &Client{Provider: tc.provider, BaseURL: "http://example.invalid", Model: "m", HTTP: doer, ReasoningEffort: tc.effort}
+		if _, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+			t.Fatalf("%s chat error: %v", tc.provider, err)
+		}
+		var req map[string]any
+		if err := json.Unmarshal(doer.requests[0], &req); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		tc.check(t, req)
+	}
+}
+
+func TestAnthropic_ThinkingBlocksEchoedWithToolResults(t *testing.T) {
+	doer := &sequenceDoer{bodies: []string{
+		`{"content":[{"type":"thinking","thinking":"plan","signature":"sig"},{"type":"tool_use","id":"toolu_1","name":"read_file","input":{"path":"a"}}],"stop_reason":"tool_use"}`,
+	}}
+	c := &Client{Provider: "anthropic", BaseURL: "http://example.invalid", Model: "m", HTTP: doer, ReasoningEffort: "low"}
+	res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "read a"}}, nil)
+	if err != nil {
+		t.Fatalf("chat error: %v", err)
+	}
+	if len(res.Reasoning) != 1 || len(res.ToolCalls) != 1 {
+		t.Fatalf("reasoning=%d toolCalls=%d", len(res.Reasoning), len(res.ToolCalls))
+	}
+
+	converted, _ := toAnthropicMessages([]Message{
+		{Role: "user", Content: "read a"},
+		{Role: "assistant", Reasoning: res.Reasoning, ToolCalls: []ToolCallPayload{{ID: "toolu_1", Type: "function", Function: ToolCallPayloadFunc{Name: "read_file", Arguments: `{"path":"a"}`}}}},
+		{Role: "tool", ToolCallID: "toolu_1", Content: "x"},
+	})
+	b, err := json.Marshal(converted[1])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(b), `"content":[{"type":"thinking","thinking":"plan","signature":"sig"},{"type":"tool_use"`) {
+		t.Fatalf("assistant message=%s", b)
+	}
+}
+
+func TestChatAzure(t *testing.T) {
+	var gotPath, gotQuery, gotKey, gotAuth string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		gotKey, gotAuth = r.Header.Get("api-key"), r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"","tool_calls":[{"id":"c1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"a\"}"}}]},"finish_reason":"tool_calls"}]}`)
+	}))
+	defer srv.Close()
+
+	c := &Client{Provider: "azure", BaseURL: srv.URL + "/", APIKey: "k", Model: "gpt-4o-prod"}
+	tools := []ToolDefinition{{Type: "function", Function: FunctionDefinition{Name: "read_file", Parameters: JSONSchema{Type: "object"}}}}
+	res, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/openai/deployments/gpt-4o-prod/chat/completions" || gotQuery != "api-version=2024-10-21" {
+		t.Fatalf("path=%q query=%q", gotPath, gotQuery)
+	}
+	if gotKey != "k" || gotAuth != "" {
+		t.Fatalf("api-key=%q authorization=%q", gotKey, gotAuth)
+	}
+	if gotBody["tool_choice"] != "auto" || len(res.ToolCalls) != 1 || string(res.ToolCalls[0].Arguments) != `{"path":"a"}` {
+		t.Fatalf("body=%v result=%+v", gotBody, res)
+	}
+
+	c.APIVersion = "2025-01-01-preview"
+	c.BaseURL = srv.URL + "/openai"
+	if _, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/openai/deployments/gpt-4o-prod/chat/completions" || gotQuery != "api-version=2025-01-01-preview" {
+		t.Fatalf("path=%q query=%q", gotPath, gotQuery)
+	}
+
+	c.BaseURL = ""
+	if _, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err == nil || !strings.Contains(err.Error(), "llm.baseURL") {
+		t.Fatalf("expected missing endpoint error, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter throttles outbound LLM requests with a token bucket (requests per
+// second) and a cap on in-flight requests. A nil *Limiter never blocks.
+// Share one Limiter between every Client in the process.
+type Limiter struct {
+	rps   float64
+	burst float64
+	sem   chan struct{}
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing rps requests per second (0 = no rate
+// limit) and at most maxConcurrent in-flight requests (0 = unlimited).
+// It returns nil when both are disabled.
+func NewLimiter(rps float64, maxConcurrent int) *Limiter {
+	if rps <= 0 && maxConcurrent <= 0 {
+		return nil
+	}
+	l := &Limiter{rps: rps}
+	if rps > 0 {
+		l.burst = max(1, rps)
+		l.tokens = l.burst
+		l.last = time.Now()
+	}
+	if maxConcurrent > 0 {
+		l.sem = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// Acquire waits for a request slot. The returned release func must be called
+// when the request finishes.
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release = func() {
+		if l.sem != nil {
+			<-l.sem
+		}
+	}
+	if err := l.waitToken(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+func (l *Limiter) waitToken(ctx context.Context) error {
+	if l.rps <= 0 {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rps)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rps * float64(time.Second))
+		l.mu.Unlock()
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
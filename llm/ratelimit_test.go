@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter_NilNeverBlocks(t *testing.T) {
+	if NewLimiter(0, 0) != nil {
+		t.Fatalf("expected nil limiter when disabled")
+	}
+	var l *Limiter
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+}
+
+func TestLimiter_ConcurrencyCapHonorsContext(t *testing.T) {
+	l := NewLimiter(0, 1)
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx); err == nil {
+		t.Fatalf("expected second acquire to wait and time out")
+	}
+	release()
+	release2, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+func TestLimiter_RateSpacesRequests(t *testing.T) {
+	l := NewLimiter(20, 0) // burst 20, then 50ms apart
+	for range 20 {
+		release, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		release()
+	}
+	start := time.Now()
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+	if waited := time.Since(start); waited < 30*time.Millisecond {
+		t.Fatalf("expected to wait for a token, waited %s", waited)
+	}
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Reasoning levels accepted by Client.ReasoningEffort. A positive integer
+// string (e.g. "8192") is treated as a thinking token budget instead.
+const (
+	ReasoningLow    = "low"
+	ReasoningMedium = "medium"
+	ReasoningHigh   = "high"
+)
+
+// anthropicMinThinkingBudget is the smallest budget Anthropic accepts.
+const anthropicMinThinkingBudget = 1024
+
+type reasoningSpec struct {
+	Effort string // low/medium/high, for OpenAI-style APIs
+	Budget int    // token budget, for Anthropic/Gemini
+}
+
+// reasoning resolves ReasoningEffort into both an effort level and a token
+// budget so each provider can use whichever form it supports.
+func (c *Client) reasoning() (reasoningSpec, bool) {
+	v := strings.ToLower(strings.TrimSpace(c.ReasoningEffort))
+	switch v {
+	case "":
+		return reasoningSpec{}, false
+	case ReasoningLow:
+		return reasoningSpec{Effort: v, Budget: 1024}, true
+	case ReasoningMedium:
+		return reasoningSpec{Effort: v, Budget: 4096}, true
+	case ReasoningHigh:
+		return reasoningSpec{Effort: v, Budget: 16384}, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return reasoningSpec{}, false
+	}
+	spec := reasoningSpec{Budget: n, Effort: ReasoningHigh}
+	switch {
+	case n <= 2048:
+		spec.Effort = ReasoningLow
+	case n <= 8192:
+		spec.Effort = ReasoningMedium
+	}
+	return spec, true
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLocalTranscriptionCommand = "whisper-cli"
+	defaultLocalTranscriptionTimeout = 5 * time.Minute
+)
+
+// defaultLocalTranscriptionArgs is the whisper.cpp command line: no
+// timestamps, no progress output.
+var defaultLocalTranscriptionArgs = []string{"-m", "{model}", "-f", "{input}", "-l", "{language}", "-nt", "-np"}
+
+// transcriptTimestamp matches the segment prefix whisper.cpp and openai-whisper
+// print when timestamps are on, e.g. "[00:00:01.000 --> 00:00:03.500]".
+var transcriptTimestamp = regexp.MustCompile(`^\[[0-9:.,]+\s*-->\s*[0-9:.,]+\]\s*`)
+
+// LocalTranscriber transcribes audio by running a local whisper.cpp (or
+// compatible) binary on a temp file, so audio never leaves the host.
+type LocalTranscriber struct {
+	// Command is the binary to run. Default: "whisper-cli".
+	Command string
+	// Model is the model file substituted for {model}.
+	Model string
+	// Args is the argument template. {input}, {model}, and {language} are
+	// replaced; empty uses defaultLocalTranscriptionArgs.
+	Args []string
+	// Timeout bounds one run. Default: 5 minutes.
+	Timeout time.Duration
+}
+
+// Transcribe writes data to a temp file, converting non-WAV audio to 16 kHz
+// mono WAV with ffmpeg when it is installed (whisper.cpp reads only WAV
+// unless built with ffmpeg), and returns the cleaned-up stdout of Command.
+func (t *LocalTranscriber) Transcribe(ctx context.Context, data []byte, mimeType, fileName, language string) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("audio data is empty")
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = defaultLocalTranscriptionTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "clawlet-transcribe-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if ext == "" {
+		ext = extensionByMIME(mimeType)
+	}
+	input := filepath.Join(dir, "audio"+ext)
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return "", err
+	}
+	if ext != ".wav" {
+		if ffmpeg, err := exec.LookPath("ffmpeg"); err == nil {
+			wav := filepath.Join(dir, "audio.wav")
+			conv := exec.CommandContext(ctx, ffmpeg, "-nostdin", "-loglevel", "error", "-i", input, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wav)
+			if out, err := conv.CombinedOutput(); err != nil {
+				return "", fmt.Errorf("convert audio with ffmpeg: %v: %s", err, strings.TrimSpace(string(out)))
+			}
+			input = wav
+		}
+	}
+
+	command := strings.TrimSpace(t.Command)
+	if command == "" {
+		command = defaultLocalTranscriptionCommand
+	}
+	args, err := t.args(input, language)
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return "", fmt.Errorf("local transcription: %v: %s", err, msg)
+	}
+	text := cleanLocalTranscript(stdout.String())
+	if text == "" {
+		return "", fmt.Errorf("audio transcription response is empty")
+	}
+	return text, nil
+}
+
+func (t *LocalTranscriber) args(input, language string) ([]string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		language = TranscriptionLanguageAuto
+	}
+	tmpl := t.Args
+	if len(tmpl) == 0 {
+		tmpl = defaultLocalTranscriptionArgs
+	}
+	out := make([]string, 0, len(tmpl))
+	for _, a := range tmpl {
+		if strings.Contains(a, "{model}") && strings.TrimSpace(t.Model) == "" {
+			return nil, fmt.Errorf("local transcription needs a model file (llm.transcription.model)")
+		}
+		a = strings.NewReplacer("{input}", input, "{model}", t.Model, "{language}", language).Replace(a)
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// cleanLocalTranscript drops timestamps and blank lines and joins the
+// segments with spaces.
+func cleanLocalTranscript(s string) string {
+	var parts []string
+	for line := range strings.SplitSeq(s, "\n") {
+		line = strings.TrimSpace(transcriptTimestamp.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -11,6 +11,9 @@ type Message struct {
 	ToolCalls  []ToolCallPayload `json:"tool_calls,omitempty"`
 	ToolCallID string            `json:"tool_call_id,omitempty"`
 	Name       string            `json:"name,omitempty"`
+	// Reasoning holds opaque provider reasoning blocks (Anthropic thinking)
+	// from an assistant reply; they are sent back with that reply.
+	Reasoning []json.RawMessage `json:"-"`
 }
 
 const (
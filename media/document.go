@@ -0,0 +1,446 @@
+package media
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+)
+
+const (
+	docxMIMEType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	// maxDocumentXMLBytes caps the decompressed size read from any archive
+	// member or PDF stream, so a small upload cannot expand without bound.
+	maxDocumentXMLBytes = 32 << 20
+)
+
+// documentKind returns "pdf" or "docx" when att is a document whose text can
+// be extracted, or "".
+func documentKind(att bus.Attachment) string {
+	mimeType := strings.ToLower(strings.TrimSpace(att.MIMEType))
+	ext := strings.ToLower(filepath.Ext(att.Name))
+	switch {
+	case mimeType == "application/pdf" || ext == ".pdf":
+		return "pdf"
+	case mimeType == docxMIMEType || ext == ".docx":
+		return "docx"
+	}
+	return ""
+}
+
+// documentSection downloads a document attachment and returns its text,
+// truncated to maxTextChars, for the attachment header. Failures return a
+// short note so the model can tell the user why the document was not read.
+func documentSection(ctx context.Context, att bus.Attachment, kind string, cfg config.MediaToolsConfig) string {
+	data, _, err := readAttachmentBytes(ctx, att, cfg.MaxFileBytes, cfg.DownloadTimeoutSec)
+	if err != nil {
+		return "\n(document not read: " + err.Error() + ")"
+	}
+	text, err := extractDocumentText(kind, data)
+	if err != nil {
+		return "\n(no text could be extracted from this document: " + err.Error() + ")"
+	}
+	text, _ = extractText([]byte(text), cfg.MaxTextChars)
+	return "\n[Extracted text]\n" + text
+}
+
+func extractDocumentText(kind string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+	switch kind {
+	case "pdf":
+		text, err = pdfText(data)
+	case "docx":
+		text, err = docxText(data)
+	default:
+		return "", errors.New("unsupported document type")
+	}
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if !mostlyReadable(text) {
+		return "", errors.New("no extractable text")
+	}
+	return text, nil
+}
+
+// docxText returns the paragraphs of word/document.xml, one per line.
+func docxText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		return docxBodyText(io.LimitReader(rc, maxDocumentXMLBytes))
+	}
+	return "", errors.New("docx: word/document.xml not found")
+}
+
+func docxBodyText(r io.Reader) (string, error) {
+	var b strings.Builder
+	dec := xml.NewDecoder(r)
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// pdfText extracts the text drawn by a PDF's content streams. It handles
+// uncompressed and Flate-compressed streams and fonts with single-byte or
+// UTF-16 strings, which covers most generated documents. Scanned PDFs and
+// fonts with custom glyph encodings yield little readable text, which
+// extractDocumentText reports as an error.
+func pdfText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return "", errors.New("pdf: missing header")
+	}
+	var b strings.Builder
+	rest := data
+	for {
+		i := bytes.Index(rest, []byte("stream"))
+		if i < 0 {
+			break
+		}
+		dict := rest[:i]
+		if j := bytes.LastIndex(dict, []byte("obj")); j >= 0 {
+			dict = dict[j:]
+		}
+		body := rest[i+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream := body[:end]
+		rest = body[end+len("endstream"):]
+
+		if !pdfContentStream(dict) {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			dec, _ := io.ReadAll(io.LimitReader(zr, maxDocumentXMLBytes))
+			zr.Close()
+			stream = dec
+		}
+		pdfContentText(&b, stream)
+		if b.Len() > maxDocumentXMLBytes {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+// pdfContentStream reports whether a stream dictionary may hold page
+// content: images, fonts, and streams with filters other than Flate are
+// skipped.
+func pdfContentStream(dict []byte) bool {
+	for _, skip := range []string{"/Image", "/XRef", "/ObjStm", "/FontFile", "/Length1", "/Metadata", "/DCTDecode", "/JPXDecode", "/CCITTFaxDecode", "/JBIG2Decode", "/LZWDecode", "/ASCII85Decode", "/RunLengthDecode"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return false
+		}
+	}
+	return true
+}
+
+// pdfContentText writes the strings shown by the text operators of a content
+// stream, with line breaks for line moves.
+func pdfContentText(b *strings.Builder, s []byte) {
+	var operands []pdfToken
+	for len(s) > 0 {
+		tok, rest := nextPDFToken(s)
+		s = rest
+		if tok.kind == pdfOperator {
+			pdfApplyOperator(b, tok.text, operands)
+			operands = operands[:0]
+			continue
+		}
+		if tok.kind != pdfNone {
+			operands = append(operands, tok)
+		}
+	}
+}
+
+func pdfApplyOperator(b *strings.Builder, op string, args []pdfToken) {
+	newline := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	switch op {
+	case "Tj":
+		if n := len(args); n > 0 {
+			b.WriteString(args[n-1].text)
+		}
+	case "'", "\"":
+		newline()
+		if n := len(args); n > 0 {
+			b.WriteString(args[n-1].text)
+		}
+	case "TJ":
+		for _, a := range args {
+			switch a.kind {
+			case pdfString:
+				b.WriteString(a.text)
+			case pdfNumber:
+				// A large negative adjustment is a word gap.
+				if v, err := strconv.ParseFloat(a.text, 64); err == nil && v < -200 {
+					b.WriteByte(' ')
+				}
+			}
+		}
+	case "Td", "TD":
+		if len(args) == 2 {
+			if v, err := strconv.ParseFloat(args[1].text, 64); err == nil && v != 0 {
+				newline()
+			} else if !strings.HasSuffix(b.String(), " ") {
+				b.WriteByte(' ')
+			}
+		}
+	case "T*", "ET":
+		newline()
+	}
+}
+
+type pdfTokenKind int
+
+const (
+	pdfNone pdfTokenKind = iota
+	pdfString
+	pdfNumber
+	pdfOperator
+	pdfOther
+)
+
+type pdfToken struct {
+	kind pdfTokenKind
+	text string
+}
+
+// nextPDFToken reads one token from a content stream. Array delimiters are
+// skipped so the elements of a TJ array become its operands.
+func nextPDFToken(s []byte) (pdfToken, []byte) {
+	for len(s) > 0 && (isPDFSpace(s[0]) || s[0] == '[' || s[0] == ']') {
+		s = s[1:]
+	}
+	if len(s) == 0 {
+		return pdfToken{}, s
+	}
+	switch c := s[0]; {
+	case c == '%':
+		if i := bytes.IndexAny(s, "\r\n"); i >= 0 {
+			return pdfToken{}, s[i:]
+		}
+		return pdfToken{}, nil
+	case c == '(':
+		raw, rest := pdfLiteralString(s[1:])
+		return pdfToken{kind: pdfString, text: pdfDecodeString(raw)}, rest
+	case c == '<' && len(s) > 1 && s[1] == '<':
+		return pdfToken{kind: pdfOther}, s[2:]
+	case c == '>' && len(s) > 1 && s[1] == '>':
+		return pdfToken{kind: pdfOther}, s[2:]
+	case c == '<':
+		end := bytes.IndexByte(s, '>')
+		if end < 0 {
+			return pdfToken{}, nil
+		}
+		return pdfToken{kind: pdfString, text: pdfDecodeString(pdfHexString(s[1:end]))}, s[end+1:]
+	case c == '/':
+		i := 1
+		for i < len(s) && !isPDFSpace(s[i]) && !isPDFDelim(s[i]) {
+			i++
+		}
+		return pdfToken{kind: pdfOther}, s[i:]
+	case c == '{' || c == '}' || c == ')' || c == '>':
+		return pdfToken{}, s[1:]
+	}
+	i := 0
+	for i < len(s) && !isPDFSpace(s[i]) && !isPDFDelim(s[i]) {
+		i++
+	}
+	word := string(s[:i])
+	if _, err := strconv.ParseFloat(word, 64); err == nil {
+		return pdfToken{kind: pdfNumber, text: word}, s[i:]
+	}
+	return pdfToken{kind: pdfOperator, text: word}, s[i:]
+}
+
+// pdfLiteralString reads a (...) string body after the opening paren,
+// handling escapes and balanced nested parens.
+func pdfLiteralString(s []byte) ([]byte, []byte) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			if i+1 >= len(s) {
+				return out, nil
+			}
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				if e == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					v, n := 0, 0
+					for n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7' {
+						v = v*8 + int(s[i]-'0')
+						i++
+						n++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			if depth == 0 {
+				return out, s[i+1:]
+			}
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func pdfHexString(s []byte) []byte {
+	var digits []byte
+	for _, c := range s {
+		if unhex(c) >= 0 {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		out[i] = byte(unhex(digits[2*i])<<4 | unhex(digits[2*i+1]))
+	}
+	return out
+}
+
+func unhex(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// pdfDecodeString decodes UTF-16BE strings (with a BOM) and treats anything
+// else as Latin-1, which matches WinAnsi and PDFDocEncoding for ASCII text.
+func pdfDecodeString(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		u := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			u = append(u, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	r := make([]rune, len(raw))
+	for i, c := range raw {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// mostlyReadable reports whether at least 80% of text is printable, to
+// reject the control bytes produced by unsupported font encodings.
+func mostlyReadable(text string) bool {
+	if text == "" {
+		return false
+	}
+	good, total := 0, 0
+	for _, r := range text {
+		total++
+		if unicode.IsGraphic(r) || unicode.IsSpace(r) {
+			good++
+		}
+	}
+	return good*10 >= total*8
+}
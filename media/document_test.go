@@ -0,0 +1,122 @@
+package media
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/bus"
+	"github.com/mosaxiv/clawlet/config"
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+// testPDF builds a minimal PDF whose page content is compressed with Flate
+// when compress is set.
+func testPDF(t *testing.T, content string, compress bool) []byte {
+	t.Helper()
+	stream := []byte(content)
+	filter := ""
+	if compress {
+		var zb bytes.Buffer
+		zw := zlib.NewWriter(&zb)
+		if _, err := zw.Write(stream); err != nil {
+			t.Fatal(err)
+		}
+		zw.Close()
+		stream = zb.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	b.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %d%s >>\nstream\n", len(stream), filter)
+	b.Write(stream)
+	b.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestPDFText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Quarterly report) Tj 0 -14 Td [(Revenue ) -250 (grew \\(12%\\))] TJ T* <FEFF00E9007400E9> Tj ET"
+	for _, compress := range []bool{false, true} {
+		got, err := extractDocumentText("pdf", testPDF(t, content, compress))
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		want := "Quarterly report\nRevenue  grew (12%)\nété"
+		if got != want {
+			t.Fatalf("compress=%v: got %q, want %q", compress, got, want)
+		}
+	}
+	if _, err := extractDocumentText("pdf", []byte("not a pdf")); err == nil {
+		t.Fatal("expected error for non-PDF data")
+	}
+	if _, err := extractDocumentText("pdf", testPDF(t, "BT <00010002000300040005> Tj ET", false)); err == nil {
+		t.Fatal("expected unreadable glyph codes to be rejected")
+	}
+}
+
+func testDocx(t *testing.T, body string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>%s</w:body></w:document>`, body)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestDocxText(t *testing.T) {
+	data := testDocx(t, `<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">world &amp; co</w:t></w:r></w:p><w:p><w:r><w:t>Second</w:t></w:r></w:p>`)
+	got, err := extractDocumentText("docx", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Hello\tworld & co\nSecond" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestPrepareInbound_DocumentAttachment(t *testing.T) {
+	cfg := config.Default().Tools.Media
+	cfg.MaxTextChars = 40
+	client := &llm.Client{Provider: "openai", Model: "gpt-4o-mini"}
+	inbound := bus.InboundMessage{
+		Content: "summarize this",
+		Attachments: []bus.Attachment{{
+			Name:     "notes.docx",
+			MIMEType: docxMIMEType,
+			Kind:     "file",
+			Data:     testDocx(t, `<w:p><w:r><w:t>`+strings.Repeat("word ", 20)+`</w:t></w:r></w:p>`),
+		}},
+	}
+	got, err := PrepareInbound(context.Background(), client, cfg, inbound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.UserMessage.Content, "[Attachment] notes.docx") ||
+		!strings.Contains(got.UserMessage.Content, "[Extracted text]\nword word") ||
+		!strings.Contains(got.UserMessage.Content, "(truncated)") {
+		t.Fatalf("content=%q", got.UserMessage.Content)
+	}
+
+	off := false
+	cfg.DocumentEnabled = &off
+	got, err = PrepareInbound(context.Background(), client, cfg, inbound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got.UserMessage.Content, "Extracted text") {
+		t.Fatalf("documentEnabled=false should skip extraction: %q", got.UserMessage.Content)
+	}
+}
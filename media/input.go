@@ -94,7 +94,7 @@ func PrepareInbound(ctx context.Context, client *llm.Client, cfg config.MediaToo
 			if cfg.AudioEnabledValue() && client.SupportsAudioTranscription() {
 				data, mimeType, err := readAttachmentBytes(ctx, att, cfg.MaxFileBytes, cfg.DownloadTimeoutSec)
 				if err == nil && len(data) > 0 {
-					transcript, txErr := client.TranscribeAudio(ctx, data, mimeType, name)
+					transcript, txErr := client.TranscribeAudio(ctx, data, mimeType, name, cfg.TranscriptionLanguage)
 					if txErr == nil && strings.TrimSpace(transcript) != "" {
 						textSections = append(textSections, fmt.Sprintf("[Audio transcript: %s]\n%s", name, strings.TrimSpace(transcript)))
 						handledAudio = true
@@ -177,6 +177,12 @@ func buildAttachmentSection(ctx context.Context, att bus.Attachment, cfg config.
 		header += fmt.Sprintf(" (%s)", strings.TrimSpace(att.MIMEType))
 	}
 
+	if kind := documentKind(att); kind != "" {
+		if !cfg.DocumentEnabledValue() {
+			return header
+		}
+		return header + documentSection(ctx, att, kind, cfg)
+	}
 	if !isTextCandidate(att) {
 		return header
 	}
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -58,6 +59,19 @@ func (s *Store) WriteLongTerm(content string) error {
 	return os.WriteFile(s.LongTerm, []byte(content), 0o644)
 }
 
+// AppendLongTerm adds fact to MEMORY.md as a list item.
+func (s *Store) AppendLongTerm(fact string) error {
+	fact = strings.Join(strings.Fields(fact), " ")
+	if fact == "" {
+		return nil
+	}
+	current := s.ReadLongTerm()
+	if current != "" && !strings.HasSuffix(current, "\n") {
+		current += "\n"
+	}
+	return s.WriteLongTerm(current + "- " + fact + "\n")
+}
+
 func (s *Store) ReadToday() string {
 	_ = s.EnsureInitialized()
 	p := s.TodayPath()
@@ -69,15 +83,14 @@ func (s *Store) ReadToday() string {
 }
 
 func (s *Store) GetContext() string {
-	longTerm := strings.TrimSpace(s.ReadLongTerm())
-	today := strings.TrimSpace(s.ReadToday())
+	longTerm, today := s.ContextSections()
 
 	var parts []string
 	if longTerm != "" {
-		parts = append(parts, "## Long-term Memory\n"+truncate(longTerm, 64<<10))
+		parts = append(parts, longTerm)
 	}
 	if today != "" {
-		parts = append(parts, "## Today's Notes\n"+truncate(today, 64<<10))
+		parts = append(parts, today)
 	}
 	if len(parts) == 0 {
 		return ""
@@ -85,6 +98,18 @@ func (s *Store) GetContext() string {
 	return strings.Join(parts, "\n\n")
 }
 
+// ContextSections returns the long-term memory and today's notes sections of
+// GetContext separately; either is empty when there is nothing to show.
+func (s *Store) ContextSections() (longTerm, today string) {
+	if lt := strings.TrimSpace(s.ReadLongTerm()); lt != "" {
+		longTerm = "## Long-term Memory\n" + truncate(lt, 64<<10)
+	}
+	if td := strings.TrimSpace(s.ReadToday()); td != "" {
+		today = "## Today's Notes\n" + truncate(td, 64<<10)
+	}
+	return longTerm, today
+}
+
 func (s *Store) AppendHistory(entry string) error {
 	entry = strings.TrimSpace(entry)
 	if entry == "" {
@@ -113,6 +138,37 @@ func (s *Store) AppendHistory(entry string) error {
 	return nil
 }
 
+// RotateHistory archives HISTORY.md as HISTORY-<date>.md once it is larger
+// than maxBytes; the next AppendHistory starts a fresh file. It returns the
+// archive path, or "" when nothing was rotated.
+func (s *Store) RotateHistory(maxBytes int64, now time.Time) (string, error) {
+	if maxBytes <= 0 {
+		return "", nil
+	}
+	st, err := os.Stat(s.History)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if st.Size() <= maxBytes {
+		return "", nil
+	}
+	base := filepath.Join(s.Dir, "HISTORY-"+now.Format("2006-01-02"))
+	archive := base + ".md"
+	for i := 2; ; i++ {
+		if _, err := os.Stat(archive); os.IsNotExist(err) {
+			break
+		}
+		archive = fmt.Sprintf("%s-%d.md", base, i)
+	}
+	if err := os.Rename(s.History, archive); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
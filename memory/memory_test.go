@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateHistory(t *testing.T) {
+	s := New(t.TempDir())
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	if err := s.AppendHistory(strings.Repeat("x", 200)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.RotateHistory(1<<10, now); err != nil || got != "" {
+		t.Fatalf("under cap: got=%q err=%v", got, err)
+	}
+
+	got, err := s.RotateHistory(100, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(s.Dir, "HISTORY-2026-03-02.md"); got != want {
+		t.Fatalf("archive=%q want %q", got, want)
+	}
+	if _, err := os.Stat(s.History); !os.IsNotExist(err) {
+		t.Fatalf("HISTORY.md should be gone: %v", err)
+	}
+
+	if err := s.AppendHistory(strings.Repeat("y", 200)); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.RotateHistory(100, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(s.Dir, "HISTORY-2026-03-02-2.md"); got != want {
+		t.Fatalf("second archive=%q want %q", got, want)
+	}
+	if got, _ := s.RotateHistory(0, now); got != "" {
+		t.Fatalf("disabled rotation rotated %q", got)
+	}
+}
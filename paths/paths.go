@@ -39,6 +39,38 @@ func CronStorePath() string {
 	return filepath.Join(dir, "cron.json")
 }
 
+func OutboundQueuePath() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ".clawlet/outbound.jsonl"
+	}
+	return filepath.Join(dir, "outbound.jsonl")
+}
+
+func HeartbeatStatePath() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ".clawlet/heartbeat.json"
+	}
+	return filepath.Join(dir, "heartbeat.json")
+}
+
+func ActivitySocketPath() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ".clawlet/activity.sock"
+	}
+	return filepath.Join(dir, "activity.sock")
+}
+
+func AuditLogPath() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ".clawlet/audit.jsonl"
+	}
+	return filepath.Join(dir, "audit.jsonl")
+}
+
 func WorkspaceDir() string {
 	dir, err := ConfigDir()
 	if err != nil {
@@ -2,10 +2,13 @@ package session
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +19,15 @@ type Message struct {
 	Content   string   `json:"content"`
 	Timestamp string   `json:"timestamp,omitempty"`
 	ToolsUsed []string `json:"tools_used,omitempty"`
+	// ToolResults keeps a truncated result of each tool call of the turn
+	// for consolidation. It is only recorded when enabled.
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+}
+
+// ToolResult is what one tool call returned.
+type ToolResult struct {
+	Name   string `json:"name"`
+	Output string `json:"output"`
 }
 
 type metadataLine struct {
@@ -34,6 +46,11 @@ type Session struct {
 
 	mu      sync.Mutex
 	version uint64
+
+	// saveMu serializes file writes; savedVersion is the version last written.
+	saveMu       sync.Mutex
+	saved        bool
+	savedVersion uint64
 }
 
 type Manager struct {
@@ -66,6 +83,17 @@ func (m *Manager) GetOrCreate(key string) (*Session, error) {
 	return s, nil
 }
 
+// Cached returns the sessions loaded or created since the manager started.
+func (m *Manager) Cached() []*Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]*Session, 0, len(m.cache))
+	for _, s := range m.cache {
+		out = append(out, s)
+	}
+	return out
+}
+
 func (m *Manager) Save(s *Session) error {
 	if err := Save(m.Dir, s); err != nil {
 		return err
@@ -77,7 +105,7 @@ func (m *Manager) Save(s *Session) error {
 }
 
 func Load(dir, key string) (*Session, error) {
-	path := filepath.Join(dir, safeFilename(strings.ReplaceAll(key, ":", "_"))+".jsonl")
+	path := filepath.Join(dir, SafeFilename(strings.ReplaceAll(key, ":", "_"))+".jsonl")
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -151,6 +179,12 @@ func (s *Session) Add(role, content string) {
 }
 
 func (s *Session) AddWithTools(role, content string, toolsUsed []string) {
+	s.AddWithToolResults(role, content, toolsUsed, nil)
+}
+
+// AddWithToolResults is AddWithTools that also stores the turn's tool
+// results.
+func (s *Session) AddWithToolResults(role, content string, toolsUsed []string, results []ToolResult) {
 	var copied []string
 	if len(toolsUsed) > 0 {
 		copied = make([]string, 0, len(toolsUsed))
@@ -165,15 +199,41 @@ func (s *Session) AddWithTools(role, content string, toolsUsed []string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.Messages = append(s.Messages, Message{
-		Role:      role,
-		Content:   content,
-		Timestamp: time.Now().Format(time.RFC3339Nano),
-		ToolsUsed: copied,
+		Role:        role,
+		Content:     content,
+		Timestamp:   time.Now().Format(time.RFC3339Nano),
+		ToolsUsed:   copied,
+		ToolResults: slices.Clone(results),
 	})
 	s.UpdatedAt = time.Now()
 	s.version++
 }
 
+// MetaString returns the string metadata value for key, or "".
+func (s *Session) MetaString(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, _ := s.Metadata[key].(string)
+	return v
+}
+
+// SetMeta stores a metadata value; an empty value removes key. Metadata is
+// saved with the session.
+func (s *Session) SetMeta(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Metadata == nil {
+		s.Metadata = map[string]any{}
+	}
+	if value == "" {
+		delete(s.Metadata, key)
+	} else {
+		s.Metadata[key] = value
+	}
+	s.UpdatedAt = time.Now()
+	s.version++
+}
+
 func (s *Session) History(max int) []Message {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -193,24 +253,62 @@ func (s *Session) NeedsConsolidation(memoryWindow int) bool {
 	return len(s.Messages) > memoryWindow
 }
 
+// ConsolidationKeep is the number of recent messages kept after consolidation.
+func ConsolidationKeep(memoryWindow int) int {
+	if memoryWindow <= 0 {
+		memoryWindow = 50
+	}
+	return min(10, max(2, memoryWindow/2))
+}
+
 func (s *Session) SnapshotForConsolidation(memoryWindow int) (oldMessages []Message, keep int, version uint64, ok bool) {
 	if memoryWindow <= 0 {
 		memoryWindow = 50
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	n := len(s.Messages)
-	if n <= memoryWindow {
+	if len(s.Messages) <= memoryWindow {
 		return nil, 0, 0, false
 	}
-	keep = min(10, max(2, memoryWindow/2))
-	if keep >= n {
+	return s.snapshotLocked(ConsolidationKeep(memoryWindow))
+}
+
+// SnapshotForIdleConsolidation is like SnapshotForConsolidation but ignores
+// the window: everything except the newest keep messages is returned.
+func (s *Session) SnapshotForIdleConsolidation(keep int) (oldMessages []Message, _ int, version uint64, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked(keep)
+}
+
+func (s *Session) snapshotLocked(keep int) (oldMessages []Message, _ int, version uint64, ok bool) {
+	n := len(s.Messages)
+	if keep < 0 || keep >= n {
 		return nil, 0, 0, false
 	}
 	oldMessages = cloneMessages(s.Messages[:n-keep])
 	return oldMessages, keep, s.version, true
 }
 
+// Clear removes every message and returns the removed ones. Metadata is
+// kept.
+func (s *Session) Clear() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.Messages
+	s.Messages = nil
+	s.UpdatedAt = time.Now()
+	s.version++
+	return old
+}
+
+// LastUpdated returns when a message was last added or the session trimmed.
+func (s *Session) LastUpdated() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.UpdatedAt
+}
+
 func (s *Session) ApplyConsolidation(version uint64, keep int) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -226,49 +324,64 @@ func (s *Session) ApplyConsolidation(version uint64, keep int) bool {
 	return true
 }
 
+// Save rewrites the session file. The session lock is held only while the
+// session is copied; encoding and file I/O happen outside it so concurrent
+// Add calls are not blocked. The file is replaced atomically via a temp file
+// and rename, so Load never sees a partial write.
 func Save(dir string, s *Session) error {
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return err
 	}
-	path := filepath.Join(dir, safeFilename(strings.ReplaceAll(s.Key, ":", "_"))+".jsonl")
+	path := filepath.Join(dir, SafeFilename(strings.ReplaceAll(s.Key, ":", "_"))+".jsonl")
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
-	if err != nil {
-		return err
-	}
-	bw := bufio.NewWriter(f)
-
+	version := s.version
 	meta := metadataLine{
 		Type:      "metadata",
 		CreatedAt: s.CreatedAt.Format(time.RFC3339Nano),
 		UpdatedAt: s.UpdatedAt.Format(time.RFC3339Nano),
-		Metadata:  s.Metadata,
+		Metadata:  maps.Clone(s.Metadata),
 	}
+	msgs := cloneMessages(s.Messages)
+	s.mu.Unlock()
+
+	var buf bytes.Buffer
 	if b, err := json.Marshal(meta); err == nil {
-		if _, err := bw.Write(append(b, '\n')); err != nil {
-			_ = f.Close()
-			return err
-		}
+		buf.Write(append(b, '\n'))
 	}
-
-	for _, m := range s.Messages {
+	for _, m := range msgs {
 		if b, err := json.Marshal(m); err == nil {
-			if _, err := bw.Write(append(b, '\n')); err != nil {
-				_ = f.Close()
-				return err
-			}
+			buf.Write(append(b, '\n'))
 		}
 	}
-	if err := bw.Flush(); err != nil {
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	// A concurrent Save already wrote a newer snapshot.
+	if s.saved && version < s.savedVersion {
+		return nil
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(buf.Bytes()); err != nil {
 		_ = f.Close()
+		_ = os.Remove(tmp)
 		return err
 	}
 	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
 		return err
 	}
+	s.saved = true
+	s.savedVersion = version
 	return nil
 }
 
@@ -283,6 +396,7 @@ func cloneMessages(in []Message) []Message {
 		if len(m.ToolsUsed) > 0 {
 			msg.ToolsUsed = append([]string{}, m.ToolsUsed...)
 		}
+		msg.ToolResults = slices.Clone(m.ToolResults)
 		out = append(out, msg)
 	}
 	return out
@@ -290,7 +404,9 @@ func cloneMessages(in []Message) []Message {
 
 var safeRe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
 
-func safeFilename(s string) string {
+// SafeFilename turns a session key into a file name, e.g. "telegram:1"
+// becomes "telegram_1".
+func SafeFilename(s string) string {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return "default"
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -23,7 +24,7 @@ func TestSaveLoad_RewriteSnapshot(t *testing.T) {
 		t.Fatalf("save #2: %v", err)
 	}
 
-	path := filepath.Join(dir, safeFilename(strings.ReplaceAll(key, ":", "_"))+".jsonl")
+	path := filepath.Join(dir, SafeFilename(strings.ReplaceAll(key, ":", "_"))+".jsonl")
 	b, err := os.ReadFile(path)
 	if err != nil {
 		t.Fatalf("read file: %v", err)
@@ -90,3 +91,62 @@ func TestSave_AfterConsolidationPersistsTrimmedMessages(t *testing.T) {
 		t.Fatalf("messages=%d want=%d", got, keep)
 	}
 }
+
+func TestSave_ConcurrentWithCompaction(t *testing.T) {
+	dir := t.TempDir()
+	key := "cli:busy"
+	s := New(key)
+	for range 200 {
+		s.Add("user", strings.Repeat("x", 256))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 8 {
+				s.Add("assistant", "reply")
+				if i == 0 {
+					if _, keep, ver, ok := s.SnapshotForConsolidation(20); ok {
+						s.ApplyConsolidation(ver, keep)
+					}
+				}
+				if err := Save(dir, s); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := Load(dir, key); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent save/load: %v", err)
+	}
+
+	if err := Save(dir, s); err != nil {
+		t.Fatalf("final save: %v", err)
+	}
+	loaded, err := Load(dir, key)
+	if err != nil || loaded == nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got, want := len(loaded.Messages), len(s.History(0)); got != want {
+		t.Fatalf("messages=%d want=%d", got, want)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("readdir: %v", err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temp file left behind: %s", e.Name())
+		}
+	}
+}
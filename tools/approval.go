@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// approvalPromptMax is the longest approval prompt, in runes. It leaves room
+// in a 2000-character Discord message for the timeout note. Calls whose
+// prompt is longer are refused rather than shown cut off.
+const approvalPromptMax = 1900
+
+// needsApproval reports whether name is listed in RequireApproval.
+func (r *Registry) needsApproval(name string) bool {
+	return slices.Contains(r.RequireApproval, name)
+}
+
+// checkApproval asks Approve before a RequireApproval tool runs, and refuses
+// the call when it is denied, times out, or cannot be asked for. Calls that
+// execute refuses anyway are not put to the user.
+func (r *Registry) checkApproval(ctx context.Context, tctx Context, name string, args []byte) error {
+	if !r.needsApproval(name) || !r.allowed(name) || !r.inProfile(tctx.Profile, name) {
+		return nil
+	}
+	if r.Approve == nil {
+		return fmt.Errorf("tool %s requires approval, which is not available here", name)
+	}
+	prompt := approvalPrompt(name, args)
+	if n := utf8.RuneCountInString(prompt); n > approvalPromptMax {
+		return fmt.Errorf("tool %s requires approval, but its arguments are too long to show in full (%d characters, max %d); split it into smaller calls", name, n, approvalPromptMax)
+	}
+	ok, err := r.Approve(ctx, tctx, prompt)
+	if err != nil {
+		return fmt.Errorf("tool %s requires approval: %w", name, err)
+	}
+	if !ok {
+		return fmt.Errorf("the user denied %s; do not retry it unless they ask", name)
+	}
+	return nil
+}
+
+// approvalPrompt describes a tool call for the user approving it.
+func approvalPrompt(name string, args []byte) string {
+	a := strings.TrimSpace(string(args))
+	if a == "" || a == "{}" || a == "null" {
+		return fmt.Sprintf("Run tool `%s`?", name)
+	}
+	return fmt.Sprintf("Run tool `%s`?\n```\n%s\n```", name, a)
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequireApproval(t *testing.T) {
+	ws := t.TempDir()
+	var prompts []string
+	answer := false
+	r := &Registry{
+		WorkspaceDir:    ws,
+		RequireApproval: []string{"write_file"},
+		Approve: func(ctx context.Context, tctx Context, prompt string) (bool, error) {
+			prompts = append(prompts, prompt)
+			return answer, nil
+		},
+	}
+	run := func(name, args string) error {
+		_, err := r.Execute(context.Background(), Context{Channel: "discord", ChatID: "c1"}, name, json.RawMessage(args))
+		return err
+	}
+
+	err := run("write_file", `{"path":"a.txt","content":"x"}`)
+	if err == nil || !strings.Contains(err.Error(), "the user denied write_file") {
+		t.Fatalf("denied: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "a.txt")); !os.IsNotExist(err) {
+		t.Fatal("denied call ran")
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "`write_file`") || !strings.Contains(prompts[0], `"path":"a.txt"`) {
+		t.Fatalf("prompts=%q", prompts)
+	}
+
+	answer = true
+	if err := run("write_file", `{"path":"a.txt","content":"x"}`); err != nil {
+		t.Fatalf("approved: %v", err)
+	}
+	if err := run("read_file", `{"path":"a.txt"}`); err != nil || len(prompts) != 2 {
+		t.Fatalf("unlisted tool: err=%v prompts=%d", err, len(prompts))
+	}
+
+	long := `{"path":"a.txt","content":"` + strings.Repeat("x", approvalPromptMax) + `"}`
+	if err := run("write_file", long); err == nil || !strings.Contains(err.Error(), "too long") || len(prompts) != 2 {
+		t.Fatalf("long arguments: err=%v prompts=%d", err, len(prompts))
+	}
+
+	r.Approve = nil
+	if err := run("write_file", `{"path":"a.txt","content":"y"}`); err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Fatalf("no approver: %v", err)
+	}
+}
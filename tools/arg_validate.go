@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+// ArgValidationError reports tool arguments that do not match the tool's
+// declared parameter schema. The message lists every problem so the model
+// can fix them in one retry.
+type ArgValidationError struct {
+	Tool     string
+	Problems []string
+}
+
+func (e *ArgValidationError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %s", e.Tool, strings.Join(e.Problems, "; "))
+}
+
+func (r *Registry) validateToolArgs(name string, args json.RawMessage) error {
+	var schema *llm.JSONSchema
+	for _, d := range r.Definitions() {
+		if d.Function.Name == name {
+			schema = &d.Function.Parameters
+			break
+		}
+	}
+	if schema == nil {
+		return nil
+	}
+	// Back-compat: edit_file still accepts the older line-range form, which is
+	// not part of the advertised schema.
+	if name == "edit_file" && !bytes.Contains(args, []byte(`"old_text"`)) && bytes.Contains(args, []byte(`"startLine"`)) {
+		return nil
+	}
+
+	trimmed := bytes.TrimSpace(args)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		trimmed = []byte("{}")
+	}
+	var v any
+	if err := json.Unmarshal(trimmed, &v); err != nil {
+		return &ArgValidationError{Tool: name, Problems: []string{"arguments are not valid JSON: " + err.Error()}}
+	}
+	var problems []string
+	validateSchemaValue(*schema, v, "", &problems)
+	if len(problems) > 0 {
+		return &ArgValidationError{Tool: name, Problems: problems}
+	}
+	return nil
+}
+
+func validateSchemaValue(s llm.JSONSchema, v any, path string, problems *[]string) {
+	if len(s.Raw) > 0 {
+		var parsed llm.JSONSchema
+		if err := json.Unmarshal(s.Raw, &parsed); err != nil {
+			return
+		}
+		s = parsed
+	}
+	label := path
+	if label == "" {
+		label = "arguments"
+	}
+
+	if s.Type != "" && !schemaTypeMatches(s.Type, v) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", label, s.Type, jsonTypeName(v)))
+		return
+	}
+	if len(s.Enum) > 0 {
+		if str, ok := v.(string); ok && !slices.Contains(s.Enum, str) {
+			*problems = append(*problems, fmt.Sprintf("%s: must be one of %s", label, strings.Join(s.Enum, ", ")))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for _, req := range s.Required {
+			if _, ok := val[req]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required field", joinSchemaPath(path, req)))
+			}
+		}
+		keys := make([]string, 0, len(s.Properties))
+		for k := range s.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if child, ok := val[k]; ok && child != nil {
+				validateSchemaValue(s.Properties[k], child, joinSchemaPath(path, k), problems)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range val {
+				validateSchemaValue(*s.Items, item, fmt.Sprintf("%s[%d]", label, i), problems)
+			}
+		}
+	}
+}
+
+func schemaTypeMatches(typ string, v any) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	default:
+		return true
+	}
+}
+
+func jsonTypeName(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func joinSchemaPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
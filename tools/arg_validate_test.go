@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecute_ValidatesArgsAgainstSchema(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir(), RestrictToWorkspace: true}
+
+	_, err := r.Execute(context.Background(), Context{}, "write_file", json.RawMessage(`{"path":5}`))
+	var verr *ArgValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ArgValidationError, got %v", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "content: missing required field") || !strings.Contains(msg, "path: expected string, got integer") {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+
+	_, err = r.Execute(context.Background(), Context{}, "write_files", json.RawMessage(`{"files":[{"path":"a.txt"}]}`))
+	if !errors.As(err, &verr) || !strings.Contains(err.Error(), "files[0].content: missing required field") {
+		t.Fatalf("nested validation err=%v", err)
+	}
+
+	_, err = r.Execute(context.Background(), Context{}, "list_dir", json.RawMessage(`{"path":".","maxEntries":1.5}`))
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected integer validation error, got %v", err)
+	}
+}
+
+func TestExecute_ValidArgsPass(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir(), RestrictToWorkspace: true}
+	if _, err := r.Execute(context.Background(), Context{}, "write_file", json.RawMessage(`{"path":"a.txt","content":"x"}`)); err != nil {
+		t.Fatalf("write_file: %v", err)
+	}
+	// Legacy line-range edit_file form is still accepted.
+	if _, err := r.Execute(context.Background(), Context{}, "edit_file", json.RawMessage(`{"path":"a.txt","startLine":1,"endLine":1,"newText":"y"}`)); err != nil {
+		t.Fatalf("legacy edit_file: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// auditArgMaxRunes bounds each string argument kept in the audit log, so file
+// contents written by the agent are not copied into it.
+const auditArgMaxRunes = 200
+
+// auditSecretKey matches argument names whose values are never logged.
+var auditSecretKey = regexp.MustCompile(`(?i)(token|secret|passw(or)?d|api[_-]?key|authorization|cookie|credential|private[_-]?key)`)
+
+// auditSecretValue matches common credential formats inside argument values.
+var auditSecretValue = regexp.MustCompile(`(sk-[A-Za-z0-9_-]{16,}|gh[pousr]_[A-Za-z0-9]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|(?i:bearer)\s+[A-Za-z0-9._~+/=-]{8,})`)
+
+// AuditEntry is one line of the tool audit log.
+type AuditEntry struct {
+	Time       time.Time       `json:"time"`
+	SessionKey string          `json:"session,omitempty"`
+	Channel    string          `json:"channel,omitempty"`
+	Tool       string          `json:"tool"`
+	Args       json.RawMessage `json:"args,omitempty"`
+	OK         bool            `json:"ok"`
+	Error      string          `json:"error,omitempty"`
+	DurationMS int64           `json:"durationMs"`
+}
+
+// AuditLog appends an AuditEntry per tool call to a JSONL file. It only ever
+// appends; rotating or pruning the file is left to the operator.
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// record logs a finished call. A write failure is logged and never fails
+// the tool.
+func (a *AuditLog) record(tctx Context, name string, args []byte, start time.Time, err error) {
+	if a == nil {
+		return
+	}
+	e := AuditEntry{
+		Time:       start.UTC(),
+		SessionKey: tctx.SessionKey,
+		Channel:    tctx.Channel,
+		Tool:       name,
+		Args:       RedactAuditArgs(args),
+		OK:         err == nil,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		e.Error = truncate(err.Error(), 500)
+	}
+	if werr := a.append(e); werr != nil {
+		log.Printf("tools: audit log: %v", werr)
+	}
+}
+
+func (a *AuditLog) append(e AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RedactAuditArgs returns args with secret-looking fields masked and long
+// strings shortened. Arguments that are not JSON are replaced by a note. It
+// is also used for tool calls shown by clawlet tail.
+func RedactAuditArgs(args []byte) json.RawMessage {
+	if len(args) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(args, &v); err != nil {
+		return json.RawMessage(fmt.Sprintf(`{"_invalid":%d}`, len(args)))
+	}
+	b, err := json.Marshal(redactAuditValue("", v))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func redactAuditValue(key string, v any) any {
+	if key != "" && auditSecretKey.MatchString(key) {
+		return "[REDACTED]"
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		for k, vv := range t {
+			t[k] = redactAuditValue(k, vv)
+		}
+		return t
+	case []any:
+		for i, vv := range t {
+			t[i] = redactAuditValue(key, vv)
+		}
+		return t
+	case string:
+		s := auditSecretValue.ReplaceAllString(t, "[REDACTED]")
+		if n := utf8.RuneCountInString(s); n > auditArgMaxRunes {
+			s = string([]rune(s)[:auditArgMaxRunes]) + fmt.Sprintf("…[%d chars]", n)
+		}
+		return s
+	}
+	return v
+}
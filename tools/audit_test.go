@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	ws := t.TempDir()
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true, Audit: NewAuditLog(logPath)}
+	tctx := Context{SessionKey: "slack:C1", Channel: "slack"}
+
+	long := strings.Repeat("x", 500)
+	if _, err := r.Execute(context.Background(), tctx, "write_file", json.RawMessage(`{"path":"a.txt","content":"`+long+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = r.Execute(context.Background(), tctx, "web_fetch", json.RawMessage(`{"url":"http://127.0.0.1:1/","headers":{"Authorization":"Bearer abcdefghijkl"}}`))
+	if _, err := r.Execute(context.Background(), tctx, "read_file", json.RawMessage(`{"path":"missing.txt"}`)); err == nil {
+		t.Fatal("expected read_file to fail")
+	}
+	if _, err := r.Execute(context.Background(), tctx, "exec", json.RawMessage(`{"command":"echo sk-abcdefghijklmnopqrstu"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("bad line %q: %v", sc.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("entries=%d", len(entries))
+	}
+	if e := entries[0]; e.Tool != "write_file" || !e.OK || e.SessionKey != "slack:C1" || e.Channel != "slack" || strings.Contains(string(e.Args), long) || !strings.Contains(string(e.Args), "[500 chars]") {
+		t.Fatalf("write_file entry=%+v args=%s", e, e.Args)
+	}
+	if e := entries[1]; strings.Contains(string(e.Args), "abcdefghijkl") || !strings.Contains(string(e.Args), `"Authorization":"[REDACTED]"`) {
+		t.Fatalf("web_fetch args=%s", e.Args)
+	}
+	if e := entries[2]; e.Tool != "read_file" || e.OK || e.Error == "" {
+		t.Fatalf("read_file entry=%+v", e)
+	}
+	if e := entries[3]; strings.Contains(string(e.Args), "sk-abc") || !strings.Contains(string(e.Args), "echo [REDACTED]") {
+		t.Fatalf("exec entry args=%s", e.Args)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"strings"
 )
 
-func formatBraveSearchResults(query string, count int, body []byte) string {
+// formatBraveSearchResults renders up to count results. Results whose URL
+// keep rejects are dropped first; nil keeps everything.
+func formatBraveSearchResults(query string, count int, body []byte, keep func(rawURL string) bool) string {
 	type item struct {
 		Title       string `json:"title"`
 		URL         string `json:"url"`
@@ -21,7 +23,22 @@ func formatBraveSearchResults(query string, count int, body []byte) string {
 		return "Error: failed to parse search results"
 	}
 	results := parsed.Web.Results
+	dropped := 0
+	if keep != nil {
+		kept := results[:0]
+		for _, it := range results {
+			if keep(strings.TrimSpace(it.URL)) {
+				kept = append(kept, it)
+			} else {
+				dropped++
+			}
+		}
+		results = kept
+	}
 	if len(results) == 0 {
+		if dropped > 0 {
+			return fmt.Sprintf("No results for: %s (%d from blocked domains omitted)", query, dropped)
+		}
 		return fmt.Sprintf("No results for: %s", query)
 	}
 	if count <= 0 || count > 10 {
@@ -43,5 +60,8 @@ func formatBraveSearchResults(query string, count int, body []byte) string {
 			lines = append(lines, "   "+desc)
 		}
 	}
+	if dropped > 0 {
+		lines = append(lines, fmt.Sprintf("\n(%d results from blocked domains omitted)", dropped))
+	}
 	return strings.Join(lines, "\n")
 }
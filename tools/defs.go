@@ -2,6 +2,7 @@ package tools
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/mosaxiv/clawlet/llm"
 )
@@ -11,11 +12,15 @@ func defReadFile() llm.ToolDefinition {
 		Type: "function",
 		Function: llm.FunctionDefinition{
 			Name:        "read_file",
-			Description: "Read a UTF-8 text file from disk.",
+			Description: "Read a UTF-8 text file from disk. Output is capped at 512KB; for larger files, read a window with offset/length (bytes) or startLine/endLine. Windowed reads start with a header giving the total size.",
 			Parameters: llm.JSONSchema{
 				Type: "object",
 				Properties: map[string]llm.JSONSchema{
-					"path": {Type: "string", Description: "File path (relative to workspace recommended)."},
+					"path":      {Type: "string", Description: "File path (relative to workspace recommended)."},
+					"offset":    {Type: "integer", Description: "Byte offset to start reading at."},
+					"length":    {Type: "integer", Description: "Number of bytes to read (max 524288)."},
+					"startLine": {Type: "integer", Description: "First line to read (1-based)."},
+					"endLine":   {Type: "integer", Description: "Last line to read (inclusive). Defaults to end of file."},
 				},
 				Required: []string{"path"},
 			},
@@ -41,6 +46,33 @@ func defWriteFile() llm.ToolDefinition {
 	}
 }
 
+func defWriteFiles() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "write_files",
+			Description: "Write multiple UTF-8 text files in one call. All paths are validated first; if any path is rejected, nothing is written.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"files": {
+						Type: "array",
+						Items: &llm.JSONSchema{
+							Type: "object",
+							Properties: map[string]llm.JSONSchema{
+								"path":    {Type: "string"},
+								"content": {Type: "string"},
+							},
+							Required: []string{"path", "content"},
+						},
+					},
+				},
+				Required: []string{"files"},
+			},
+		},
+	}
+}
+
 func defEditFile() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Type: "function",
@@ -60,18 +92,243 @@ func defEditFile() llm.ToolDefinition {
 	}
 }
 
+func defReplaceInFiles() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "replace_in_files",
+			Description: "Replace text in every workspace file matching a glob. Returns the number of replacements per file. Run with dry_run first to preview a diff without writing.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"glob":    {Type: "string", Description: "Files to change, relative to the workspace, e.g. \"**/*.go\" or \"docs/*.md\". Patterns without / match the file name at any depth."},
+					"old":     {Type: "string", Description: "Text to replace (every occurrence)."},
+					"new":     {Type: "string", Description: "Replacement text. With regex, $1 or ${name} insert capture groups."},
+					"regex":   {Type: "boolean", Description: "Treat old as a Go regular expression."},
+					"dry_run": {Type: "boolean", Description: "Preview the changes as a diff without writing."},
+				},
+				Required: []string{"glob", "old", "new"},
+			},
+		},
+	}
+}
+
+func defJSONPatch() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "json_patch",
+			Description: "Apply RFC 6902 JSON Patch operations to a JSON file. Key order and indentation are preserved. If any operation fails, nothing is written. Prefer this over edit_file for JSON.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path": {Type: "string"},
+					"patch": {
+						Type:        "array",
+						Description: "Operations applied in order.",
+						Items: &llm.JSONSchema{
+							Type: "object",
+							Properties: map[string]llm.JSONSchema{
+								"op":    {Type: "string", Enum: []string{"add", "remove", "replace", "move", "copy", "test"}},
+								"path":  {Type: "string", Description: "JSON Pointer, e.g. /tools/exec/timeoutSec."},
+								"from":  {Type: "string", Description: "Source pointer for move and copy."},
+								"value": {Description: "Value for add, replace, and test."},
+							},
+							Required: []string{"op", "path"},
+						},
+					},
+				},
+				Required: []string{"path", "patch"},
+			},
+		},
+	}
+}
+
+func defSummarizeFile() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "summarize_file",
+			Description: "Summarize a file without reading it into context. Use it for large logs, data, or documents when you only need the gist.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path":  {Type: "string"},
+					"focus": {Type: "string", Description: "Optional: what the summary should focus on (e.g. \"errors\", \"API changes\")."},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func defListTools() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "list_tools",
+			Description: "List your tools with descriptions, parameter schemas, and whether each is currently enabled.",
+			Parameters: llm.JSONSchema{
+				Type:       "object",
+				Properties: map[string]llm.JSONSchema{},
+			},
+		},
+	}
+}
+
+func defContextInfo() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "context_info",
+			Description: "Get the current date, time, weekday and timezone, plus the current channel and chat. Use it before reasoning about relative dates.",
+			Parameters: llm.JSONSchema{
+				Type:       "object",
+				Properties: map[string]llm.JSONSchema{},
+			},
+		},
+	}
+}
+
 func defListDir() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Type: "function",
 		Function: llm.FunctionDefinition{
 			Name:        "list_dir",
-			Description: "List directory entries (names only).",
+			Description: "List directory entries (names only). Returns {entries, hasMore, nextOffset}; pass nextOffset as offset to get the next page.",
 			Parameters: llm.JSONSchema{
 				Type: "object",
 				Properties: map[string]llm.JSONSchema{
 					"path":       {Type: "string"},
 					"recursive":  {Type: "boolean"},
 					"maxEntries": {Type: "integer", Description: "Limit results (default 200)."},
+					"offset":     {Type: "integer", Description: "Skip this many entries (default 0)."},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func defGlob() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "glob",
+			Description: "Find files and directories matching a glob such as `**/*.go` or `cmd/*/main.go`. `**` matches any number of directories. Skips .git, node_modules, and patterns in .clawletignore. Returns {matches, hasMore}.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"pattern":    {Type: "string", Description: "Glob relative to path."},
+					"path":       {Type: "string", Description: "Directory to search (default: workspace root)."},
+					"maxEntries": {Type: "integer", Description: "Limit results (default 200)."},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+	}
+}
+
+func defTree() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "tree",
+			Description: "Show a directory as an indented tree, like `tree -L depth`. Use it to get oriented in a project before searching or editing. Skips .git, node_modules, and patterns in .clawletignore.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path":       {Type: "string", Description: "Directory to show (default: workspace root)."},
+					"depth":      {Type: "integer", Description: "Levels to descend (default 3, max 10)."},
+					"maxEntries": {Type: "integer", Description: "Stop after this many entries (default 500, max 2000)."},
+				},
+			},
+		},
+	}
+}
+
+func defHash() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "hash",
+			Description: "Compute the md5, sha1, or sha256 hex digest of a file or of text. Use it for checksums instead of running md5sum or sha256sum with exec.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path":      {Type: "string", Description: "File to hash. Give path or text."},
+					"text":      {Type: "string", Description: "Text to hash (UTF-8). Give path or text."},
+					"algorithm": {Type: "string", Description: "md5, sha1, sha256 (default), or all."},
+				},
+			},
+		},
+	}
+}
+
+func defDiff() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "diff",
+			Description: "Show a unified diff from a file to another file (otherPath) or to proposed content (text). Use it to preview or verify edits.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path":      {Type: "string"},
+					"otherPath": {Type: "string", Description: "File to compare against."},
+					"text":      {Type: "string", Description: "Proposed content to compare against."},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func defFileInfo() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "file_info",
+			Description: "Describe a file without reading it: size, MIME type sniffed from its content, whether it looks like text, and the first 32 bytes as hex. Use it before read_file on files that may be binary.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path": {Type: "string", Description: "File path (relative to workspace recommended)."},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func defValidateConfig() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "validate_config",
+			Description: "Check that a JSON, YAML, or TOML file parses. Returns \"valid <TYPE>\" or the parse error with its line (and column, for JSON and TOML). Run it after editing a config file.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path": {Type: "string", Description: "File path (relative to workspace recommended)."},
+					"type": {Type: "string", Enum: []string{"json", "yaml", "toml"}, Description: "Format; defaults to the file extension."},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func defBase64File() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "base64_file",
+			Description: "Return a small file (up to 64KB) encoded as standard base64, e.g. to embed an image or binary in a data URI or API payload. Larger files are refused.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path": {Type: "string", Description: "File path (relative to workspace recommended)."},
 				},
 				Required: []string{"path"},
 			},
@@ -79,6 +336,44 @@ func defListDir() llm.ToolDefinition {
 	}
 }
 
+func defRunScript(interpreters []string) llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "run_script",
+			Description: "Run a multi-line script with an allowed interpreter. The script is written to a temporary file in the workspace, run with the exec timeout and environment, and deleted. Use it instead of exec when a command needs several lines, pipes, or redirection.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"interpreter": {Type: "string", Enum: append([]string(nil), interpreters...)},
+					"script":      {Type: "string", Description: "Script body."},
+					"args":        {Type: "array", Items: &llm.JSONSchema{Type: "string"}, Description: "Optional arguments passed to the script."},
+				},
+				Required: []string{"interpreter", "script"},
+			},
+		},
+	}
+}
+
+func defCommandHelp(commands []string) llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "command_help",
+			Description: "Show usage for an allowed command-line tool: its --help output, or its man page. Use it before exec when unsure of a command's flags.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"command":     {Type: "string", Enum: append([]string(nil), commands...)},
+					"subcommands": {Type: "array", Items: &llm.JSONSchema{Type: "string"}, Description: "Optional subcommands, e.g. [\"remote\", \"add\"] for \"git remote add --help\"."},
+					"source":      {Type: "string", Enum: []string{"help", "man"}, Description: "\"help\" (default) runs --help; \"man\" reads the man page."},
+				},
+				Required: []string{"command"},
+			},
+		},
+	}
+}
+
 func defExec() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Type: "function",
@@ -166,6 +461,7 @@ func defWebFetch() llm.ToolDefinition {
 						Enum: []string{"markdown", "text"},
 					},
 					"maxChars": {Type: "integer", Description: "Max characters in extracted text (default 50000)."},
+					"raw":      {Type: "boolean", Description: "Return the extracted text even when it is long, instead of an automatic summary."},
 					"headers": {
 						Raw: json.RawMessage(`{"type":"object","description":"HTTP request headers to include (e.g. {\"Authorization\":\"Bearer token\"}).","additionalProperties":{"type":"string"}}`),
 					},
@@ -194,20 +490,45 @@ func defWebSearch() llm.ToolDefinition {
 	}
 }
 
-func defMessage() llm.ToolDefinition {
+func defMessage(allowedTargets []string) llm.ToolDefinition {
+	desc := "Send a message to another chat, e.g. to alert an ops channel. Do not use for replying to the current conversation."
+	if len(allowedTargets) > 0 {
+		desc += " Allowed destinations (channel:chat_id): " + strings.Join(allowedTargets, ", ") + "."
+	}
 	return llm.ToolDefinition{
 		Type: "function",
 		Function: llm.FunctionDefinition{
 			Name:        "message",
-			Description: "Send a message to a specific channel/chat_id. Do not use for replying to the current conversation.",
+			Description: desc,
 			Parameters: llm.JSONSchema{
 				Type: "object",
 				Properties: map[string]llm.JSONSchema{
 					"content": {Type: "string"},
-					"channel": {Type: "string"},
-					"chat_id": {Type: "string"},
+					"channel": {Type: "string", Description: "Destination channel, e.g. \"slack\" or \"telegram\"."},
+					"chat_id": {Type: "string", Description: "Destination chat ID in that channel."},
+					"to":      {Type: "string", Description: "Alias for chat_id."},
 				},
-				Required: []string{"content", "channel", "chat_id"},
+				Required: []string{"content", "channel"},
+			},
+		},
+	}
+}
+
+func defSendFile() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "send_file",
+			Description: "Upload a workspace file (e.g. a generated report or archive) to the current chat, or to channel/chat_id. Files over the platform's upload limit are sent compressed or as a temporary download link.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"path":    {Type: "string", Description: "File path (relative to workspace recommended)."},
+					"caption": {Type: "string", Description: "Optional text sent with the file."},
+					"channel": {Type: "string", Description: "Destination channel. Default: the current chat."},
+					"chat_id": {Type: "string", Description: "Destination chat ID in that channel."},
+				},
+				Required: []string{"path"},
 			},
 		},
 	}
@@ -255,6 +576,137 @@ func defCron() llm.ToolDefinition {
 	}
 }
 
+func defGetWeather() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get current weather conditions and local time for a place (city name, optionally with region or country).",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"location": {Type: "string"},
+				},
+				Required: []string{"location"},
+			},
+		},
+	}
+}
+
+func defGitHub() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "github",
+			Description: "Read from GitHub through its API (returns JSON): a file's contents at a ref (action file), a directory listing (action list), or an issue or pull request with its comments (action issue). Prefer it over web_fetch for github.com.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"action": {Type: "string", Enum: []string{"file", "list", "issue"}},
+					"repo":   {Type: "string", Description: "owner/name"},
+					"path":   {Type: "string", Description: "File or directory path (file, list; empty lists the root)."},
+					"ref":    {Type: "string", Description: "Branch, tag, or commit (default branch when empty)."},
+					"number": {Type: "integer", Description: "Issue or pull request number (issue)."},
+				},
+				Required: []string{"action", "repo"},
+			},
+		},
+	}
+}
+
+func defSystemInfo(processes bool) llm.ToolDefinition {
+	props := map[string]llm.JSONSchema{}
+	desc := "Report this host's CPU, memory, and disk usage (read-only)."
+	if processes {
+		desc = "Report this host's CPU, memory, and disk usage, and optionally the busiest processes (read-only). Prefer it over exec ps or top."
+		props["processes"] = llm.JSONSchema{Type: "boolean", Description: "Include the top processes."}
+		props["sort"] = llm.JSONSchema{Type: "string", Enum: []string{"cpu", "memory"}, Description: "Process order (default cpu)."}
+		props["limit"] = llm.JSONSchema{Type: "integer", Description: "Max processes to list."}
+	}
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "system_info",
+			Description: desc,
+			Parameters:  llm.JSONSchema{Type: "object", Properties: props},
+		},
+	}
+}
+
+func defReadMemory() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "read_memory",
+			Description: "Read your long-term memory (MEMORY.md) and today's notes. Use it to recall facts about the user or project.",
+			Parameters:  llm.JSONSchema{Type: "object", Properties: map[string]llm.JSONSchema{}},
+		},
+	}
+}
+
+func defWriteMemory() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "write_memory",
+			Description: "Save one durable fact to long-term memory (MEMORY.md), e.g. a preference or decision. Keep it short and self-contained.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"fact": {Type: "string"},
+				},
+				Required: []string{"fact"},
+			},
+		},
+	}
+}
+
+func defSetState() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "set_state",
+			Description: "Store a value for later in this conversation (e.g. a chosen file or a plan). It survives across turns; an empty value removes the key. Total state is limited to 16KB.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"key":   {Type: "string"},
+					"value": {Type: "string"},
+				},
+				Required: []string{"key", "value"},
+			},
+		},
+	}
+}
+
+func defGetState() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "get_state",
+			Description: "Read a value stored with set_state in this conversation.",
+			Parameters: llm.JSONSchema{
+				Type: "object",
+				Properties: map[string]llm.JSONSchema{
+					"key": {Type: "string"},
+				},
+				Required: []string{"key"},
+			},
+		},
+	}
+}
+
+func defListState() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        "list_state",
+			Description: "List the keys stored with set_state in this conversation, with their sizes.",
+			Parameters:  llm.JSONSchema{Type: "object", Properties: map[string]llm.JSONSchema{}},
+		},
+	}
+}
+
 func defMemorySearch() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Type: "function",
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// egressTransport returns an HTTP transport that refuses to dial hosts
+// outside allowHosts, or nil when allowHosts is empty. The check runs on
+// every connection, including redirects, so it holds even if a tool's own
+// URL policy is bypassed. Environment proxies are ignored while an allowlist
+// is active, since a proxy would hide the real destination from the dialer.
+func egressTransport(allowHosts []string) *http.Transport {
+	if len(allowHosts) == 0 {
+		return nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = egressDialContext(allowHosts, &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+	return t
+}
+
+func egressDialContext(allowHosts []string, d *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		if allowed, _ := allowHostByPolicy(host, allowHosts, nil); !allowed {
+			return nil, fmt.Errorf("egress blocked: %s is not in tools.egressAllowHosts", host)
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// httpClient returns a client with the given timeout that honors
+// r.EgressAllowHosts. The clients share one egress transport.
+func (r *Registry) httpClient(timeout time.Duration) *http.Client {
+	r.egressOnce.Do(func() { r.egress = egressTransport(r.EgressAllowHosts) })
+	c := &http.Client{Timeout: timeout}
+	if r.egress != nil {
+		c.Transport = r.egress
+	}
+	return c
+}
+
+func newEgressHTTPClient(timeout time.Duration, allowHosts []string) *http.Client {
+	c := &http.Client{Timeout: timeout}
+	if t := egressTransport(allowHosts); t != nil {
+		c.Transport = t
+	}
+	return c
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEgressAllowHosts_BlocksAtDial(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	args, _ := json.Marshal(map[string]any{"url": srv.URL})
+
+	// web_fetch's own domain policy allows everything; only egress blocks.
+	r := newTestRegistry()
+	r.EgressAllowHosts = []string{"example.com"}
+	out, err := r.Execute(context.Background(), Context{}, "web_fetch", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "egress blocked") || hits != 0 {
+		t.Fatalf("expected egress block, hits=%d out=%s", hits, out)
+	}
+
+	// The egress transport is built once per Registry.
+	r = newTestRegistry()
+	r.EgressAllowHosts = []string{"127.0.0.1"}
+	out, err = r.Execute(context.Background(), Context{}, "web_fetch", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 1 || strings.Contains(out, "egress blocked") {
+		t.Fatalf("expected request to pass, hits=%d out=%s", hits, out)
+	}
+}
+
+func TestRegistryHTTPClient_SharesEgressTransport(t *testing.T) {
+	r := newTestRegistry()
+	r.EgressAllowHosts = []string{"example.com"}
+	a, b := r.httpClient(time.Second), r.httpClient(time.Minute)
+	if a.Transport == nil || a.Transport != b.Transport {
+		t.Fatalf("clients do not share the egress transport: %v %v", a.Transport, b.Transport)
+	}
+	if a.Timeout != time.Second || b.Timeout != time.Minute {
+		t.Fatalf("timeouts=%s %s", a.Timeout, b.Timeout)
+	}
+}
+
+func TestEgressTransport_DisabledWhenEmpty(t *testing.T) {
+	if egressTransport(nil) != nil {
+		t.Fatalf("expected no custom transport without an allowlist")
+	}
+}
@@ -1,9 +1,11 @@
 package tools
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -148,3 +150,146 @@ func guardExecCommand(command string, workspaceDir string, restrict bool) string
 
 	return ""
 }
+
+// execDeniedArgs are arguments refused in exec allowlist mode even for
+// allowed programs, because they run arbitrary code, reach outside the
+// project, or destroy work: per-invocation git config and hooks, global
+// package installs, custom toolchains and package indexes.
+var execDeniedArgs = map[string][]string{
+	"git":    {"-c", "--config-env", "--exec-path", "--upload-pack", "--receive-pack", "-f", "--force", "--hard"},
+	"go":     {"-toolexec", "-exec"},
+	"npm":    {"-g", "--global", "--prefix", "--unsafe-perm", "--registry"},
+	"pnpm":   {"-g", "--global", "--registry"},
+	"yarn":   {"global", "--registry"},
+	"pip":    {"-i", "--index-url", "--extra-index-url", "--trusted-host", "--break-system-packages", "--user"},
+	"pip3":   {"-i", "--index-url", "--extra-index-url", "--trusted-host", "--break-system-packages", "--user"},
+	"cargo":  {"--config", "-Z", "--index", "--registry"},
+	"make":   {"--eval", "-E"},
+	"pytest": {"-p"},
+}
+
+// execDeniedSubcommands are subcommands refused in exec allowlist mode.
+// git config would persist the settings -c is refused for (core.sshCommand,
+// aliases running "!sh ...").
+var execDeniedSubcommands = map[string][]string{
+	"git": {"config"},
+}
+
+// execValueFlags are options that take the next argument as their value, so
+// it is not mistaken for the subcommand.
+var execValueFlags = map[string][]string{
+	"git": {"-C", "--git-dir", "--work-tree", "--namespace"},
+}
+
+// execAlwaysAllowed may lead a stage in allowlist mode without being listed.
+// cd only changes directory; its path is checked by guardExecCommand.
+var execAlwaysAllowed = []string{"cd"}
+
+// guardExecAllowlist checks command against the exec allowlist: each stage
+// of a pipeline or &&/|| chain must start with an allowed program, named
+// bare (no path or leading VAR=value), and must not pass one of its
+// execDeniedArgs or execDeniedSubcommands. Arguments are compared after
+// removing quotes and escapes the way sh does, so '-c' is still -c. It
+// assumes guardExecCommand already passed.
+func guardExecAllowlist(command string, allow []string) string {
+	for _, stage := range splitExecStages(command) {
+		fields, ok := shellFields(stage)
+		if !ok {
+			return "Error: Command blocked by exec allowlist (unterminated quote)"
+		}
+		if len(fields) == 0 {
+			return "Error: Command blocked by exec allowlist (empty pipeline stage)"
+		}
+		name := fields[0]
+		if !slices.Contains(allow, name) && !slices.Contains(execAlwaysAllowed, name) {
+			return fmt.Sprintf("Error: Command blocked by exec allowlist (%s is not in tools.exec.allowCommands)", name)
+		}
+		for _, arg := range fields[1:] {
+			for _, denied := range execDeniedArgs[name] {
+				if arg == denied || strings.HasPrefix(arg, denied+"=") {
+					return fmt.Sprintf("Error: Command blocked by exec allowlist (%s %s is not allowed)", name, denied)
+				}
+			}
+		}
+		if sub := execSubcommand(name, fields[1:]); slices.Contains(execDeniedSubcommands[name], sub) {
+			return fmt.Sprintf("Error: Command blocked by exec allowlist (%s %s is not allowed)", name, sub)
+		}
+	}
+	return ""
+}
+
+// execSubcommand returns the first argument that is not an option or the
+// value of one of name's execValueFlags.
+func execSubcommand(name string, args []string) string {
+	for i := 0; i < len(args); i++ {
+		if slices.Contains(execValueFlags[name], args[i]) {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			return args[i]
+		}
+	}
+	return ""
+}
+
+// shellFields splits s into words the way sh does for quotes and
+// backslashes: '...' is literal, "..." keeps everything but \ before
+// ", \, $ or `, and an unquoted backslash escapes the next byte. It reports
+// false for an unterminated quote or trailing backslash.
+func shellFields(s string) ([]string, bool) {
+	var fields []string
+	var cur strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, false
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, false
+			}
+			i++
+			cur.WriteByte(s[i])
+			inWord = true
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		fields = append(fields, cur.String())
+	}
+	return fields, true
+}
+
+// splitExecStages splits command at |, &&, and ||.
+func splitExecStages(command string) []string {
+	command = strings.NewReplacer("&&", "|", "||", "|").Replace(command)
+	return strings.Split(command, "|")
+}
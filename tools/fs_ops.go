@@ -1,9 +1,11 @@
 package tools
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -158,12 +160,118 @@ func (r *Registry) readFile(path string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	const max = 512 << 10
-	if len(b) > max {
-		b = b[:max]
-		return string(b) + "\n\n(truncated)", nil
+	out := truncateMode(string(b), readFileMaxBytes, r.TruncateMode)
+	if len(b) > readFileMaxBytes {
+		out += fmt.Sprintf("\n[file is %d bytes; use offset/length or startLine/endLine to read other parts]", len(b))
 	}
-	return string(b), nil
+	return out, nil
+}
+
+const readFileMaxBytes = 512 << 10
+
+// readRange selects part of a file: a byte window (Offset/Length) or a
+// 1-based inclusive line window (StartLine/EndLine).
+type readRange struct {
+	Offset    int64 `json:"offset"`
+	Length    int   `json:"length"`
+	StartLine int   `json:"startLine"`
+	EndLine   int   `json:"endLine"`
+}
+
+func (rg readRange) bytes() bool { return rg.Offset != 0 || rg.Length != 0 }
+func (rg readRange) lines() bool { return rg.StartLine != 0 || rg.EndLine != 0 }
+
+// readFileRange reads a window of path without loading the whole file. The
+// output starts with a header giving the window and the file's total size.
+func (r *Registry) readFileRange(path string, rg readRange) (string, error) {
+	if rg.bytes() && rg.lines() {
+		return "", errors.New("use either offset/length or startLine/endLine, not both")
+	}
+	if rg.Offset < 0 || rg.Length < 0 || rg.StartLine < 0 || rg.EndLine < 0 {
+		return "", errors.New("range values must not be negative")
+	}
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", abs)
+	}
+	size := info.Size()
+	if rg.lines() {
+		return readLineRange(f, rg.StartLine, rg.EndLine, size)
+	}
+
+	length := rg.Length
+	if length == 0 || length > readFileMaxBytes {
+		length = readFileMaxBytes
+	}
+	if rg.Offset >= size {
+		return fmt.Sprintf("[offset %d is past end of file; file is %d bytes]", rg.Offset, size), nil
+	}
+	buf := make([]byte, length)
+	n, err := f.ReadAt(buf, rg.Offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	end := rg.Offset + int64(n)
+	header := fmt.Sprintf("[bytes %d-%d of %d", rg.Offset, end, size)
+	if end < size {
+		header += fmt.Sprintf("; next offset %d", end)
+	}
+	return header + "]\n" + string(buf[:n]), nil
+}
+
+func readLineRange(f *os.File, start, end int, size int64) (string, error) {
+	if start == 0 {
+		start = 1
+	}
+	if end != 0 && end < start {
+		return "", errors.New("endLine must be >= startLine")
+	}
+	br := bufio.NewReader(f)
+	var sb strings.Builder
+	line, last, total := 0, 0, 0
+	capped := false
+	for {
+		s, err := br.ReadString('\n')
+		if s != "" {
+			line++
+			total = line
+			inRange := line >= start && (end == 0 || line <= end)
+			if inRange && !capped {
+				if sb.Len()+len(s) > readFileMaxBytes {
+					capped = true
+				} else {
+					sb.WriteString(s)
+					last = line
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", err
+		}
+	}
+	if start > total {
+		return fmt.Sprintf("[startLine %d is past end of file; file has %d lines, %d bytes]", start, total, size), nil
+	}
+	header := fmt.Sprintf("[lines %d-%d of %d; %d bytes", start, last, total, size)
+	if capped {
+		header += fmt.Sprintf("; output capped at %d bytes, continue from startLine %d", readFileMaxBytes, last+1)
+	}
+	return header + "]\n" + sb.String(), nil
 }
 
 func (r *Registry) writeFile(path, content string) (string, error) {
@@ -171,6 +279,9 @@ func (r *Registry) writeFile(path, content string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if err := r.ensureWriteAllowed(abs); err != nil {
+		return "", err
+	}
 	parent := filepath.Dir(abs)
 	if err := os.MkdirAll(parent, 0o755); err != nil {
 		return "", err
@@ -192,6 +303,9 @@ func (r *Registry) writeFile(path, content string) (string, error) {
 	if err := ensurePathAllowedByPolicy(target); err != nil {
 		return "", err
 	}
+	if err := r.ensureWriteAllowed(target); err != nil {
+		return "", err
+	}
 	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
 		return "", fmt.Errorf("refusing to write through symlink: %s", target)
 	}
@@ -201,11 +315,95 @@ func (r *Registry) writeFile(path, content string) (string, error) {
 	return fmt.Sprintf("wrote %d bytes to %s", len(content), target), nil
 }
 
+// validateWriteTarget checks that path can be written without touching the
+// filesystem. writeFile re-validates after creating parent directories.
+func (r *Registry) validateWriteTarget(path string) (string, error) {
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	if err := r.ensureWriteAllowed(abs); err != nil {
+		return "", err
+	}
+	if r.RestrictToWorkspace {
+		wsAbs, err := r.workspaceAbs()
+		if err != nil {
+			return "", err
+		}
+		existing := filepath.Dir(abs)
+		for {
+			if _, err := os.Lstat(existing); err == nil {
+				break
+			}
+			next := filepath.Dir(existing)
+			if next == existing {
+				break
+			}
+			existing = next
+		}
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err != nil {
+			return "", err
+		}
+		if !isSameOrChildPath(resolved, wsAbs) {
+			return "", fmt.Errorf("path is outside workspace: %s", resolved)
+		}
+	}
+	if info, err := os.Lstat(abs); err == nil {
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("refusing to write through symlink: %s", abs)
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("path is a directory: %s", abs)
+		}
+	}
+	return abs, nil
+}
+
+type fileWrite struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+func (r *Registry) writeFiles(files []fileWrite) (string, error) {
+	if len(files) == 0 {
+		return "", errors.New("files is empty")
+	}
+	seen := map[string]bool{}
+	for i, f := range files {
+		abs, err := r.validateWriteTarget(f.Path)
+		if err != nil {
+			return "", fmt.Errorf("files[%d] %s: %w (nothing written)", i, f.Path, err)
+		}
+		if seen[abs] {
+			return "", fmt.Errorf("files[%d] %s: duplicate path (nothing written)", i, f.Path)
+		}
+		seen[abs] = true
+	}
+
+	lines := make([]string, 0, len(files))
+	failed := 0
+	for _, f := range files {
+		out, err := r.writeFile(f.Path, f.Content)
+		if err != nil {
+			failed++
+			lines = append(lines, fmt.Sprintf("error: %s: %v", f.Path, err))
+			continue
+		}
+		lines = append(lines, out)
+	}
+	lines = append(lines, fmt.Sprintf("%d/%d files written", len(files)-failed, len(files)))
+	return strings.Join(lines, "\n"), nil
+}
+
 func (r *Registry) editFile(path string, startLine, endLine int, newText string) (string, error) {
 	abs, err := r.resolvePath(path)
 	if err != nil {
 		return "", err
 	}
+	if err := r.ensureWriteAllowed(abs); err != nil {
+		return "", err
+	}
 	b, err := os.ReadFile(abs)
 	if err != nil {
 		return "", err
@@ -255,6 +453,9 @@ func (r *Registry) editFileReplace(path, oldText, newText string) (string, error
 	if err != nil {
 		return "", err
 	}
+	if err := r.ensureWriteAllowed(abs); err != nil {
+		return "", err
+	}
 	if strings.TrimSpace(oldText) == "" {
 		return "", errors.New("old_text is empty")
 	}
@@ -277,18 +478,37 @@ func (r *Registry) editFileReplace(path, oldText, newText string) (string, error
 	return fmt.Sprintf("edited %s", abs), nil
 }
 
-func (r *Registry) listDir(path string, recursive bool, maxEntries int) (string, error) {
+type listDirResult struct {
+	Entries    []string `json:"entries"`
+	HasMore    bool     `json:"hasMore"`
+	NextOffset int      `json:"nextOffset,omitempty"`
+}
+
+func (r *Registry) listDir(path string, recursive bool, maxEntries, offset int) (string, error) {
 	if maxEntries <= 0 {
 		maxEntries = 200
 	}
+	offset = max(offset, 0)
 	abs, err := r.resolvePath(path)
 	if err != nil {
 		return "", err
 	}
-	var entries []string
+	res := listDirResult{Entries: []string{}}
+	seen := 0
+	// add reports whether listing should continue. It stops one entry past
+	// the page so hasMore is exact.
 	add := func(p string) bool {
-		entries = append(entries, p)
-		return len(entries) < maxEntries
+		seen++
+		if seen <= offset {
+			return true
+		}
+		if len(res.Entries) == maxEntries {
+			res.HasMore = true
+			res.NextOffset = offset + maxEntries
+			return false
+		}
+		res.Entries = append(res.Entries, p)
+		return true
 	}
 
 	if !recursive {
@@ -323,6 +543,6 @@ func (r *Registry) listDir(path string, recursive bool, maxEntries int) (string,
 		}
 	}
 
-	b, _ := json.Marshal(entries)
+	b, _ := json.Marshal(res)
 	return string(b), nil
 }
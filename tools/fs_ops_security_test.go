@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -93,3 +94,73 @@ func TestWriteFile_BlocksSymlinkTarget(t *testing.T) {
 		t.Fatalf("outside file was modified: %q", string(got))
 	}
 }
+
+func TestWriteFiles_WritesNothingOnValidationFailure(t *testing.T) {
+	ws := t.TempDir()
+	r := &Registry{
+		WorkspaceDir:        ws,
+		RestrictToWorkspace: true,
+	}
+	_, err := r.writeFiles([]fileWrite{
+		{Path: "ok.txt", Content: "ok"},
+		{Path: "../escape.txt", Content: "nope"},
+	})
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	if _, err := os.Stat(filepath.Join(ws, "ok.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected ok.txt not to be written, stat err=%v", err)
+	}
+}
+
+func TestWriteFiles_WritesAllFiles(t *testing.T) {
+	ws := t.TempDir()
+	r := &Registry{
+		WorkspaceDir:        ws,
+		RestrictToWorkspace: true,
+	}
+	out, err := r.writeFiles([]fileWrite{
+		{Path: "a.txt", Content: "a"},
+		{Path: "sub/b.txt", Content: "b"},
+	})
+	if err != nil {
+		t.Fatalf("writeFiles error: %v", err)
+	}
+	if !strings.Contains(out, "2/2 files written") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	got, err := os.ReadFile(filepath.Join(ws, "sub", "b.txt"))
+	if err != nil || string(got) != "b" {
+		t.Fatalf("sub/b.txt=%q err=%v", string(got), err)
+	}
+}
+
+func TestWriteDenyGlobs_BlocksMatchingPaths(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "go.sum"), []byte("x\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r := &Registry{
+		WorkspaceDir:        ws,
+		RestrictToWorkspace: true,
+		WriteDenyGlobs:      []string{".git/**", "**/*.lock", "go.sum"},
+	}
+
+	for _, p := range []string{".git/config", "web/yarn.lock", "yarn.lock", "sub/go.sum"} {
+		if _, err := r.writeFile(p, "x"); err == nil || !strings.Contains(err.Error(), "write policy") {
+			t.Fatalf("expected %s to be blocked, err=%v", p, err)
+		}
+	}
+	if _, err := r.editFileReplace("go.sum", "x", "y"); err == nil {
+		t.Fatalf("expected edit of go.sum to be blocked")
+	}
+	if _, err := r.writeFile("main.go", "package main\n"); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "web")); !os.IsNotExist(err) {
+		t.Fatalf("blocked write should not create parent dirs: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListDir_Paging(t *testing.T) {
+	ws := t.TempDir()
+	for i := range 5 {
+		if err := os.WriteFile(filepath.Join(ws, fmt.Sprintf("f%d.txt", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+
+	var got []string
+	offset := 0
+	for page := 0; ; page++ {
+		out, err := r.listDir(".", false, 2, offset)
+		if err != nil {
+			t.Fatalf("listDir: %v", err)
+		}
+		var res listDirResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		got = append(got, res.Entries...)
+		if !res.HasMore {
+			if page != 2 || res.NextOffset != 0 {
+				t.Fatalf("page=%d res=%+v", page, res)
+			}
+			break
+		}
+		if res.NextOffset != offset+2 {
+			t.Fatalf("nextOffset=%d", res.NextOffset)
+		}
+		offset = res.NextOffset
+	}
+	if fmt.Sprint(got) != "[f0.txt f1.txt f2.txt f3.txt f4.txt]" {
+		t.Fatalf("entries=%v", got)
+	}
+
+	// An exact fit must not report more.
+	out, _ := r.listDir(".", false, 5, 0)
+	if out != `{"entries":["f0.txt","f1.txt","f2.txt","f3.txt","f4.txt"],"hasMore":false}` {
+		t.Fatalf("out=%s", out)
+	}
+}
+
+func TestReadFile_Ranges(t *testing.T) {
+	ws := t.TempDir()
+	var sb strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&sb, "line %03d\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "big.log"), []byte(sb.String()), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+	run := func(args string) string {
+		t.Helper()
+		out, err := r.Execute(context.Background(), Context{}, "read_file", json.RawMessage(args))
+		if err != nil {
+			t.Fatalf("read_file %s: %v", args, err)
+		}
+		return out
+	}
+
+	if got := run(`{"path":"big.log","offset":9,"length":9}`); got != "[bytes 9-18 of 900; next offset 18]\nline 002\n" {
+		t.Fatalf("byte range=%q", got)
+	}
+	if got := run(`{"path":"big.log","startLine":99}`); got != "[lines 99-100 of 100; 900 bytes]\nline 099\nline 100\n" {
+		t.Fatalf("line range=%q", got)
+	}
+	if got := run(`{"path":"big.log","startLine":2,"endLine":3}`); got != "[lines 2-3 of 100; 900 bytes]\nline 002\nline 003\n" {
+		t.Fatalf("line range=%q", got)
+	}
+	if got := run(`{"path":"big.log","offset":5000}`); !strings.Contains(got, "past end of file") {
+		t.Fatalf("past end=%q", got)
+	}
+	if _, err := r.Execute(context.Background(), Context{}, "read_file", json.RawMessage(`{"path":"big.log","offset":1,"startLine":1}`)); err == nil {
+		t.Fatalf("expected error when mixing byte and line ranges")
+	}
+}
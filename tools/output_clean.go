@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ansiRe matches CSI sequences (colors, cursor moves), OSC sequences (titles,
+// hyperlinks), and other two-byte escapes.
+var ansiRe = regexp.MustCompile(`\x1b(?:\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// cleanToolOutput strips ANSI escapes, resolves carriage-return progress
+// redraws to their final state, trims trailing spaces, and collapses runs of
+// blank lines to one.
+func cleanToolOutput(s string) string {
+	if s == "" {
+		return s
+	}
+	s = ansiRe.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, line := range lines {
+		if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+			line = line[i+1:]
+		}
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
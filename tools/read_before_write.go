@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ReadSet records the files read or written during one turn, for
+// RequireReadBeforeWrite. Loops create one per turn and pass it in Context.
+type ReadSet struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func NewReadSet() *ReadSet {
+	return &ReadSet{paths: map[string]bool{}}
+}
+
+func (s *ReadSet) add(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths[path] = true
+}
+
+func (s *ReadSet) has(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths[path]
+}
+
+// fileToolPaths returns the files a read_file or editing call touches.
+func fileToolPaths(name string, args json.RawMessage) []string {
+	switch name {
+	case "read_file", "write_file", "edit_file", "json_patch":
+		var a struct {
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(args, &a) != nil || a.Path == "" {
+			return nil
+		}
+		return []string{a.Path}
+	case "write_files":
+		var a struct {
+			Files []fileWrite `json:"files"`
+		}
+		if json.Unmarshal(args, &a) != nil {
+			return nil
+		}
+		paths := make([]string, 0, len(a.Files))
+		for _, f := range a.Files {
+			paths = append(paths, f.Path)
+		}
+		return paths
+	}
+	return nil
+}
+
+// checkReadBeforeWrite refuses to change an existing file the model has not
+// read (or written) this turn. New files are always allowed.
+func (r *Registry) checkReadBeforeWrite(tctx Context, name string, args json.RawMessage) error {
+	if !r.RequireReadBeforeWrite || tctx.Reads == nil || name == "read_file" {
+		return nil
+	}
+	for _, p := range fileToolPaths(name, args) {
+		abs, err := r.resolvePath(p)
+		if err != nil {
+			// Let the tool report the bad path itself.
+			continue
+		}
+		if _, err := os.Stat(abs); err != nil {
+			continue
+		}
+		if !tctx.Reads.has(abs) {
+			return fmt.Errorf("%s exists and has not been read in this turn; read it with read_file first, then retry", p)
+		}
+	}
+	return nil
+}
+
+// recordReads marks the files of a successful read or write as seen.
+func (r *Registry) recordReads(tctx Context, name string, args json.RawMessage) {
+	if !r.RequireReadBeforeWrite || tctx.Reads == nil {
+		return
+	}
+	for _, p := range fileToolPaths(name, args) {
+		if abs, err := r.resolvePath(p); err == nil {
+			tctx.Reads.add(abs)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequireReadBeforeWrite(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RequireReadBeforeWrite: true}
+	tctx := Context{Reads: NewReadSet()}
+	run := func(tctx Context, name, args string) error {
+		_, err := r.Execute(context.Background(), tctx, name, json.RawMessage(args))
+		return err
+	}
+
+	err := run(tctx, "write_file", `{"path":"a.txt","content":"bye\n"}`)
+	if err == nil || !strings.Contains(err.Error(), "read it with read_file first") {
+		t.Fatalf("unread overwrite: %v", err)
+	}
+	if err := run(tctx, "edit_file", `{"path":"a.txt","old_text":"hello","new_text":"bye"}`); err == nil {
+		t.Fatal("unread edit should be refused")
+	}
+	if err := run(tctx, "write_files", `{"files":[{"path":"new.txt","content":"x"},{"path":"a.txt","content":"y"}]}`); err == nil {
+		t.Fatal("write_files touching an unread file should be refused")
+	}
+	if b, _ := os.ReadFile(filepath.Join(ws, "a.txt")); string(b) != "hello\n" {
+		t.Fatalf("refused write changed the file: %q", b)
+	}
+
+	if err := run(tctx, "write_file", `{"path":"new.txt","content":"fresh"}`); err != nil {
+		t.Fatalf("new file: %v", err)
+	}
+	if err := run(tctx, "edit_file", `{"path":"new.txt","old_text":"fresh","new_text":"edited"}`); err != nil {
+		t.Fatalf("edit after own write: %v", err)
+	}
+
+	if err := run(tctx, "read_file", `{"path":"a.txt"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(tctx, "edit_file", `{"path":"a.txt","old_text":"hello","new_text":"bye"}`); err != nil {
+		t.Fatalf("edit after read: %v", err)
+	}
+
+	if err := run(Context{Reads: NewReadSet()}, "write_file", `{"path":"a.txt","content":"z"}`); err == nil {
+		t.Fatal("reads from an earlier turn should not count")
+	}
+	if err := run(Context{}, "write_file", `{"path":"a.txt","content":"z"}`); err != nil {
+		t.Fatalf("no read tracking should skip the check: %v", err)
+	}
+	r.RequireReadBeforeWrite = false
+	if err := run(Context{Reads: NewReadSet()}, "write_file", `{"path":"a.txt","content":"z"}`); err != nil {
+		t.Fatalf("disabled: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -19,41 +20,219 @@ type Context struct {
 	Channel    string
 	ChatID     string
 	SessionKey string
+	// Profile limits the call to the tools of a Profiles entry. Empty allows
+	// every tool.
+	Profile string
+	// Intent is the user's message for the current turn. web_fetch uses it
+	// to focus summaries.
+	Intent string
+	// Reads tracks the files seen this turn for RequireReadBeforeWrite. Nil
+	// skips the check.
+	Reads *ReadSet
 }
 
 type Registry struct {
 	WorkspaceDir        string
 	RestrictToWorkspace bool
 	ExecTimeout         time.Duration
+	// Location is the timezone context_info reports. Nil uses the host zone.
+	Location *time.Location
+	// ToolTimeouts overrides the timeout of individual tools by name. Tools
+	// without an entry use their own field (ExecTimeout, WebFetchTimeout) or
+	// built-in default.
+	ToolTimeouts map[string]time.Duration
+	// WriteDenyGlobs blocks write/edit on matching paths (e.g. ".git/**", "*.lock").
+	WriteDenyGlobs []string
+	// RequireReadBeforeWrite refuses edits to existing files that were not
+	// read earlier in the turn (see Context.Reads).
+	RequireReadBeforeWrite bool
+	// RequireApproval lists tools that only run after Approve says yes.
+	RequireApproval []string
+	// Approve asks the user of tctx's chat to approve a tool call described
+	// by prompt. When it is nil, RequireApproval tools are refused.
+	Approve func(ctx context.Context, tctx Context, prompt string) (bool, error)
+	// TruncateMode controls which part of oversized exec/read_file/web_fetch
+	// output is kept: "head" (default), "tail", or "middle".
+	TruncateMode string
+	// SpillThreshold, when positive, saves tool results larger than this many
+	// bytes to a workspace file and returns a preview plus the path, so the
+	// model can page through it with read_file. exec output is then kept up
+	// to spillMaxBytes instead of being truncated at 64KB.
+	SpillThreshold int
+	// ExecCleanOutput strips ANSI escapes and redundant blank lines from exec
+	// output.
+	ExecCleanOutput bool
+	// ExecExtraEnv adds variables to the exec environment: "NAME" copies the
+	// gateway's value, "NAME=value" sets it.
+	ExecExtraEnv []string
+	// ExecAllowCommands, when non-empty, limits exec to pipelines whose
+	// stages start with these programs (see guardExecAllowlist).
+	ExecAllowCommands []string
+	// ScriptInterpreters lists the interpreters run_script may use (e.g.
+	// "python3", "bash"). run_script is only offered when it is non-empty.
+	ScriptInterpreters []string
+	// HelpCommands lists the commands command_help may show --help or man
+	// pages for. command_help is only offered when it is non-empty.
+	HelpCommands []string
 
 	// If non-empty, only these tools are exposed and executable.
 	// Unknown tool names are ignored.
 	AllowTools []string
+	// SafeMode hides and refuses every tool that can modify files, run
+	// commands, or schedule work (see mutatingTools).
+	SafeMode bool
+	// Profiles names tool subsets selectable per call (see DefinitionsFor
+	// and Context.Profile). They narrow AllowTools and SafeMode, never widen.
+	Profiles map[string][]string
 
-	BraveAPIKey             string
-	WebFetchAllowedDomains  []string
-	WebFetchBlockedDomains  []string
+	// EgressAllowHosts, when non-empty, limits every network tool (web_fetch,
+	// web_search) to these hosts at the transport level. Patterns follow
+	// tools.web.allowedDomains: "example.com" also matches subdomains.
+	EgressAllowHosts []string
+
+	BraveAPIKey            string
+	WebFetchAllowedDomains []string
+	WebFetchBlockedDomains []string
+	// WebSearchBlockedDomains drops web_search results on these hosts, in
+	// addition to WebFetchBlockedDomains.
+	WebSearchBlockedDomains []string
 	WebFetchMaxResponse     int64
 	WebFetchTimeout         time.Duration
-	Outbound                func(ctx context.Context, msg bus.OutboundMessage) error
+	// WebFetchRetries is how many times web_fetch retries after a transient
+	// network error (see transientNetError). 0 disables retries.
+	WebFetchRetries int
+	// WeatherProvider, when set, enables get_weather: "open-meteo" or
+	// "openweathermap" (which needs WeatherAPIKey). WeatherUnits is
+	// "metric" (default) or "imperial".
+	WeatherProvider string
+	WeatherAPIKey   string
+	WeatherUnits    string
+	// Audit, when set, logs every Execute call with redacted arguments.
+	Audit *AuditLog
+	// SystemInfo enables system_info: read-only CPU, memory, and disk usage.
+	// SystemInfoProcesses also lets it list the top processes, at most
+	// SystemInfoMaxProcesses (default 20) of them.
+	SystemInfo             bool
+	SystemInfoProcesses    bool
+	SystemInfoMaxProcesses int
+	// GitHub enables the github tool against GitHubAPIURL (default
+	// https://api.github.com), sending GitHubToken when set.
+	GitHub       bool
+	GitHubToken  string
+	GitHubAPIURL string
+	// weatherBaseURLs overrides provider endpoints in tests.
+	weatherBaseURLs map[string]string
+	Outbound        func(ctx context.Context, msg bus.OutboundMessage) error
+	// MessageAllowedTargets limits message destinations to these
+	// "channel:chat_id" or "channel:*" entries. Empty allows any.
+	MessageAllowedTargets   []string
 	Spawn                   func(ctx context.Context, task, label, originChannel, originChatID string) (string, error)
 	Cron                    *cron.Service
 	ReadSkill               func(name string) (string, bool)
 	SkillRegistry           SkillRegistry
 	SkillSearchDefaultLimit int
 	MemorySearch            memory.SearchManager
+	// MemoryStore, when set, enables read_memory; MemoryWrite also enables
+	// write_memory.
+	MemoryStore *memory.Store
+	MemoryWrite bool
+	// Summarize, when set, enables summarize_file. It summarizes text
+	// following instructions, usually with a cheaper model.
+	Summarize func(ctx context.Context, text, instructions string) (string, error)
+	// WebFetchSummarizeOver, when > 0 and Summarize is set, summarizes
+	// web_fetch text longer than this many characters unless raw is set.
+	WebFetchSummarizeOver int
+	// SessionState, when set, enables set_state, get_state and list_state.
+	// It returns the metadata of the session with the given key.
+	SessionState func(sessionKey string) (MetaStore, error)
 
 	skillInstallMu sync.Mutex
+	// egressOnce builds egress, the transport shared by every httpClient
+	// while EgressAllowHosts is set, so its connections are reused.
+	egressOnce sync.Once
+	egress     *http.Transport
+}
+
+// toolTimeout returns ToolTimeouts[name] when set, otherwise fallback.
+func (r *Registry) toolTimeout(name string, fallback time.Duration) time.Duration {
+	if d := r.ToolTimeouts[name]; d > 0 {
+		return d
+	}
+	return fallback
 }
 
 func (r *Registry) Definitions() []llm.ToolDefinition {
+	defs := r.configuredDefinitions()
+	if len(r.AllowTools) == 0 && !r.SafeMode {
+		return defs
+	}
+	out := make([]llm.ToolDefinition, 0, len(defs))
+	for _, d := range defs {
+		name := strings.TrimSpace(d.Function.Name)
+		if name != "" && r.allowed(name) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// DefinitionsFor returns Definitions limited to the named profile. An empty
+// or unknown profile returns every allowed tool.
+func (r *Registry) DefinitionsFor(profile string) []llm.ToolDefinition {
+	defs := r.Definitions()
+	if _, ok := r.Profiles[profile]; !ok {
+		return defs
+	}
+	out := make([]llm.ToolDefinition, 0, len(defs))
+	for _, d := range defs {
+		if r.inProfile(profile, d.Function.Name) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (r *Registry) inProfile(profile, name string) bool {
+	tools, ok := r.Profiles[profile]
+	if !ok || name == "list_tools" {
+		return true
+	}
+	for _, t := range tools {
+		if strings.TrimSpace(t) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configuredDefinitions returns every tool whose dependencies are configured,
+// before AllowTools and SafeMode filtering.
+func (r *Registry) configuredDefinitions() []llm.ToolDefinition {
 	defs := []llm.ToolDefinition{
 		defReadFile(),
 		defWriteFile(),
+		defWriteFiles(),
 		defEditFile(),
+		defReplaceInFiles(),
+		defJSONPatch(),
 		defListDir(),
+		defGlob(),
+		defTree(),
+		defDiff(),
+		defFileInfo(),
+		defValidateConfig(),
+		defHash(),
+		defBase64File(),
 		defExec(),
 		defWebFetch(),
+		defListTools(),
+		defContextInfo(),
+	}
+	if len(r.ScriptInterpreters) > 0 {
+		defs = append(defs, defRunScript(r.ScriptInterpreters))
+	}
+	if len(r.HelpCommands) > 0 {
+		defs = append(defs, defCommandHelp(r.HelpCommands))
 	}
 	if r.ReadSkill != nil {
 		defs = append(defs, defReadSkill())
@@ -64,8 +243,17 @@ func (r *Registry) Definitions() []llm.ToolDefinition {
 	if strings.TrimSpace(r.BraveAPIKey) != "" {
 		defs = append(defs, defWebSearch())
 	}
+	if r.WeatherProvider != "" {
+		defs = append(defs, defGetWeather())
+	}
+	if r.SystemInfo {
+		defs = append(defs, defSystemInfo(r.SystemInfoProcesses))
+	}
+	if r.GitHub {
+		defs = append(defs, defGitHub())
+	}
 	if r.Outbound != nil {
-		defs = append(defs, defMessage())
+		defs = append(defs, defMessage(r.MessageAllowedTargets), defSendFile())
 	}
 	if r.Spawn != nil {
 		defs = append(defs, defSpawn())
@@ -73,35 +261,65 @@ func (r *Registry) Definitions() []llm.ToolDefinition {
 	if r.Cron != nil {
 		defs = append(defs, defCron())
 	}
+	if r.MemoryStore != nil {
+		defs = append(defs, defReadMemory())
+		if r.MemoryWrite {
+			defs = append(defs, defWriteMemory())
+		}
+	}
 	if r.MemorySearch != nil {
 		defs = append(defs, defMemorySearch(), defMemoryGet())
 	}
-	if len(r.AllowTools) == 0 {
-		return defs
+	if r.Summarize != nil {
+		defs = append(defs, defSummarizeFile())
 	}
-	allow := r.allowSet()
-	out := make([]llm.ToolDefinition, 0, len(defs))
-	for _, d := range defs {
-		name := strings.TrimSpace(d.Function.Name)
-		if name != "" && allow[name] {
-			out = append(out, d)
-		}
+	if r.SessionState != nil {
+		defs = append(defs, defSetState(), defGetState(), defListState())
 	}
-	return out
+	return defs
 }
 
-func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args json.RawMessage) (string, error) {
+func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args json.RawMessage) (_ string, err error) {
+	if r.Audit != nil {
+		start := time.Now()
+		defer func() { r.Audit.record(tctx, name, args, start, err) }()
+	}
+	if err := r.checkReadBeforeWrite(tctx, name, args); err != nil {
+		return "", err
+	}
+	if err := r.checkApproval(ctx, tctx, name, args); err != nil {
+		return "", err
+	}
+	out, err := r.execute(ctx, tctx, name, args)
+	if err != nil {
+		return out, err
+	}
+	r.recordReads(tctx, name, args)
+	return r.spillResult(name, out), nil
+}
+
+func (r *Registry) execute(ctx context.Context, tctx Context, name string, args json.RawMessage) (string, error) {
 	if !r.allowed(name) {
 		return "", fmt.Errorf("tool disabled: %s", name)
 	}
+	if !r.inProfile(tctx.Profile, name) {
+		return "", fmt.Errorf("tool %s is not in the %q tool profile", name, tctx.Profile)
+	}
+	if err := r.validateToolArgs(name, args); err != nil {
+		return "", err
+	}
 	switch name {
 	case "read_file":
 		var a struct {
 			Path string `json:"path"`
+			readRange
 		}
 		if err := json.Unmarshal(args, &a); err != nil {
 			return "", err
 		}
+		if a.bytes() || a.lines() {
+			return r.readFileRange(a.Path, a.readRange)
+		}
 		return r.readFile(a.Path)
 	case "write_file":
 		var a struct {
@@ -112,6 +330,14 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			return "", err
 		}
 		return r.writeFile(a.Path, a.Content)
+	case "write_files":
+		var a struct {
+			Files []fileWrite `json:"files"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.writeFiles(a.Files)
 	case "edit_file":
 		var raw map[string]json.RawMessage
 		if err := json.Unmarshal(args, &raw); err != nil {
@@ -141,16 +367,107 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			return "", err
 		}
 		return r.editFileReplace(a.Path, a.OldText, a.NewText)
+	case "replace_in_files":
+		var a struct {
+			Glob   string `json:"glob"`
+			Old    string `json:"old"`
+			New    string `json:"new"`
+			Regex  bool   `json:"regex"`
+			DryRun bool   `json:"dry_run"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		var reads *ReadSet
+		if r.RequireReadBeforeWrite {
+			reads = tctx.Reads
+		}
+		return r.replaceInFiles(a.Glob, a.Old, a.New, a.Regex, a.DryRun, reads)
+	case "json_patch":
+		var a struct {
+			Path  string        `json:"path"`
+			Patch []jsonPatchOp `json:"patch"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.jsonPatch(a.Path, a.Patch)
 	case "list_dir":
 		var a struct {
 			Path       string `json:"path"`
 			Recursive  bool   `json:"recursive"`
 			MaxEntries int    `json:"maxEntries"`
+			Offset     int    `json:"offset"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.listDir(a.Path, a.Recursive, a.MaxEntries, a.Offset)
+	case "glob":
+		var a struct {
+			Pattern    string `json:"pattern"`
+			Path       string `json:"path"`
+			MaxEntries int    `json:"maxEntries"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.glob(a.Pattern, a.Path, a.MaxEntries)
+	case "tree":
+		var a struct {
+			Path       string `json:"path"`
+			Depth      int    `json:"depth"`
+			MaxEntries int    `json:"maxEntries"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.tree(a.Path, a.Depth, a.MaxEntries)
+	case "diff":
+		var a struct {
+			Path      string  `json:"path"`
+			OtherPath string  `json:"otherPath"`
+			Text      *string `json:"text"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.diff(a.Path, a.OtherPath, a.Text)
+	case "file_info":
+		var a struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.fileInfo(a.Path)
+	case "validate_config":
+		var a struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.validateConfig(a.Path, a.Type)
+	case "hash":
+		var a struct {
+			Path      string  `json:"path"`
+			Text      *string `json:"text"`
+			Algorithm string  `json:"algorithm"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.hashDigest(a.Path, a.Text, a.Algorithm)
+	case "base64_file":
+		var a struct {
+			Path string `json:"path"`
 		}
 		if err := json.Unmarshal(args, &a); err != nil {
 			return "", err
 		}
-		return r.listDir(a.Path, a.Recursive, a.MaxEntries)
+		return r.base64File(a.Path)
 	case "exec":
 		var a struct {
 			Command string `json:"command"`
@@ -159,6 +476,26 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			return "", err
 		}
 		return r.exec(ctx, a.Command)
+	case "run_script":
+		var a struct {
+			Interpreter string   `json:"interpreter"`
+			Script      string   `json:"script"`
+			Args        []string `json:"args"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.runScript(ctx, a.Interpreter, a.Script, a.Args)
+	case "command_help":
+		var a struct {
+			Command     string   `json:"command"`
+			Subcommands []string `json:"subcommands"`
+			Source      string   `json:"source"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.commandHelp(ctx, a.Command, a.Subcommands, a.Source)
 	case "read_skill":
 		var a struct {
 			Name string `json:"name"`
@@ -193,11 +530,52 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			ExtractMode string            `json:"extractMode"`
 			MaxChars    int               `json:"maxChars"`
 			Headers     map[string]string `json:"headers"`
+			Raw         bool              `json:"raw"`
 		}
 		if err := json.Unmarshal(args, &a); err != nil {
 			return "", err
 		}
-		return r.webFetch(ctx, a.URL, a.ExtractMode, a.MaxChars, a.Headers)
+		out, err := r.webFetch(ctx, a.URL, a.ExtractMode, a.MaxChars, a.Headers)
+		if err != nil || a.Raw {
+			return out, err
+		}
+		return r.summarizeWebFetch(ctx, out, tctx.Intent), nil
+	case "get_weather":
+		var a struct {
+			Location string `json:"location"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.getWeather(ctx, a.Location)
+	case "github":
+		if !r.GitHub {
+			return "", fmt.Errorf("github is disabled (tools.github.enabled)")
+		}
+		var a struct {
+			Action string `json:"action"`
+			Repo   string `json:"repo"`
+			Path   string `json:"path"`
+			Ref    string `json:"ref"`
+			Number int    `json:"number"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.github(ctx, a.Action, a.Repo, a.Path, a.Ref, a.Number)
+	case "system_info":
+		if !r.SystemInfo {
+			return "", fmt.Errorf("system_info is disabled (tools.systemInfo.enabled)")
+		}
+		var a struct {
+			Processes bool   `json:"processes"`
+			Sort      string `json:"sort"`
+			Limit     int    `json:"limit"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.systemInfo(ctx, a.Processes, a.Sort, a.Limit)
 	case "web_search":
 		var a struct {
 			Query string `json:"query"`
@@ -212,12 +590,16 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			Content string `json:"content"`
 			Channel string `json:"channel"`
 			ChatID  string `json:"chat_id"`
+			To      string `json:"to"`
 		}
 		if err := json.Unmarshal(args, &a); err != nil {
 			return "", err
 		}
 		ch := strings.TrimSpace(a.Channel)
 		cid := strings.TrimSpace(a.ChatID)
+		if cid == "" {
+			cid = strings.TrimSpace(a.To)
+		}
 		if ch == "" || cid == "" {
 			return "", errors.New("message requires explicit channel and chat_id")
 		}
@@ -228,6 +610,17 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			}
 		}
 		return r.message(ctx, ch, cid, a.Content)
+	case "send_file":
+		var a struct {
+			Path    string `json:"path"`
+			Caption string `json:"caption"`
+			Channel string `json:"channel"`
+			ChatID  string `json:"chat_id"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.sendFile(ctx, tctx, a.Path, a.Caption, a.Channel, a.ChatID)
 	case "spawn":
 		var a struct {
 			Task  string `json:"task"`
@@ -259,6 +652,16 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			return "", err
 		}
 		return r.memorySearch(ctx, a.Query, a.MaxResults, a.MinScore)
+	case "read_memory":
+		return r.readMemory()
+	case "write_memory":
+		var a struct {
+			Fact string `json:"fact"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.writeMemory(a.Fact)
 	case "memory_get":
 		var a struct {
 			Path  string `json:"path"`
@@ -269,12 +672,68 @@ func (r *Registry) Execute(ctx context.Context, tctx Context, name string, args
 			return "", err
 		}
 		return r.memoryGet(a.Path, a.From, a.Lines)
+	case "summarize_file":
+		var a struct {
+			Path  string `json:"path"`
+			Focus string `json:"focus"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		return r.summarizeFile(ctx, a.Path, a.Focus)
+	case "set_state", "get_state":
+		var a struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", err
+		}
+		if name == "get_state" {
+			return r.getState(tctx, a.Key)
+		}
+		return r.setState(tctx, a.Key, a.Value)
+	case "list_state":
+		return r.listState(tctx)
+	case "list_tools":
+		return r.listTools(tctx.Profile)
+	case "context_info":
+		return r.contextInfo(tctx)
 	default:
 		return "", fmt.Errorf("unknown tool: %s", name)
 	}
 }
 
+// mutatingTools are removed in safe mode.
+var mutatingTools = map[string]bool{
+	"write_file":       true,
+	"write_files":      true,
+	"edit_file":        true,
+	"replace_in_files": true,
+	"json_patch":       true,
+	"exec":             true,
+	"run_script":       true,
+	"command_help":     true,
+	"install_skill":    true,
+	"spawn":            true,
+	"cron":             true,
+	"write_memory":     true,
+}
+
+// HasSideEffects reports whether a call to name can change files, run
+// commands, schedule work, send messages, or change session state.
+func HasSideEffects(name string) bool {
+	return mutatingTools[name] || name == "message" || name == "send_file" || name == "set_state"
+}
+
 func (r *Registry) allowed(name string) bool {
+	// list_tools is read-only introspection and is never gated.
+	if name == "list_tools" {
+		return true
+	}
+	if r.SafeMode && mutatingTools[name] {
+		return false
+	}
 	if len(r.AllowTools) == 0 {
 		return true
 	}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// spillDirName holds oversized tool results inside the workspace.
+	spillDirName = ".tool-results"
+	// spillMaxBytes caps what is written to a spill file.
+	spillMaxBytes = 8 << 20
+	// spillPreviewBytes is how much of a spilled result goes to the model.
+	spillPreviewBytes = 2 << 10
+	// spillTTL is how long spill files are kept.
+	spillTTL = 24 * time.Hour
+
+	execMaxOutputBytes = 64 << 10
+)
+
+var spillSeq atomic.Uint64
+
+// noSpillTools return results that are already windows of a file, so
+// spilling them would only send the model in a circle.
+var noSpillTools = map[string]bool{
+	"read_file":  true,
+	"list_tools": true,
+}
+
+func (r *Registry) execMaxOutput() int {
+	if r.SpillThreshold > 0 {
+		return spillMaxBytes
+	}
+	return execMaxOutputBytes
+}
+
+// spillResult replaces an oversized result with a preview and the path of a
+// workspace file holding the full text. It returns out unchanged when
+// spilling is off, the result is small, or the file cannot be written.
+func (r *Registry) spillResult(name, out string) string {
+	if r.SpillThreshold <= 0 || len(out) <= r.SpillThreshold || noSpillTools[name] {
+		return out
+	}
+	dir := filepath.Join(r.WorkspaceDir, spillDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return out
+	}
+	pruneSpillFiles(dir, time.Now())
+	file := fmt.Sprintf("%s-%d-%d.txt", name, time.Now().Unix(), spillSeq.Add(1))
+	full := truncate(out, spillMaxBytes)
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(full), 0o644); err != nil {
+		return out
+	}
+
+	preview := full[:min(spillPreviewBytes, r.SpillThreshold/2, len(full))]
+	for !utf8.ValidString(preview) {
+		preview = preview[:len(preview)-1]
+	}
+	rel := filepath.ToSlash(filepath.Join(spillDirName, file))
+	return fmt.Sprintf("Result too large (%d bytes, %d lines); saved to %s.\nRead the rest with read_file(path=%q, startLine, endLine).\n\nFirst %d bytes:\n%s",
+		len(full), strings.Count(full, "\n")+1, rel, rel, len(preview), preview)
+}
+
+func pruneSpillFiles(dir string, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		if now.Sub(info.ModTime()) > spillTTL {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
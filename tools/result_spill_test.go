@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpillResult(t *testing.T) {
+	ws := t.TempDir()
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true, SpillThreshold: 1 << 10}
+
+	if got := r.spillResult("exec", "small"); got != "small" {
+		t.Fatalf("small result changed: %q", got)
+	}
+	big := strings.Repeat("line of output\n", 500)
+	if got := r.spillResult("read_file", big); got != big {
+		t.Fatal("read_file results must not spill")
+	}
+
+	got := r.spillResult("exec", big)
+	if len(got) > 1<<10 {
+		t.Fatalf("spilled reply is %d bytes", len(got))
+	}
+	m := regexp.MustCompile(`saved to (\S+)\.`).FindStringSubmatch(got)
+	if m == nil || !strings.HasPrefix(m[1], spillDirName+"/exec-") {
+		t.Fatalf("reply=%q", got)
+	}
+	// The model can page through the file with read_file.
+	out, err := r.Execute(context.Background(), Context{}, "read_file", json.RawMessage(`{"path":"`+m[1]+`","startLine":499,"endLine":500}`))
+	if err != nil || !strings.Contains(out, "line of output") {
+		t.Fatalf("read_file: out=%q err=%v", out, err)
+	}
+	b, err := os.ReadFile(filepath.Join(ws, m[1]))
+	if err != nil || string(b) != big {
+		t.Fatalf("spill file mismatch: %d bytes err=%v", len(b), err)
+	}
+}
+
+func TestPruneSpillFiles(t *testing.T) {
+	dir := t.TempDir()
+	old, fresh := filepath.Join(dir, "old.txt"), filepath.Join(dir, "fresh.txt")
+	for _, p := range []string{old, fresh} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-2 * spillTTL)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+	pruneSpillFiles(dir, time.Now())
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatal("old spill file kept")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatal("fresh spill file removed")
+	}
+}
@@ -31,6 +31,8 @@ type ClawHubRegistryConfig struct {
 	TimeoutSec       int
 	MaxZipBytes      int64
 	MaxResponseBytes int64
+	// EgressAllowHosts limits connections to these hosts (see Registry.EgressAllowHosts).
+	EgressAllowHosts []string
 }
 
 type ClawHubRegistry struct {
@@ -82,9 +84,7 @@ func NewClawHubRegistry(cfg ClawHubRegistryConfig) *ClawHubRegistry {
 		downloadPath:     downloadPath,
 		maxZipBytes:      maxZipBytes,
 		maxResponseBytes: maxResponseBytes,
-		client: &http.Client{
-			Timeout: time.Duration(timeoutSec) * time.Second,
-		},
+		client:           newEgressHTTPClient(time.Duration(timeoutSec)*time.Second, cfg.EgressAllowHosts),
 	}
 }
 
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultCommandHelpTimeout = 10 * time.Second
+
+var (
+	// helpWordRe limits subcommands to plain words so nothing reaches the
+	// command that could be read as a path, flag, or shell syntax.
+	helpWordRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	// overstrikeRe matches "x\bx" bold and "_\bx" underline sequences that
+	// some man setups emit even when output is not a terminal.
+	overstrikeRe = regexp.MustCompile(`.\x08`)
+)
+
+// commandHelp runs "<command> [subcommands...] --help", or "man -P cat
+// <command>[-<subcommand>]" when source is "man", for a command in
+// r.HelpCommands. It goes through the exec environment with a short timeout.
+func (r *Registry) commandHelp(ctx context.Context, command string, subcommands []string, source string) (string, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", errors.New("command is empty")
+	}
+	if !slices.Contains(r.HelpCommands, command) {
+		return "", fmt.Errorf("command %q is not allowed (allowed: %s)", command, strings.Join(r.HelpCommands, ", "))
+	}
+	for _, w := range subcommands {
+		if !helpWordRe.MatchString(w) {
+			return "", fmt.Errorf("invalid subcommand %q", w)
+		}
+	}
+
+	name, args := command, append(slices.Clone(subcommands), "--help")
+	if source == "man" {
+		page := strings.Join(append([]string{command}, subcommands...), "-")
+		name, args = "man", []string{"-P", "cat", page}
+	}
+	if msg := guardExecCommand(name+" "+strings.Join(args, " "), r.WorkspaceDir, r.RestrictToWorkspace); msg != "" {
+		return msg, nil
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("command not found: %s", name)
+	}
+	out := r.runExecCmd(ctx, r.toolTimeout("command_help", defaultCommandHelpTimeout), bin, args...)
+	return overstrikeRe.ReplaceAllString(out, ""), nil
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandHelp(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"usage: fakecli $*\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "fakecli"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	r := &Registry{WorkspaceDir: t.TempDir(), HelpCommands: []string{"fakecli"}}
+	if !hasTool(r, "command_help") {
+		t.Fatal("command_help should be offered when helpCommands is set")
+	}
+	out, err := r.Execute(context.Background(), Context{}, "command_help", json.RawMessage(`{"command":"fakecli","subcommands":["remote","add"]}`))
+	if err != nil {
+		t.Fatalf("command_help: %v", err)
+	}
+	if !strings.Contains(out, "usage: fakecli remote add --help") {
+		t.Fatalf("out=%q", out)
+	}
+
+	if _, err := r.commandHelp(context.Background(), "sh", nil, ""); err == nil {
+		t.Fatal("expected error for command outside the allowlist")
+	}
+	if _, err := r.commandHelp(context.Background(), "fakecli", []string{"--output=/etc/passwd"}, ""); err == nil {
+		t.Fatal("expected error for flag-like subcommand")
+	}
+	if hasTool(&Registry{}, "command_help") {
+		t.Fatal("command_help should be hidden without helpCommands")
+	}
+	if hasTool(&Registry{HelpCommands: []string{"fakecli"}, SafeMode: true}, "command_help") {
+		t.Fatal("command_help should be hidden in safe mode")
+	}
+}
+
+func hasTool(r *Registry, name string) bool {
+	for _, d := range r.Definitions() {
+		if d.Function.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FormatCurrentTime renders now for the system prompt, e.g.
+// "2026-03-02 09:30 (Mon) Asia/Tokyo, UTC+09:00".
+func FormatCurrentTime(now time.Time) string {
+	return now.Format("2006-01-02 15:04 (Mon)") + " " + zoneLabel(now) + ", UTC" + now.Format("-07:00")
+}
+
+// zoneLabel prefers the IANA name and falls back to the zone abbreviation
+// for the host zone, whose Location is just "Local".
+func zoneLabel(now time.Time) string {
+	if name := now.Location().String(); name != "Local" && name != "" {
+		return name
+	}
+	abbr, _ := now.Zone()
+	return abbr
+}
+
+func (r *Registry) location() *time.Location {
+	if r.Location != nil {
+		return r.Location
+	}
+	return time.Local
+}
+
+// contextInfo reports the current time in the configured timezone and the
+// conversation the tool was called from.
+func (r *Registry) contextInfo(tctx Context) (string, error) {
+	now := time.Now().In(r.location())
+	out := struct {
+		Now       string `json:"now"`
+		Date      string `json:"date"`
+		Weekday   string `json:"weekday"`
+		Time      string `json:"time"`
+		Timezone  string `json:"timezone"`
+		UTCOffset string `json:"utcOffset"`
+		Unix      int64  `json:"unix"`
+		Channel   string `json:"channel,omitempty"`
+		ChatID    string `json:"chatId,omitempty"`
+		Workspace string `json:"workspace,omitempty"`
+	}{
+		Now:       now.Format(time.RFC3339),
+		Date:      now.Format("2006-01-02"),
+		Weekday:   now.Weekday().String(),
+		Time:      now.Format("15:04:05"),
+		Timezone:  zoneLabel(now),
+		UTCOffset: now.Format("-07:00"),
+		Unix:      now.Unix(),
+		Channel:   tctx.Channel,
+		ChatID:    tctx.ChatID,
+		Workspace: r.WorkspaceDir,
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
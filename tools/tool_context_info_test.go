@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContextInfo_UsesConfiguredLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+9", 9*60*60)
+	r := &Registry{WorkspaceDir: t.TempDir(), Location: loc}
+	out, err := r.Execute(context.Background(), Context{Channel: "telegram", ChatID: "42"}, "context_info", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("context_info: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", out, err)
+	}
+	if got["utcOffset"] != "+09:00" || got["timezone"] != "UTC+9" || got["channel"] != "telegram" || got["chatId"] != "42" {
+		t.Fatalf("unexpected info: %s", out)
+	}
+	now, err := time.Parse(time.RFC3339, got["now"].(string))
+	if err != nil || time.Since(now) > time.Minute {
+		t.Fatalf("now=%v err=%v", got["now"], err)
+	}
+}
+
+func TestFormatCurrentTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	got := FormatCurrentTime(time.Date(2026, 3, 2, 9, 30, 0, 0, tokyo))
+	if got != "2026-03-02 09:30 (Mon) Asia/Tokyo, UTC+09:00" {
+		t.Fatalf("got %q", got)
+	}
+	if local := FormatCurrentTime(time.Now()); strings.Contains(local, "Local") {
+		t.Fatalf("host zone should use its abbreviation: %q", local)
+	}
+}
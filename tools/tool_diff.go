@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	diffContextLines = 3
+	// diffMaxEdits bounds the Myers search; larger differences are reported
+	// as a single whole-file hunk.
+	diffMaxEdits  = 2000
+	diffMaxOutput = 256 << 10
+)
+
+// diff returns a unified diff from path to otherPath, or from path to text
+// when otherPath is empty.
+func (r *Registry) diff(path, otherPath string, text *string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("path is empty")
+	}
+	if (otherPath == "") == (text == nil) {
+		return "", errors.New("provide exactly one of otherPath or text")
+	}
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	before, err := os.ReadFile(abs)
+	if err != nil {
+		return "", err
+	}
+
+	toName := "b/" + path + " (proposed)"
+	var after string
+	if text != nil {
+		after = *text
+	} else {
+		otherAbs, err := r.resolvePath(otherPath)
+		if err != nil {
+			return "", err
+		}
+		b, err := os.ReadFile(otherAbs)
+		if err != nil {
+			return "", err
+		}
+		after = string(b)
+		toName = "b/" + otherPath
+	}
+
+	out := unifiedDiff("a/"+path, toName, string(before), after)
+	if out == "" {
+		return "No differences.", nil
+	}
+	return truncateMode(out, diffMaxOutput, r.TruncateMode), nil
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedDiff renders a unified diff of a and b, or "" when they are equal.
+func unifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitDiffLines(a), splitDiffLines(b))
+
+	// aPos[i]/bPos[i] are the number of a/b lines before ops[i].
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-diffContextLines, 0)
+		last := i
+		j := i
+		for ; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			} else if j-last > 2*diffContextLines {
+				break
+			}
+		}
+		end := min(last+diffContextLines+1, len(ops))
+
+		aLen, bLen := aPos[end]-aPos[start], bPos[end]-bPos[start]
+		aStart, bStart := aPos[start]+1, bPos[start]+1
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// diffLines computes a line edit script with the Myers O(ND) algorithm.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := n + m
+	off := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] holds v[-d-1..d+1] as it was before step d.
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		if d > diffMaxEdits {
+			return replaceAllOps(a, b)
+		}
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	var rev []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		vv := trace[d]
+		at := func(k int) int { return vv[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, diffOp{kind: ' ', line: a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			rev = append(rev, diffOp{kind: '+', line: b[y-1]})
+			y--
+		} else {
+			rev = append(rev, diffOp{kind: '-', line: a[x-1]})
+			x--
+		}
+	}
+	slices.Reverse(rev)
+	return rev
+}
+
+func replaceAllOps(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a {
+		ops = append(ops, diffOp{kind: '-', line: l})
+	}
+	for _, l := range b {
+		ops = append(ops, diffOp{kind: '+', line: l})
+	}
+	return ops
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff_Hunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	got := unifiedDiff("a/x", "b/x", a, b)
+	want := "--- a/x\n+++ b/x\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n"
+	if got != want {
+		t.Fatalf("diff=\n%s\nwant=\n%s", got, want)
+	}
+	if unifiedDiff("a", "b", a, a) != "" {
+		t.Fatalf("expected empty diff for equal input")
+	}
+}
+
+func TestUnifiedDiff_NoTrailingNewline(t *testing.T) {
+	got := unifiedDiff("a/x", "b/x", "a\nb", "a\nc\n")
+	if !strings.Contains(got, "-b\n\\ No newline at end of file\n+c\n") {
+		t.Fatalf("diff=\n%s", got)
+	}
+}
+
+func TestDiffTool_FileAndText(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("hello\nworld\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "b.txt"), []byte("hello\nthere\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+
+	out, err := r.Execute(context.Background(), Context{}, "diff", json.RawMessage(`{"path":"a.txt","otherPath":"b.txt"}`))
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+	if !strings.Contains(out, "+++ b/b.txt") || !strings.Contains(out, "-world\n+there\n") {
+		t.Fatalf("out=%s", out)
+	}
+
+	out, err = r.Execute(context.Background(), Context{}, "diff", json.RawMessage(`{"path":"a.txt","text":"hello\nworld\n"}`))
+	if err != nil || out != "No differences." {
+		t.Fatalf("out=%q err=%v", out, err)
+	}
+
+	if _, err := r.Execute(context.Background(), Context{}, "diff", json.RawMessage(`{"path":"a.txt"}`)); err == nil {
+		t.Fatalf("expected error without otherPath or text")
+	}
+}
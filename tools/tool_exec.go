@@ -23,13 +23,50 @@ var safeExecEnvVars = []string{
 	"TMPDIR",
 }
 
-func applySafeExecEnv(cmd *exec.Cmd) {
-	cmd.Env = []string{}
+// defaultExecEnv is set for every command to keep output plain and
+// non-interactive. ExecExtraEnv entries override it.
+var defaultExecEnv = []string{
+	"NO_COLOR=1",
+	"CI=1",
+}
+
+// applySafeExecEnv builds a minimal environment: safeExecEnvVars and names in
+// extra are copied from the process, "NAME=value" entries in extra are set
+// as given, and defaultExecEnv fills anything left unset.
+func applySafeExecEnv(cmd *exec.Cmd, extra []string) {
+	env := map[string]string{}
+	var order []string
+	set := func(key, val string) {
+		if _, ok := env[key]; !ok {
+			order = append(order, key)
+		}
+		env[key] = val
+	}
+	for _, kv := range defaultExecEnv {
+		k, v, _ := strings.Cut(kv, "=")
+		set(k, v)
+	}
 	for _, key := range safeExecEnvVars {
 		if val, ok := os.LookupEnv(key); ok {
-			cmd.Env = append(cmd.Env, key+"="+val)
+			set(key, val)
 		}
 	}
+	for _, e := range extra {
+		e = strings.TrimSpace(e)
+		if k, v, ok := strings.Cut(e, "="); ok {
+			if k = strings.TrimSpace(k); k != "" {
+				set(k, v)
+			}
+			continue
+		}
+		if val, ok := os.LookupEnv(e); ok && e != "" {
+			set(e, val)
+		}
+	}
+	cmd.Env = make([]string, 0, len(order))
+	for _, k := range order {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
+	}
 }
 
 func (r *Registry) exec(ctx context.Context, command string) (string, error) {
@@ -39,25 +76,46 @@ func (r *Registry) exec(ctx context.Context, command string) (string, error) {
 	if msg := guardExecCommand(command, r.WorkspaceDir, r.RestrictToWorkspace); msg != "" {
 		return msg, nil
 	}
+	if len(r.ExecAllowCommands) > 0 {
+		if msg := guardExecAllowlist(command, r.ExecAllowCommands); msg != "" {
+			return msg, nil
+		}
+	}
+	// Use sh -lc for portability (pipes, redirects, etc.)
+	return r.runExecCmd(ctx, r.execTimeout("exec"), "sh", "-lc", command), nil
+}
+
+// execTimeout is the timeout for tool, falling back to exec's entry and
+// then r.ExecTimeout.
+func (r *Registry) execTimeout(tool string) time.Duration {
 	timeout := r.ExecTimeout
 	if timeout <= 0 {
 		timeout = 60 * time.Second
 	}
+	return r.toolTimeout(tool, r.toolTimeout("exec", timeout))
+}
+
+// runExecCmd runs name with args in the workspace under timeout and the safe
+// environment, and formats exit code, stdout and stderr.
+func (r *Registry) runExecCmd(ctx context.Context, timeout time.Duration, name string, args ...string) string {
 	cctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Use sh -lc for portability (pipes, redirects, etc.)
-	cmd := exec.CommandContext(cctx, "sh", "-lc", command)
+	cmd := exec.CommandContext(cctx, name, args...)
 	cmd.Dir = r.WorkspaceDir
-	applySafeExecEnv(cmd)
+	applySafeExecEnv(cmd, r.ExecExtraEnv)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	err := cmd.Run()
 
-	out := truncate(stdout.String(), 64<<10)
-	serr := truncate(stderr.String(), 64<<10)
+	rawOut, rawErr := stdout.String(), stderr.String()
+	if r.ExecCleanOutput {
+		rawOut, rawErr = cleanToolOutput(rawOut), cleanToolOutput(rawErr)
+	}
+	out := truncateMode(rawOut, r.execMaxOutput(), r.TruncateMode)
+	serr := truncateMode(rawErr, r.execMaxOutput(), r.TruncateMode)
 	exit := 0
 	if err != nil {
 		var ee *exec.ExitError
@@ -76,8 +134,8 @@ func (r *Registry) exec(ctx context.Context, command string) (string, error) {
 	}
 	if err != nil && cctx.Err() == context.DeadlineExceeded {
 		res += "error: timeout\n"
-		return res, nil
+		return res
 	}
 	// Return output even if non-zero; the model can decide next step.
-	return strings.TrimRight(res, "\n"), nil
+	return strings.TrimRight(res, "\n")
 }
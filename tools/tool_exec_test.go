@@ -44,3 +44,64 @@ func TestExec_PreservesSafeEnvironmentVariables(t *testing.T) {
 		t.Fatalf("expected non-empty PATH in output, got: %q", out)
 	}
 }
+
+func TestExec_CleanOutputStripsANSI(t *testing.T) {
+	r := &Registry{
+		WorkspaceDir:        t.TempDir(),
+		RestrictToWorkspace: true,
+		ExecTimeout:         5 * time.Second,
+		ExecCleanOutput:     true,
+	}
+
+	out, err := r.exec(context.Background(), `printf '\033[31mFAIL\033[0m\n\n\n\nok\n'`)
+	if err != nil {
+		t.Fatalf("exec returned error: %v", err)
+	}
+	if out != "exit=0\nstdout:\nFAIL\n\nok" {
+		t.Fatalf("out=%q", out)
+	}
+}
+
+func TestExec_ExtraEnvAndDefaults(t *testing.T) {
+	t.Setenv("CLAWLET_EXEC_TEST_GOPATH", "/opt/go")
+	t.Setenv("CLAWLET_EXEC_TEST_SECRET", "super-secret")
+
+	r := &Registry{
+		WorkspaceDir:        t.TempDir(),
+		RestrictToWorkspace: true,
+		ExecTimeout:         5 * time.Second,
+		ExecExtraEnv:        []string{"CLAWLET_EXEC_TEST_GOPATH", "GOFLAGS=-mod=mod", "CI=0"},
+	}
+
+	out, err := r.exec(context.Background(), "env")
+	if err != nil {
+		t.Fatalf("exec returned error: %v", err)
+	}
+	for _, want := range []string{"CLAWLET_EXEC_TEST_GOPATH=/opt/go", "GOFLAGS=-mod=mod", "NO_COLOR=1", "CI=0"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %s in env: %q", want, out)
+		}
+	}
+	if strings.Contains(out, "super-secret") {
+		t.Fatalf("non-allowlisted env var leaked")
+	}
+}
+
+func TestExec_ToolTimeoutOverridesExecTimeout(t *testing.T) {
+	r := &Registry{
+		WorkspaceDir: t.TempDir(),
+		ExecTimeout:  30 * time.Second,
+		ToolTimeouts: map[string]time.Duration{"exec": 100 * time.Millisecond},
+	}
+	start := time.Now()
+	out, err := r.exec(context.Background(), "exec sleep 5")
+	if err != nil {
+		t.Fatalf("exec returned error: %v", err)
+	}
+	if !strings.Contains(out, "error: timeout") || time.Since(start) > 5*time.Second {
+		t.Fatalf("expected timeout from ToolTimeouts, got %q", out)
+	}
+	if got := r.execTimeout("run_script"); got != 100*time.Millisecond {
+		t.Fatalf("run_script should inherit exec's entry, got %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// fileInfoHeadBytes is how many leading bytes file_info shows as hex.
+	fileInfoHeadBytes = 32
+	// base64FileMaxBytes caps the file size base64_file will encode.
+	base64FileMaxBytes = 64 << 10
+)
+
+// fileInfo describes a file without returning its contents: size, a MIME
+// type sniffed from the first 512 bytes, and the leading bytes as hex.
+func (r *Registry) fileInfo(path string) (string, error) {
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", abs)
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	head = head[:n]
+	out := struct {
+		Path     string `json:"path"`
+		Size     int64  `json:"size"`
+		MIMEType string `json:"mimeType"`
+		Text     bool   `json:"text"`
+		Modified string `json:"modified"`
+		Mode     string `json:"mode"`
+		HeadHex  string `json:"headHex"`
+	}{
+		Path:     abs,
+		Size:     info.Size(),
+		MIMEType: http.DetectContentType(head),
+		Text:     looksLikeText(head),
+		Modified: info.ModTime().Format(time.RFC3339),
+		Mode:     info.Mode().String(),
+		HeadHex:  hex.EncodeToString(head[:min(len(head), fileInfoHeadBytes)]),
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// looksLikeText reports whether head is valid UTF-8 without NUL bytes. A
+// rune cut at the end of the sample is allowed.
+func looksLikeText(head []byte) bool {
+	for len(head) > 0 {
+		c, size := utf8.DecodeRune(head)
+		if c == utf8.RuneError && size <= 1 {
+			return len(head) < utf8.UTFMax && !utf8.FullRune(head)
+		}
+		if c == 0 {
+			return false
+		}
+		head = head[size:]
+	}
+	return true
+}
+
+// base64File returns a small file as base64. Larger files are refused rather
+// than truncated, since a partial encoding is useless.
+func (r *Registry) base64File(path string) (string, error) {
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", abs)
+	}
+	if info.Size() > base64FileMaxBytes {
+		return "", fmt.Errorf("file is %d bytes; base64_file is limited to %d bytes", info.Size(), base64FileMaxBytes)
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return "", err
+	}
+	if len(b) > base64FileMaxBytes {
+		return "", fmt.Errorf("file is %d bytes; base64_file is limited to %d bytes", len(b), base64FileMaxBytes)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
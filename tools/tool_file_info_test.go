@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileInfo_PNGHeader(t *testing.T) {
+	ws := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(filepath.Join(ws, "a.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+	out, err := r.Execute(context.Background(), Context{}, "file_info", json.RawMessage(`{"path":"a.png"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Size     int64  `json:"size"`
+		MIMEType string `json:"mimeType"`
+		Text     bool   `json:"text"`
+		HeadHex  string `json:"headHex"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if got.Size != int64(len(png)) || got.MIMEType != "image/png" || got.Text || !strings.HasPrefix(got.HeadHex, "89504e47") {
+		t.Fatalf("info=%+v", got)
+	}
+}
+
+func TestLooksLikeText(t *testing.T) {
+	if !looksLikeText([]byte("héllo")) || !looksLikeText([]byte("h\xc3")) {
+		t.Fatal("text should be detected, including a cut rune at the end")
+	}
+	if looksLikeText([]byte("a\x00b")) || looksLikeText([]byte("\xff\xfeab")) {
+		t.Fatal("binary should not look like text")
+	}
+}
+
+func TestBase64File_SizeCapAndPolicy(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "small.bin"), []byte{0, 1, 2, 255}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "big.bin"), make([]byte, base64FileMaxBytes+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+	out, err := r.base64File("small.bin")
+	if err != nil || out != base64.StdEncoding.EncodeToString([]byte{0, 1, 2, 255}) {
+		t.Fatalf("out=%q err=%v", out, err)
+	}
+	if _, err := r.base64File("big.bin"); err == nil || !strings.Contains(err.Error(), "limited to") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+	if _, err := r.base64File("../outside.bin"); err == nil {
+		t.Fatal("expected traversal to be rejected")
+	}
+}
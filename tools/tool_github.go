@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	// githubMaxFileBytes bounds the file content returned by one call.
+	githubMaxFileBytes = 100_000
+	// githubMaxBodyBytes bounds issue, pull request, and comment bodies.
+	githubMaxBodyBytes = 8000
+	// githubMaxComments is how many issue comments are returned, oldest first.
+	githubMaxComments = 30
+)
+
+// githubRepoRe matches "owner/name".
+var githubRepoRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+type githubFile struct {
+	Repo      string `json:"repo"`
+	Path      string `json:"path"`
+	Ref       string `json:"ref,omitempty"`
+	SHA       string `json:"sha"`
+	Size      int    `json:"size"`
+	Binary    bool   `json:"binary,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type githubEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int    `json:"size,omitempty"`
+}
+
+type githubComment struct {
+	Author    string `json:"author"`
+	CreatedAt string `json:"createdAt"`
+	Body      string `json:"body"`
+}
+
+type githubIssue struct {
+	Repo        string          `json:"repo"`
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	State       string          `json:"state"`
+	Author      string          `json:"author"`
+	Labels      []string        `json:"labels,omitempty"`
+	CreatedAt   string          `json:"createdAt"`
+	UpdatedAt   string          `json:"updatedAt"`
+	URL         string          `json:"url"`
+	Body        string          `json:"body,omitempty"`
+	PullRequest *githubPull     `json:"pullRequest,omitempty"`
+	Comments    []githubComment `json:"comments,omitempty"`
+	// MoreComments counts comments left out after githubMaxComments.
+	MoreComments int `json:"moreComments,omitempty"`
+}
+
+type githubPull struct {
+	Head         string `json:"head"`
+	Base         string `json:"base"`
+	Draft        bool   `json:"draft,omitempty"`
+	Merged       bool   `json:"merged"`
+	Mergeable    *bool  `json:"mergeable,omitempty"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+	ChangedFiles int    `json:"changedFiles"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+// github reads from the GitHub REST API: a file at a ref ("file"), a
+// directory listing ("list"), or an issue or pull request with its comments
+// ("issue"). Results are JSON.
+func (r *Registry) github(ctx context.Context, action, repo, path, ref string, number int) (string, error) {
+	repo = strings.Trim(strings.TrimSpace(repo), "/")
+	if !githubRepoRe.MatchString(repo) {
+		return "", fmt.Errorf("repo must be owner/name, got %q", repo)
+	}
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	for _, seg := range strings.Split(path, "/") {
+		if seg == ".." {
+			return "", errors.New("path must not contain ..")
+		}
+	}
+	ref = strings.TrimSpace(ref)
+
+	var (
+		out any
+		err error
+	)
+	switch action {
+	case "file":
+		if path == "" {
+			return "", errors.New("path is required for file")
+		}
+		out, err = r.githubFile(ctx, repo, path, ref)
+	case "list":
+		out, err = r.githubList(ctx, repo, path, ref)
+	case "issue":
+		if number <= 0 {
+			return "", errors.New("number is required for issue")
+		}
+		out, err = r.githubIssue(ctx, repo, number)
+	default:
+		return "", fmt.Errorf("unknown action %q (want file, list, or issue)", action)
+	}
+	if err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func githubContentsPath(repo, path string) string {
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return "/repos/" + repo + "/contents/" + strings.Join(segs, "/")
+}
+
+func githubRefQuery(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	return "?" + url.Values{"ref": {ref}}.Encode()
+}
+
+func (r *Registry) githubFile(ctx context.Context, repo, path, ref string) (githubFile, error) {
+	var raw json.RawMessage
+	if err := r.githubGet(ctx, githubContentsPath(repo, path)+githubRefQuery(ref), &raw); err != nil {
+		return githubFile{}, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		return githubFile{}, fmt.Errorf("%s is a directory; use action list", path)
+	}
+	var f struct {
+		Type     string `json:"type"`
+		SHA      string `json:"sha"`
+		Size     int    `json:"size"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return githubFile{}, fmt.Errorf("github: decode response: %w", err)
+	}
+	if f.Type != "file" {
+		return githubFile{}, fmt.Errorf("%s is a %s, not a file", path, f.Type)
+	}
+	out := githubFile{Repo: repo, Path: path, Ref: ref, SHA: f.SHA, Size: f.Size}
+	if f.Encoding != "base64" {
+		// Files over 1MB come back without content.
+		out.Truncated = true
+		return out, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(f.Content, "\n", ""))
+	if err != nil {
+		return githubFile{}, fmt.Errorf("github: decode content: %w", err)
+	}
+	if bytes.IndexByte(b, 0) >= 0 {
+		out.Binary = true
+		return out, nil
+	}
+	if len(b) > githubMaxFileBytes {
+		b, out.Truncated = b[:githubMaxFileBytes], true
+	}
+	out.Content = strings.ToValidUTF8(string(b), "")
+	return out, nil
+}
+
+func (r *Registry) githubList(ctx context.Context, repo, path, ref string) ([]githubEntry, error) {
+	var raw json.RawMessage
+	if err := r.githubGet(ctx, githubContentsPath(repo, path)+githubRefQuery(ref), &raw); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		return nil, fmt.Errorf("%s is not a directory; use action file", path)
+	}
+	var entries []githubEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("github: decode response: %w", err)
+	}
+	if entries == nil {
+		entries = []githubEntry{}
+	}
+	return entries, nil
+}
+
+func (r *Registry) githubIssue(ctx context.Context, repo string, number int) (githubIssue, error) {
+	var is struct {
+		Number    int        `json:"number"`
+		Title     string     `json:"title"`
+		State     string     `json:"state"`
+		User      githubUser `json:"user"`
+		Body      string     `json:"body"`
+		HTMLURL   string     `json:"html_url"`
+		CreatedAt string     `json:"created_at"`
+		UpdatedAt string     `json:"updated_at"`
+		Comments  int        `json:"comments"`
+		Labels    []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+	base := fmt.Sprintf("/repos/%s/issues/%d", repo, number)
+	if err := r.githubGet(ctx, base, &is); err != nil {
+		return githubIssue{}, err
+	}
+	out := githubIssue{
+		Repo:      repo,
+		Number:    is.Number,
+		Title:     is.Title,
+		State:     is.State,
+		Author:    is.User.Login,
+		CreatedAt: is.CreatedAt,
+		UpdatedAt: is.UpdatedAt,
+		URL:       is.HTMLURL,
+		Body:      truncate(is.Body, githubMaxBodyBytes),
+	}
+	for _, l := range is.Labels {
+		out.Labels = append(out.Labels, l.Name)
+	}
+	if is.PullRequest != nil {
+		var pr struct {
+			Draft     bool  `json:"draft"`
+			Merged    bool  `json:"merged"`
+			Mergeable *bool `json:"mergeable"`
+			Head      struct {
+				Label string `json:"label"`
+			} `json:"head"`
+			Base struct {
+				Label string `json:"label"`
+			} `json:"base"`
+			Additions    int `json:"additions"`
+			Deletions    int `json:"deletions"`
+			ChangedFiles int `json:"changed_files"`
+		}
+		if err := r.githubGet(ctx, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), &pr); err != nil {
+			return githubIssue{}, err
+		}
+		out.PullRequest = &githubPull{
+			Head:         pr.Head.Label,
+			Base:         pr.Base.Label,
+			Draft:        pr.Draft,
+			Merged:       pr.Merged,
+			Mergeable:    pr.Mergeable,
+			Additions:    pr.Additions,
+			Deletions:    pr.Deletions,
+			ChangedFiles: pr.ChangedFiles,
+		}
+	}
+	if is.Comments > 0 {
+		var comments []struct {
+			User      githubUser `json:"user"`
+			Body      string     `json:"body"`
+			CreatedAt string     `json:"created_at"`
+		}
+		if err := r.githubGet(ctx, fmt.Sprintf("%s/comments?per_page=%d", base, githubMaxComments), &comments); err != nil {
+			return githubIssue{}, err
+		}
+		for _, c := range comments {
+			out.Comments = append(out.Comments, githubComment{Author: c.User.Login, CreatedAt: c.CreatedAt, Body: truncate(c.Body, githubMaxBodyBytes)})
+		}
+		out.MoreComments = max(is.Comments-len(comments), 0)
+	}
+	return out, nil
+}
+
+// githubGet fetches an API path as JSON into out. Requests obey the
+// web_fetch domain policy and tools.egressAllowHosts like every other
+// network tool.
+func (r *Registry) githubGet(ctx context.Context, apiPath string, out any) error {
+	base := strings.TrimRight(r.GitHubAPIURL, "/")
+	if base == "" {
+		base = defaultGitHubAPIURL
+	}
+	u, err := url.Parse(base + apiPath)
+	if err != nil {
+		return err
+	}
+	if ok, reason := allowHostByPolicy(u.Hostname(), r.WebFetchAllowedDomains, r.WebFetchBlockedDomains); !ok {
+		return fmt.Errorf("github: %s: %s", u.Hostname(), reason)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if tok := strings.TrimSpace(r.GitHubToken); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := r.httpClient(r.toolTimeout("github", 20*time.Second)).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		msg := strings.TrimSpace(string(b))
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Message != "" {
+			msg = apiErr.Message
+		}
+		if resp.StatusCode == http.StatusNotFound && strings.TrimSpace(r.GitHubToken) == "" {
+			msg += " (private repositories need tools.github.token)"
+		}
+		return fmt.Errorf("github http %d: %s", resp.StatusCode, truncate(msg, 500))
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("github: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHub(t *testing.T) {
+	var auth, fileQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/repos/acme/app/contents/src/main.go":
+			fileQuery = r.URL.RawQuery
+			content := base64.StdEncoding.EncodeToString([]byte("package main\n"))
+			_, _ = w.Write([]byte(`{"type":"file","sha":"abc","size":13,"encoding":"base64","content":"` + content + `"}`))
+		case "/repos/acme/app/contents/src":
+			_, _ = w.Write([]byte(`[{"name":"main.go","path":"src/main.go","type":"file","size":13,"sha":"abc"},{"name":"pkg","path":"src/pkg","type":"dir","size":0}]`))
+		case "/repos/acme/app/issues/7":
+			_, _ = w.Write([]byte(`{"number":7,"title":"Fix build","state":"open","user":{"login":"alice"},"body":"It breaks.","html_url":"https://github.com/acme/app/pull/7","comments":1,"labels":[{"name":"bug"}],"pull_request":{}}`))
+		case "/repos/acme/app/pulls/7":
+			_, _ = w.Write([]byte(`{"draft":false,"merged":false,"mergeable":true,"head":{"label":"alice:fix"},"base":{"label":"acme:main"},"additions":3,"deletions":1,"changed_files":1}`))
+		case "/repos/acme/app/issues/7/comments":
+			_, _ = w.Write([]byte(`[{"user":{"login":"bob"},"body":"LGTM","created_at":"2026-01-02T00:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	r := &Registry{GitHub: true, GitHubToken: "tok", GitHubAPIURL: srv.URL}
+	if !hasTool(r, "github") {
+		t.Fatal("github not offered")
+	}
+	run := func(args string) string {
+		t.Helper()
+		out, err := r.Execute(context.Background(), Context{}, "github", json.RawMessage(args))
+		if err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+		return out
+	}
+
+	var f githubFile
+	if err := json.Unmarshal([]byte(run(`{"action":"file","repo":"acme/app","path":"src/main.go","ref":"v1.0"}`)), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Content != "package main\n" || f.SHA != "abc" || fileQuery != "ref=v1.0" || auth != "Bearer tok" {
+		t.Fatalf("file=%+v query=%q auth=%q", f, fileQuery, auth)
+	}
+
+	var entries []githubEntry
+	if err := json.Unmarshal([]byte(run(`{"action":"list","repo":"acme/app","path":"src"}`)), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Type != "dir" {
+		t.Fatalf("entries=%+v", entries)
+	}
+
+	var is githubIssue
+	if err := json.Unmarshal([]byte(run(`{"action":"issue","repo":"acme/app","number":7}`)), &is); err != nil {
+		t.Fatal(err)
+	}
+	if is.Title != "Fix build" || is.PullRequest == nil || is.PullRequest.Head != "alice:fix" || len(is.Comments) != 1 || is.Comments[0].Author != "bob" {
+		t.Fatalf("issue=%+v", is)
+	}
+
+	if _, err := r.Execute(context.Background(), Context{}, "github", json.RawMessage(`{"action":"file","repo":"acme/app","path":"src"}`)); err == nil {
+		t.Fatal("expected directory error")
+	}
+	r.GitHubToken = ""
+	_, err := r.Execute(context.Background(), Context{}, "github", json.RawMessage(`{"action":"file","repo":"acme/secret","path":"x"}`))
+	if err == nil || !strings.Contains(err.Error(), "github http 404") || !strings.Contains(err.Error(), "tools.github.token") {
+		t.Fatalf("expected 404 hint, got %v", err)
+	}
+	if _, err := r.Execute(context.Background(), Context{}, "github", json.RawMessage(`{"action":"file","repo":"../etc","path":"x"}`)); err == nil {
+		t.Fatal("expected bad repo to be refused")
+	}
+}
+
+func TestGitHub_Policy(t *testing.T) {
+	r := &Registry{GitHub: true, WebFetchBlockedDomains: []string{"api.github.com"}}
+	_, err := r.Execute(context.Background(), Context{}, "github", json.RawMessage(`{"action":"list","repo":"acme/app"}`))
+	if err == nil || !strings.Contains(err.Error(), "api.github.com") {
+		t.Fatalf("expected blocked domain, got %v", err)
+	}
+	if hasTool(&Registry{}, "github") {
+		t.Fatal("github offered while disabled")
+	}
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const globDefaultEntries = 200
+
+type globResult struct {
+	Matches []string `json:"matches"`
+	HasMore bool     `json:"hasMore"`
+}
+
+// glob lists the files and directories under dir whose path relative to dir
+// matches pattern. Segments use path.Match syntax and "**" spans any number
+// of directories. Entries skipped by tree (.git, node_modules,
+// .clawletignore, sensitive paths) are never matched. Matches keep dir as
+// their prefix so they can be passed straight to read_file.
+func (r *Registry) glob(pattern, dir string, maxEntries int) (string, error) {
+	pattern = strings.Trim(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+	if pattern == "" {
+		return "", errors.New("pattern is empty")
+	}
+	segs := strings.Split(pattern, "/")
+	for _, s := range segs {
+		if s == ".." {
+			return "", errors.New("pattern must not contain ..")
+		}
+		if _, err := path.Match(s, ""); err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if strings.TrimSpace(dir) == "" {
+		dir = "."
+	}
+	if maxEntries <= 0 {
+		maxEntries = globDefaultEntries
+	}
+	abs, err := r.resolvePath(dir)
+	if err != nil {
+		return "", err
+	}
+	st, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !st.IsDir() {
+		return "", errors.New("path is not a directory")
+	}
+	w := &treeWalker{r: r}
+	if wsAbs, err := filepath.Abs(r.WorkspaceDir); err == nil {
+		w.wsAbs = filepath.Clean(wsAbs)
+		w.ignore = readTreeIgnore(filepath.Join(w.wsAbs, treeIgnoreFile))
+	}
+	// Without "**" nothing deeper than the pattern can match.
+	maxDepth := len(segs)
+	if slices.Contains(segs, "**") {
+		maxDepth = -1
+	}
+
+	res := globResult{Matches: []string{}}
+	err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == abs {
+			return nil
+		}
+		if w.skip(p, d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(abs, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		var next error
+		if d.IsDir() && maxDepth > 0 && strings.Count(rel, "/")+1 >= maxDepth {
+			next = filepath.SkipDir
+		}
+		if !matchGlobSegments(segs, strings.Split(rel, "/")) {
+			return next
+		}
+		if _, err := r.resolvePath(p); err != nil {
+			return next
+		}
+		if len(res.Matches) == maxEntries {
+			res.HasMore = true
+			return fs.SkipAll
+		}
+		out := filepath.ToSlash(filepath.Join(dir, rel))
+		if d.IsDir() {
+			out += "/"
+		}
+		res.Matches = append(res.Matches, out)
+		return next
+	})
+	if err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(res)
+	return string(b), nil
+}
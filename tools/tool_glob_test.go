@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	ws := t.TempDir()
+	for _, p := range []string{"cmd/app/main.go", "cmd/app/main_test.go", "cmd/tool/main.go", "go.mod", "main.go", "build/gen.go", ".git/x.go", "node_modules/m/a.go", "docs/a.md"} {
+		full := filepath.Join(ws, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(ws, treeIgnoreFile), []byte("build/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+	if !hasTool(r, "glob") {
+		t.Fatal("glob not exposed")
+	}
+	run := func(args string) globResult {
+		t.Helper()
+		out, err := r.Execute(context.Background(), Context{}, "glob", json.RawMessage(args))
+		if err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+		var res globResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if got := run(`{"pattern":"**/*.go"}`).Matches; !slices.Equal(got, []string{"cmd/app/main.go", "cmd/app/main_test.go", "cmd/tool/main.go", "main.go"}) {
+		t.Fatalf("**/*.go = %v", got)
+	}
+	if got := run(`{"pattern":"*.go"}`).Matches; !slices.Equal(got, []string{"main.go"}) {
+		t.Fatalf("*.go = %v", got)
+	}
+	if got := run(`{"pattern":"*/main.go","path":"cmd"}`).Matches; !slices.Equal(got, []string{"cmd/app/main.go", "cmd/tool/main.go"}) {
+		t.Fatalf("cmd */main.go = %v", got)
+	}
+	if got := run(`{"pattern":"cmd/*"}`).Matches; !slices.Equal(got, []string{"cmd/app/", "cmd/tool/"}) {
+		t.Fatalf("cmd/* = %v", got)
+	}
+	if res := run(`{"pattern":"**/*.go","maxEntries":2}`); len(res.Matches) != 2 || !res.HasMore {
+		t.Fatalf("capped = %+v", res)
+	}
+
+	for _, args := range []string{`{"pattern":""}`, `{"pattern":"[a"}`, `{"pattern":"../*"}`, `{"pattern":"*","path":"../"}`, `{"pattern":"*","path":"go.mod"}`} {
+		if _, err := r.Execute(context.Background(), Context{}, "glob", json.RawMessage(args)); err == nil {
+			t.Fatalf("%s: expected error", args)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// hashAlgorithms are the digests hash can compute, in output order.
+var hashAlgorithms = []string{"md5", "sha1", "sha256"}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	}
+	return nil
+}
+
+// hashDigest returns hex digests of a workspace file or of text. algorithm
+// is md5, sha1, sha256 (default), or "all".
+func (r *Registry) hashDigest(path string, text *string, algorithm string) (string, error) {
+	if (strings.TrimSpace(path) == "") == (text == nil) {
+		return "", errors.New("provide exactly one of path or text")
+	}
+	algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+	names := []string{algorithm}
+	switch algorithm {
+	case "":
+		names = []string{"sha256"}
+	case "all":
+		names = hashAlgorithms
+	default:
+		if newHash(algorithm) == nil {
+			return "", fmt.Errorf("unsupported algorithm %q (want md5, sha1, sha256, or all)", algorithm)
+		}
+	}
+	hashes := make([]hash.Hash, len(names))
+	writers := make([]io.Writer, len(names))
+	for i, n := range names {
+		hashes[i] = newHash(n)
+		writers[i] = hashes[i]
+	}
+	w := io.MultiWriter(writers...)
+
+	if text != nil {
+		_, _ = io.WriteString(w, *text)
+	} else {
+		abs, err := r.resolvePath(path)
+		if err != nil {
+			return "", err
+		}
+		f, err := os.Open(abs)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if st, err := f.Stat(); err != nil {
+			return "", err
+		} else if st.IsDir() {
+			return "", fmt.Errorf("path is a directory: %s", path)
+		}
+		if _, err := io.Copy(w, f); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	for i, n := range names {
+		fmt.Fprintf(&b, "%s: %s\n", n, hex.EncodeToString(hashes[i].Sum(nil)))
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
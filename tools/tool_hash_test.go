@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+	if !hasTool(r, "hash") {
+		t.Fatal("hash not exposed")
+	}
+	run := func(args string) (string, error) {
+		return r.Execute(context.Background(), Context{}, "hash", json.RawMessage(args))
+	}
+
+	out, err := run(`{"path":"a.txt"}`)
+	if err != nil || out != "sha256: 5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03" {
+		t.Fatalf("file sha256: %q %v", out, err)
+	}
+	out, err = run(`{"text":"hello\n","algorithm":"all"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "md5: b1946ac92492d2347c6235b4d2611184\nsha1: f572d396fae9206628714fb2ce00f72e94f2258f\nsha256: 5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if out != want {
+		t.Fatalf("text all:\n%s", out)
+	}
+
+	for _, args := range []string{`{}`, `{"path":"a.txt","text":"x"}`, `{"text":"x","algorithm":"crc32"}`, `{"path":"../outside.txt"}`, `{"path":"."}`} {
+		if _, err := run(args); err == nil {
+			t.Fatalf("%s should fail", args)
+		}
+	}
+	if out, err := run(`{"path":"a.txt","algorithm":"MD5"}`); err != nil || !strings.HasPrefix(out, "md5: b1946ac9") {
+		t.Fatalf("case-insensitive algorithm: %q %v", out, err)
+	}
+}
@@ -0,0 +1,476 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const jsonPatchMaxOutput = 64 << 10
+
+// jsonPatchOp is one RFC 6902 operation.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonObject keeps key order so patched files stay close to the original.
+type jsonObject struct {
+	keys []string
+	vals map[string]any
+}
+
+type jsonArray struct {
+	items []any
+}
+
+// jsonPatch applies ops to the JSON file at path. Every operation must
+// succeed; otherwise nothing is written.
+func (r *Registry) jsonPatch(path string, ops []jsonPatchOp) (string, error) {
+	if len(ops) == 0 {
+		return "", errors.New("patch is empty")
+	}
+	abs, err := r.validateWriteTarget(path)
+	if err != nil {
+		return "", err
+	}
+	orig, err := os.ReadFile(abs)
+	if err != nil {
+		return "", err
+	}
+	doc, err := decodeOrderedJSON(orig)
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid JSON: %w", path, err)
+	}
+	for i, op := range ops {
+		if doc, err = applyJSONPatchOp(doc, op); err != nil {
+			return "", fmt.Errorf("op %d (%s %s): %w; nothing written", i, op.Op, op.Path, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	indent := detectJSONIndent(orig)
+	writeOrderedJSON(&buf, doc, indent, "")
+	if bytes.HasSuffix(bytes.TrimRight(orig, " \t\r"), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(abs, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	out := fmt.Sprintf("patched %s (%d operations)\n\n%s", abs, len(ops), buf.String())
+	return truncateMode(out, jsonPatchMaxOutput, r.TruncateMode), nil
+}
+
+func applyJSONPatchOp(doc any, op jsonPatchOp) (any, error) {
+	tokens, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return doc, err
+	}
+	value := func() (any, error) {
+		if len(op.Value) == 0 {
+			return nil, errors.New("value is required")
+		}
+		return decodeOrderedJSON(op.Value)
+	}
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return doc, err
+		}
+		return jsonPointerAdd(doc, tokens, v)
+	case "remove":
+		_, doc, err = jsonPointerRemove(doc, tokens)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return doc, err
+		}
+		return jsonPointerReplace(doc, tokens, v)
+	case "move", "copy":
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return doc, fmt.Errorf("from: %w", err)
+		}
+		var v any
+		if op.Op == "move" {
+			if len(tokens) > len(from) && slices.Equal(tokens[:len(from)], from) {
+				return doc, errors.New("cannot move a value into itself")
+			}
+			if v, doc, err = jsonPointerRemove(doc, from); err != nil {
+				return doc, fmt.Errorf("from: %w", err)
+			}
+		} else {
+			if v, err = jsonPointerGet(doc, from); err != nil {
+				return doc, fmt.Errorf("from: %w", err)
+			}
+			v = cloneOrderedJSON(v)
+		}
+		return jsonPointerAdd(doc, tokens, v)
+	case "test":
+		v, err := value()
+		if err != nil {
+			return doc, err
+		}
+		cur, err := jsonPointerGet(doc, tokens)
+		if err != nil {
+			return doc, err
+		}
+		if !orderedJSONEqual(cur, v) {
+			return doc, errors.New("test failed: value does not match")
+		}
+		return doc, nil
+	default:
+		return doc, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// parseJSONPointer splits an RFC 6901 pointer into unescaped tokens.
+func parseJSONPointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", p)
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func arrayIndex(tok string, n int, allowEnd bool) (int, error) {
+	if allowEnd && tok == "-" {
+		return n, nil
+	}
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	limit := n - 1
+	if allowEnd {
+		limit = n
+	}
+	if i > limit {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func jsonPointerGet(doc any, tokens []string) (any, error) {
+	cur := doc
+	for _, tok := range tokens {
+		switch c := cur.(type) {
+		case *jsonObject:
+			v, ok := c.vals[tok]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %q", tok)
+			}
+			cur = v
+		case *jsonArray:
+			i, err := arrayIndex(tok, len(c.items), false)
+			if err != nil {
+				return nil, err
+			}
+			cur = c.items[i]
+		default:
+			return nil, fmt.Errorf("path not found: %q is not in a container", tok)
+		}
+	}
+	return cur, nil
+}
+
+func jsonPointerAdd(doc any, tokens []string, v any) (any, error) {
+	if len(tokens) == 0 {
+		return v, nil
+	}
+	parent, err := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return doc, err
+	}
+	tok := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case *jsonObject:
+		if _, ok := p.vals[tok]; !ok {
+			p.keys = append(p.keys, tok)
+		}
+		p.vals[tok] = v
+	case *jsonArray:
+		i, err := arrayIndex(tok, len(p.items), true)
+		if err != nil {
+			return doc, err
+		}
+		p.items = append(p.items, nil)
+		copy(p.items[i+1:], p.items[i:])
+		p.items[i] = v
+	default:
+		return doc, errors.New("parent is not an object or array")
+	}
+	return doc, nil
+}
+
+// jsonPointerReplace overwrites an existing value in place, keeping its
+// position among its siblings.
+func jsonPointerReplace(doc any, tokens []string, v any) (any, error) {
+	if len(tokens) == 0 {
+		return v, nil
+	}
+	parent, err := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return doc, err
+	}
+	tok := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case *jsonObject:
+		if _, ok := p.vals[tok]; !ok {
+			return doc, fmt.Errorf("path not found: %q", tok)
+		}
+		p.vals[tok] = v
+	case *jsonArray:
+		i, err := arrayIndex(tok, len(p.items), false)
+		if err != nil {
+			return doc, err
+		}
+		p.items[i] = v
+	default:
+		return doc, errors.New("parent is not an object or array")
+	}
+	return doc, nil
+}
+
+func jsonPointerRemove(doc any, tokens []string) (removed, newDoc any, err error) {
+	if len(tokens) == 0 {
+		return doc, nil, nil
+	}
+	parent, err := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, doc, err
+	}
+	tok := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case *jsonObject:
+		v, ok := p.vals[tok]
+		if !ok {
+			return nil, doc, fmt.Errorf("path not found: %q", tok)
+		}
+		delete(p.vals, tok)
+		for i, k := range p.keys {
+			if k == tok {
+				p.keys = append(p.keys[:i], p.keys[i+1:]...)
+				break
+			}
+		}
+		return v, doc, nil
+	case *jsonArray:
+		i, err := arrayIndex(tok, len(p.items), false)
+		if err != nil {
+			return nil, doc, err
+		}
+		v := p.items[i]
+		p.items = append(p.items[:i], p.items[i+1:]...)
+		return v, doc, nil
+	default:
+		return nil, doc, errors.New("parent is not an object or array")
+	}
+}
+
+func decodeOrderedJSON(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &jsonObject{vals: map[string]any{}}
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				k, _ := kt.(string)
+				v, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := obj.vals[k]; !ok {
+					obj.keys = append(obj.keys, k)
+				}
+				obj.vals[k] = v
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			arr := &jsonArray{items: []any{}}
+			for dec.More() {
+				v, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr.items = append(arr.items, v)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	default:
+		return t, nil
+	}
+}
+
+func cloneOrderedJSON(v any) any {
+	switch c := v.(type) {
+	case *jsonObject:
+		out := &jsonObject{keys: append([]string(nil), c.keys...), vals: make(map[string]any, len(c.vals))}
+		for k, val := range c.vals {
+			out.vals[k] = cloneOrderedJSON(val)
+		}
+		return out
+	case *jsonArray:
+		out := &jsonArray{items: make([]any, len(c.items))}
+		for i, item := range c.items {
+			out.items[i] = cloneOrderedJSON(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func orderedJSONEqual(a, b any) bool {
+	switch x := a.(type) {
+	case *jsonObject:
+		y, ok := b.(*jsonObject)
+		if !ok || len(x.vals) != len(y.vals) {
+			return false
+		}
+		for k, v := range x.vals {
+			w, ok := y.vals[k]
+			if !ok || !orderedJSONEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case *jsonArray:
+		y, ok := b.(*jsonArray)
+		if !ok || len(x.items) != len(y.items) {
+			return false
+		}
+		for i := range x.items {
+			if !orderedJSONEqual(x.items[i], y.items[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	default:
+		return a == b
+	}
+}
+
+// detectJSONIndent returns the indentation unit of the first indented line,
+// or "" for single-line documents.
+func detectJSONIndent(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n")[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || len(trimmed) == len(line) {
+			continue
+		}
+		return line[:len(line)-len(trimmed)]
+	}
+	return ""
+}
+
+func writeOrderedJSON(buf *bytes.Buffer, v any, indent, prefix string) {
+	nl := func(p string) {
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(p)
+		}
+	}
+	colon := ":"
+	if indent != "" {
+		colon = ": "
+	}
+	switch c := v.(type) {
+	case *jsonObject:
+		if len(c.keys) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteByte('{')
+		for i, k := range c.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			nl(prefix + indent)
+			writeJSONScalar(buf, k)
+			buf.WriteString(colon)
+			writeOrderedJSON(buf, c.vals[k], indent, prefix+indent)
+		}
+		nl(prefix)
+		buf.WriteByte('}')
+	case *jsonArray:
+		if len(c.items) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteByte('[')
+		for i, item := range c.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			nl(prefix + indent)
+			writeOrderedJSON(buf, item, indent, prefix+indent)
+		}
+		nl(prefix)
+		buf.WriteByte(']')
+	default:
+		writeJSONScalar(buf, v)
+	}
+}
+
+func writeJSONScalar(buf *bytes.Buffer, v any) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	// Encode appends a newline.
+	buf.Truncate(buf.Len() - 1)
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONPatch_PreservesOrderAndIndent(t *testing.T) {
+	ws := t.TempDir()
+	p := filepath.Join(ws, "cfg.json")
+	orig := "{\n    \"zeta\": 1,\n    \"alpha\": {\n        \"list\": [\"a\", \"b\"]\n    },\n    \"url\": \"http://x/?a=1&b=2\"\n}\n"
+	if err := os.WriteFile(p, []byte(orig), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+
+	patch := `{"path":"cfg.json","patch":[
+		{"op":"test","path":"/zeta","value":1.0},
+		{"op":"replace","path":"/zeta","value":2},
+		{"op":"add","path":"/alpha/list/1","value":"mid"},
+		{"op":"add","path":"/alpha/list/-","value":"end"},
+		{"op":"copy","from":"/alpha/list","path":"/copied"},
+		{"op":"move","from":"/url","path":"/link"}
+	]}`
+	if _, err := r.Execute(context.Background(), Context{}, "json_patch", json.RawMessage(patch)); err != nil {
+		t.Fatalf("json_patch: %v", err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := "{\n    \"zeta\": 2,\n    \"alpha\": {\n        \"list\": [\n            \"a\",\n            \"mid\",\n            \"b\",\n            \"end\"\n        ]\n    },\n" +
+		"    \"copied\": [\n        \"a\",\n        \"mid\",\n        \"b\",\n        \"end\"\n    ],\n    \"link\": \"http://x/?a=1&b=2\"\n}\n"
+	if string(b) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestJSONPatch_FailedOpWritesNothing(t *testing.T) {
+	ws := t.TempDir()
+	p := filepath.Join(ws, "cfg.json")
+	orig := `{"a":1,"b":[1,2]}`
+	if err := os.WriteFile(p, []byte(orig), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+
+	for _, patch := range []string{
+		`[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/missing"}]`,
+		`[{"op":"add","path":"/b/5","value":3}]`,
+		`[{"op":"add","path":"/b/01","value":3}]`,
+		`[{"op":"test","path":"/a","value":"1"}]`,
+		`[{"op":"move","from":"/b","path":"/b/0"}]`,
+	} {
+		args := `{"path":"cfg.json","patch":` + patch + `}`
+		if _, err := r.Execute(context.Background(), Context{}, "json_patch", json.RawMessage(args)); err == nil {
+			t.Fatalf("expected error for %s", patch)
+		} else if !strings.Contains(err.Error(), "nothing written") {
+			t.Fatalf("unexpected error for %s: %v", patch, err)
+		}
+		b, _ := os.ReadFile(p)
+		if string(b) != orig {
+			t.Fatalf("file changed after %s: %s", patch, b)
+		}
+	}
+}
+
+func TestJSONPatch_BlockedOutsideWorkspace(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir(), RestrictToWorkspace: true}
+	if _, err := r.jsonPatch("../x.json", []jsonPatchOp{{Op: "remove", Path: "/a"}}); err == nil {
+		t.Fatalf("expected traversal to be rejected")
+	}
+}
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/mosaxiv/clawlet/llm"
+)
+
+type toolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  llm.JSONSchema `json:"parameters"`
+	Enabled     bool           `json:"enabled"`
+}
+
+// listTools describes every configured tool. Tools hidden by AllowTools,
+// SafeMode, or the active profile are included with enabled=false so the
+// model knows why a call fails.
+func (r *Registry) listTools(profile string) (string, error) {
+	defs := r.configuredDefinitions()
+	out := make([]toolInfo, 0, len(defs))
+	for _, d := range defs {
+		out = append(out, toolInfo{
+			Name:        d.Function.Name,
+			Description: d.Function.Description,
+			Parameters:  d.Function.Parameters,
+			Enabled:     r.allowed(d.Function.Name) && r.inProfile(profile, d.Function.Name),
+		})
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
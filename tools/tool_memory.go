@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 
 	"github.com/mosaxiv/clawlet/memory"
@@ -72,3 +73,27 @@ func jsonResult(v any) (string, error) {
 	}
 	return string(b), nil
 }
+
+func (r *Registry) readMemory() (string, error) {
+	if r.MemoryStore == nil {
+		return "", errors.New("read_memory is disabled")
+	}
+	mem := r.MemoryStore.GetContext()
+	if strings.TrimSpace(mem) == "" {
+		return "(memory is empty)", nil
+	}
+	return mem, nil
+}
+
+func (r *Registry) writeMemory(fact string) (string, error) {
+	if r.MemoryStore == nil || !r.MemoryWrite {
+		return "", errors.New("write_memory is disabled")
+	}
+	if strings.TrimSpace(fact) == "" {
+		return "", errors.New("fact is empty")
+	}
+	if err := r.MemoryStore.AppendLongTerm(fact); err != nil {
+		return "", err
+	}
+	return "Saved to MEMORY.md", nil
+}
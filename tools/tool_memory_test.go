@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/memory"
+)
+
+func TestReadWriteMemory(t *testing.T) {
+	ws := t.TempDir()
+	r := &Registry{WorkspaceDir: ws, MemoryStore: memory.New(ws)}
+	ctx := context.Background()
+
+	if _, err := r.Execute(ctx, Context{}, "write_memory", json.RawMessage(`{"fact":"x"}`)); err == nil {
+		t.Fatal("write_memory should be disabled by default")
+	}
+
+	r.MemoryWrite = true
+	for _, fact := range []string{"User prefers metric units", "Deploys happen on Fridays"} {
+		b, _ := json.Marshal(map[string]string{"fact": fact})
+		if _, err := r.Execute(ctx, Context{}, "write_memory", b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err := r.Execute(ctx, Context{}, "read_memory", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "- User prefers metric units\n- Deploys happen on Fridays") {
+		t.Fatalf("out=%q", out)
+	}
+}
+
+func TestMemoryToolDefinitions(t *testing.T) {
+	ws := t.TempDir()
+	r := &Registry{WorkspaceDir: ws, MemoryStore: memory.New(ws)}
+	names := map[string]bool{}
+	for _, d := range r.Definitions() {
+		names[d.Function.Name] = true
+	}
+	if !names["read_memory"] || names["write_memory"] {
+		t.Fatalf("names=%v", names)
+	}
+	r.MemoryWrite = true
+	r.SafeMode = true
+	for _, d := range r.Definitions() {
+		if d.Function.Name == "write_memory" {
+			t.Fatal("write_memory should be hidden in safe mode")
+		}
+	}
+}
@@ -20,9 +20,30 @@ func (r *Registry) message(ctx context.Context, channel, chatID, content string)
 	if r.Outbound == nil {
 		return "", errors.New("message sending not configured")
 	}
+	if !messageTargetAllowed(r.MessageAllowedTargets, channel, chatID) {
+		return "", fmt.Errorf("destination %s:%s is not allowed (allowed: %s)", channel, chatID, strings.Join(r.MessageAllowedTargets, ", "))
+	}
 	msg := bus.OutboundMessage{Channel: channel, ChatID: chatID, Content: content}
 	if err := r.Outbound(ctx, msg); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("Message sent to %s:%s", channel, chatID), nil
 }
+
+// messageTargetAllowed matches channel:chatID against "channel:chat_id" and
+// "channel:*" entries. An empty allowlist allows any destination.
+func messageTargetAllowed(allowed []string, channel, chatID string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, entry := range allowed {
+		ch, id, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || ch != channel {
+			continue
+		}
+		if id == "*" || id == chatID {
+			return true
+		}
+	}
+	return false
+}
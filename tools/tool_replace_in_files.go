@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// replaceMaxFiles bounds how many files one replace_in_files call may
+	// change, so a too-broad glob fails instead of rewriting half the tree.
+	replaceMaxFiles = 200
+	// replaceMaxFileBytes skips files too large to be hand-edited source.
+	replaceMaxFileBytes = 2 << 20
+	// replacePreviewBytes bounds the dry-run diff preview.
+	replacePreviewBytes = 20000
+)
+
+type replaceChange struct {
+	rel     string
+	abs     string
+	count   int
+	before  string
+	after   string
+	oldMode fs.FileMode
+}
+
+// replaceInFiles replaces oldText with newText in every workspace file
+// matching glob (tools.writeDenyGlobs syntax, relative to the workspace).
+// With regex, oldText is a Go regular expression and newText may use $1-style
+// groups. Files skipped by tree (.git, node_modules, .clawletignore,
+// sensitive paths), write-denied files, symlinks, and binary files are left
+// alone. With dryRun nothing is written
+// and the result includes a diff preview.
+//
+// reads, when non-nil, enforces tools.requireReadBeforeWrite: a dry run
+// counts as reading the files it previews, and a real run refuses files that
+// were neither read nor previewed this turn.
+func (r *Registry) replaceInFiles(glob, oldText, newText string, regex, dryRun bool, reads *ReadSet) (string, error) {
+	glob = strings.Trim(filepath.ToSlash(strings.TrimSpace(glob)), "/")
+	if glob == "" {
+		return "", errors.New("glob is empty")
+	}
+	if oldText == "" {
+		return "", errors.New("old is empty")
+	}
+	var re *regexp.Regexp
+	if regex {
+		var err error
+		if re, err = regexp.Compile(oldText); err != nil {
+			return "", fmt.Errorf("invalid regex: %w", err)
+		}
+		if re.MatchString("") {
+			return "", errors.New("regex matches the empty string")
+		}
+	}
+	wsAbs, err := r.workspaceAbs()
+	if err != nil {
+		return "", err
+	}
+	w := &treeWalker{r: r, wsAbs: wsAbs, ignore: readTreeIgnore(filepath.Join(wsAbs, treeIgnoreFile))}
+
+	var changes []replaceChange
+	matched := 0
+	err = filepath.WalkDir(wsAbs, func(abs string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if abs == wsAbs {
+				return err
+			}
+			return nil
+		}
+		if abs == wsAbs {
+			return nil
+		}
+		if w.skip(abs, d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(wsAbs, abs)
+		if err != nil || !matchWriteGlob(glob, filepath.ToSlash(rel)) {
+			return nil
+		}
+		if _, err := r.resolvePath(abs); err != nil {
+			return nil
+		}
+		if r.ensureWriteAllowed(abs) != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > replaceMaxFileBytes {
+			return nil
+		}
+		b, err := os.ReadFile(abs)
+		if err != nil || bytes.IndexByte(b, 0) >= 0 {
+			return nil
+		}
+		matched++
+		before := string(b)
+		var count int
+		var after string
+		if re != nil {
+			count = len(re.FindAllStringIndex(before, -1))
+			after = re.ReplaceAllString(before, newText)
+		} else {
+			count = strings.Count(before, oldText)
+			after = strings.ReplaceAll(before, oldText, newText)
+		}
+		if count == 0 || after == before {
+			return nil
+		}
+		changes = append(changes, replaceChange{rel: filepath.ToSlash(rel), abs: abs, count: count, before: before, after: after, oldMode: info.Mode().Perm()})
+		if len(changes) > replaceMaxFiles {
+			return fmt.Errorf("more than %d files would change; narrow the glob", replaceMaxFiles)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(changes) == 0 {
+		return fmt.Sprintf("no matches in %d files matching %s", matched, glob), nil
+	}
+
+	if !dryRun && reads != nil {
+		var unread []string
+		for _, c := range changes {
+			if !reads.has(c.abs) {
+				unread = append(unread, c.rel)
+			}
+		}
+		if len(unread) > 0 {
+			return "", fmt.Errorf("%d files would change but have not been read in this turn (%s); run with dry_run first or read them, then retry", len(unread), truncate(strings.Join(unread, ", "), 500))
+		}
+	}
+
+	total := 0
+	var sb strings.Builder
+	for i, c := range changes {
+		total += c.count
+		if !dryRun {
+			if err := os.WriteFile(c.abs, []byte(c.after), c.oldMode); err != nil {
+				return "", fmt.Errorf("%s: %w (%d files before it were already changed)", c.rel, err, i)
+			}
+		}
+		if reads != nil {
+			reads.add(c.abs)
+		}
+		fmt.Fprintf(&sb, "%s: %d\n", c.rel, c.count)
+	}
+	verb := "replaced"
+	if dryRun {
+		verb = "dry run: would replace"
+	}
+	head := fmt.Sprintf("%s %d occurrences in %d files\n", verb, total, len(changes))
+	if !dryRun {
+		return head + sb.String(), nil
+	}
+	var diff strings.Builder
+	for _, c := range changes {
+		diff.WriteString(unifiedDiff("a/"+c.rel, "b/"+c.rel, c.before, c.after))
+	}
+	return head + sb.String() + "\n" + truncate(diff.String(), replacePreviewBytes), nil
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceInFiles(t *testing.T) {
+	ws := t.TempDir()
+	files := map[string]string{
+		"a.go":               "oldName()\noldName()\n",
+		"pkg/b.go":           "x := oldName\n",
+		"pkg/c.txt":          "oldName\n",
+		"vendor/d.go":        "oldName\n",
+		"node_modules/e.go":  "oldName\n",
+		"go.sum":             "oldName\n",
+		"bin.go":             "oldName\x00\n",
+		"pkg/clean.go":       "nothing here\n",
+		treeIgnoreFile:       "vendor/\n",
+		"pkg/nested/deep.go": "oldName(1)\n",
+	}
+	for p, c := range files {
+		full := filepath.Join(ws, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(c), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true, WriteDenyGlobs: []string{"go.sum", "**/*.sum"}}
+	if !hasTool(r, "replace_in_files") {
+		t.Fatal("replace_in_files not exposed")
+	}
+	read := func(p string) string {
+		b, err := os.ReadFile(filepath.Join(ws, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	out, err := r.Execute(context.Background(), Context{}, "replace_in_files", json.RawMessage(`{"glob":"**/*.go","old":"oldName","new":"newName","dry_run":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"would replace 4 occurrences in 3 files", "a.go: 2\n", "pkg/b.go: 1\n", "pkg/nested/deep.go: 1\n", "-x := oldName\n+x := newName\n"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("dry run missing %q:\n%s", want, out)
+		}
+	}
+	for _, skipped := range []string{"vendor/", "node_modules/", "bin.go", "pkg/c.txt", "go.sum"} {
+		if strings.Contains(out, skipped) {
+			t.Fatalf("dry run touched %s:\n%s", skipped, out)
+		}
+	}
+	if read("a.go") != files["a.go"] {
+		t.Fatal("dry run wrote a.go")
+	}
+
+	out, err = r.Execute(context.Background(), Context{}, "replace_in_files", json.RawMessage(`{"glob":"**/*.go","old":"old(\\w+)\\(","new":"new$1(","regex":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "replaced 3 occurrences in 2 files") {
+		t.Fatalf("regex replace: %s", out)
+	}
+	if read("a.go") != "newName()\nnewName()\n" || read("pkg/nested/deep.go") != "newName(1)\n" || read("pkg/b.go") != files["pkg/b.go"] {
+		t.Fatal("regex replace wrote the wrong content")
+	}
+	if read("vendor/d.go") != files["vendor/d.go"] || read("go.sum") != files["go.sum"] {
+		t.Fatal("ignored or write-denied file changed")
+	}
+
+	if _, err := r.replaceInFiles("**/*.go", "x*", "y", true, false, nil); err == nil {
+		t.Fatal("expected empty-match regex to be refused")
+	}
+	if out, err := r.replaceInFiles("*.md", "oldName", "x", false, false, nil); err != nil || !strings.HasPrefix(out, "no matches") {
+		t.Fatalf("no match: %q %v", out, err)
+	}
+}
+
+func TestReplaceInFiles_RequireReadBeforeWrite(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true, RequireReadBeforeWrite: true}
+	tctx := Context{Reads: NewReadSet()}
+	args := `{"glob":"*.txt","old":"foo","new":"bar"}`
+
+	if _, err := r.Execute(context.Background(), tctx, "replace_in_files", json.RawMessage(args)); err == nil || !strings.Contains(err.Error(), "dry_run") {
+		t.Fatalf("expected unread refusal, got %v", err)
+	}
+	if _, err := r.Execute(context.Background(), tctx, "replace_in_files", json.RawMessage(`{"glob":"*.txt","old":"foo","new":"bar","dry_run":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Execute(context.Background(), tctx, "replace_in_files", json.RawMessage(args)); err != nil {
+		t.Fatalf("after dry run: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(ws, "a.txt")); string(b) != "bar\n" {
+		t.Fatalf("a.txt = %q", b)
+	}
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// scratchDirName holds run_script's temporary files inside the workspace.
+	scratchDirName     = ".scratch"
+	runScriptMaxScript = 256 << 10
+)
+
+var scriptExtensions = map[string]string{
+	"python":  ".py",
+	"python3": ".py",
+	"node":    ".js",
+	"bash":    ".sh",
+	"sh":      ".sh",
+	"ruby":    ".rb",
+	"perl":    ".pl",
+	"deno":    ".ts",
+}
+
+// runScript writes script to a temporary file in the workspace, runs it with
+// interpreter (which must be in r.ScriptInterpreters) through the same
+// environment as exec, and removes the file afterwards. It uses exec's
+// timeout unless run_script has its own entry in ToolTimeouts.
+func (r *Registry) runScript(ctx context.Context, interpreter, script string, args []string) (string, error) {
+	interpreter = strings.TrimSpace(interpreter)
+	if interpreter == "" {
+		return "", errors.New("interpreter is empty")
+	}
+	if !slices.Contains(r.ScriptInterpreters, interpreter) {
+		return "", fmt.Errorf("interpreter %q is not allowed (allowed: %s)", interpreter, strings.Join(r.ScriptInterpreters, ", "))
+	}
+	if strings.TrimSpace(script) == "" {
+		return "", errors.New("script is empty")
+	}
+	if len(script) > runScriptMaxScript {
+		return "", fmt.Errorf("script is too large (%d bytes, max %d)", len(script), runScriptMaxScript)
+	}
+	if msg := guardScript(script); msg != "" {
+		return msg, nil
+	}
+	bin, err := exec.LookPath(interpreter)
+	if err != nil {
+		return "", fmt.Errorf("interpreter not found: %s", interpreter)
+	}
+
+	wsAbs, err := r.workspaceAbs()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(wsAbs, scratchDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "script-*"+scriptExtensions[interpreter])
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	return r.runExecCmd(ctx, r.execTimeout("run_script"), bin, append([]string{f.Name()}, args...)...), nil
+}
+
+// guardScript applies the content checks from exec that still make sense
+// for a script body: dangerous commands and sensitive state paths. Shell
+// syntax checks are skipped because scripts are not run through sh -c.
+func guardScript(script string) string {
+	lower := strings.ToLower(script)
+	for _, re := range execDenyPatterns {
+		if re.MatchString(lower) {
+			return "Error: Script blocked by safety guard (dangerous pattern detected)"
+		}
+	}
+	var paths []string
+	for _, m := range rePosixAbs.FindAllStringSubmatch(script, -1) {
+		if len(m) >= 3 && m[2] != "/" {
+			paths = append(paths, m[2])
+		}
+	}
+	paths = append(paths, reHomeAbs.FindAllString(script, -1)...)
+	for _, raw := range paths {
+		if err := ensurePathAllowedByPolicy(expandHomePath(raw)); err != nil {
+			return "Error: Script blocked by safety guard (sensitive path is not allowed)"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunScript_RunsAndCleansUp(t *testing.T) {
+	ws := t.TempDir()
+	t.Setenv("CLAWLET_SCRIPT_TEST_SECRET", "super-secret")
+	r := &Registry{
+		WorkspaceDir:        ws,
+		RestrictToWorkspace: true,
+		ExecTimeout:         5 * time.Second,
+		ScriptInterpreters:  []string{"sh"},
+	}
+
+	script := "for x in a b; do\n  echo \"item $x\"\ndone > out.txt\ncat out.txt\necho \"arg=$1 secret=${CLAWLET_SCRIPT_TEST_SECRET}\"\n"
+	args, _ := json.Marshal(map[string]any{"interpreter": "sh", "script": script, "args": []string{"one"}})
+	out, err := r.Execute(context.Background(), Context{}, "run_script", args)
+	if err != nil {
+		t.Fatalf("run_script: %v", err)
+	}
+	if !strings.HasPrefix(out, "exit=0") || !strings.Contains(out, "item a\nitem b") || !strings.Contains(out, "arg=one secret=") || strings.Contains(out, "super-secret") {
+		t.Fatalf("out=%q", out)
+	}
+	entries, err := os.ReadDir(filepath.Join(ws, scratchDirName))
+	if err != nil {
+		t.Fatalf("read scratch dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("temp script not removed: %v", entries)
+	}
+}
+
+func TestRunScript_RejectsInterpreterAndDangerousScript(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir(), RestrictToWorkspace: true, ScriptInterpreters: []string{"sh"}}
+	if _, err := r.runScript(context.Background(), "bash", "echo hi", nil); err == nil {
+		t.Fatalf("expected non-allowlisted interpreter to be rejected")
+	}
+	out, err := r.runScript(context.Background(), "sh", "cd /tmp\nrm -rf build\n", nil)
+	if err != nil {
+		t.Fatalf("runScript: %v", err)
+	}
+	if !strings.Contains(out, "blocked by safety guard") {
+		t.Fatalf("expected guard, got %q", out)
+	}
+}
+
+func TestRunScript_OfferedOnlyWhenConfigured(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir()}
+	for _, d := range r.Definitions() {
+		if d.Function.Name == "run_script" {
+			t.Fatalf("run_script should not be offered without interpreters")
+		}
+	}
+}
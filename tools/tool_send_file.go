@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mosaxiv/clawlet/bus"
+)
+
+// sendFile uploads a workspace file to channel:chatID, the current chat
+// when both are empty. The channel manager turns files over the channel's
+// upload limit into a compressed upload or a download link.
+func (r *Registry) sendFile(ctx context.Context, tctx Context, path, caption, channel, chatID string) (string, error) {
+	if r.Outbound == nil {
+		return "", errors.New("message sending not configured")
+	}
+	channel, chatID = strings.TrimSpace(channel), strings.TrimSpace(chatID)
+	current := channel == "" && chatID == ""
+	if current {
+		channel, chatID = strings.TrimSpace(tctx.Channel), strings.TrimSpace(tctx.ChatID)
+	} else if channel == strings.TrimSpace(tctx.Channel) && chatID == strings.TrimSpace(tctx.ChatID) {
+		current = true
+	}
+	if channel == "" || chatID == "" || channel == "cli" {
+		return "", errors.New("no chat to send the file to; give channel and chat_id")
+	}
+	if !current && !messageTargetAllowed(r.MessageAllowedTargets, channel, chatID) {
+		return "", fmt.Errorf("destination %s:%s is not allowed (allowed: %s)", channel, chatID, strings.Join(r.MessageAllowedTargets, ", "))
+	}
+
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file: %s", path)
+	}
+	mimeType, err := fileMIMEType(abs)
+	if err != nil {
+		return "", err
+	}
+	msg := bus.OutboundMessage{
+		Channel: channel,
+		ChatID:  chatID,
+		Content: strings.TrimSpace(caption),
+		Attachments: []bus.Attachment{{
+			Name:      filepath.Base(abs),
+			MIMEType:  mimeType,
+			Kind:      bus.InferAttachmentKind(mimeType),
+			SizeBytes: info.Size(),
+			LocalPath: abs,
+		}},
+	}
+	if err := r.Outbound(ctx, msg); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("File %s (%d bytes) queued for %s:%s", filepath.Base(abs), info.Size(), channel, chatID), nil
+}
+
+// fileMIMEType guesses the type of the file at path from its extension,
+// then its content.
+func fileMIMEType(path string) (string, error) {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mosaxiv/clawlet/bus"
+)
+
+func TestSendFile(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "report.csv"), []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sent []bus.OutboundMessage
+	r := &Registry{
+		WorkspaceDir:          ws,
+		RestrictToWorkspace:   true,
+		MessageAllowedTargets: []string{"slack:ops"},
+		Outbound: func(ctx context.Context, msg bus.OutboundMessage) error {
+			sent = append(sent, msg)
+			return nil
+		},
+	}
+	if !hasTool(r, "send_file") {
+		t.Fatal("send_file not exposed")
+	}
+	tctx := Context{Channel: "discord", ChatID: "c1"}
+	run := func(args string) error {
+		_, err := r.Execute(context.Background(), tctx, "send_file", json.RawMessage(args))
+		return err
+	}
+
+	if err := run(`{"path":"report.csv","caption":"Here you go"}`); err != nil {
+		t.Fatalf("send to current chat: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Channel != "discord" || sent[0].ChatID != "c1" || sent[0].Content != "Here you go" {
+		t.Fatalf("sent=%+v", sent)
+	}
+	att := sent[0].Attachments
+	if len(att) != 1 || att[0].Name != "report.csv" || att[0].SizeBytes != 8 || att[0].LocalPath != filepath.Join(ws, "report.csv") || att[0].MIMEType == "" {
+		t.Fatalf("attachment=%+v", att)
+	}
+
+	if err := run(`{"path":"report.csv","channel":"slack","chat_id":"ops"}`); err != nil {
+		t.Fatalf("send to allowed target: %v", err)
+	}
+	if err := run(`{"path":"report.csv","channel":"slack","chat_id":"general"}`); err == nil {
+		t.Fatal("expected disallowed target error")
+	}
+	if err := run(`{"path":"../outside.txt"}`); err == nil {
+		t.Fatal("expected path outside workspace to be refused")
+	}
+	if err := run(`{"path":"."}`); err == nil {
+		t.Fatal("expected directory to be refused")
+	}
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(sent))
+	}
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// StateMetaKey is the session metadata key holding set_state values.
+	StateMetaKey = "state"
+	// stateMaxBytes bounds the encoded state of one session.
+	stateMaxBytes = 16 << 10
+)
+
+// MetaStore is the session metadata a Registry reads and writes for the
+// state tools. *session.Session implements it.
+type MetaStore interface {
+	MetaString(key string) string
+	SetMeta(key, value string)
+}
+
+func (r *Registry) sessionState(tctx Context) (MetaStore, map[string]string, error) {
+	if strings.TrimSpace(tctx.SessionKey) == "" {
+		return nil, nil, errors.New("no session for state")
+	}
+	store, err := r.SessionState(tctx.SessionKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	state := map[string]string{}
+	if raw := store.MetaString(StateMetaKey); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			return nil, nil, fmt.Errorf("session state is corrupt: %w", err)
+		}
+	}
+	return store, state, nil
+}
+
+// setState stores value under key in the session state; an empty value
+// removes key. Writes that would grow the state past stateMaxBytes fail.
+func (r *Registry) setState(tctx Context, key, value string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	store, state, err := r.sessionState(tctx)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		if _, ok := state[key]; !ok {
+			return "no state for " + key, nil
+		}
+		delete(state, key)
+	} else {
+		state[key] = value
+	}
+	raw := ""
+	if len(state) > 0 {
+		b, err := json.Marshal(state)
+		if err != nil {
+			return "", err
+		}
+		if len(b) > stateMaxBytes {
+			return "", fmt.Errorf("session state would be %d bytes, over the %d byte limit; remove keys you no longer need", len(b), stateMaxBytes)
+		}
+		raw = string(b)
+	}
+	store.SetMeta(StateMetaKey, raw)
+	if value == "" {
+		return "removed " + key, nil
+	}
+	return fmt.Sprintf("stored %s (%d bytes, %d/%d used)", key, len(value), len(raw), stateMaxBytes), nil
+}
+
+func (r *Registry) getState(tctx Context, key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	_, state, err := r.sessionState(tctx)
+	if err != nil {
+		return "", err
+	}
+	v, ok := state[key]
+	if !ok {
+		return "", fmt.Errorf("no state for %s", key)
+	}
+	return v, nil
+}
+
+func (r *Registry) listState(tctx Context) (string, error) {
+	_, state, err := r.sessionState(tctx)
+	if err != nil {
+		return "", err
+	}
+	if len(state) == 0 {
+		return "(no state)", nil
+	}
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s (%d bytes)\n", k, len(state[k]))
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
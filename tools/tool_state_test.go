@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type fakeMetaStore map[string]string
+
+func (m fakeMetaStore) MetaString(key string) string { return m[key] }
+
+func (m fakeMetaStore) SetMeta(key, value string) {
+	if value == "" {
+		delete(m, key)
+		return
+	}
+	m[key] = value
+}
+
+func TestStateTools(t *testing.T) {
+	sessions := map[string]fakeMetaStore{"a": {}, "b": {}}
+	r := &Registry{
+		WorkspaceDir: t.TempDir(),
+		SessionState: func(key string) (MetaStore, error) { return sessions[key], nil },
+	}
+	for _, name := range []string{"set_state", "get_state", "list_state"} {
+		if !hasTool(r, name) {
+			t.Fatalf("%s not exposed", name)
+		}
+	}
+	if hasTool(&Registry{}, "set_state") {
+		t.Fatal("state tools exposed without SessionState")
+	}
+	run := func(session, name, args string) (string, error) {
+		return r.Execute(context.Background(), Context{SessionKey: session}, name, json.RawMessage(args))
+	}
+
+	if out, err := run("a", "set_state", `{"key":"plan","value":"1. read 2. edit"}`); err != nil || !strings.HasPrefix(out, "stored plan") {
+		t.Fatalf("set: out=%q err=%v", out, err)
+	}
+	if _, err := run("a", "set_state", `{"key":"file","value":"main.go"}`); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := run("a", "get_state", `{"key":"plan"}`); err != nil || out != "1. read 2. edit" {
+		t.Fatalf("get: out=%q err=%v", out, err)
+	}
+	if out, err := run("a", "list_state", `{}`); err != nil || out != "file (7 bytes)\nplan (15 bytes)" {
+		t.Fatalf("list: out=%q err=%v", out, err)
+	}
+	if sessions["a"][StateMetaKey] == "" {
+		t.Fatal("state not stored in session metadata")
+	}
+	if _, err := run("b", "get_state", `{"key":"plan"}`); err == nil {
+		t.Fatal("state leaked across sessions")
+	}
+
+	if _, err := run("a", "set_state", `{"key":"big","value":"`+strings.Repeat("x", stateMaxBytes)+`"}`); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("oversized set: err=%v", err)
+	}
+	if _, err := run("a", "get_state", `{"key":"big"}`); err == nil {
+		t.Fatal("rejected value was stored")
+	}
+
+	if out, err := run("a", "set_state", `{"key":"plan","value":""}`); err != nil || out != "removed plan" {
+		t.Fatalf("remove: out=%q err=%v", out, err)
+	}
+	if _, err := run("a", "set_state", `{"key":"file","value":""}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sessions["a"][StateMetaKey]; ok {
+		t.Fatal("empty state should drop the metadata key")
+	}
+	if out, _ := run("a", "list_state", `{}`); out != "(no state)" {
+		t.Fatalf("list empty: %q", out)
+	}
+	if _, err := run("", "list_state", `{}`); err == nil {
+		t.Fatal("expected error without a session")
+	}
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// summarizeFileMaxBytes caps how much of a file is summarized.
+	summarizeFileMaxBytes = 2 << 20
+	// summarizeFileChunkBytes is the size of each part sent to the model.
+	summarizeFileChunkBytes = 64 << 10
+)
+
+// summarizeFile summarizes a file with r.Summarize without returning its
+// contents. Large files are split into chunks that are summarized separately
+// and then merged.
+func (r *Registry) summarizeFile(ctx context.Context, path, focus string) (string, error) {
+	if r.Summarize == nil {
+		return "", errors.New("summarize_file not configured")
+	}
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", abs)
+	}
+	b, err := io.ReadAll(io.LimitReader(f, summarizeFileMaxBytes))
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return "", fmt.Errorf("file is empty: %s", abs)
+	}
+
+	base := "Summarize the following file content concisely. Keep key facts, names, numbers, and structure."
+	if fc := strings.TrimSpace(focus); fc != "" {
+		base += " Focus on: " + fc
+	}
+	chunks := splitSummaryChunks(b, summarizeFileChunkBytes)
+	var summary string
+	if len(chunks) == 1 {
+		summary, err = r.Summarize(ctx, chunks[0], base)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		parts := make([]string, 0, len(chunks))
+		for i, c := range chunks {
+			s, err := r.Summarize(ctx, c, fmt.Sprintf("%s This is part %d of %d of %s.", base, i+1, len(chunks), path))
+			if err != nil {
+				return "", fmt.Errorf("summarize part %d/%d: %w", i+1, len(chunks), err)
+			}
+			parts = append(parts, fmt.Sprintf("Part %d:\n%s", i+1, strings.TrimSpace(s)))
+		}
+		summary, err = r.Summarize(ctx, strings.Join(parts, "\n\n"), "Merge these part summaries of one file into a single concise summary.")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	header := fmt.Sprintf("[summary of %s: %d bytes", path, info.Size())
+	if info.Size() > summarizeFileMaxBytes {
+		header += fmt.Sprintf("; only the first %d bytes were summarized", summarizeFileMaxBytes)
+	}
+	return header + "]\n" + strings.TrimSpace(summary), nil
+}
+
+// splitSummaryChunks splits b into chunks of at most size bytes, breaking at
+// line boundaries when possible.
+func splitSummaryChunks(b []byte, size int) []string {
+	var out []string
+	for len(b) > 0 {
+		n := min(size, len(b))
+		if n < len(b) {
+			if i := bytes.LastIndexByte(b[:n], '\n'); i > 0 {
+				n = i + 1
+			}
+		}
+		out = append(out, string(b[:n]))
+		b = b[n:]
+	}
+	return out
+}
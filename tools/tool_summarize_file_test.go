@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeFile_ChunksAndMerges(t *testing.T) {
+	ws := t.TempDir()
+	line := strings.Repeat("x", 1023) + "\n"
+	content := strings.Repeat(line, 150) // 150KB => 3 chunks
+	if err := os.WriteFile(filepath.Join(ws, "big.log"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var calls []string
+	r := &Registry{
+		WorkspaceDir:        ws,
+		RestrictToWorkspace: true,
+		Summarize: func(ctx context.Context, text, instructions string) (string, error) {
+			calls = append(calls, instructions)
+			if strings.HasPrefix(instructions, "Merge") {
+				return "merged summary", nil
+			}
+			if !strings.HasSuffix(text, "\n") || len(text) > summarizeFileChunkBytes {
+				t.Fatalf("chunk not split on a line boundary within size: %d bytes", len(text))
+			}
+			return "part summary", nil
+		},
+	}
+	out, err := r.Execute(context.Background(), Context{}, "summarize_file", json.RawMessage(`{"path":"big.log","focus":"errors"}`))
+	if err != nil {
+		t.Fatalf("summarize_file: %v", err)
+	}
+	if out != "[summary of big.log: 153600 bytes]\nmerged summary" {
+		t.Fatalf("out=%q", out)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("calls=%d", len(calls))
+	}
+	if !strings.Contains(calls[0], "Focus on: errors") || !strings.Contains(calls[0], "part 1 of 3") {
+		t.Fatalf("instructions=%q", calls[0])
+	}
+}
+
+func TestSummarizeFile_RequiresHook(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir(), RestrictToWorkspace: true}
+	for _, d := range r.Definitions() {
+		if d.Function.Name == "summarize_file" {
+			t.Fatalf("summarize_file should not be offered without a Summarize hook")
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const (
+	defaultSystemInfoProcesses = 20
+	maxSystemInfoProcesses     = 100
+	// systemInfoCPUSample is how long CPU usage is measured over.
+	systemInfoCPUSample = 200 * time.Millisecond
+)
+
+// systemReport is the result of system_info. Sections that cannot be read
+// on this platform are left out rather than failing the call.
+type systemReport struct {
+	Hostname  string          `json:"hostname,omitempty"`
+	OS        string          `json:"os"`
+	UptimeSec uint64          `json:"uptimeSec,omitempty"`
+	CPU       *systemCPU      `json:"cpu,omitempty"`
+	Memory    *systemMemory   `json:"memory,omitempty"`
+	Disks     []systemDisk    `json:"disks,omitempty"`
+	Processes []systemProcess `json:"processes,omitempty"`
+}
+
+type systemCPU struct {
+	Cores        int       `json:"cores"`
+	UsagePercent float64   `json:"usagePercent"`
+	Load         []float64 `json:"load,omitempty"`
+}
+
+type systemMemory struct {
+	TotalMB      uint64  `json:"totalMB"`
+	UsedMB       uint64  `json:"usedMB"`
+	AvailableMB  uint64  `json:"availableMB"`
+	UsagePercent float64 `json:"usagePercent"`
+	SwapTotalMB  uint64  `json:"swapTotalMB,omitempty"`
+	SwapUsedMB   uint64  `json:"swapUsedMB,omitempty"`
+}
+
+type systemDisk struct {
+	Path         string  `json:"path"`
+	Fstype       string  `json:"fstype,omitempty"`
+	TotalGB      float64 `json:"totalGB"`
+	FreeGB       float64 `json:"freeGB"`
+	UsagePercent float64 `json:"usagePercent"`
+}
+
+type systemProcess struct {
+	PID        int32   `json:"pid"`
+	Name       string  `json:"name"`
+	User       string  `json:"user,omitempty"`
+	CPUPercent float64 `json:"cpuPercent"`
+	MemoryMB   uint64  `json:"memoryMB"`
+}
+
+// systemInfo reports CPU, memory, and disk usage for / and the workspace,
+// plus, when SystemInfoProcesses is set and processes is true, the top
+// processes by sortBy ("cpu" or "memory"). It only reads.
+func (r *Registry) systemInfo(ctx context.Context, processes bool, sortBy string, limit int) (string, error) {
+	sortBy = strings.ToLower(strings.TrimSpace(sortBy))
+	switch sortBy {
+	case "":
+		sortBy = "cpu"
+	case "cpu", "memory":
+	default:
+		return "", fmt.Errorf("sort must be cpu or memory, got %q", sortBy)
+	}
+	if processes && !r.SystemInfoProcesses {
+		return "", fmt.Errorf("process listing is disabled (tools.systemInfo.processes)")
+	}
+
+	rep := systemReport{OS: runtime.GOOS + "/" + runtime.GOARCH}
+	if info, err := host.InfoWithContext(ctx); err == nil {
+		rep.Hostname = info.Hostname
+		rep.UptimeSec = info.Uptime
+		if info.Platform != "" {
+			rep.OS = strings.TrimSpace(info.Platform + " " + info.PlatformVersion + " (" + rep.OS + ")")
+		}
+	}
+	if pct, err := cpu.PercentWithContext(ctx, systemInfoCPUSample, false); err == nil && len(pct) > 0 {
+		c := &systemCPU{UsagePercent: round1(pct[0]), Cores: runtime.NumCPU()}
+		if n, err := cpu.CountsWithContext(ctx, true); err == nil && n > 0 {
+			c.Cores = n
+		}
+		if avg, err := load.AvgWithContext(ctx); err == nil {
+			c.Load = []float64{round1(avg.Load1), round1(avg.Load5), round1(avg.Load15)}
+		}
+		rep.CPU = c
+	}
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		m := &systemMemory{
+			TotalMB:      vm.Total >> 20,
+			UsedMB:       vm.Used >> 20,
+			AvailableMB:  vm.Available >> 20,
+			UsagePercent: round1(vm.UsedPercent),
+		}
+		if sw, err := mem.SwapMemoryWithContext(ctx); err == nil {
+			m.SwapTotalMB, m.SwapUsedMB = sw.Total>>20, sw.Used>>20
+		}
+		rep.Memory = m
+	}
+	rep.Disks = systemDisks(ctx, "/", r.WorkspaceDir)
+
+	if processes {
+		if limit <= 0 {
+			limit = cmp.Or(r.SystemInfoMaxProcesses, defaultSystemInfoProcesses)
+		}
+		limit = min(limit, cmp.Or(r.SystemInfoMaxProcesses, defaultSystemInfoProcesses), maxSystemInfoProcesses)
+		procs, err := topProcesses(ctx, sortBy, limit)
+		if err != nil {
+			return "", fmt.Errorf("list processes: %w", err)
+		}
+		rep.Processes = procs
+	}
+
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// systemDisks reports the filesystems holding paths, once each.
+func systemDisks(ctx context.Context, paths ...string) []systemDisk {
+	var out []systemDisk
+	for _, p := range paths {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		u, err := disk.UsageWithContext(ctx, p)
+		if err != nil || u.Total == 0 {
+			continue
+		}
+		if slices.ContainsFunc(out, func(d systemDisk) bool {
+			return d.Fstype == u.Fstype && d.TotalGB == bytesToGB(u.Total) && d.FreeGB == bytesToGB(u.Free)
+		}) {
+			continue
+		}
+		out = append(out, systemDisk{
+			Path:         p,
+			Fstype:       u.Fstype,
+			TotalGB:      bytesToGB(u.Total),
+			FreeGB:       bytesToGB(u.Free),
+			UsagePercent: round1(u.UsedPercent),
+		})
+	}
+	return out
+}
+
+// topProcesses returns the limit busiest processes by sortBy. Command lines
+// are left out since they often carry secrets.
+func topProcesses(ctx context.Context, sortBy string, limit int) ([]systemProcess, error) {
+	ps, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]systemProcess, 0, len(ps))
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			// Exited, or not ours to inspect.
+			continue
+		}
+		sp := systemProcess{PID: p.Pid, Name: name}
+		sp.User, _ = p.UsernameWithContext(ctx)
+		if pct, err := p.CPUPercentWithContext(ctx); err == nil {
+			sp.CPUPercent = round1(pct)
+		}
+		if mi, err := p.MemoryInfoWithContext(ctx); err == nil && mi != nil {
+			sp.MemoryMB = mi.RSS >> 20
+		}
+		out = append(out, sp)
+	}
+	slices.SortFunc(out, func(a, b systemProcess) int {
+		if sortBy == "memory" {
+			return cmp.Or(cmp.Compare(b.MemoryMB, a.MemoryMB), cmp.Compare(b.CPUPercent, a.CPUPercent), cmp.Compare(a.PID, b.PID))
+		}
+		return cmp.Or(cmp.Compare(b.CPUPercent, a.CPUPercent), cmp.Compare(b.MemoryMB, a.MemoryMB), cmp.Compare(a.PID, b.PID))
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func bytesToGB(n uint64) float64 {
+	return round1(float64(n) / (1 << 30))
+}
+
+func round1(f float64) float64 {
+	return float64(int64(f*10+0.5)) / 10
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSystemInfo(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir()}
+	if hasTool(r, "system_info") {
+		t.Fatal("system_info exposed without tools.systemInfo.enabled")
+	}
+	if _, err := r.Execute(context.Background(), Context{}, "system_info", json.RawMessage(`{}`)); err == nil {
+		t.Fatal("expected disabled error")
+	}
+
+	r.SystemInfo = true
+	if !hasTool(r, "system_info") {
+		t.Fatal("system_info not exposed")
+	}
+	run := func(args string) (systemReport, error) {
+		var rep systemReport
+		out, err := r.Execute(context.Background(), Context{}, "system_info", json.RawMessage(args))
+		if err != nil {
+			return rep, err
+		}
+		if err := json.Unmarshal([]byte(out), &rep); err != nil {
+			t.Fatalf("decode %q: %v", out, err)
+		}
+		return rep, nil
+	}
+
+	rep, err := run(`{}`)
+	if err != nil {
+		t.Fatalf("system_info: %v", err)
+	}
+	if rep.OS == "" || len(rep.Processes) != 0 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if _, err := run(`{"processes":true}`); err == nil || !strings.Contains(err.Error(), "tools.systemInfo.processes") {
+		t.Fatalf("expected process listing to be refused, got %v", err)
+	}
+	if _, err := run(`{"sort":"disk"}`); err == nil {
+		t.Fatal("expected bad sort error")
+	}
+
+	r.SystemInfoProcesses, r.SystemInfoMaxProcesses = true, 3
+	rep, err = run(`{"processes":true,"sort":"memory","limit":50}`)
+	if err != nil {
+		t.Fatalf("system_info processes: %v", err)
+	}
+	if len(rep.Processes) == 0 || len(rep.Processes) > 3 {
+		t.Fatalf("processes=%d, want 1..3", len(rep.Processes))
+	}
+	for i := 1; i < len(rep.Processes); i++ {
+		if rep.Processes[i].MemoryMB > rep.Processes[i-1].MemoryMB {
+			t.Fatalf("not sorted by memory: %+v", rep.Processes)
+		}
+	}
+}
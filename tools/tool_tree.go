@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	treeDefaultDepth   = 3
+	treeMaxDepth       = 10
+	treeDefaultEntries = 500
+	treeMaxEntries     = 2000
+	// treeIgnoreFile in the workspace root lists extra patterns for tree to
+	// skip, one per line, in tools.writeDenyGlobs syntax.
+	treeIgnoreFile = ".clawletignore"
+)
+
+// treeSkipNames are never descended into: they are large and rarely what
+// the model is looking for.
+var treeSkipNames = []string{".git", "node_modules"}
+
+type treeWalker struct {
+	r          *Registry
+	wsAbs      string
+	ignore     []string
+	maxDepth   int
+	maxEntries int
+	b          strings.Builder
+	dirs       int
+	files      int
+	truncated  bool
+}
+
+// tree renders path as an indented tree like `tree -L depth`.
+func (r *Registry) tree(path string, depth, maxEntries int) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		path = "."
+	}
+	if depth <= 0 {
+		depth = treeDefaultDepth
+	}
+	if maxEntries <= 0 {
+		maxEntries = treeDefaultEntries
+	}
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	st, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !st.IsDir() {
+		return "", errors.New("path is not a directory")
+	}
+	w := &treeWalker{
+		r:          r,
+		maxDepth:   min(depth, treeMaxDepth),
+		maxEntries: min(maxEntries, treeMaxEntries),
+	}
+	if wsAbs, err := filepath.Abs(r.WorkspaceDir); err == nil {
+		w.wsAbs = filepath.Clean(wsAbs)
+		w.ignore = readTreeIgnore(filepath.Join(w.wsAbs, treeIgnoreFile))
+	}
+	w.b.WriteString(strings.TrimSuffix(filepath.ToSlash(path), "/") + "/\n")
+	w.walk(abs, "", 1)
+
+	fmt.Fprintf(&w.b, "\n%d directories, %d files", w.dirs, w.files)
+	if w.truncated {
+		fmt.Fprintf(&w.b, " (stopped at %d entries; narrow path or depth)", w.maxEntries)
+	}
+	return w.b.String(), nil
+}
+
+func (w *treeWalker) walk(dir, prefix string, level int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Fprintf(&w.b, "%s└── (error: %v)\n", prefix, err)
+		return
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if !w.skip(filepath.Join(dir, e.Name()), e.Name()) {
+			kept = append(kept, e)
+		}
+	}
+	for i, e := range kept {
+		if w.dirs+w.files >= w.maxEntries {
+			w.truncated = true
+			return
+		}
+		branch, indent := "├── ", "│   "
+		if i == len(kept)-1 {
+			branch, indent = "└── ", "    "
+		}
+		name := e.Name()
+		if e.IsDir() {
+			w.dirs++
+			w.b.WriteString(prefix + branch + name + "/\n")
+			if level < w.maxDepth {
+				w.walk(filepath.Join(dir, name), prefix+indent, level+1)
+				if w.truncated {
+					return
+				}
+			}
+			continue
+		}
+		w.files++
+		w.b.WriteString(prefix + branch + name + "\n")
+	}
+}
+
+func (w *treeWalker) skip(abs, name string) bool {
+	for _, n := range treeSkipNames {
+		if name == n {
+			return true
+		}
+	}
+	if ensurePathAllowedByPolicy(abs) != nil {
+		return true
+	}
+	if len(w.ignore) == 0 || w.wsAbs == "" || !isSameOrChildPath(abs, w.wsAbs) {
+		return false
+	}
+	rel, err := filepath.Rel(w.wsAbs, abs)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range w.ignore {
+		if matchWriteGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// readTreeIgnore reads the patterns in path, skipping blank lines and "#"
+// comments. A missing file yields none.
+func readTreeIgnore(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, strings.TrimSuffix(filepath.ToSlash(line), "/"))
+	}
+	return out
+}
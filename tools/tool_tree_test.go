@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	ws := t.TempDir()
+	for _, p := range []string{"cmd/app/main.go", "go.mod", "docs/a.md", "build/out.bin", ".git/HEAD", "node_modules/x/index.js", "pkg/deep/er/file.go"} {
+		full := filepath.Join(ws, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(ws, treeIgnoreFile), []byte("# generated\nbuild/\n.clawletignore\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{WorkspaceDir: ws}
+	if !hasTool(r, "tree") {
+		t.Fatal("tree not exposed")
+	}
+
+	out, err := r.Execute(context.Background(), Context{}, "tree", json.RawMessage(`{"depth":2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `./
+├── cmd/
+│   └── app/
+├── docs/
+│   └── a.md
+├── go.mod
+└── pkg/
+    └── deep/
+
+5 directories, 2 files`
+	if out != want {
+		t.Fatalf("tree:\n%s", out)
+	}
+
+	out, err = r.tree("pkg", 0, 0)
+	if err != nil || !strings.Contains(out, "        └── file.go") {
+		t.Fatalf("subdir tree: %v\n%s", err, out)
+	}
+	out, _ = r.tree(".", 5, 3)
+	if !strings.Contains(out, "stopped at 3 entries") {
+		t.Fatalf("cap:\n%s", out)
+	}
+	if _, err := r.tree("go.mod", 0, 0); err == nil {
+		t.Fatal("expected error for a file")
+	}
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// validateConfigMaxBytes caps the files validate_config parses.
+const validateConfigMaxBytes = 4 << 20
+
+// validateConfig parses the file at path as JSON, YAML, or TOML (typ, or
+// the extension when typ is empty) and reports "valid" or where parsing
+// failed. A parse failure is a result, not an error, so the model can fix
+// the file and call again.
+func (r *Registry) validateConfig(path, typ string) (string, error) {
+	abs, err := r.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "" {
+		switch strings.ToLower(filepath.Ext(abs)) {
+		case ".json":
+			typ = "json"
+		case ".yaml", ".yml":
+			typ = "yaml"
+		case ".toml":
+			typ = "toml"
+		default:
+			return "", fmt.Errorf("cannot tell the format of %s from its extension; pass type: json, yaml, or toml", path)
+		}
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", abs)
+	}
+	if info.Size() > validateConfigMaxBytes {
+		return "", fmt.Errorf("file is %d bytes; validate_config reads files up to %d bytes", info.Size(), validateConfigMaxBytes)
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return "", err
+	}
+
+	var perr string
+	switch typ {
+	case "json":
+		perr = validateJSON(b)
+	case "yaml":
+		perr = validateYAML(b)
+	case "toml":
+		perr = validateTOML(b)
+	default:
+		return "", fmt.Errorf("unknown type %q; want json, yaml, or toml", typ)
+	}
+	if perr != "" {
+		return fmt.Sprintf("invalid %s: %s", strings.ToUpper(typ), perr), nil
+	}
+	return fmt.Sprintf("valid %s", strings.ToUpper(typ)), nil
+}
+
+func validateJSON(b []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	var v any
+	err := dec.Decode(&v)
+	if err == nil {
+		end := dec.InputOffset()
+		rest := bytes.TrimLeft(b[end:], " \t\r\n")
+		if len(rest) == 0 {
+			return ""
+		}
+		line, col := lineCol(b, int64(len(b)-len(rest)))
+		return fmt.Sprintf("line %d, column %d: unexpected data after the top-level value", line, col)
+	}
+	var se *json.SyntaxError
+	switch {
+	case errors.As(err, &se):
+		// Offset counts the offending byte.
+		line, col := lineCol(b, se.Offset-1)
+		return fmt.Sprintf("line %d, column %d: %v", line, col, se)
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		line, col := lineCol(b, int64(len(b)))
+		return fmt.Sprintf("line %d, column %d: unexpected end of input", line, col)
+	}
+	return err.Error()
+}
+
+func validateYAML(b []byte) string {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var v any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return ""
+		}
+		if err != nil {
+			// yaml.v3 reports lines but not columns, e.g. "line 3: mapping
+			// values are not allowed in this context". Multi-line errors
+			// (duplicate keys) are joined onto one line.
+			return strings.Join(strings.Fields(strings.TrimPrefix(err.Error(), "yaml: ")), " ")
+		}
+	}
+}
+
+func validateTOML(b []byte) string {
+	var v map[string]any
+	_, err := toml.Decode(string(b), &v)
+	if err == nil {
+		return ""
+	}
+	var pe toml.ParseError
+	if errors.As(err, &pe) {
+		return fmt.Sprintf("line %d, column %d: %s", pe.Position.Line, pe.Position.Col, pe.Message)
+	}
+	return strings.TrimPrefix(err.Error(), "toml: ")
+}
+
+// lineCol converts a byte offset in b to a 1-based line and column.
+func lineCol(b []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(b)))
+	head := b[:offset]
+	line := bytes.Count(head, []byte("\n")) + 1
+	col := len(head) - bytes.LastIndexByte(head, '\n')
+	return line, col
+}
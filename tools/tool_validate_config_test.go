@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	ws := t.TempDir()
+	files := map[string]string{
+		"ok.json":     `{"a": [1, 2]}`,
+		"bad.json":    "{\n  \"a\": 1,\n}\n",
+		"short.json":  `{"a": `,
+		"extra.json":  `{} {}`,
+		"ok.yaml":     "a: 1\nb:\n  - x\n",
+		"bad.yml":     "a: 1\nb: c: d\n",
+		"dup.yaml":    "a: 1\na: 2\n",
+		"ok.toml":     "[server]\nport = 8080\n",
+		"bad.toml":    "[server]\nport = \n",
+		"config.conf": `{"a": 1}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Registry{WorkspaceDir: ws, RestrictToWorkspace: true}
+	if !hasTool(r, "validate_config") {
+		t.Fatal("validate_config not exposed")
+	}
+
+	for path, want := range map[string]string{
+		"ok.json":    "valid JSON",
+		"bad.json":   "invalid JSON: line 3, column 1: invalid character '}'",
+		"short.json": "invalid JSON: line 1, column 7: unexpected end of input",
+		"extra.json": "invalid JSON: line 1, column 4: unexpected data",
+		"ok.yaml":    "valid YAML",
+		"bad.yml":    "invalid YAML: line 2: mapping values are not allowed",
+		"dup.yaml":   `invalid YAML: unmarshal errors: line 2: mapping key "a" already defined at line 1`,
+		"ok.toml":    "valid TOML",
+		"bad.toml":   "invalid TOML: line 2, column",
+	} {
+		out, err := r.Execute(context.Background(), Context{}, "validate_config", json.RawMessage(`{"path":"`+path+`"}`))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !strings.HasPrefix(out, want) {
+			t.Fatalf("%s: got %q, want prefix %q", path, out, want)
+		}
+	}
+
+	if _, err := r.validateConfig("config.conf", ""); err == nil || !strings.Contains(err.Error(), "pass type") {
+		t.Fatalf("unknown extension: %v", err)
+	}
+	if out, err := r.validateConfig("config.conf", "json"); err != nil || out != "valid JSON" {
+		t.Fatalf("explicit type: %q %v", out, err)
+	}
+	if _, err := r.validateConfig("../x.json", ""); err == nil {
+		t.Fatal("expected path traversal to be refused")
+	}
+}
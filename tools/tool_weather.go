@@ -0,0 +1,300 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+	openMeteoForecastURL  = "https://api.open-meteo.com/v1/forecast"
+	openWeatherMapURL     = "https://api.openweathermap.org/data/2.5/weather"
+)
+
+// weatherReport is the provider-independent result of get_weather.
+type weatherReport struct {
+	Location      string       `json:"location"`
+	Country       string       `json:"country,omitempty"`
+	Latitude      float64      `json:"latitude"`
+	Longitude     float64      `json:"longitude"`
+	LocalTime     string       `json:"localTime,omitempty"`
+	Timezone      string       `json:"timezone,omitempty"`
+	UTCOffset     string       `json:"utcOffset,omitempty"`
+	Conditions    string       `json:"conditions,omitempty"`
+	Temperature   float64      `json:"temperature"`
+	FeelsLike     *float64     `json:"feelsLike,omitempty"`
+	Humidity      *float64     `json:"humidity,omitempty"`
+	Precipitation *float64     `json:"precipitation,omitempty"`
+	WindSpeed     *float64     `json:"windSpeed,omitempty"`
+	Units         weatherUnits `json:"units"`
+	Provider      string       `json:"provider"`
+}
+
+type weatherUnits struct {
+	Temperature   string `json:"temperature"`
+	WindSpeed     string `json:"windSpeed"`
+	Precipitation string `json:"precipitation,omitempty"`
+}
+
+func (r *Registry) getWeather(ctx context.Context, location string) (string, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return "", errors.New("location is empty")
+	}
+	var (
+		rep weatherReport
+		err error
+	)
+	switch r.WeatherProvider {
+	case "open-meteo":
+		rep, err = r.openMeteoWeather(ctx, location)
+	case "openweathermap":
+		rep, err = r.openWeatherMapWeather(ctx, location)
+	default:
+		return "", fmt.Errorf("weather provider %q not supported", r.WeatherProvider)
+	}
+	if err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *Registry) weatherImperial() bool {
+	return r.WeatherUnits == "imperial"
+}
+
+func (r *Registry) weatherURL(name, fallback string) string {
+	if u := r.weatherBaseURLs[name]; u != "" {
+		return u
+	}
+	return fallback
+}
+
+// weatherGet fetches rawURL as JSON into out. Requests obey the web_fetch
+// domain policy and tools.egressAllowHosts like every other network tool.
+func (r *Registry) weatherGet(ctx context.Context, rawURL string, out any) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if ok, reason := allowHostByPolicy(u.Hostname(), r.WebFetchAllowedDomains, r.WebFetchBlockedDomains); !ok {
+		return fmt.Errorf("weather: %s: %s", u.Hostname(), reason)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.httpClient(r.toolTimeout("get_weather", 15*time.Second)).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("weather http %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("weather: decode response: %w", err)
+	}
+	return nil
+}
+
+func (r *Registry) openMeteoWeather(ctx context.Context, location string) (weatherReport, error) {
+	var geo struct {
+		Results []struct {
+			Name      string  `json:"name"`
+			Admin1    string  `json:"admin1"`
+			Country   string  `json:"country"`
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		} `json:"results"`
+	}
+	q := url.Values{"name": {location}, "count": {"1"}, "format": {"json"}}
+	if err := r.weatherGet(ctx, r.weatherURL("geocoding", openMeteoGeocodingURL)+"?"+q.Encode(), &geo); err != nil {
+		return weatherReport{}, err
+	}
+	if len(geo.Results) == 0 {
+		return weatherReport{}, fmt.Errorf("location not found: %s", location)
+	}
+	place := geo.Results[0]
+
+	q = url.Values{
+		"latitude":  {strconv.FormatFloat(place.Latitude, 'f', 4, 64)},
+		"longitude": {strconv.FormatFloat(place.Longitude, 'f', 4, 64)},
+		"current":   {"temperature_2m,apparent_temperature,relative_humidity_2m,precipitation,weather_code,wind_speed_10m"},
+		"timezone":  {"auto"},
+	}
+	if r.weatherImperial() {
+		q.Set("temperature_unit", "fahrenheit")
+		q.Set("wind_speed_unit", "mph")
+		q.Set("precipitation_unit", "inch")
+	}
+	var fc struct {
+		Timezone         string `json:"timezone"`
+		UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+		Current          struct {
+			Time                string   `json:"time"`
+			Temperature         float64  `json:"temperature_2m"`
+			ApparentTemperature *float64 `json:"apparent_temperature"`
+			RelativeHumidity    *float64 `json:"relative_humidity_2m"`
+			Precipitation       *float64 `json:"precipitation"`
+			WeatherCode         *int     `json:"weather_code"`
+			WindSpeed           *float64 `json:"wind_speed_10m"`
+		} `json:"current"`
+		CurrentUnits struct {
+			Temperature   string `json:"temperature_2m"`
+			Precipitation string `json:"precipitation"`
+			WindSpeed     string `json:"wind_speed_10m"`
+		} `json:"current_units"`
+	}
+	if err := r.weatherGet(ctx, r.weatherURL("forecast", openMeteoForecastURL)+"?"+q.Encode(), &fc); err != nil {
+		return weatherReport{}, err
+	}
+
+	name := place.Name
+	if place.Admin1 != "" && place.Admin1 != place.Name {
+		name += ", " + place.Admin1
+	}
+	rep := weatherReport{
+		Location:      name,
+		Country:       place.Country,
+		Latitude:      place.Latitude,
+		Longitude:     place.Longitude,
+		Timezone:      fc.Timezone,
+		Temperature:   fc.Current.Temperature,
+		FeelsLike:     fc.Current.ApparentTemperature,
+		Humidity:      fc.Current.RelativeHumidity,
+		Precipitation: fc.Current.Precipitation,
+		WindSpeed:     fc.Current.WindSpeed,
+		Units: weatherUnits{
+			Temperature:   fc.CurrentUnits.Temperature,
+			WindSpeed:     fc.CurrentUnits.WindSpeed,
+			Precipitation: fc.CurrentUnits.Precipitation,
+		},
+		Provider: "open-meteo",
+	}
+	if fc.Current.WeatherCode != nil {
+		rep.Conditions = wmoWeatherCondition(*fc.Current.WeatherCode)
+	}
+	setWeatherLocalTime(&rep, time.Now(), fc.UTCOffsetSeconds)
+	return rep, nil
+}
+
+func (r *Registry) openWeatherMapWeather(ctx context.Context, location string) (weatherReport, error) {
+	if strings.TrimSpace(r.WeatherAPIKey) == "" {
+		return weatherReport{}, errors.New("weather apiKey not configured (config.tools.weather.apiKey)")
+	}
+	units := "metric"
+	if r.weatherImperial() {
+		units = "imperial"
+	}
+	q := url.Values{"q": {location}, "appid": {r.WeatherAPIKey}, "units": {units}}
+	var owm struct {
+		Name  string `json:"name"`
+		Coord struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"coord"`
+		Sys struct {
+			Country string `json:"country"`
+		} `json:"sys"`
+		Weather []struct {
+			Description string `json:"description"`
+		} `json:"weather"`
+		Main struct {
+			Temp      float64  `json:"temp"`
+			FeelsLike *float64 `json:"feels_like"`
+			Humidity  *float64 `json:"humidity"`
+		} `json:"main"`
+		Wind struct {
+			Speed *float64 `json:"speed"`
+		} `json:"wind"`
+		Timezone int `json:"timezone"`
+	}
+	if err := r.weatherGet(ctx, r.weatherURL("openweathermap", openWeatherMapURL)+"?"+q.Encode(), &owm); err != nil {
+		return weatherReport{}, err
+	}
+	rep := weatherReport{
+		Location:    owm.Name,
+		Country:     owm.Sys.Country,
+		Latitude:    owm.Coord.Lat,
+		Longitude:   owm.Coord.Lon,
+		Temperature: owm.Main.Temp,
+		FeelsLike:   owm.Main.FeelsLike,
+		Humidity:    owm.Main.Humidity,
+		WindSpeed:   owm.Wind.Speed,
+		Units:       weatherUnits{Temperature: "°C", WindSpeed: "m/s"},
+		Provider:    "openweathermap",
+	}
+	if r.weatherImperial() {
+		rep.Units = weatherUnits{Temperature: "°F", WindSpeed: "mph"}
+	}
+	if len(owm.Weather) > 0 {
+		rep.Conditions = owm.Weather[0].Description
+	}
+	setWeatherLocalTime(&rep, time.Now(), owm.Timezone)
+	return rep, nil
+}
+
+// setWeatherLocalTime fills the local time at the location from its UTC
+// offset, so "what time is it in X" works with the same call.
+func setWeatherLocalTime(rep *weatherReport, now time.Time, offsetSec int) {
+	zone := time.FixedZone("", offsetSec)
+	if loc, err := time.LoadLocation(rep.Timezone); err == nil && rep.Timezone != "" {
+		zone = loc
+	}
+	local := now.In(zone)
+	rep.LocalTime = local.Format("2006-01-02 15:04 (Mon)")
+	rep.UTCOffset = "UTC" + local.Format("-07:00")
+}
+
+// wmoWeatherCondition describes a WMO weather interpretation code as used by
+// open-meteo.
+func wmoWeatherCondition(code int) string {
+	switch code {
+	case 0:
+		return "clear sky"
+	case 1:
+		return "mainly clear"
+	case 2:
+		return "partly cloudy"
+	case 3:
+		return "overcast"
+	case 45, 48:
+		return "fog"
+	case 51, 53, 55:
+		return "drizzle"
+	case 56, 57:
+		return "freezing drizzle"
+	case 61, 63, 65:
+		return "rain"
+	case 66, 67:
+		return "freezing rain"
+	case 71, 73, 75:
+		return "snow"
+	case 77:
+		return "snow grains"
+	case 80, 81, 82:
+		return "rain showers"
+	case 85, 86:
+		return "snow showers"
+	case 95:
+		return "thunderstorm"
+	case 96, 99:
+		return "thunderstorm with hail"
+	}
+	return fmt.Sprintf("weather code %d", code)
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetWeather_OpenMeteo(t *testing.T) {
+	var forecastQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/geo":
+			if r.URL.Query().Get("name") != "Osaka" {
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"results":[{"name":"Osaka","admin1":"Osaka Prefecture","country":"Japan","latitude":34.6937,"longitude":135.5023}]}`))
+		case "/forecast":
+			forecastQuery = r.URL.RawQuery
+			_, _ = w.Write([]byte(`{"timezone":"Asia/Tokyo","utc_offset_seconds":32400,
+				"current":{"time":"2026-03-02T09:30","temperature_2m":12.5,"apparent_temperature":10.1,"relative_humidity_2m":60,"precipitation":0,"weather_code":2,"wind_speed_10m":8.3},
+				"current_units":{"temperature_2m":"°C","precipitation":"mm","wind_speed_10m":"km/h"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	r := &Registry{
+		WeatherProvider: "open-meteo",
+		weatherBaseURLs: map[string]string{"geocoding": srv.URL + "/geo", "forecast": srv.URL + "/forecast"},
+	}
+	if !hasTool(r, "get_weather") {
+		t.Fatal("get_weather not offered")
+	}
+	out, err := r.Execute(context.Background(), Context{}, "get_weather", json.RawMessage(`{"location":"Osaka"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep weatherReport
+	if err := json.Unmarshal([]byte(out), &rep); err != nil {
+		t.Fatalf("bad json %q: %v", out, err)
+	}
+	if rep.Location != "Osaka, Osaka Prefecture" || rep.Conditions != "partly cloudy" || rep.Temperature != 12.5 {
+		t.Fatalf("report=%+v", rep)
+	}
+	if rep.Timezone != "Asia/Tokyo" || rep.UTCOffset != "UTC+09:00" || rep.LocalTime == "" {
+		t.Fatalf("time fields=%+v", rep)
+	}
+	if !strings.Contains(forecastQuery, "latitude=34.6937") || strings.Contains(forecastQuery, "fahrenheit") {
+		t.Fatalf("forecast query=%q", forecastQuery)
+	}
+
+	if _, err := r.Execute(context.Background(), Context{}, "get_weather", json.RawMessage(`{"location":"Nowhere"}`)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestGetWeather_OpenWeatherMap(t *testing.T) {
+	var gotKey, gotUnits string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotUnits = r.URL.Query().Get("appid"), r.URL.Query().Get("units")
+		_, _ = w.Write([]byte(`{"name":"Paris","coord":{"lat":48.85,"lon":2.35},"sys":{"country":"FR"},
+			"weather":[{"description":"light rain"}],"main":{"temp":51.2,"feels_like":49,"humidity":80},"wind":{"speed":6},"timezone":3600}`))
+	}))
+	defer srv.Close()
+
+	r := &Registry{
+		WeatherProvider: "openweathermap",
+		WeatherAPIKey:   "k123",
+		WeatherUnits:    "imperial",
+		weatherBaseURLs: map[string]string{"openweathermap": srv.URL},
+	}
+	out, err := r.getWeather(context.Background(), "Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "k123" || gotUnits != "imperial" {
+		t.Fatalf("key=%q units=%q", gotKey, gotUnits)
+	}
+	if !strings.Contains(out, `"conditions": "light rain"`) || !strings.Contains(out, `"temperature": "°F"`) || !strings.Contains(out, `"utcOffset": "UTC+01:00"`) {
+		t.Fatalf("out=%s", out)
+	}
+}
+
+func TestGetWeather_DomainPolicy(t *testing.T) {
+	r := &Registry{
+		WeatherProvider:        "open-meteo",
+		WebFetchBlockedDomains: []string{"open-meteo.com"},
+	}
+	_, err := r.getWeather(context.Background(), "Osaka")
+	if err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Fatalf("err=%v", err)
+	}
+}
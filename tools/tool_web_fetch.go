@@ -7,9 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -18,6 +20,21 @@ const (
 	defaultWebFetchBodyMaxSize = int64(4 << 20)
 )
 
+// webFetchResult is the JSON returned by web_fetch.
+type webFetchResult struct {
+	URL               string `json:"url"`
+	FinalURL          string `json:"finalUrl,omitempty"`
+	Status            int    `json:"status"`
+	Extractor         string `json:"extractor"`
+	Truncated         bool   `json:"truncated"`
+	ResponseTruncated bool   `json:"responseTruncated,omitempty"`
+	Summarized        bool   `json:"summarized,omitempty"`
+	Length            int    `json:"length"`
+	Text              string `json:"text"`
+	Note              string `json:"note,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
 func (r *Registry) webFetch(ctx context.Context, rawURL string, extractMode string, maxChars int, headers map[string]string) (string, error) {
 	rawURL = strings.TrimSpace(rawURL)
 	if rawURL == "" {
@@ -58,35 +75,22 @@ func (r *Registry) webFetch(ctx context.Context, rawURL string, extractMode stri
 	if timeout <= 0 {
 		timeout = defaultWebFetchTimeoutSec * time.Second
 	}
+	timeout = r.toolTimeout("web_fetch", timeout)
 	maxBodyBytes := r.WebFetchMaxResponse
 	if maxBodyBytes <= 0 {
 		maxBodyBytes = defaultWebFetchBodyMaxSize
 	}
 
-	type outT struct {
-		URL               string `json:"url"`
-		FinalURL          string `json:"finalUrl,omitempty"`
-		Status            int    `json:"status"`
-		Extractor         string `json:"extractor"`
-		Truncated         bool   `json:"truncated"`
-		ResponseTruncated bool   `json:"responseTruncated,omitempty"`
-		Length            int    `json:"length"`
-		Text              string `json:"text"`
-		Error             string `json:"error,omitempty"`
-	}
-
-	client := &http.Client{
-		Timeout: timeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 5 {
-				return errors.New("stopped after 5 redirects")
-			}
-			rh := normalizeFetchHost(req.URL.Host)
-			if allowed, reason := allowHostByPolicy(rh, r.WebFetchAllowedDomains, r.WebFetchBlockedDomains); !allowed {
-				return fmt.Errorf("redirect blocked: %s", reason)
-			}
-			return nil
-		},
+	client := r.httpClient(timeout)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("stopped after 5 redirects")
+		}
+		rh := normalizeFetchHost(req.URL.Host)
+		if allowed, reason := allowHostByPolicy(rh, r.WebFetchAllowedDomains, r.WebFetchBlockedDomains); !allowed {
+			return fmt.Errorf("redirect blocked: %s", reason)
+		}
+		return nil
 	}
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
@@ -96,9 +100,13 @@ func (r *Registry) webFetch(ctx context.Context, rawURL string, extractMode stri
 	for k, v := range headers {
 		request.Header.Set(k, v)
 	}
-	resp, err := client.Do(request)
+	resp, attempts, err := doWithRetry(ctx, client, request, r.WebFetchRetries)
 	if err != nil {
-		b, _ := json.Marshal(outT{URL: rawURL, Status: 0, Extractor: "error", Truncated: false, Length: 0, Text: "", Error: err.Error()})
+		msg := err.Error()
+		if attempts > 1 {
+			msg = fmt.Sprintf("%s (after %d attempts)", msg, attempts)
+		}
+		b, _ := json.Marshal(webFetchResult{URL: rawURL, Status: 0, Extractor: "error", Truncated: false, Length: 0, Text: "", Error: msg})
 		return string(b), nil
 	}
 	defer resp.Body.Close()
@@ -145,7 +153,9 @@ func (r *Registry) webFetch(ctx context.Context, rawURL string, extractMode stri
 	outputTruncated := responseTruncated
 	if len(text) > maxChars {
 		outputTruncated = true
-		text = text[:maxChars]
+		// The result reports truncated, so skip the inline note and stay
+		// within maxChars.
+		text = clipMode(text, maxChars, r.TruncateMode)
 	}
 
 	errText := ""
@@ -153,7 +163,7 @@ func (r *Registry) webFetch(ctx context.Context, rawURL string, extractMode stri
 		errText = fmt.Sprintf("http %d", resp.StatusCode)
 	}
 
-	o := outT{
+	o := webFetchResult{
 		URL:               rawURL,
 		FinalURL:          finalURL,
 		Status:            resp.StatusCode,
@@ -167,3 +177,49 @@ func (r *Registry) webFetch(ctx context.Context, rawURL string, extractMode stri
 	b, _ := json.Marshal(o)
 	return string(b), nil
 }
+
+// webFetchRetryBackoff is the wait before the first web_fetch retry. It
+// doubles with each further attempt.
+var webFetchRetryBackoff = 500 * time.Millisecond
+
+// doWithRetry sends req, retrying up to retries times after transient
+// network errors. HTTP error statuses are responses, not errors, and are
+// never retried. It returns how many attempts were made.
+func doWithRetry(ctx context.Context, client *http.Client, req *http.Request, retries int) (*http.Response, int, error) {
+	wait := webFetchRetryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if err == nil || attempt > retries || !transientNetError(err) {
+			return resp, attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, attempt, err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// transientNetError reports whether err is a network failure worth another
+// try: a temporary DNS failure or a connection refused, reset, or closed
+// early. Timeouts are not retried, since the attempt already used the whole
+// budget, and neither are policy, TLS, or unknown-host errors.
+func transientNetError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary && !dnsErr.IsNotFound
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
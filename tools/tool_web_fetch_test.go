@@ -3,11 +3,18 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestAllowHostByPolicy_DefaultAllowAll(t *testing.T) {
@@ -155,6 +162,32 @@ func TestWebFetch_RespectsResponseLimit(t *testing.T) {
 	}
 }
 
+func TestWebFetch_StaysWithinMaxChars(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("ü", 500)))
+	}))
+	defer server.Close()
+
+	for _, mode := range []string{TruncateHead, TruncateTail, TruncateMiddle} {
+		r := &Registry{WebFetchAllowedDomains: []string{"*"}, WebFetchTimeout: 5 * time.Second, TruncateMode: mode}
+		out, err := r.webFetch(context.Background(), server.URL, "text", 101, nil)
+		if err != nil {
+			t.Fatalf("webFetch failed: %v", err)
+		}
+		var payload struct {
+			Text      string `json:"text"`
+			Truncated bool   `json:"truncated"`
+		}
+		if err := json.Unmarshal([]byte(out), &payload); err != nil {
+			t.Fatalf("invalid json output: %v", err)
+		}
+		if !payload.Truncated || len(payload.Text) > 101 || strings.ContainsRune(payload.Text, utf8.RuneError) {
+			t.Fatalf("%s: truncated=%v len=%d text=%q", mode, payload.Truncated, len(payload.Text), payload.Text)
+		}
+	}
+}
+
 func TestWebFetch_DomainPolicyBlocks(t *testing.T) {
 	r := &Registry{WebFetchAllowedDomains: []string{"example.com"}}
 	_, err := r.webFetch(context.Background(), "https://openai.com", "text", 200, nil)
@@ -191,3 +224,125 @@ func TestWebFetch_ExecuteDispatch(t *testing.T) {
 		t.Fatalf("expected Accept header forwarded, got %q", gotAccept)
 	}
 }
+
+func TestWebFetch_SummarizesLongPages(t *testing.T) {
+	page := strings.Repeat("Clawlet release notes. ", 200)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	var gotInstructions string
+	r := newTestRegistry()
+	r.WebFetchSummarizeOver = 1000
+	r.Summarize = func(ctx context.Context, text, instructions string) (string, error) {
+		gotInstructions = instructions
+		return "short summary", nil
+	}
+	tctx := Context{Intent: "what changed in the last release?"}
+	args := json.RawMessage(`{"url":"` + srv.URL + `"}`)
+	out, err := r.Execute(context.Background(), tctx, "web_fetch", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res webFetchResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Summarized || res.Text != "short summary" || !strings.Contains(res.Note, "raw=true") {
+		t.Fatalf("result=%+v", res)
+	}
+	if !strings.Contains(gotInstructions, "what changed in the last release?") {
+		t.Fatalf("instructions=%q", gotInstructions)
+	}
+
+	out, err = r.Execute(context.Background(), tctx, "web_fetch", json.RawMessage(`{"url":"`+srv.URL+`","raw":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = webFetchResult{}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Summarized || res.Text != strings.TrimSpace(page) {
+		t.Fatalf("raw result summarized=%v len=%d", res.Summarized, len(res.Text))
+	}
+}
+
+func TestWebFetch_RetriesTransientErrors(t *testing.T) {
+	defer func(d time.Duration) { webFetchRetryBackoff = d }(webFetchRetryBackoff)
+	webFetchRetryBackoff = time.Millisecond
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	r := newTestRegistry()
+	r.WebFetchRetries = 2
+	out, err := r.webFetch(context.Background(), srv.URL, "text", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"status":200`) || calls.Load() != 2 {
+		t.Fatalf("calls=%d out=%s", calls.Load(), out)
+	}
+
+	// An HTTP error status is a response and is not retried.
+	calls.Store(1)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.NotFound(w, r)
+	})
+	out, _ = r.webFetch(context.Background(), srv.URL, "text", 0, nil)
+	if !strings.Contains(out, `"status":404`) || calls.Load() != 2 {
+		t.Fatalf("calls=%d out=%s", calls.Load(), out)
+	}
+
+	// Without retries the first failure is returned.
+	calls.Store(0)
+	drop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	}))
+	defer drop.Close()
+	r.WebFetchRetries = 0
+	out, _ = r.webFetch(context.Background(), drop.URL, "text", 0, nil)
+	if !strings.Contains(out, `"extractor":"error"`) || calls.Load() != 1 {
+		t.Fatalf("calls=%d out=%s", calls.Load(), out)
+	}
+}
+
+func TestTransientNetError(t *testing.T) {
+	for _, err := range []error{
+		&net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true},
+		&url.Error{Op: "Get", URL: "http://x", Err: syscall.ECONNRESET},
+		io.EOF,
+	} {
+		if !transientNetError(err) {
+			t.Fatalf("%v should be transient", err)
+		}
+	}
+	for _, err := range []error{
+		&net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true},
+		context.DeadlineExceeded,
+		errors.New("redirect blocked: example.com is blocked"),
+	} {
+		if transientNetError(err) {
+			t.Fatalf("%v should not be transient", err)
+		}
+	}
+}
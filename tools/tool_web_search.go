@@ -34,7 +34,7 @@ func (r *Registry) webSearch(ctx context.Context, query string, count int) (stri
 	rc := retryablehttp.NewClient()
 	rc.RetryMax = 2
 	rc.Logger = nil
-	rc.HTTPClient.Timeout = 20 * time.Second
+	rc.HTTPClient = r.httpClient(r.toolTimeout("web_search", 20*time.Second))
 	resp, err := rc.Do(req)
 	if err != nil {
 		return "", err
@@ -44,5 +44,21 @@ func (r *Registry) webSearch(ctx context.Context, query string, count int) (stri
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return "", fmt.Errorf("brave http %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
 	}
-	return formatBraveSearchResults(query, count, b), nil
+	return formatBraveSearchResults(query, count, b, r.searchResultAllowed), nil
+}
+
+// searchResultAllowed drops results on hosts web_fetch would refuse
+// (tools.web.blockedDomains) or that tools.web.searchBlockedDomains lists,
+// so the agent is not pointed at sources it cannot or should not fetch.
+func (r *Registry) searchResultAllowed(rawURL string) bool {
+	if len(r.WebFetchBlockedDomains) == 0 && len(r.WebSearchBlockedDomains) == 0 {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	blocked := append(append([]string(nil), r.WebFetchBlockedDomains...), r.WebSearchBlockedDomains...)
+	ok, _ := allowHostByPolicy(u.Hostname(), nil, blocked)
+	return ok
 }
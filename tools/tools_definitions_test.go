@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -62,7 +63,7 @@ func TestRegistryDefinitions_GatedByCapabilities(t *testing.T) {
 	}
 
 	// Always present.
-	for _, n := range []string{"read_file", "write_file", "edit_file", "list_dir", "exec", "web_fetch"} {
+	for _, n := range []string{"read_file", "write_file", "write_files", "edit_file", "json_patch", "list_dir", "diff", "exec", "web_fetch", "list_tools", "context_info"} {
 		if !has[n] {
 			t.Fatalf("expected tool definition: %s", n)
 		}
@@ -119,3 +120,94 @@ func TestRegistryDefinitions_IncludesSkillRegistryToolsWhenEnabled(t *testing.T)
 		}
 	}
 }
+
+func TestRegistryDefinitions_SafeModeRemovesMutatingTools(t *testing.T) {
+	r := &Registry{
+		WorkspaceDir: t.TempDir(),
+		ExecTimeout:  1 * time.Second,
+		BraveAPIKey:  "k",
+		Spawn: func(ctx context.Context, task, label, originChannel, originChatID string) (string, error) {
+			return "", nil
+		},
+		SkillRegistry: stubSkillRegistry{},
+		MemorySearch:  stubMemoryManager{},
+		SafeMode:      true,
+	}
+
+	got := map[string]bool{}
+	for _, d := range r.Definitions() {
+		got[d.Function.Name] = true
+	}
+	want := []string{"read_file", "list_dir", "glob", "tree", "diff", "file_info", "validate_config", "hash", "base64_file", "web_fetch", "list_tools", "context_info", "web_search", "find_skills", "memory_search", "memory_get"}
+	if len(got) != len(want) {
+		t.Fatalf("tools=%v", got)
+	}
+	for _, n := range want {
+		if !got[n] {
+			t.Fatalf("missing read-only tool %s: %v", n, got)
+		}
+	}
+
+	if _, err := r.Execute(context.Background(), Context{}, "exec", json.RawMessage(`{"command":"echo hi"}`)); err == nil {
+		t.Fatalf("expected exec to be refused in safe mode")
+	}
+	if _, err := r.Execute(context.Background(), Context{}, "write_file", json.RawMessage(`{"path":"a.txt","content":"x"}`)); err == nil {
+		t.Fatalf("expected write_file to be refused in safe mode")
+	}
+}
+
+func TestListTools_ReportsEnabledState(t *testing.T) {
+	r := &Registry{
+		WorkspaceDir: t.TempDir(),
+		ExecTimeout:  1 * time.Second,
+		AllowTools:   []string{"read_file"},
+	}
+	out, err := r.Execute(context.Background(), Context{}, "list_tools", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("list_tools error: %v", err)
+	}
+	var tools []struct {
+		Name       string          `json:"name"`
+		Parameters json.RawMessage `json:"parameters"`
+		Enabled    bool            `json:"enabled"`
+	}
+	if err := json.Unmarshal([]byte(out), &tools); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, out)
+	}
+	enabled := map[string]bool{}
+	for _, tl := range tools {
+		enabled[tl.Name] = tl.Enabled
+		if len(tl.Parameters) == 0 {
+			t.Fatalf("missing schema for %s", tl.Name)
+		}
+	}
+	if !enabled["read_file"] || !enabled["list_tools"] {
+		t.Fatalf("expected read_file and list_tools enabled: %v", enabled)
+	}
+	if v, ok := enabled["exec"]; !ok || v {
+		t.Fatalf("expected exec listed as disabled: %v", enabled)
+	}
+}
+
+func TestDefinitionsForProfile(t *testing.T) {
+	r := &Registry{
+		WorkspaceDir: t.TempDir(),
+		Profiles:     map[string][]string{"chat": {"web_fetch", "exec"}},
+		SafeMode:     true,
+	}
+	var names []string
+	for _, d := range r.DefinitionsFor("chat") {
+		names = append(names, d.Function.Name)
+	}
+	// exec stays hidden by safe mode; list_tools is always offered.
+	if strings.Join(names, ",") != "web_fetch,list_tools" {
+		t.Fatalf("names=%v", names)
+	}
+	if len(r.DefinitionsFor("")) != len(r.Definitions()) {
+		t.Fatal("empty profile should offer every allowed tool")
+	}
+	_, err := r.Execute(context.Background(), Context{Profile: "chat"}, "read_file", json.RawMessage(`{"path":"x"}`))
+	if err == nil || !strings.Contains(err.Error(), "tool profile") {
+		t.Fatalf("err=%v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mosaxiv/clawlet/paths"
@@ -88,3 +90,55 @@ func TestGuardExecCommand_BlocksSensitiveStatePath(t *testing.T) {
 		}
 	}
 }
+
+func TestGuardExecAllowlist(t *testing.T) {
+	allow := []string{"go", "git", "npm", "ls"}
+	for _, c := range []string{
+		"go test ./...",
+		"cd sub && npm test",
+		"git status | ls",
+		"go vet ./... || git diff",
+		"npm install --save-dev typescript",
+		`git commit -m "fix: go vet"`,
+		"git -C sub log --oneline",
+	} {
+		if msg := guardExecAllowlist(c, allow); msg != "" {
+			t.Fatalf("%q blocked: %s", c, msg)
+		}
+	}
+	for c, want := range map[string]string{
+		"python3 -c 'print(1)'":                 "python3 is not in",
+		"go test ./... | sh":                    "sh is not in",
+		"/usr/bin/git status":                   "/usr/bin/git is not in",
+		"GIT_SSH_COMMAND=x git fetch":           "GIT_SSH_COMMAND=x is not in",
+		"git -c core.pager=x log":               "git -c",
+		"git push --force origin main":          "git --force",
+		"go build -toolexec=./evil ./...":       "go -toolexec",
+		"npm install -g left-pad":               "npm -g",
+		"npm test |":                            "empty pipeline stage",
+		"git '-c' core.sshCommand=evil fetch":   "git -c",
+		`git "--upload-pack=evil" fetch origin`: "git --upload-pack",
+		`git \-c core.pager=x log`:              "git -c",
+		`'sh' -x`:                               "sh is not in",
+		"git config core.sshCommand evil":       "git config",
+		"git config alias.x '!sh -c evil'":      "git config",
+		"git -C sub config --get user.name":     "git config",
+		`git commit -m "unterminated`:           "unterminated quote",
+	} {
+		if msg := guardExecAllowlist(c, allow); !strings.Contains(msg, want) {
+			t.Fatalf("%q: got %q, want %q", c, msg, want)
+		}
+	}
+}
+
+func TestExec_AllowlistMode(t *testing.T) {
+	r := &Registry{WorkspaceDir: t.TempDir(), ExecAllowCommands: []string{"echo"}}
+	out, err := r.exec(context.Background(), "echo hi")
+	if err != nil || !strings.Contains(out, "hi") {
+		t.Fatalf("echo: %q %v", out, err)
+	}
+	out, err = r.exec(context.Background(), "cat go.mod")
+	if err != nil || !strings.Contains(out, "exec allowlist") {
+		t.Fatalf("cat: %q %v", out, err)
+	}
+}
@@ -32,3 +32,38 @@ func TestMessageRejectsCurrentSessionTarget(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestMessageAllowedTargets(t *testing.T) {
+	var sent []bus.OutboundMessage
+	r := &Registry{
+		Outbound: func(ctx context.Context, msg bus.OutboundMessage) error {
+			sent = append(sent, msg)
+			return nil
+		},
+		MessageAllowedTargets: []string{"slack:C0OPS", "telegram:*"},
+	}
+	tctx := Context{Channel: "discord", ChatID: "123"}
+	for _, args := range []string{
+		`{"content":"deploy done","channel":"slack","to":"C0OPS"}`,
+		`{"content":"hi","channel":"telegram","chat_id":"42"}`,
+	} {
+		if _, err := r.Execute(context.Background(), tctx, "message", json.RawMessage(args)); err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+	}
+	if len(sent) != 2 || sent[0].ChatID != "C0OPS" || sent[1].Channel != "telegram" {
+		t.Fatalf("sent=%+v", sent)
+	}
+
+	for _, args := range []string{
+		`{"content":"x","channel":"slack","chat_id":"C0OTHER"}`,
+		`{"content":"x","channel":"discord","chat_id":"999"}`,
+	} {
+		if _, err := r.Execute(context.Background(), tctx, "message", json.RawMessage(args)); err == nil {
+			t.Fatalf("%s: expected error", args)
+		}
+	}
+	if len(sent) != 2 {
+		t.Fatalf("blocked message was sent: %+v", sent)
+	}
+}
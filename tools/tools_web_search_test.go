@@ -14,7 +14,7 @@ func TestFormatBraveSearchResults(t *testing.T) {
     ]
   }
 }`)
-	out := formatBraveSearchResults("test query", 5, body)
+	out := formatBraveSearchResults("test query", 5, body, nil)
 	if out == "" {
 		t.Fatalf("expected non-empty output")
 	}
@@ -31,8 +31,33 @@ func TestFormatBraveSearchResults(t *testing.T) {
 
 func TestFormatBraveSearchResults_NoResults(t *testing.T) {
 	body := []byte(`{ "web": { "results": [] } }`)
-	out := formatBraveSearchResults("zzz", 5, body)
+	out := formatBraveSearchResults("zzz", 5, body, nil)
 	if out != "No results for: zzz" {
 		t.Fatalf("unexpected: %q", out)
 	}
 }
+
+func TestFormatBraveSearchResults_DropsBlockedDomains(t *testing.T) {
+	body := []byte(`{"web":{"results":[
+      { "title": "Spam", "url": "https://www.spam.example/page" },
+      { "title": "Good", "url": "https://docs.example.org/a" },
+      { "title": "Paywall", "url": "https://news.paywall.test/story" },
+      { "title": "Other", "url": "https://other.example.net" }
+    ]}}`)
+	r := &Registry{WebFetchBlockedDomains: []string{"spam.example"}, WebSearchBlockedDomains: []string{"paywall.test"}}
+	out := formatBraveSearchResults("q", 2, body, r.searchResultAllowed)
+	if strings.Contains(out, "Spam") || strings.Contains(out, "Paywall") {
+		t.Fatalf("blocked results kept:\n%s", out)
+	}
+	if !strings.Contains(out, "1. Good") || !strings.Contains(out, "2. Other") || !strings.Contains(out, "(2 results from blocked domains omitted)") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	if !(&Registry{}).searchResultAllowed("https://anything.example") {
+		t.Fatal("no policy should keep every result")
+	}
+	only := []byte(`{"web":{"results":[{ "title": "Spam", "url": "https://spam.example" }]}}`)
+	if out := formatBraveSearchResults("q", 5, only, r.searchResultAllowed); out != "No results for: q (1 from blocked domains omitted)" {
+		t.Fatalf("all blocked: %q", out)
+	}
+}
@@ -1,8 +1,79 @@
 package tools
 
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Truncation modes for oversized tool output.
+const (
+	TruncateHead   = "head"
+	TruncateTail   = "tail"
+	TruncateMiddle = "middle"
+)
+
 func truncate(s string, max int) string {
+	return truncateMode(s, max, TruncateHead)
+}
+
+// truncateMode caps s at max bytes. "tail" keeps the end, "middle" keeps the
+// head and tail around an elided marker; anything else keeps the head. Cuts
+// fall on rune boundaries.
+func truncateMode(s string, max int, mode string) string {
 	if len(s) <= max {
 		return s
 	}
-	return s[:max] + "\n(truncated)"
+	switch mode {
+	case TruncateTail:
+		return "(truncated)\n" + tailBytes(s, max)
+	case TruncateMiddle:
+		head, tail := headBytes(s, max/2), tailBytes(s, max-max/2)
+		return head + fmt.Sprintf("\n... (%d bytes elided) ...\n", len(s)-len(head)-len(tail)) + tail
+	default:
+		return headBytes(s, max) + "\n(truncated)"
+	}
+}
+
+// clipMode is truncateMode without the "(truncated)" notes, so the result
+// never exceeds max bytes. It is for callers that report truncation
+// themselves.
+func clipMode(s string, max int, mode string) string {
+	if len(s) <= max {
+		return s
+	}
+	const gap = "\n...\n"
+	switch {
+	case mode == TruncateTail:
+		return tailBytes(s, max)
+	case mode == TruncateMiddle && max > len(gap):
+		budget := max - len(gap)
+		return headBytes(s, budget/2) + gap + tailBytes(s, budget-budget/2)
+	default:
+		return headBytes(s, max)
+	}
+}
+
+// headBytes returns the longest prefix of s of at most n bytes that does not
+// split a rune.
+func headBytes(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// tailBytes returns the longest suffix of s of at most n bytes that does not
+// split a rune.
+func tailBytes(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	i := len(s) - max(n, 0)
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
 }
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMode(t *testing.T) {
+	s := "HEAD-" + strings.Repeat("x", 100) + "-TAIL"
+
+	if got := truncateMode(s, 200, TruncateMiddle); got != s {
+		t.Fatalf("short input changed: %q", got)
+	}
+	if got := truncateMode(s, 10, TruncateHead); !strings.HasPrefix(got, "HEAD-") || strings.Contains(got, "TAIL") {
+		t.Fatalf("head=%q", got)
+	}
+	if got := truncateMode(s, 10, ""); !strings.HasSuffix(got, "(truncated)") {
+		t.Fatalf("default=%q", got)
+	}
+	if got := truncateMode(s, 10, TruncateTail); !strings.HasSuffix(got, "-TAIL") || strings.Contains(got, "HEAD") {
+		t.Fatalf("tail=%q", got)
+	}
+	got := truncateMode(s, 10, TruncateMiddle)
+	if !strings.HasPrefix(got, "HEAD-") || !strings.HasSuffix(got, "-TAIL") || !strings.Contains(got, "bytes elided") {
+		t.Fatalf("middle=%q", got)
+	}
+}
+
+func TestTruncateMode_RuneBoundaries(t *testing.T) {
+	s := strings.Repeat("é", 20) // 2 bytes each
+	for _, mode := range []string{TruncateHead, TruncateTail, TruncateMiddle} {
+		if got := truncateMode(s, 7, mode); !utf8.ValidString(got) {
+			t.Fatalf("%s: invalid UTF-8 %q", mode, got)
+		}
+		got := clipMode(s, 11, mode)
+		if !utf8.ValidString(got) || len(got) > 11 {
+			t.Fatalf("%s: clip=%q (%d bytes)", mode, got, len(got))
+		}
+	}
+	if got := clipMode("HEAD-"+strings.Repeat("x", 100)+"-TAIL", 20, TruncateMiddle); len(got) > 20 || !strings.HasPrefix(got, "HEAD-") || !strings.HasSuffix(got, "-TAIL") {
+		t.Fatalf("clip middle=%q", got)
+	}
+}
+
+func TestCleanToolOutput(t *testing.T) {
+	in := "\x1b[32mok\x1b[0m  \tpkg\r\n" +
+		"\x1b]8;;https://example.com\x07link\x1b]8;;\x07\n" +
+		"\n\n\n" +
+		"10%\r50%\r100%\n" +
+		"done"
+	want := "ok  \tpkg\nlink\n\n100%\ndone"
+	if got := cleanToolOutput(in); got != want {
+		t.Fatalf("got=%q want=%q", got, want)
+	}
+}
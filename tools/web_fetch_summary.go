@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// summarizeWebFetch replaces the text of a web_fetch result longer than
+// WebFetchSummarizeOver characters with a summary focused on intent, the
+// user's request. Errors, disabled summarization, and failed summaries
+// leave out unchanged.
+func (r *Registry) summarizeWebFetch(ctx context.Context, out, intent string) string {
+	if r.Summarize == nil || r.WebFetchSummarizeOver <= 0 || len(out) <= r.WebFetchSummarizeOver {
+		return out
+	}
+	var res webFetchResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		return out
+	}
+	if res.Error != "" || len(res.Text) <= r.WebFetchSummarizeOver {
+		return out
+	}
+	instructions := "Summarize this web page for an assistant answering the user. Keep the facts, names, numbers, dates, and links relevant to the request; drop navigation, ads, and boilerplate."
+	if intent = strings.TrimSpace(intent); intent != "" {
+		instructions += "\nUser's request: " + truncate(intent, 2000)
+	}
+	summary, err := r.Summarize(ctx, res.Text, instructions)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		log.Printf("tools: web_fetch summary failed for %s: %v", res.URL, err)
+		return out
+	}
+	res.Note = fmt.Sprintf("Summary of %d characters of page text. Call web_fetch again with raw=true for the full text.", len(res.Text))
+	res.Text = strings.TrimSpace(summary)
+	res.Length = len(res.Text)
+	res.Summarized = true
+	res.Truncated = true
+	b, _ := json.Marshal(res)
+	return string(b)
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ensureWriteAllowed rejects abs when it matches one of r.WriteDenyGlobs.
+// Paths inside the workspace are matched relative to it; patterns without a
+// slash match the base name at any depth (e.g. "go.sum", "*.lock").
+func (r *Registry) ensureWriteAllowed(abs string) error {
+	if len(r.WriteDenyGlobs) == 0 {
+		return nil
+	}
+	rel := filepath.ToSlash(filepath.Clean(abs))
+	if wsAbs, err := filepath.Abs(r.WorkspaceDir); err == nil && isSameOrChildPath(abs, wsAbs) {
+		if p, err := filepath.Rel(wsAbs, abs); err == nil {
+			rel = filepath.ToSlash(p)
+		}
+	}
+	for _, pattern := range r.WriteDenyGlobs {
+		pattern = strings.TrimSpace(filepath.ToSlash(pattern))
+		if pattern == "" {
+			continue
+		}
+		if matchWriteGlob(pattern, rel) {
+			return fmt.Errorf("path is blocked by write policy (%s): %s", pattern, abs)
+		}
+	}
+	return nil
+}
+
+func matchWriteGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchGlobSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(rel, "/"), "/"))
+}
+
+// matchGlobSegments matches slash-separated segments where "**" spans zero or
+// more segments.
+func matchGlobSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchGlobSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}